@@ -1,16 +1,32 @@
 package main
 
 type Config struct {
-	NetmonAddrs         []string
-	PromAddr            string
-	PromMetrics         []string
-	KubeProxyAddr       string
-	KubeNodesEndpoint   string
-	KubePodsEndpoint    string
-	KubeDeleteEndpoint  string
-	KubeNamespaces      []string
-	MonDurationSeconds  int
-	ValuationInterval   int64
-	UtilChangeThreshold float64
-	HeadroomThreshold   float32
+	NetmonAddrs                  []string
+	PromAddr                     string
+	PromMetrics                  []string
+	KubeProxyAddr                string
+	KubeNodesEndpoint            string
+	KubePodsEndpoint             string
+	KubeDeleteEndpoint           string
+	KubeEvictEndpoint            string // eviction subresource of a pod, so PodDisruptionBudgets are respected
+	KubeEventsEndpoint           string
+	KubeConfigMapsEndpoint       string // where the bw reservations the scheduler made are kept
+	KubeNamespaces               []string
+	MonDurationSeconds           int
+	ValuationInterval            int64
+	UtilChangeThreshold          float64
+	HeadroomThreshold            float32
+	EnforceQos                   bool    // have netmon limit what pods send to the bw reserved for them
+	MaxMigrationsPerNamespace    int     // migrations in flight in a namespace, 0 keeps the default
+	MaxMigrationsPerNode         int     // migrations in flight from a node, 0 keeps the default
+	MigrationIntervalSeconds     int64   // time between two migrations in a namespace or from a node
+	MigrationTimeoutSeconds      int64   // time a migration may take until its replacement runs, 0 keeps the default
+	MigrationGainThreshold       float64 // fraction of the bw it needs a pod has to gain on its target to be moved, 0 keeps the default
+	MigrationCooldownSeconds     int64   // time a moved pod stays where it was moved to, 0 keeps the default
+	MigrationBudget              int     // migrations started per budget window, 0 keeps the default
+	MigrationBudgetWindowSeconds int64
+	HighWatermark                float64 // fraction of the bw it needs a pod has to use to be moved, 0 is UtilChangeThreshold
+	LowWatermark                 float64 // fraction a moved pod has to use less than before it is moved again, 0 is half the high one
+	MigrationRearmSeconds        int64   // time after which a moved pod may be moved again without going below the low watermark, 0 keeps the default
+	ReturnWindowSeconds          int64   // time a pod is not moved back to a node it left, 0 keeps the default
 }
@@ -1,6 +1,7 @@
 package bw_controller
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -10,13 +11,14 @@ import (
 	//"io/ioutil"
 	//"io"
 	"os"
+	"sync"
 	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
 )
 
 type Controller struct {
-	promClient   *PromClient
+	promClient   PromClientIntf
 	netmonClient *netmon_client.NetmonClient
-	kubeClient   *KubeClient
+	kubeClient   KubeClientIntf
 	pods         PodSet
 	podDepActual PodDeps
 	podDepReq    PodDeps
@@ -38,11 +40,26 @@ type Controller struct {
 	headroomThreshold float32
 	ipMap		map[string]string
 	headroomReference netmon_client.PathSet
+	events		*EventRecorder
+	staleNodes	map[string]bool // node name -> netmon data of the node is stale or missing
+	ctx		context.Context // cancelled when the monitor stops, aborts a netmon fetch in flight
+	cancel		context.CancelFunc
+	routeLock	*sync.Mutex
+	routeChanges	map[string]map[string]bool // src ip -> dst ip -> route changed since the last evaluation
+	reevaluate	chan bool // gets a value when a route changed, the monitor evaluates right away
+	podsRunning	PodSet // namespace/pod name -> pod running and not terminating in the last UpdatePods, pods holds every pod ever seen
+	ledger		LedgerStore // bw reserved by the scheduler, nil to not keep reservations
+	enforceQos	bool // have netmon limit what pods send to their reservations
+	qosVersion	int64 // of the last qos policy sent
+	qosStatus	[]netmon_client.QosStatus // what the nodes enforce after the last policy
+	migrations	*MigrationTracker
+	migrationGain	float64 // fraction of the bw it needs a pod has to gain to be moved
+	damper		*Damper
 }
 
-func NewController(promClient *PromClient, 
+func NewController(promClient PromClientIntf, 
 		   netmonClient *netmon_client.NetmonClient, 
-		   kubeClient *KubeClient, 
+		   kubeClient KubeClientIntf, 
 		   valuationInterval int64, 
 		   utilChangeThreshold float64, 
 		   bwFile string, 
@@ -50,6 +67,7 @@ func NewController(promClient *PromClient,
 		   headroomThreshold float32,
 	   	   ipMap map[string]string) *Controller {
 	controller := &Controller{promClient: promClient, netmonClient: netmonClient, kubeClient: kubeClient, pendingBwUpdate: false}
+	controller.events = NewEventRecorder(kubeClient, EVENT_COMPONENT)
 	controller.podDepReq = make(PodDeps, 0)
 	controller.podDepActual = make(PodDeps, 0)
 	controller.pods = make(PodSet, 0)
@@ -63,12 +81,20 @@ func NewController(promClient *PromClient,
 	controller.namespaceAvgUtilization = make(map[string]float64, 0)
 	controller.headroomReq = make(map[string]map[string]float32, 0)
 	controller.headroomAvailable = make(netmon_client.PathSet, 0)
+	controller.staleNodes = make(map[string]bool, 0)
+	controller.ctx, controller.cancel = context.WithCancel(context.Background())
+	controller.routeLock = &sync.Mutex{}
+	controller.routeChanges = make(map[string]map[string]bool, 0)
+	controller.reevaluate = make(chan bool, 1)
 	controller.headroomInit = false
 	controller.headroomThreshold = headroomThreshold
 	controller.bwFile, _ = os.OpenFile(bwFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	controller.migrationFile, _ = os.OpenFile(migrationFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	controller.bwFile.WriteString(fmt.Sprintf("time,src,dst,bw\n"))
-	controller.migrationFile.WriteString("time,pod\n")
+	controller.migrationFile.WriteString(MIGRATION_HEADER)
+	controller.migrations = NewMigrationTracker(DEFAULT_MIGRATION_LIMITS, controller.migrationFile)
+	controller.migrationGain = DEFAULT_MIGRATION_GAIN
+	controller.damper = NewDamper(DefaultDampingPolicy(utilChangeThreshold))
 	controller.ipMap = ipMap
 	// intialize state for cluster
 	controller.UpdateNodes()
@@ -81,6 +107,7 @@ func NewController(promClient *PromClient,
 }
 
 func (controller *Controller) Shutdown() {
+	controller.cancel()
 	controller.bwFile.Close()
 	controller.migrationFile.Close()
 }
@@ -145,8 +172,9 @@ func (controller *Controller) UpdateNetMetrics(isBwUpdate bool) {
 	var links netmon_client.LinkSet
 	var paths netmon_client.PathSet
 	var traffics netmon_client.TrafficSet
+	var nodeErrors []netmon_client.NodeError
 	
-	links, paths, traffics = controller.netmonClient.GetStats(nodemap, isBwUpdate)
+	links, paths, traffics, nodeErrors = controller.netmonClient.GetStats(controller.ctx, nodemap, isBwUpdate)
 	controller.pendingBwUpdate = false
 	 
 	
@@ -180,7 +208,14 @@ func (controller *Controller) UpdateNetMetrics(isBwUpdate bool) {
 		}
 	}
 
-	_, hpaths, traffics := controller.netmonClient.GetHeadroomStats(nodemap, controller.headroomReq)
+	_, hpaths, traffics, headroomErrors := controller.netmonClient.GetHeadroomStats(controller.ctx, nodemap, controller.headroomReq)
+	controller.staleNodes = make(map[string]bool, 0)
+	for _, nodeErr := range append(nodeErrors, headroomErrors...) {
+		if nodeName, exists := controller.nodes[nodeErr.Host]; exists {
+			logger(fmt.Sprintf("node %s: %v", nodeName, nodeErr))
+			controller.staleNodes[nodeName] = true
+		}
+	}
 	for src, dstPaths := range paths {
 		for dst, path := range dstPaths {
 			srcNode, exists := controller.nodes[src]
@@ -248,7 +283,7 @@ func (controller *Controller) UpdateNetMetrics(isBwUpdate bool) {
 			if !exists || !dexists {
 				continue
 			}
-			logger(fmt.Sprintf("src = %s dst = %s used = %f\n", src, dst, traffic.Bytes))
+			logger(fmt.Sprintf("src = %s dst = %s used = %f xdp rates = %v\n", src, dst, traffic.Bytes, traffic.KernelRates))
 			srcTraf, tExists := controller.pathsUsed[srcNode]
 			if !tExists {
 				controller.pathsUsed[srcNode] = make(map[string]netmon_client.Traffic, 0)
@@ -279,6 +314,8 @@ func (controller *Controller) UpdatePods() {
 	podLists := controller.kubeClient.GetPods()
 	podSet := make(PodSet, 0)
 	podDeps := make(PodDeps, 0)
+	running := make([]Pod, 0)
+	present := make(map[string]bool, 0) // ns/name of every pod, for the damper
 	logger(fmt.Sprintf("Got pods from %d namespaces\n", len(podLists)))
 
 	for _, podList := range podLists {
@@ -290,8 +327,15 @@ func (controller *Controller) UpdatePods() {
 			//	controller.namespaceAvgUtilization[ns] = 0.0
 			//}
 			podName := getPodName(kubePod.Metadata.Name)
-			podInfo := Pod{podName: podName, podId: kubePod.Metadata.Name, deployedNode: kubePod.Spec.NodeName, namespace: kubePod.Metadata.Namespace}
+			podInfo := Pod{podName: podName, podId: kubePod.Metadata.Name, deployedNode: kubePod.Spec.NodeName, namespace: kubePod.Metadata.Namespace, uid: kubePod.Metadata.Uid, ip: kubePod.Status.PodIP}
+			if created, err := time.Parse(time.RFC3339, kubePod.Metadata.CreationTimestamp); err == nil {
+				podInfo.created = created.Unix()
+			}
 			podSet[podName] = podInfo
+			present[podInfo.namespace+"/"+podName] = true
+			if kubePod.Status.Phase == POD_RUNNING && kubePod.Metadata.DeletionTimestamp == "" {
+				running = append(running, podInfo)
+			}
 			//logger(fmt.Sprintf("Got pod %s", kubePod.Metadata.Name))
 			podDeps[podName] = make(map[string]PodDependency, 0)
 		}
@@ -347,6 +391,20 @@ func (controller *Controller) UpdatePods() {
 			}
 		}
 	}
+	// a pod that is pending or terminating does not hold on to its reservations, nor does it
+	// shadow the pod replacing it
+	controller.podsRunning = make(PodSet, 0)
+	for _, pod := range running {
+		controller.podsRunning[pod.namespace+"/"+pod.podName] = pod
+	}
+	now := time.Now().Unix()
+	for _, pod := range controller.migrations.Update(running, now) {
+		if controller.damper.Returned(pod, now) {
+			logger(fmt.Sprintf("pod %s is back on node %s", pod.podId, pod.deployedNode))
+			controller.migrations.Record(now, pod, MIGRATION_RETURNED, pod.deployedNode, 0, "placed on a node it left recently")
+		}
+	}
+	controller.damper.Prune(present, now)
 	for pname, pod := range podSet {
 		controller.pods[pname] = pod
 		_, cExists := controller.podDepReq[pname]
@@ -369,6 +427,159 @@ func (controller *Controller) UpdatePods() {
 
 }
 
+// the ledger the scheduler keeps the bw pods were placed with in
+func (controller *Controller) SetLedgerStore(store LedgerStore) {
+	controller.ledger = store
+}
+
+// brings the reservations in line with the pods: what a pod uses of its reservation is written to it, and
+// the reservations of pods that run on other nodes than they were reserved for, or have not been running
+// for RESERVATION_GRACE_SECONDS since they were first seen not running, are released. Placement hints older than that are dropped
+func (controller *Controller) ReconcileReservations() {
+	if controller.ledger == nil {
+		return
+	}
+	ledgers, err := controller.ledger.LoadLedgers()
+	if err != nil {
+		logger(fmt.Sprintf("could not load the reservations: %v", err))
+		return
+	}
+	now := time.Now()
+	for _, ledger := range ledgers {
+		err := UpdateLedger(controller.ledger, ledger.Namespace, func(ledger *ReservationLedger) bool {
+			return controller.reconcileLedger(ledger, now)
+		})
+		if err != nil {
+			logger(fmt.Sprintf("could not update the reservations of %s: %v", ledger.Namespace, err))
+		}
+	}
+}
+
+func (controller *Controller) reconcileLedger(ledger *ReservationLedger, now time.Time) bool {
+	changed := false
+	for key, r := range ledger.Reservations {
+		src, srcRunning := controller.podsRunning[ledger.Namespace+"/"+r.Pod]
+		dst, dstRunning := controller.podsRunning[ledger.Namespace+"/"+r.Dep]
+		moved := (srcRunning && src.deployedNode != r.SrcNode) || (dstRunning && dst.deployedNode != r.DstNode)
+		if moved || ((!srcRunning || !dstRunning) && reservationExpired(r, now)) {
+			logger(fmt.Sprintf("releasing %f reserved from %s on %s to %s on %s", r.Bandwidth, r.Pod, r.SrcNode, r.Dep, r.DstNode))
+			delete(ledger.Reservations, key)
+			changed = true
+			continue
+		}
+		// the grace period starts when a pod of the reservation is first seen not running
+		if srcRunning && dstRunning && r.Missing != 0 {
+			r.Missing = 0
+			ledger.Reservations[key] = r
+			changed = true
+		} else if (!srcRunning || !dstRunning) && r.Missing == 0 {
+			r.Missing = now.Unix()
+			ledger.Reservations[key] = r
+			changed = true
+		}
+		used := controller.podDepActual[r.Pod][r.Dep].Bandwidth
+		if math.Abs(used-r.Used) > RESERVATION_USED_STEP*r.Bandwidth {
+			r.Used = used
+			ledger.Reservations[key] = r
+			changed = true
+		}
+	}
+	// a hint the scheduler did not take within the grace period is stale
+	for pod, hint := range ledger.Hints {
+		if now.Unix()-hint.Time > RESERVATION_GRACE_SECONDS {
+			logger(fmt.Sprintf("dropping the hint to place %s on %s", pod, hint.Node))
+			delete(ledger.Hints, pod)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// have the netmon of every node limit what its pods send to other nodes to what was reserved for them
+func (controller *Controller) SetQosEnforcement(enforce bool) {
+	controller.enforceQos = enforce
+}
+
+// sends every node the limits of the reservations of the pods running on it, so that a pod cannot take
+// more of a path than it was placed with. A node that does not enforce a limit is logged
+func (controller *Controller) EnforceReservations() {
+	if !controller.enforceQos || controller.ledger == nil {
+		return
+	}
+	ledgers, err := controller.ledger.LoadLedgers()
+	if err != nil {
+		logger(fmt.Sprintf("could not load the reservations to enforce: %v", err))
+		return
+	}
+	limits := controller.qosLimits(ledgers)
+	controller.qosVersion += 1
+	statuses, nodeErrors := controller.netmonClient.SetQosPolicies(controller.ctx, controller.qosVersion, limits)
+	for _, nodeErr := range nodeErrors {
+		logger(fmt.Sprintf("could not set the qos policy: %v", nodeErr))
+	}
+	for _, status := range statuses {
+		if status.Error != "" {
+			logger(fmt.Sprintf("netmon %s does not enforce limits: %s", status.Node, status.Error))
+			continue
+		}
+		for _, limitStatus := range status.Limits {
+			if !limitStatus.Enforced {
+				logger(fmt.Sprintf("netmon %s does not enforce %s: %s", status.Node, limitStatus.Limit.Id, limitStatus.Error))
+			}
+		}
+	}
+	controller.qosStatus = statuses
+}
+
+// netmon host -> limits on the pods running on its node, for the reservations between pods on different nodes
+func (controller *Controller) qosLimits(ledgers []*ReservationLedger) map[string][]netmon_client.QosLimit {
+	hosts := make(map[string]string, 0) // node name -> netmon host
+	for nodeIp, nodeName := range controller.nodes {
+		hosts[nodeName] = nodeIp
+	}
+	for host, nodeIp := range controller.ipMap {
+		if nodeName, exists := controller.nodes[nodeIp]; exists {
+			hosts[nodeName] = host
+		}
+	}
+	limits := make(map[string][]netmon_client.QosLimit, 0)
+	for _, ledger := range ledgers {
+		keys := make([]string, 0)
+		for key := range ledger.Reservations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			r := ledger.Reservations[key]
+			src, srcRunning := controller.podsRunning[ledger.Namespace+"/"+r.Pod]
+			dst, dstRunning := controller.podsRunning[ledger.Namespace+"/"+r.Dep]
+			if !srcRunning || !dstRunning || src.ip == "" || dst.ip == "" || src.deployedNode == dst.deployedNode {
+				continue
+			}
+			host, exists := hosts[src.deployedNode]
+			if !exists {
+				continue
+			}
+			limits[host] = append(limits[host], netmon_client.QosLimit{Id: ledger.Namespace + "/" + key, Source: src.ip, Destination: dst.ip, Rate: r.Bandwidth})
+		}
+	}
+	return limits
+}
+
+// releases the reservations of a pod that was moved away
+func (controller *Controller) releaseReservations(pod Pod) {
+	if controller.ledger == nil {
+		return
+	}
+	err := UpdateLedger(controller.ledger, pod.namespace, func(ledger *ReservationLedger) bool {
+		return len(ledger.ReleasePod(pod.podName)) > 0
+	})
+	if err != nil {
+		logger(fmt.Sprintf("could not release the reservations of %s: %v", pod.podId, err))
+	}
+}
+
+// in name order, the bw of the pods looked at first is taken off the links the others share
 func (controller *Controller) getPodsOnNode(nodeName string) []Pod {
 	podList := make([]Pod, 0)
 	for _, pod := range controller.pods {
@@ -376,6 +587,7 @@ func (controller *Controller) getPodsOnNode(nodeName string) []Pod {
 			podList = append(podList, pod)
 		}
 	}
+	sort.Slice(podList, func(i, j int) bool { return podList[i].podName < podList[j].podName })
 	return podList
 }
 
@@ -470,8 +682,11 @@ func (controller *Controller) CheckPodReqSatisfiedOne(bwNeeded map[string]map[st
 	return false, bwUsed, fracUsed
 }
 
+// the bw used by the pods looked at is taken off the links of their paths, and bwAvailable of every path
+// going over one of those links goes down with it
 func (controller *Controller) findPodsToReschedule(bwNeeded map[string]map[string]float64,
 	bwAvailable map[string]map[string]float64,
+	links *netmon_client.LinkModel,
 	node string) (bool, []Pod) {
 	pList := make(PairList, 0)
 
@@ -483,6 +698,7 @@ func (controller *Controller) findPodsToReschedule(bwNeeded map[string]map[strin
 			continue	// hack to prevent db migration
 		}
 		toRelo, usedBw, fracUsed := controller.CheckPodReqSatisfiedOne(bwNeeded, bwAvailable, pod)
+		controller.damper.Observe(pod, fracUsed)
 		for node, val := range usedBw {
 			if pod.deployedNode != node {
 				logger(fmt.Sprintf("pod = %s node=%s needed = %f headrrom=%f \n", pod.podName, node, float64(bwAvailable[pod.deployedNode][node] - val ) , controller.headroomReference[pod.deployedNode][node].Bandwidth))
@@ -491,7 +707,8 @@ func (controller *Controller) findPodsToReschedule(bwNeeded map[string]map[strin
 					totalShortfall += float64(bwAvailable[pod.deployedNode][node]) - val
 				} 
 				if val < bwAvailable[pod.deployedNode][node] {
-					bwAvailable[pod.deployedNode][node] -= val
+					links.Reserve(pod.deployedNode, node, val)
+					updateAvailable(bwAvailable, links)
 				}
 				logger(fmt.Sprintf("dst node %s bw used %f avail %f\n", node, val, bwAvailable[pod.deployedNode][node]))
 			}
@@ -523,6 +740,17 @@ func (controller *Controller) findPodsToReschedule(bwNeeded map[string]map[strin
 	return true, podsToReschedule
 }
 
+// lowers the bw available on every path to what is left on its links
+func updateAvailable(bwAvailable map[string]map[string]float64, links *netmon_client.LinkModel) {
+	for src, dstBws := range bwAvailable {
+		for dst, bw := range dstBws {
+			if left := links.Available(src, dst); left < bw {
+				dstBws[dst] = left
+			}
+		}
+	}
+}
+
 func (controller *Controller) ShouldReschedulePods(namespace string) bool {
 	// for pods in the same namespace check if the usage is much lesser or greater than a set threshold. On average if most pods are under/overutilizing bandwidth, we reschedule
 	avgUtilization := 0.0
@@ -583,6 +811,8 @@ func (controller *Controller) EvaluateUsage() {
 // Check if the node has sufficient bw to all dependees of the pod
 func (controller *Controller) EvaluateDeployment() {
 	logger("REQ\n")
+	// pods whose paths changed are evaluated even if their namespace is not due
+	rerouted := controller.podsOnChangedRoutes()
 	bwNeeded := make(map[string]map[string]float64, 0)
 	bwAvailable := make(map[string]map[string]float64, 0)
 	// calculate available bandwidth
@@ -611,7 +841,7 @@ func (controller *Controller) EvaluateDeployment() {
 			nsValTime = 0
 		}
 		diff := time.Now().Unix() - nsValTime
-		if diff < controller.valuationInterval {
+		if diff < controller.valuationInterval && !rerouted[src] {
 			continue
 		}
 		for dst, podReq := range podDeps {
@@ -679,22 +909,53 @@ func (controller *Controller) EvaluateDeployment() {
 		logger("No pods to reschedule in any namespace")
 		return
 	}
+	// paths are keyed by node name, their hops are ips
+	links := netmon_client.NewLinkModel(controller.pathsFree, controller.nodes)
 	nodes := controller.getNodes()
 	numRescheduled := 0
 	for _, node := range nodes {
-		needToReschedule, pods := controller.findPodsToReschedule(bwNeeded, bwAvailable, node)
+		if controller.staleNodes[node] {
+			// don't move pods based on what an unreachable netmon reported last
+			logger("netmon data of node " + node + " is stale, skipping")
+			continue
+		}
+		needToReschedule, pods := controller.findPodsToReschedule(bwNeeded, bwAvailable, links, node)
 		if len(pods) == 0{
 			continue
 		}
 		ts, _ := controller.namespaceValuationTime[pods[0].namespace]
 		timediff := time.Now().Unix() - ts
 		logger(fmt.Sprintf("time diff = %d", timediff))
-		if timediff >= controller.valuationInterval && needToReschedule {
+		due := timediff >= controller.valuationInterval
+		if needToReschedule {
 			logger(fmt.Sprintf("%d pods need to be rescheduled from node %s\n", len(pods), node))
 			for _, pod := range pods {
-				controller.migrationFile.WriteString(fmt.Sprintf("%d,%s\n", time.Now().Unix(), pod.podName))	
-				logger("moving pod " + pod.podId)
-				controller.kubeClient.DeletePod(pod.podId, pod.namespace)
+				if !due && !rerouted[pod.podName] {
+					continue
+				}
+				now := time.Now().Unix()
+				if reason := controller.damper.Suppress(pod, now); reason != "" {
+					logger(fmt.Sprintf("not moving pod %s: %s", pod.podId, reason))
+					controller.migrations.Record(now, pod, MIGRATION_SUPPRESSED, "", 0, reason)
+					continue
+				}
+				plan, reason := controller.planMigration(pod, links)
+				if reason != "" {
+					logger(fmt.Sprintf("not moving pod %s: %s", pod.podId, reason))
+					controller.migrations.Record(now, pod, MIGRATION_SKIPPED, plan.To, 0, reason)
+					continue
+				}
+				if reason := controller.damper.SuppressPlan(plan, now); reason != "" {
+					logger(fmt.Sprintf("not moving pod %s: %s", pod.podId, reason))
+					controller.migrations.Record(now, pod, MIGRATION_SUPPRESSED, plan.To, 0, reason)
+					continue
+				}
+				if !controller.migratePod(plan) {
+					continue
+				}
+				controller.damper.Started(plan, now)
+				reservePlan(plan, links)
+				updateAvailable(bwAvailable, links)
 				controller.namespaceValuationTime[pod.namespace] = time.Now().Unix()
 				numRescheduled += 1
 			}
@@ -708,8 +969,136 @@ func (controller *Controller) EvaluateDeployment() {
 
 }
 
+func (controller *Controller) SetDampingPolicy(policy DampingPolicy) {
+	if policy.HighWatermark < controller.utilChangeThreshold {
+		logger(fmt.Sprintf("high watermark %.2f is below the util change threshold %.2f, pods below the threshold are not moved anyway",
+			policy.HighWatermark, controller.utilChangeThreshold))
+	}
+	controller.damper.policy = policy
+}
+
+func (controller *Controller) SetMigrationLimits(limits MigrationLimits) {
+	controller.migrations.limits = limits
+}
+
+// evicts the pod so that its owner recreates it and the scheduler places it on the node of the plan, unless
+// the migration limits or a PodDisruptionBudget do not allow it now. Whatever happens is written to the migration file
+func (controller *Controller) migratePod(plan MigrationPlan) bool {
+	pod, node := plan.Pod, plan.From
+	now := time.Now().Unix()
+	if reason := controller.migrations.Allowed(pod, now); reason != "" {
+		logger(fmt.Sprintf("not moving pod %s: %s", pod.podId, reason))
+		controller.migrations.Record(now, pod, MIGRATION_THROTTLED, plan.To, 0, reason)
+		return false
+	}
+	logger(fmt.Sprintf("moving pod %s from %s to %s", pod.podId, node, plan.To))
+	// the hint has to be there before the scheduler sees the pod replacing it
+	controller.hintPlacement(plan, true)
+	err := controller.kubeClient.EvictPod(pod.podId, pod.namespace)
+	if err != nil {
+		controller.hintPlacement(plan, false)
+	}
+	if err == ErrEvictionBlocked {
+		logger(fmt.Sprintf("not moving pod %s: %v", pod.podId, err))
+		controller.migrations.Record(now, pod, MIGRATION_BLOCKED, plan.To, 0, err.Error())
+		controller.emitPodEvent(pod, "EvictionBlocked", fmt.Sprintf("Not evicted from node %s, %v", node, err), EVENT_WARNING)
+		return false
+	}
+	if err == ErrEvictionThrottled {
+		logger(fmt.Sprintf("not moving pod %s: %v", pod.podId, err))
+		controller.migrations.Record(now, pod, MIGRATION_THROTTLED, plan.To, 0, err.Error())
+		return false
+	}
+	if err != nil {
+		logger(fmt.Sprintf("could not evict pod %s: %v", pod.podId, err))
+		controller.migrations.Record(now, pod, MIGRATION_FAILED, plan.To, 0, err.Error())
+		return false
+	}
+	controller.migrations.Start(pod, plan.To, now, fmt.Sprintf("gain %.2f", plan.Gain()))
+	controller.emitPodEvent(pod, "Rescheduled", fmt.Sprintf("Evicted from node %s to be placed on node %s, bandwidth needed by its dependencies exceeds the bandwidth available", node, plan.To), EVENT_NORMAL)
+	controller.releaseReservations(pod)
+	return true
+}
+
+// remembers that the route from the src of change to its dst changed, the monitor evaluates the
+// pods on that path as soon as it can
+func (controller *Controller) RouteChanged(change netmon_client.RouteChange) {
+	logger(fmt.Sprintf("route %s -> %s changed from %v to %v, version %d", change.Source, change.Destination, change.OldHops, change.NewHops, change.Version))
+	controller.routeLock.Lock()
+	if _, exists := controller.routeChanges[change.Source]; !exists {
+		controller.routeChanges[change.Source] = make(map[string]bool, 0)
+	}
+	controller.routeChanges[change.Source][change.Destination] = true
+	controller.routeLock.Unlock()
+	select {
+	case controller.reevaluate <- true:
+	default:
+	}
+}
+
+// pods with a dependency between two nodes whose route changed since the last call, by pod name
+func (controller *Controller) podsOnChangedRoutes() map[string]bool {
+	controller.routeLock.Lock()
+	routeChanges := controller.routeChanges
+	controller.routeChanges = make(map[string]map[string]bool, 0)
+	controller.routeLock.Unlock()
+
+	changed := make(map[string]map[string]bool, 0)
+	for src, dsts := range routeChanges {
+		for dst, _ := range dsts {
+			srcNode, exists := controller.nodes[src]
+			dstNode, dexists := controller.nodes[dst]
+			if !exists || !dexists {
+				continue
+			}
+			// traffic both ways may take the changed route
+			for _, pair := range [][]string{{srcNode, dstNode}, {dstNode, srcNode}} {
+				if _, exists := changed[pair[0]]; !exists {
+					changed[pair[0]] = make(map[string]bool, 0)
+				}
+				changed[pair[0]][pair[1]] = true
+			}
+		}
+	}
+	rerouted := make(map[string]bool, 0)
+	for src, podDeps := range controller.podDepReq {
+		srcPod, exists := controller.pods[src]
+		if !exists {
+			continue
+		}
+		for dst, _ := range podDeps {
+			dstPod, exists := controller.pods[dst]
+			if !exists || !changed[srcPod.deployedNode][dstPod.deployedNode] {
+				continue
+			}
+			if !rerouted[src] {
+				logger(fmt.Sprintf("route of pod %s to %s changed, evaluating it", src, dst))
+				controller.emitPodEvent(srcPod, "RouteChanged", fmt.Sprintf("Route from node %s to node %s changed, evaluating the placement", srcPod.deployedNode, dstPod.deployedNode), EVENT_NORMAL)
+			}
+			rerouted[src] = true
+		}
+	}
+	return rerouted
+}
+
 func (controller *Controller) MonitorState(delay time.Duration) chan bool {
 	stop := make(chan bool)
+	go func() {
+		<-stop
+		controller.cancel()
+	}()
+	routes := controller.netmonClient.WatchRoutes(controller.ctx, controller.ipMap)
+	go func() {
+		defer routes.Close()
+		for {
+			select {
+			case change := <-routes.Changes():
+				controller.RouteChanged(change)
+			case <-controller.ctx.Done():
+				return
+			}
+		}
+	}()
 	go func() {
 		logger("Monitor started")
 		for {
@@ -717,12 +1106,16 @@ func (controller *Controller) MonitorState(delay time.Duration) chan bool {
 			controller.UpdateNodes()
 			controller.UpdatePods()
 			controller.UpdatePodMetrics()
+			controller.ReconcileReservations()
+			controller.EnforceReservations()
 			controller.UpdateNetMetrics(controller.pendingBwUpdate)	// by default we only update headroom not total link capacity
 				controller.EvaluateDeployment()
 			//controller.EvaluateUsage()
 			select {
 			case <-time.After(delay):
-			case <-stop:
+			case <-controller.reevaluate:
+				logger("routes changed, evaluating now")
+			case <-controller.ctx.Done():
 				logger("Monitor stopped")
 				return
 			}
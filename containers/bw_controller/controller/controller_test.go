@@ -0,0 +1,270 @@
+package bw_controller
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+var TEST_NODES = map[string]string{"10.0.0.1": "n1", "10.0.0.2": "n2", "10.0.0.3": "n3"}
+
+// kube client with a fixed set of nodes and pods, evictions and events are only remembered.
+// Evicting a pod in blocked fails with its error, as if a PodDisruptionBudget did not allow it or the api server throttled it
+type fakeKubeClient struct {
+	pods    []PodList
+	evicted []string
+	blocked map[string]error
+	events  []Event
+}
+
+func (cl *fakeKubeClient) GetNodes() (*NodeList, error) {
+	nodeList := &NodeList{Items: make([]Node, 0)}
+	for ip, name := range TEST_NODES {
+		annotations := map[string]string{"alpha.kubernetes.io/provided-node-ip": ip}
+		nodeList.Items = append(nodeList.Items, Node{Metadata: Metadata{Name: name, Annotations: annotations}})
+	}
+	return nodeList, nil
+}
+
+func (cl *fakeKubeClient) GetPods() []PodList {
+	return cl.pods
+}
+
+func (cl *fakeKubeClient) EvictPod(podname string, namespace string) error {
+	if err, exists := cl.blocked[podname]; exists {
+		return err
+	}
+	cl.evicted = append(cl.evicted, podname)
+	return nil
+}
+
+func (cl *fakeKubeClient) PostEvent(event Event, ns string) error {
+	cl.events = append(cl.events, event)
+	return nil
+}
+
+func (cl *fakeKubeClient) PatchEvent(event Event, ns string) error {
+	return nil
+}
+
+// prometheus client that reports fixed pod to pod usage, in bytes like the real one
+type fakePromClient struct {
+	podDeps PodDeps
+}
+
+func (cl *fakePromClient) GetPodMetrics() (PodSet, PodDeps) {
+	return make(PodSet, 0), cl.podDeps
+}
+
+func getTestPod(name string, node string, annotations map[string]string) K3sPod {
+	return K3sPod{Metadata: Metadata{Name: name, Namespace: "app", Uid: name, Annotations: annotations}, Spec: PodSpec{NodeName: node},
+		Status: PodStatus{Phase: POD_RUNNING}}
+}
+
+// controller for a src pod on n1 that needs 100 towards back on n2 and uses used of it
+func getTestController(t *testing.T, topo *netmontest.Topology, src string, used float64) (*Controller, *fakeKubeClient) {
+	fake := netmontest.NewFakeNetmon(topo, "10.0.0.1", "10.0.0.2", "10.0.0.3")
+	netmonClient := netmon_client.NewNetmonClientWithOptions(fake.Addresses(), fake.DialOption())
+	kubeClient := &fakeKubeClient{evicted: make([]string, 0), blocked: make(map[string]error, 0), events: make([]Event, 0)}
+	kubeClient.pods = []PodList{{Items: []K3sPod{
+		getTestPod(src+"-5d9c8-x2k4", "n1", map[string]string{"dependson.back.bw": "100"}),
+		getTestPod("back-7f9c-x2k4", "n2", map[string]string{}),
+	}}}
+	promClient := &fakePromClient{podDeps: PodDeps{src: {"back": PodDependency{Source: src, Destination: "back", Bandwidth: used / 8}}}}
+	ipMap := make(map[string]string, 0)
+	for ip, _ := range TEST_NODES {
+		ipMap[ip] = ip
+	}
+	dir := t.TempDir()
+	controller := NewController(promClient, netmonClient, kubeClient, 0, 0.5, filepath.Join(dir, "bw.csv"), filepath.Join(dir, "migration.csv"), 0.5, ipMap)
+	t.Cleanup(func() {
+		controller.Shutdown()
+		netmonClient.Close()
+		fake.Close()
+	})
+	return controller, kubeClient
+}
+
+func TestEvaluateDeployment(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		linkBw  float64
+		traffic float64
+		used    float64
+		down    bool     // netmon of n1 is unreachable after the first round
+		want    []string // pods evicted
+	}{
+		{"enough bandwidth", "front", 1000, 80, 80, false, []string{}},
+		{"congested path", "front", 100, 10, 80, false, []string{"front-5d9c8-x2k4"}},
+		{"low usage", "front", 100, 10, 16, false, []string{}},
+		{"db pod is never moved", "db", 100, 10, 80, false, []string{}},
+		{"stale node is left alone", "front", 100, 10, 80, true, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", test.linkBw, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 1000, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", test.traffic)
+			controller, kubeClient := getTestController(t, topo, test.src, test.used)
+			if test.down {
+				topo.SetDown("10.0.0.1", true)
+				controller.UpdateNetMetrics(false)
+				if !controller.staleNodes["n1"] || len(controller.pathsFree["n1"]) == 0 {
+					t.Fatalf("Want n1 stale with its cached paths, got %v", controller.pathsFree["n1"])
+				}
+			}
+			controller.EvaluateDeployment()
+
+			sort.Strings(kubeClient.evicted)
+			if len(kubeClient.evicted) != len(test.want) {
+				t.Fatalf("Want %v evicted, got %v", test.want, kubeClient.evicted)
+			}
+			for i, pod := range test.want {
+				if kubeClient.evicted[i] != pod {
+					t.Fatalf("Want %v evicted, got %v", test.want, kubeClient.evicted)
+				}
+			}
+			if len(kubeClient.events) != len(test.want) || controller.pendingBwUpdate != (len(test.want) > 0) {
+				t.Fatalf("Want a Rescheduled event and a bw update for each evicted pod, got %d events, bw update %v", len(kubeClient.events), controller.pendingBwUpdate)
+			}
+		})
+	}
+}
+
+func TestHeadroomChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		traffic []float64
+		want    bool // bw update pending after the second round
+	}{
+		{"steady traffic", []float64{10, 20}, false},
+		{"traffic eats the headroom", []float64{10, 80}, true},
+		{"traffic goes away", []float64{90, 10}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", test.traffic...)
+			controller, _ := getTestController(t, topo, "front", 16)
+			if topo.BwUpdates("10.0.0.1") != 1 {
+				t.Fatalf("Want the link bw measured on start, got %d updates", topo.BwUpdates("10.0.0.1"))
+			}
+			// headroom requested is the link bw times the headroom threshold
+			if req, _ := topo.HeadroomRequest("10.0.0.1", "10.0.0.2"); req != 50 {
+				t.Fatalf("Want headroom request 50, got %f", req)
+			}
+			topo.Step()
+			controller.UpdateNetMetrics(false)
+			if controller.pendingBwUpdate != test.want {
+				t.Fatalf("Want bw update pending %v, got %v", test.want, controller.pendingBwUpdate)
+			}
+		})
+	}
+}
+
+func TestRouteChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		bw12   float64 // bw of the direct link between n1 and n2
+		bw32   float64
+		src    string // route from src to dst changes to go over hop
+		dst    string
+		hop    string
+		want   []string // pods evicted
+		events []string // reasons of the events posted
+	}{
+		{"rerouted over a slow link", 1000, 100, "10.0.0.1", "10.0.0.2", "10.0.0.3", []string{"front-5d9c8-x2k4"}, []string{"RouteChanged", "Rescheduled"}},
+		{"rerouted over a fast link", 1000, 1000, "10.0.0.1", "10.0.0.2", "10.0.0.3", []string{}, []string{"RouteChanged"}},
+		{"reverse route changed", 1000, 100, "10.0.0.2", "10.0.0.1", "10.0.0.3", []string{}, []string{"RouteChanged"}},
+		{"other route changed", 100, 1000, "10.0.0.2", "10.0.0.3", "10.0.0.1", []string{}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", test.bw12, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.2", test.bw32, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+			controller, kubeClient := getTestController(t, topo, "front", 80)
+			// the namespace is not due, only a route change gets its pods evaluated
+			controller.valuationInterval = 3600
+			controller.namespaceValuationTime["app"] = time.Now().Unix()
+			routes := controller.netmonClient.WatchRoutes(context.Background(), controller.ipMap)
+			defer routes.Close()
+
+			topo.SetRoute(test.src, test.dst, test.hop)
+			select {
+			case change := <-routes.Changes():
+				controller.RouteChanged(change)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for the route change")
+			}
+			select {
+			case <-controller.reevaluate:
+			default:
+				t.Fatalf("Want an evaluation right after the route change")
+			}
+			controller.UpdateNetMetrics(false)
+			controller.EvaluateDeployment()
+
+			if !reflect.DeepEqual(kubeClient.evicted, test.want) {
+				t.Fatalf("Want %v evicted, got %v", test.want, kubeClient.evicted)
+			}
+			reasons := make([]string, 0)
+			for _, event := range kubeClient.events {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(reasons, test.events) {
+				t.Fatalf("Want events %v, got %v", test.events, reasons)
+			}
+			// the change is only acted on once
+			if rerouted := controller.podsOnChangedRoutes(); len(rerouted) != 0 {
+				t.Fatalf("Want no pods left to evaluate, got %v", rerouted)
+			}
+		})
+	}
+}
+
+func TestSharedLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		route []string // hops from n1 to n3
+		want  []string // pods evicted
+	}{
+		{"paths share the link n1 -> n2", []string{"10.0.0.2"}, []string{"web-6b7d4-p9q2"}},
+		{"direct path", []string{}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 1000, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.3", test.route...)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+			controller, kubeClient := getTestController(t, topo, "front", 40)
+			// web needs 50 towards store on n3 and uses 40, front uses 40 of the link towards n2 first
+			kubeClient.pods[0].Items = append(kubeClient.pods[0].Items,
+				getTestPod("web-6b7d4-p9q2", "n1", map[string]string{"dependson.store.bw": "50"}),
+				getTestPod("store-8c2d-m3n4", "n3", map[string]string{}))
+			controller.promClient.(*fakePromClient).podDeps["web"] = map[string]PodDependency{"store": PodDependency{Source: "web", Destination: "store", Bandwidth: 40 / 8}}
+			controller.UpdatePods()
+			controller.UpdatePodMetrics()
+			controller.EvaluateDeployment()
+
+			if !reflect.DeepEqual(kubeClient.evicted, test.want) {
+				t.Fatalf("Want %v evicted, got %v", test.want, kubeClient.evicted)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package bw_controller
+
+import (
+	"fmt"
+	"sort"
+)
+
+// why a migration was suppressed, first word of the detail in the migration file
+const (
+	SUPPRESS_WATERMARK = "watermark"
+	SUPPRESS_COOLDOWN  = "cooldown"
+	SUPPRESS_BUDGET    = "budget"
+	SUPPRESS_RETURN    = "return"
+)
+
+// DampingPolicy keeps pods from moving back and forth on a mesh whose bw fluctuates, a value of 0 turns that part off.
+// The controller only looks at pods that use the util change threshold of their bw, a HighWatermark below it holds none back
+type DampingPolicy struct {
+	Cooldown      int64   // seconds a moved pod stays where it was moved to
+	Budget        int     // migrations started per window
+	Window        int64   // seconds
+	HighWatermark float64 // fraction of the bw it needs a pod has to use to be moved
+	LowWatermark  float64 // fraction a moved pod has to use less than before it is moved again
+	Rearm         int64   // seconds after which a moved pod may be moved again without going below the low watermark
+	ReturnWindow  int64   // seconds a pod is not moved back to a node it left
+}
+
+// policy with both watermarks taken from the threshold the pods are evaluated against
+func DefaultDampingPolicy(utilChangeThreshold float64) DampingPolicy {
+	return DampingPolicy{Cooldown: 600, Budget: 5, Window: 600, HighWatermark: utilChangeThreshold, LowWatermark: utilChangeThreshold / 2,
+		Rearm: 3600, ReturnWindow: 1800}
+}
+
+type podHistory struct {
+	fracUsed  float64
+	armed     bool             // used less than the low watermark since it was moved
+	lastMoved int64            // time of the last migration started
+	left      map[string]int64 // node -> last time the pod was moved away from it
+}
+
+// Damper decides which of the migrations the controller wants are suppressed. Pods are kept by
+// namespace and name, so the history carries over to the pods replacing them
+type Damper struct {
+	policy  DampingPolicy
+	pods    map[string]*podHistory
+	started []int64 // times of the migrations started in the window
+}
+
+func NewDamper(policy DampingPolicy) *Damper {
+	return &Damper{policy: policy, pods: make(map[string]*podHistory, 0), started: make([]int64, 0)}
+}
+
+func (damper *Damper) getHistory(pod Pod) *podHistory {
+	key := pod.namespace + "/" + pod.podName
+	history, exists := damper.pods[key]
+	if !exists {
+		history = &podHistory{armed: true, left: make(map[string]int64, 0)}
+		damper.pods[key] = history
+	}
+	return history
+}
+
+// remembers the fraction of its bw the pod used in the last evaluation
+func (damper *Damper) Observe(pod Pod, fracUsed float64) {
+	history := damper.getHistory(pod)
+	history.fracUsed = fracUsed
+	if fracUsed < damper.policy.LowWatermark {
+		history.armed = true
+	}
+}
+
+// why the pod may not be moved now, "" if it may
+func (damper *Damper) Suppress(pod Pod, now int64) string {
+	history := damper.getHistory(pod)
+	policy := damper.policy
+	if history.fracUsed < policy.HighWatermark {
+		return fmt.Sprintf("%s: uses %.2f, below the high watermark %.2f", SUPPRESS_WATERMARK, history.fracUsed, policy.HighWatermark)
+	}
+	if !history.armed && (policy.Rearm == 0 || now-history.lastMoved < policy.Rearm) {
+		return fmt.Sprintf("%s: has not used less than the low watermark %.2f since it was moved %ds ago", SUPPRESS_WATERMARK, policy.LowWatermark,
+			now-history.lastMoved)
+	}
+	if history.lastMoved > 0 && now-history.lastMoved < policy.Cooldown {
+		return fmt.Sprintf("%s: moved %ds ago", SUPPRESS_COOLDOWN, now-history.lastMoved)
+	}
+	damper.expire(now)
+	if policy.Budget > 0 && len(damper.started) >= policy.Budget {
+		return fmt.Sprintf("%s: %d migrations in the last %ds", SUPPRESS_BUDGET, len(damper.started), policy.Window)
+	}
+	return ""
+}
+
+// why the pod may not be moved to the target of the plan, "" if it may
+func (damper *Damper) SuppressPlan(plan MigrationPlan, now int64) string {
+	if left, returns := damper.leftRecently(plan.Pod, plan.To, now); returns {
+		return fmt.Sprintf("%s: would go back to %s it left %ds ago", SUPPRESS_RETURN, plan.To, now-left)
+	}
+	return ""
+}
+
+func (damper *Damper) leftRecently(pod Pod, node string, now int64) (int64, bool) {
+	left, exists := damper.getHistory(pod).left[node]
+	return left, exists && now-left < damper.policy.ReturnWindow
+}
+
+// counts the migration of the plan against the budget, the pod has to cool down and drop below the low watermark
+func (damper *Damper) Started(plan MigrationPlan, now int64) {
+	history := damper.getHistory(plan.Pod)
+	history.lastMoved = now
+	history.left[plan.From] = now
+	if damper.policy.LowWatermark > 0 {
+		history.armed = false
+	}
+	damper.started = append(damper.started, now)
+}
+
+// whether the pod replacing a moved one runs on a node the pod left recently, the scheduler did not follow the plan
+func (damper *Damper) Returned(replacement Pod, now int64) bool {
+	_, returned := damper.leftRecently(replacement, replacement.deployedNode, now)
+	return returned
+}
+
+// forgets the nodes pods left before the return window, and the pods that are gone once nothing holds them back
+// anymore. A moved pod is gone until its replacement is created, its history is kept for the replacement until then
+func (damper *Damper) Prune(present map[string]bool, now int64) {
+	for key, history := range damper.pods {
+		for node, left := range history.left {
+			if now-left >= damper.policy.ReturnWindow {
+				delete(history.left, node)
+			}
+		}
+		cooledDown := history.lastMoved == 0 || now-history.lastMoved >= damper.policy.Cooldown
+		if !present[key] && len(history.left) == 0 && cooledDown {
+			delete(damper.pods, key)
+		}
+	}
+}
+
+// drops the migrations that are out of the window
+func (damper *Damper) expire(now int64) {
+	sort.Slice(damper.started, func(i, j int) bool { return damper.started[i] < damper.started[j] })
+	idx := 0
+	for idx < len(damper.started) && now-damper.started[idx] >= damper.policy.Window {
+		idx += 1
+	}
+	damper.started = damper.started[idx:]
+}
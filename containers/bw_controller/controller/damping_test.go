@@ -0,0 +1,137 @@
+package bw_controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+func TestDamper(t *testing.T) {
+	policy := DampingPolicy{Cooldown: 300, Budget: 2, Window: 600, HighWatermark: 0.5, LowWatermark: 0.2, Rearm: 1500, ReturnWindow: 1000}
+	front := Pod{podId: "front-5d9c8-x2k4", podName: "front", namespace: "app", deployedNode: "n1"}
+	web := Pod{podId: "web-6b7d4-p9q2", podName: "web", namespace: "app", deployedNode: "n1"}
+	store := Pod{podId: "store-8c2d-m3n4", podName: "store", namespace: "app", deployedNode: "n3"}
+	tests := []struct {
+		name  string
+		setup func(damper *Damper)
+		pod   Pod
+		now   int64
+		want  string // reason the migration is suppressed
+	}{
+		{"below the high watermark", func(damper *Damper) { damper.Observe(front, 0.4) }, front, 100, SUPPRESS_WATERMARK},
+		{"above the high watermark", func(damper *Damper) { damper.Observe(front, 0.8) }, front, 100, ""},
+		{"moved and not below the low watermark since", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+			damper.Observe(front, 0.8)
+		}, front, 1000, SUPPRESS_WATERMARK},
+		{"moved and rearmed after a while", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+			damper.Observe(front, 0.8)
+		}, front, 1600, ""},
+		{"cooling down", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+			damper.Observe(front, 0.1)
+			damper.Observe(front, 0.8)
+		}, front, 200, SUPPRESS_COOLDOWN},
+		{"cooled down", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+			damper.Observe(front, 0.1)
+			damper.Observe(front, 0.8)
+		}, front, 500, ""},
+		{"budget used", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: web, From: "n1", To: "n2"}, 100)
+			damper.Started(MigrationPlan{Pod: store, From: "n3", To: "n2"}, 150)
+			damper.Observe(front, 0.8)
+		}, front, 200, SUPPRESS_BUDGET},
+		{"budget window passed", func(damper *Damper) {
+			damper.Started(MigrationPlan{Pod: web, From: "n1", To: "n2"}, 100)
+			damper.Started(MigrationPlan{Pod: store, From: "n3", To: "n2"}, 150)
+			damper.Observe(front, 0.8)
+		}, front, 750, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			damper := NewDamper(policy)
+			test.setup(damper)
+			reason := damper.Suppress(test.pod, test.now)
+			if (test.want == "") != (reason == "") || !strings.HasPrefix(reason, test.want) {
+				t.Fatalf("Want %q, got %q", test.want, reason)
+			}
+		})
+	}
+
+	// front left n1 for n2, it is not planned back there until the return window passed
+	damper := NewDamper(policy)
+	damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+	back := MigrationPlan{Pod: Pod{podId: "front-5d9c8-h7j1", podName: "front", namespace: "app", deployedNode: "n2"}, From: "n2", To: "n1"}
+	if reason := damper.SuppressPlan(back, 500); !strings.HasPrefix(reason, SUPPRESS_RETURN) {
+		t.Fatalf("Want the return suppressed, got %q", reason)
+	}
+	if reason := damper.SuppressPlan(back, 1200); reason != "" {
+		t.Fatalf("Want the return allowed after the window, got %q", reason)
+	}
+	if !damper.Returned(Pod{podId: "front-5d9c8-h7j1", podName: "front", namespace: "app", deployedNode: "n1"}, 150) {
+		t.Fatalf("Want a replacement on n1 detected as returned")
+	}
+}
+
+func TestDampingRecorded(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+	controller, kubeClient := getTestController(t, topo, "front", 80)
+	policy := DefaultDampingPolicy(0.5)
+	policy.Budget = 1
+	controller.SetDampingPolicy(policy)
+	// another pod used up the budget
+	controller.damper.Started(MigrationPlan{Pod: Pod{podName: "web", namespace: "app"}, From: "n1", To: "n2"}, time.Now().Unix())
+	controller.EvaluateDeployment()
+
+	if len(kubeClient.evicted) != 0 {
+		t.Fatalf("Want no pod evicted, got %v", kubeClient.evicted)
+	}
+	line := migrationLine(t, controller.migrationFile, 1)
+	if line[4] != MIGRATION_SUPPRESSED || !strings.HasPrefix(line[7], SUPPRESS_BUDGET) {
+		t.Fatalf("Want the migration suppressed by the budget, got %v", line)
+	}
+
+	// the scheduler put the pod replacing front back on n1
+	controller.damper = NewDamper(DefaultDampingPolicy(0.5))
+	controller.EvaluateDeployment()
+	replacement := getTestPod("front-5d9c8-h7j1", "n1", map[string]string{"dependson.back.bw": "100"})
+	replacement.Status.Phase = POD_RUNNING
+	replacement.Metadata.CreationTimestamp = time.Now().UTC().Format(time.RFC3339)
+	kubeClient.pods[0].Items[0] = replacement
+	controller.UpdatePods()
+	want := []string{MIGRATION_SUPPRESSED, MIGRATION_STARTED, MIGRATION_COMPLETED, MIGRATION_RETURNED}
+	if outcomes := migrationOutcomes(t, controller.migrationFile); strings.Join(outcomes, " ") != strings.Join(want, " ") {
+		t.Fatalf("Want outcomes %v, got %v", want, outcomes)
+	}
+}
+
+func TestDamperPrune(t *testing.T) {
+	damper := NewDamper(DampingPolicy{Cooldown: 300, HighWatermark: 0.5, LowWatermark: 0.2, ReturnWindow: 1000})
+	front := Pod{podId: "front-5d9c8-x2k4", podName: "front", namespace: "app", deployedNode: "n1"}
+	web := Pod{podId: "web-6b7d4-p9q2", podName: "web", namespace: "app", deployedNode: "n1"}
+	damper.Started(MigrationPlan{Pod: front, From: "n1", To: "n2"}, 100)
+	damper.Observe(web, 0.8)
+
+	// front is gone until its replacement is created, it keeps its history
+	damper.Prune(map[string]bool{}, 200)
+	if _, exists := damper.pods["app/front"]; !exists {
+		t.Fatalf("Want the history of the moved front kept")
+	}
+	if _, exists := damper.pods["app/web"]; exists {
+		t.Fatalf("Want the history of the gone web dropped")
+	}
+	damper.Prune(map[string]bool{"app/front": true}, 1200)
+	if len(damper.getHistory(front).left) != 0 {
+		t.Fatalf("Want n1 forgotten after the return window")
+	}
+	damper.Prune(map[string]bool{}, 1300)
+	if len(damper.pods) != 0 {
+		t.Fatalf("Want every history dropped, got %d", len(damper.pods))
+	}
+}
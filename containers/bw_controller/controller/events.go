@@ -0,0 +1,184 @@
+package bw_controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	EVENT_NORMAL  = "Normal"
+	EVENT_WARNING = "Warning"
+)
+
+const EVENT_COMPONENT = "bw-controller"
+
+// repeats of an event within the window update the count of the posted event instead of creating a new one
+const EVENT_DEDUP_WINDOW = 10 * time.Minute
+
+// EventClient posts events to the api server, the scheduler and the controller both record events through it
+type EventClient interface {
+	PostEvent(event Event, ns string) error
+	PatchEvent(event Event, ns string) error
+}
+
+// EventPoster is the EventClient of the kube clients, it talks to the events endpoint of the api server
+type EventPoster struct {
+	address        string
+	eventsEndpoint string // with the namespace as %s
+}
+
+func NewEventPoster(address string, eventsEndpoint string) *EventPoster {
+	return &EventPoster{address: address, eventsEndpoint: eventsEndpoint}
+}
+
+func (poster *EventPoster) PostEvent(event Event, ns string) error {
+	var b []byte
+	body := bytes.NewBuffer(b)
+	err := json.NewEncoder(body).Encode(event)
+	if err != nil {
+		return err
+	}
+
+	request := &http.Request{
+		Body:          ioutil.NopCloser(body),
+		ContentLength: int64(body.Len()),
+		Header:        make(http.Header),
+		Method:        http.MethodPost,
+		URL: &url.URL{
+			Host:   poster.address,
+			Path:   fmt.Sprintf(poster.eventsEndpoint, ns),
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 201 {
+		return errors.New("Event: Unexpected HTTP status code" + resp.Status)
+	}
+	return nil
+}
+
+// updates the count and last timestamp of an event posted earlier
+func (poster *EventPoster) PatchEvent(event Event, ns string) error {
+	patch := map[string]interface{}{"count": event.Count, "lastTimestamp": event.LastTimestamp}
+	var b []byte
+	body := bytes.NewBuffer(b)
+	err := json.NewEncoder(body).Encode(patch)
+	if err != nil {
+		return err
+	}
+
+	request := &http.Request{
+		Body:          ioutil.NopCloser(body),
+		ContentLength: int64(body.Len()),
+		Header:        make(http.Header),
+		Method:        http.MethodPatch,
+		URL: &url.URL{
+			Host:   poster.address,
+			Path:   fmt.Sprintf(poster.eventsEndpoint, ns) + "/" + event.Metadata.Name,
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return errors.New("Event: Unexpected HTTP status code" + resp.Status)
+	}
+	return nil
+}
+
+type recordedEvent struct {
+	event    Event
+	lastSeen time.Time
+}
+
+// EventRecorder posts events about objects and folds repeated ones into a single event
+type EventRecorder struct {
+	client    EventClient
+	component string                   // source of the events
+	events    map[string]recordedEvent // ns/name/reason/message -> event posted last
+	lock      *sync.Mutex
+}
+
+func NewEventRecorder(client EventClient, component string) *EventRecorder {
+	return &EventRecorder{client: client, component: component, events: make(map[string]recordedEvent, 0), lock: &sync.Mutex{}}
+}
+
+// reference to a pod the controller knows, for the events about it
+func PodReference(pod Pod) ObjectReference {
+	return ObjectReference{ApiVersion: "v1", Kind: "Pod", Name: pod.podId, Namespace: pod.namespace, Uid: pod.uid}
+}
+
+func newObjectEvent(object ObjectReference, component string, reason string, message string, eventType string) Event {
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	event := Event{
+		Count:          1,
+		Message:        message,
+		Reason:         reason,
+		LastTimestamp:  timestamp,
+		FirstTimestamp: timestamp,
+		Type:           eventType,
+		Source:         EventSource{Component: component},
+		InvolvedObject: object,
+	}
+	event.Metadata.Name = fmt.Sprintf("%s.%x", object.Name, time.Now().UnixNano())
+	event.Metadata.Namespace = object.Namespace
+	return event
+}
+
+func (recorder *EventRecorder) Record(object ObjectReference, reason string, message string, eventType string) error {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	now := time.Now()
+	for key, recorded := range recorder.events {
+		if now.Sub(recorded.lastSeen) > EVENT_DEDUP_WINDOW {
+			delete(recorder.events, key)
+		}
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s", object.Namespace, object.Name, reason, message)
+	recorded, exists := recorder.events[key]
+	if exists {
+		recorded.event.Count += 1
+		recorded.event.LastTimestamp = now.UTC().Format(time.RFC3339)
+		err := recorder.client.PatchEvent(recorded.event, object.Namespace)
+		if err == nil {
+			recorder.events[key] = recordedEvent{event: recorded.event, lastSeen: now}
+			return nil
+		}
+		// the event may have expired in the api server, post a new one
+		logger(fmt.Sprintf("could not update event %s: %v", recorded.event.Metadata.Name, err))
+	}
+	event := newObjectEvent(object, recorder.component, reason, message, eventType)
+	err := recorder.client.PostEvent(event, object.Namespace)
+	if err != nil {
+		return err
+	}
+	recorder.events[key] = recordedEvent{event: event, lastSeen: now}
+	return nil
+}
+
+// posts an event for the pod, errors are only logged
+func (controller *Controller) emitPodEvent(pod Pod, reason string, message string, eventType string) {
+	err := controller.events.Record(PodReference(pod), reason, message, eventType)
+	if err != nil {
+		logger(fmt.Sprintf("could not post event %s for pod %s: %v", reason, pod.podId, err))
+	}
+}
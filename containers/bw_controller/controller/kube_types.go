@@ -58,9 +58,15 @@ type PodWatchEvent struct {
 }
 
 type K3sPod struct {
-	Kind     string   `json:"kind,omitempty"`
-	Metadata Metadata `json:"metadata"`
-	Spec     PodSpec  `json:"spec"`
+	Kind     string    `json:"kind,omitempty"`
+	Metadata Metadata  `json:"metadata"`
+	Spec     PodSpec   `json:"spec"`
+	Status   PodStatus `json:"status"`
+}
+
+type PodStatus struct {
+	Phase string `json:"phase"`
+	PodIP string `json:"podIP"`
 }
 
 type PodSpec struct {
@@ -121,6 +127,9 @@ type Metadata struct {
 	Labels          map[string]string `json:"labels"`
 	Annotations     map[string]string `json:"annotations"`
 	Uid             string            `json:"uid"`
+	// set once the pod is terminating, it may still be in phase Running until its containers stopped
+	DeletionTimestamp string `json:"deletionTimestamp,omitempty"`
+	CreationTimestamp string `json:"creationTimestamp,omitempty"`
 }
 
 type Deployment struct {
@@ -131,3 +140,35 @@ type Deployment struct {
 	Annotations     map[string]string `json:"annotations"`
 	Uid             string            `json:"uid"`
 }
+
+type ConfigMap struct {
+	ApiVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   Metadata          `json:"metadata"`
+	Data       map[string]string `json:"data"`
+}
+
+// Eviction is posted to the eviction subresource of a pod
+type Eviction struct {
+	ApiVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+}
+
+// Status is the body the api server answers a failed request with
+type Status struct {
+	Kind    string        `json:"kind"`
+	Message string        `json:"message"`
+	Reason  string        `json:"reason"`
+	Details StatusDetails `json:"details"`
+	Code    int           `json:"code"`
+}
+
+type StatusDetails struct {
+	Causes []StatusCause `json:"causes"`
+}
+
+type StatusCause struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
@@ -14,11 +14,16 @@ package bw_controller
 // limitations under the License.
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -28,10 +33,12 @@ type KubeClient struct {
 	nodesEndpoint  string
 	podsEndpoint   string
 	deleteEndpoint string
+	evictEndpoint  string
+	events         *EventPoster
 }
 
-func NewKubeClient(address string, nodesEndpoint string, podsEndpoint string, deleteEndpoint string, namespaces []string) *KubeClient {
-	client := &KubeClient{address: address, nodesEndpoint: nodesEndpoint, podsEndpoint: podsEndpoint, deleteEndpoint: deleteEndpoint, namespaces: namespaces}
+func NewKubeClient(address string, nodesEndpoint string, podsEndpoint string, deleteEndpoint string, evictEndpoint string, eventsEndpoint string, namespaces []string) *KubeClient {
+	client := &KubeClient{address: address, nodesEndpoint: nodesEndpoint, podsEndpoint: podsEndpoint, deleteEndpoint: deleteEndpoint, evictEndpoint: evictEndpoint, events: NewEventPoster(address, eventsEndpoint), namespaces: namespaces}
 	success := client.WaitForProxy()
 	if !success {
 		panic("Unable to connect to K3s proxy")
@@ -153,9 +160,78 @@ func (client *KubeClient) DeletePod(podname string, namespace string) error {
 	resp, err := http.DefaultClient.Do(request)
 
 	if err != nil {
-		return nil
+		return err
 	}
-	logger(fmt.Sprintf("got response %s", resp.Body))
+	logger(fmt.Sprintf("got response %s", resp.Status))
+	if resp.StatusCode != 200 && resp.StatusCode != 202 {
+		return errors.New("Delete: Unexpected HTTP status code: " + resp.Status)
+	}
+
+	return nil
+}
+
+// evicts the pod through the eviction subresource, so the api server checks the PodDisruptionBudgets
+// of the pod first. Fails with ErrEvictionBlocked if a budget does not allow it now, and with
+// ErrEvictionThrottled if the api server throttles requests
+func (client *KubeClient) EvictPod(podname string, namespace string) error {
+	eviction := Eviction{ApiVersion: "policy/v1", Kind: "Eviction", Metadata: Metadata{Name: podname, Namespace: namespace}}
+	var b []byte
+	body := bytes.NewBuffer(b)
+	err := json.NewEncoder(body).Encode(eviction)
+	if err != nil {
+		return err
+	}
+
+	request := &http.Request{
+		Body:          ioutil.NopCloser(body),
+		ContentLength: int64(body.Len()),
+		Header:        make(http.Header),
+		Method:        http.MethodPost,
+		URL: &url.URL{
+			Host:   client.address,
+			Path:   fmt.Sprintf(client.evictEndpoint, namespace, podname),
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	logger(fmt.Sprintf("got response %s", resp.Status))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return evictionRejection(resp.Body)
+	}
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return errors.New("Evict: Unexpected HTTP status code: " + resp.Status)
+	}
+	return nil
+}
+
+// the api server answers too many requests when a PodDisruptionBudget does not allow the eviction and when
+// it throttles requests, the status it answers with tells them apart. A status that cannot be read is taken as throttling
+func evictionRejection(body io.Reader) error {
+	var status Status
+	if err := json.NewDecoder(body).Decode(&status); err != nil {
+		return ErrEvictionThrottled
+	}
+	for _, cause := range status.Details.Causes {
+		if cause.Reason == "DisruptionBudget" {
+			return ErrEvictionBlocked
+		}
+	}
+	if strings.Contains(strings.ToLower(status.Message), "disruption budget") {
+		return ErrEvictionBlocked
+	}
+	return ErrEvictionThrottled
+}
+
+func (client *KubeClient) PostEvent(event Event, ns string) error {
+	return client.events.PostEvent(event, ns)
+}
 
-	return err
+func (client *KubeClient) PatchEvent(event Event, ns string) error {
+	return client.events.PatchEvent(event, ns)
 }
@@ -0,0 +1,311 @@
+package bw_controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the reservations of a namespace are kept in a config map of that namespace
+const LEDGER_CONFIGMAP = "bw-reservations"
+const LEDGER_KEY = "reservations.json"
+const LEDGER_HINTS_KEY = "hints.json"
+const LEDGER_LABEL = "epl/bw-reservations"
+
+// times an update is tried again when the reservations changed since they were loaded
+const LEDGER_RETRIES = 5
+
+// reservations of pods that are not running are kept this long after they were first seen not running
+const RESERVATION_GRACE_SECONDS = 300
+
+// the usage of a reservation is written back when it moved by more than this fraction of the reservation
+const RESERVATION_USED_STEP = 0.05
+
+var ErrLedgerConflict = errors.New("reservations changed since they were loaded")
+
+// Reservation is the bw a pod was placed with towards one of its dependencies. Pods are named like
+// in PodDeps, without the replica suffix
+type Reservation struct {
+	Pod       string  `json:"pod"` // sends to Dep
+	Dep       string  `json:"dep"`
+	SrcNode   string  `json:"srcNode"`
+	DstNode   string  `json:"dstNode"`
+	Bandwidth float64 `json:"bandwidth"`
+	Used      float64 `json:"used"`              // last measured by the controller, netmon sees that part already
+	Time      int64   `json:"time"`              // unix time it was made
+	Missing   int64   `json:"missing,omitempty"` // unix time one of its pods was first seen not running, 0 while both run
+}
+
+// PlacementHint is the node the controller planned for a pod it evicted, the scheduler tries it first
+// when it places the pod that replaces it
+type PlacementHint struct {
+	Pod  string `json:"pod"`
+	Node string `json:"node"`
+	From string `json:"from"` // node the pod was evicted from
+	Time int64  `json:"time"`
+}
+
+// ReservationLedger has the reservations of the pods of a namespace
+type ReservationLedger struct {
+	Namespace    string
+	Version      string                   // resourceVersion of the config map, "" if there is none yet
+	Reservations map[string]Reservation   // pod/dep -> reservation
+	Hints        map[string]PlacementHint // pod -> hint
+}
+
+// LedgerStore loads and saves the ledgers, SaveLedger fails with ErrLedgerConflict if the ledger
+// changed since it was loaded
+type LedgerStore interface {
+	LoadLedger(ns string) (*ReservationLedger, error)
+	LoadLedgers() ([]*ReservationLedger, error) // of every namespace that has one
+	SaveLedger(ledger *ReservationLedger) error
+}
+
+func NewReservationLedger(ns string) *ReservationLedger {
+	return &ReservationLedger{Namespace: ns, Reservations: make(map[string]Reservation, 0), Hints: make(map[string]PlacementHint, 0)}
+}
+
+func reservationKey(pod string, dep string) string {
+	return pod + "/" + dep
+}
+
+// adds the reservation, replacing the one for the same pods
+func (ledger *ReservationLedger) Reserve(r Reservation) {
+	ledger.Reservations[reservationKey(r.Pod, r.Dep)] = r
+}
+
+// removes the reservations to and from pod
+func (ledger *ReservationLedger) ReleasePod(pod string) []Reservation {
+	released := make([]Reservation, 0)
+	for key, r := range ledger.Reservations {
+		if r.Pod == pod || r.Dep == pod {
+			released = append(released, r)
+			delete(ledger.Reservations, key)
+		}
+	}
+	return released
+}
+
+// adds the hint, replacing the one for the same pod
+func (ledger *ReservationLedger) Hint(hint PlacementHint) {
+	ledger.Hints[hint.Pod] = hint
+}
+
+// removes the hint of pod, returns whether it had one
+func (ledger *ReservationLedger) ClearHint(pod string) bool {
+	if _, exists := ledger.Hints[pod]; !exists {
+		return false
+	}
+	delete(ledger.Hints, pod)
+	return true
+}
+
+// bw reserved between nodes that is not in use yet, src node -> dst node -> bw
+func (ledger *ReservationLedger) Pending() map[string]map[string]float64 {
+	pending := make(map[string]map[string]float64, 0)
+	for _, r := range ledger.Reservations {
+		if r.SrcNode == r.DstNode || r.Bandwidth <= r.Used {
+			continue
+		}
+		if _, exists := pending[r.SrcNode]; !exists {
+			pending[r.SrcNode] = make(map[string]float64, 0)
+		}
+		pending[r.SrcNode][r.DstNode] += r.Bandwidth - r.Used
+	}
+	return pending
+}
+
+// the reservations as stored in the config map, sorted so the same ledger is always stored the same
+func (ledger *ReservationLedger) Encode() (string, error) {
+	reservations := make([]Reservation, 0)
+	for _, r := range ledger.Reservations {
+		reservations = append(reservations, r)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservationKey(reservations[i].Pod, reservations[i].Dep) < reservationKey(reservations[j].Pod, reservations[j].Dep)
+	})
+	data, err := json.Marshal(reservations)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func DecodeLedger(ns string, version string, data string) (*ReservationLedger, error) {
+	ledger := NewReservationLedger(ns)
+	ledger.Version = version
+	if data == "" {
+		return ledger, nil
+	}
+	reservations := make([]Reservation, 0)
+	if err := json.Unmarshal([]byte(data), &reservations); err != nil {
+		return nil, fmt.Errorf("reservations of %s: %v", ns, err)
+	}
+	for _, r := range reservations {
+		ledger.Reserve(r)
+	}
+	return ledger, nil
+}
+
+// the hints as stored in the config map, sorted like the reservations
+func (ledger *ReservationLedger) EncodeHints() (string, error) {
+	hints := make([]PlacementHint, 0)
+	for _, hint := range ledger.Hints {
+		hints = append(hints, hint)
+	}
+	sort.Slice(hints, func(i, j int) bool { return hints[i].Pod < hints[j].Pod })
+	data, err := json.Marshal(hints)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// adds the hints stored in data to the ledger, ledgers saved before there were hints have none
+func (ledger *ReservationLedger) DecodeHints(data string) error {
+	if data == "" {
+		return nil
+	}
+	hints := make([]PlacementHint, 0)
+	if err := json.Unmarshal([]byte(data), &hints); err != nil {
+		return fmt.Errorf("hints of %s: %v", ledger.Namespace, err)
+	}
+	for _, hint := range hints {
+		ledger.Hint(hint)
+	}
+	return nil
+}
+
+// ns -> pod -> node planned for it, of every ledger
+func PlacementHints(ledgers []*ReservationLedger) map[string]map[string]string {
+	hints := make(map[string]map[string]string, 0)
+	for _, ledger := range ledgers {
+		for pod, hint := range ledger.Hints {
+			if _, exists := hints[ledger.Namespace]; !exists {
+				hints[ledger.Namespace] = make(map[string]string, 0)
+			}
+			hints[ledger.Namespace][pod] = hint.Node
+		}
+	}
+	return hints
+}
+
+// adds up the bw pending in every ledger
+func PendingReservations(ledgers []*ReservationLedger) map[string]map[string]float64 {
+	pending := make(map[string]map[string]float64, 0)
+	for _, ledger := range ledgers {
+		for src, dstBws := range ledger.Pending() {
+			if _, exists := pending[src]; !exists {
+				pending[src] = make(map[string]float64, 0)
+			}
+			for dst, bw := range dstBws {
+				pending[src][dst] += bw
+			}
+		}
+	}
+	return pending
+}
+
+// loads the ledger of ns, applies update and saves it if update says it changed. Loads it again
+// and retries when someone else saved it in between
+func UpdateLedger(store LedgerStore, ns string, update func(ledger *ReservationLedger) bool) error {
+	var err error
+	for i := 0; i < LEDGER_RETRIES; i++ {
+		var ledger *ReservationLedger
+		ledger, err = store.LoadLedger(ns)
+		if err != nil {
+			return err
+		}
+		if !update(ledger) {
+			return nil
+		}
+		err = store.SaveLedger(ledger)
+		if err != ErrLedgerConflict {
+			return err
+		}
+		logger(fmt.Sprintf("reservations of %s changed while updating them, retrying", ns))
+	}
+	return err
+}
+
+// MemoryLedgerStore keeps the ledgers in memory, for tests and for running without a cluster
+type MemoryLedgerStore struct {
+	lock    *sync.Mutex
+	data    map[string]string // ns -> encoded ledger
+	hints   map[string]string // ns -> encoded hints
+	version map[string]int
+}
+
+func NewMemoryLedgerStore() *MemoryLedgerStore {
+	return &MemoryLedgerStore{lock: &sync.Mutex{}, data: make(map[string]string, 0), hints: make(map[string]string, 0), version: make(map[string]int, 0)}
+}
+
+func (store *MemoryLedgerStore) LoadLedger(ns string) (*ReservationLedger, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	version := ""
+	if v, exists := store.version[ns]; exists {
+		version = fmt.Sprintf("%d", v)
+	}
+	ledger, err := DecodeLedger(ns, version, store.data[ns])
+	if err != nil {
+		return nil, err
+	}
+	if err := ledger.DecodeHints(store.hints[ns]); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+func (store *MemoryLedgerStore) LoadLedgers() ([]*ReservationLedger, error) {
+	store.lock.Lock()
+	namespaces := make([]string, 0)
+	for ns := range store.data {
+		namespaces = append(namespaces, ns)
+	}
+	store.lock.Unlock()
+	sort.Strings(namespaces)
+	ledgers := make([]*ReservationLedger, 0)
+	for _, ns := range namespaces {
+		ledger, err := store.LoadLedger(ns)
+		if err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, ledger)
+	}
+	return ledgers, nil
+}
+
+func (store *MemoryLedgerStore) SaveLedger(ledger *ReservationLedger) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	version := ""
+	if v, exists := store.version[ledger.Namespace]; exists {
+		version = fmt.Sprintf("%d", v)
+	}
+	if version != ledger.Version {
+		return ErrLedgerConflict
+	}
+	data, err := ledger.Encode()
+	if err != nil {
+		return err
+	}
+	hints, err := ledger.EncodeHints()
+	if err != nil {
+		return err
+	}
+	store.data[ledger.Namespace] = data
+	store.hints[ledger.Namespace] = hints
+	store.version[ledger.Namespace] += 1
+	ledger.Version = fmt.Sprintf("%d", store.version[ledger.Namespace])
+	return nil
+}
+
+// whether the pods of the reservation have not been running for long enough to release it. A pod that
+// restarts or misses a list keeps its reservation
+func reservationExpired(r Reservation, now time.Time) bool {
+	return r.Missing > 0 && now.Unix()-r.Missing > RESERVATION_GRACE_SECONDS
+}
@@ -0,0 +1,231 @@
+package bw_controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+// store that saves the ledger of "app" itself once before the first save, as if another writer raced it
+type racingLedgerStore struct {
+	*MemoryLedgerStore
+	raced bool
+}
+
+func (store *racingLedgerStore) SaveLedger(ledger *ReservationLedger) error {
+	if !store.raced {
+		store.raced = true
+		other, _ := store.MemoryLedgerStore.LoadLedger(ledger.Namespace)
+		other.Reserve(Reservation{Pod: "api", Dep: "db", SrcNode: "n2", DstNode: "n3", Bandwidth: 20})
+		store.MemoryLedgerStore.SaveLedger(other)
+	}
+	return store.MemoryLedgerStore.SaveLedger(ledger)
+}
+
+func TestReservationLedger(t *testing.T) {
+	ledger := NewReservationLedger("app")
+	ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100, Used: 30})
+	ledger.Reserve(Reservation{Pod: "back", Dep: "front", SrcNode: "n2", DstNode: "n1", Bandwidth: 10, Used: 20})
+	ledger.Reserve(Reservation{Pod: "back", Dep: "cache", SrcNode: "n2", DstNode: "n2", Bandwidth: 50})
+	// used above the reservation and pods on the same node reserve no path bw
+	want := map[string]map[string]float64{"n1": {"n2": 70}}
+	if pending := ledger.Pending(); !reflect.DeepEqual(pending, want) {
+		t.Fatalf("Want %v pending, got %v", want, pending)
+	}
+
+	data, err := ledger.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeLedger("app", "1", data)
+	if err != nil || !reflect.DeepEqual(decoded.Reservations, ledger.Reservations) {
+		t.Fatalf("Want the ledger back after encoding, got %v err %v", decoded, err)
+	}
+
+	ledger.Hint(PlacementHint{Pod: "front", Node: "n2", From: "n1", Time: 100})
+	hints, err := ledger.EncodeHints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.DecodeHints(hints); err != nil || !reflect.DeepEqual(decoded.Hints, ledger.Hints) {
+		t.Fatalf("Want the hints back after encoding, got %v err %v", decoded.Hints, err)
+	}
+
+	if released := ledger.ReleasePod("front"); len(released) != 2 || len(ledger.Reservations) != 1 {
+		t.Fatalf("Want both reservations of front released, got %v", released)
+	}
+}
+
+func TestUpdateLedgerConflict(t *testing.T) {
+	store := &racingLedgerStore{MemoryLedgerStore: NewMemoryLedgerStore()}
+	updates := 0
+	err := UpdateLedger(store, "app", func(ledger *ReservationLedger) bool {
+		updates += 1
+		ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100})
+		return true
+	})
+	if err != nil || updates != 2 {
+		t.Fatalf("Want the update retried once, got %d updates err %v", updates, err)
+	}
+	// both writes are kept
+	ledger, _ := store.LoadLedger("app")
+	if len(ledger.Reservations) != 2 {
+		t.Fatalf("Want 2 reservations, got %v", ledger.Reservations)
+	}
+}
+
+func TestReconcileReservations(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 1000, 1)
+	controller, _ := getTestController(t, topo, "front", 40)
+	store := NewMemoryLedgerStore()
+	controller.SetLedgerStore(store)
+	old := time.Now().Unix() - 2*RESERVATION_GRACE_SECONDS
+	UpdateLedger(store, "app", func(ledger *ReservationLedger) bool {
+		// front runs on n1 and uses 40 towards back on n2
+		ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100, Time: old})
+		// web is not running yet, only api that has not been running for long is released
+		ledger.Reserve(Reservation{Pod: "web", Dep: "back", SrcNode: "n3", DstNode: "n2", Bandwidth: 50, Time: time.Now().Unix()})
+		ledger.Reserve(Reservation{Pod: "api", Dep: "back", SrcNode: "n3", DstNode: "n2", Bandwidth: 50, Time: old, Missing: old})
+		// back was reserved on n3 but runs on n2
+		ledger.Reserve(Reservation{Pod: "back", Dep: "front", SrcNode: "n3", DstNode: "n1", Bandwidth: 10, Time: time.Now().Unix()})
+		// the scheduler never placed web on n3
+		ledger.Hint(PlacementHint{Pod: "web", Node: "n3", From: "n1", Time: old})
+		return true
+	})
+	controller.UpdatePods()
+	controller.UpdatePodMetrics()
+	controller.ReconcileReservations()
+
+	ledger, _ := store.LoadLedger("app")
+	keys := make([]string, 0)
+	for key := range ledger.Reservations {
+		keys = append(keys, key)
+	}
+	if len(keys) != 2 || ledger.Reservations["front/back"].Used != 40 {
+		t.Fatalf("Want front/back with 40 used and web/back left, got %v", ledger.Reservations)
+	}
+	if _, exists := ledger.Reservations["web/back"]; !exists {
+		t.Fatalf("Want web/back kept within its grace period, got %v", ledger.Reservations)
+	}
+	if len(ledger.Hints) != 0 {
+		t.Fatalf("Want the old hint dropped, got %v", ledger.Hints)
+	}
+
+	// the reservations of a moved pod go with it
+	controller.releaseReservations(controller.pods["front"])
+	if ledger, _ := store.LoadLedger("app"); len(ledger.Reservations) != 1 {
+		t.Fatalf("Want only web/back left after front moved, got %v", ledger.Reservations)
+	}
+}
+
+func TestReconcileReservationsRunningOnly(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 1000, 1)
+	controller, kubeClient := getTestController(t, topo, "front", 40)
+	// web never got past pending, the old back on n3 is terminating while its replacement runs on n2
+	web := getTestPod("web-6b7d4-p9q2", "n3", map[string]string{})
+	web.Status.Phase = "Pending"
+	oldBack := getTestPod("back-7f9c-a1b2", "n3", map[string]string{})
+	oldBack.Metadata.DeletionTimestamp = "2026-10-18T08:00:00Z"
+	kubeClient.pods[0].Items = append(kubeClient.pods[0].Items, web, oldBack)
+	store := NewMemoryLedgerStore()
+	controller.SetLedgerStore(store)
+	old := time.Now().Unix() - 2*RESERVATION_GRACE_SECONDS
+	UpdateLedger(store, "app", func(ledger *ReservationLedger) bool {
+		ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100, Time: old})
+		ledger.Reserve(Reservation{Pod: "web", Dep: "back", SrcNode: "n3", DstNode: "n2", Bandwidth: 50, Time: old, Missing: old})
+		return true
+	})
+	controller.UpdatePods()
+	controller.ReconcileReservations()
+
+	ledger, _ := store.LoadLedger("app")
+	if _, exists := ledger.Reservations["front/back"]; !exists || len(ledger.Reservations) != 1 {
+		t.Fatalf("Want only front/back kept, got %v", ledger.Reservations)
+	}
+}
+
+func TestReconcileReservationsRestart(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 1000, 1)
+	controller, kubeClient := getTestController(t, topo, "front", 40)
+	store := NewMemoryLedgerStore()
+	controller.SetLedgerStore(store)
+	old := time.Now().Unix() - 2*RESERVATION_GRACE_SECONDS
+	UpdateLedger(store, "app", func(ledger *ReservationLedger) bool {
+		ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100, Time: old})
+		return true
+	})
+	// back restarts, the old reservation is kept through it
+	back := kubeClient.pods[0].Items[1]
+	kubeClient.pods[0].Items = kubeClient.pods[0].Items[:1]
+	controller.UpdatePods()
+	controller.ReconcileReservations()
+	ledger, _ := store.LoadLedger("app")
+	if r, exists := ledger.Reservations["front/back"]; !exists || r.Missing == 0 {
+		t.Fatalf("Want front/back kept and back seen missing, got %v", ledger.Reservations)
+	}
+	kubeClient.pods[0].Items = append(kubeClient.pods[0].Items, back)
+	controller.UpdatePods()
+	controller.ReconcileReservations()
+	ledger, _ = store.LoadLedger("app")
+	if r, exists := ledger.Reservations["front/back"]; !exists || r.Missing != 0 {
+		t.Fatalf("Want front/back kept once back runs again, got %v", ledger.Reservations)
+	}
+
+	// back is gone longer than the grace period
+	kubeClient.pods[0].Items = kubeClient.pods[0].Items[:1]
+	controller.UpdatePods()
+	controller.ReconcileReservations()
+	ledger, _ = store.LoadLedger("app")
+	controller.reconcileLedger(ledger, time.Now().Add(2*RESERVATION_GRACE_SECONDS*time.Second))
+	if len(ledger.Reservations) != 0 {
+		t.Fatalf("Want front/back released after the grace period, got %v", ledger.Reservations)
+	}
+}
+
+func TestEnforceReservations(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 1000, 1)
+	topo.SetQosOff("10.0.0.3", true)
+	controller, kubeClient := getTestController(t, topo, "front", 40)
+	for i, ip := range []string{"10.244.1.5", "10.244.2.7"} {
+		kubeClient.pods[0].Items[i].Status.PodIP = ip
+	}
+	store := NewMemoryLedgerStore()
+	controller.SetLedgerStore(store)
+	controller.SetQosEnforcement(true)
+	now := time.Now().Unix()
+	UpdateLedger(store, "app", func(ledger *ReservationLedger) bool {
+		ledger.Reserve(Reservation{Pod: "front", Dep: "back", SrcNode: "n1", DstNode: "n2", Bandwidth: 100, Time: now})
+		// web is not running, there is no pod to limit yet
+		ledger.Reserve(Reservation{Pod: "web", Dep: "back", SrcNode: "n3", DstNode: "n2", Bandwidth: 50, Time: now})
+		return true
+	})
+	controller.UpdatePods()
+	controller.EnforceReservations()
+
+	policy := topo.QosPolicy("10.0.0.1")
+	if policy == nil || len(policy.Limits) != 1 {
+		t.Fatalf("Want one limit on n1, got %v", policy)
+	}
+	limit := policy.Limits[0]
+	if limit.Id != "app/front/back" || limit.Src != "10.244.1.5" || limit.Dst != "10.244.2.7" || limit.Rate != 100 {
+		t.Fatalf("Want front limited to 100 towards back, got %v", limit)
+	}
+	// nodes without limits get an empty policy, n3 does not enforce any
+	if policy := topo.QosPolicy("10.0.0.2"); policy == nil || policy.Version != 1 || len(policy.Limits) != 0 {
+		t.Fatalf("Want an empty policy on n2, got %v", policy)
+	}
+	if len(controller.qosStatus) != 3 {
+		t.Fatalf("Want the status of 3 nodes, got %v", controller.qosStatus)
+	}
+	for _, status := range controller.qosStatus {
+		if (status.Node == "10.0.0.3") != (status.Error != "") {
+			t.Fatalf("Want only n3 to report that it does not enforce limits, got %v", status)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package bw_controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// ConfigMapLedgerStore keeps the ledger of each namespace in its LEDGER_CONFIGMAP config map. The
+// scheduler and the controller both use it, the resourceVersion of the config map keeps their updates apart
+type ConfigMapLedgerStore struct {
+	address            string
+	configMapsEndpoint string // "/api/v1/namespaces/%s/configmaps"
+	namespaces         []string
+}
+
+func NewConfigMapLedgerStore(address string, configMapsEndpoint string, namespaces []string) *ConfigMapLedgerStore {
+	return &ConfigMapLedgerStore{address: address, configMapsEndpoint: configMapsEndpoint, namespaces: namespaces}
+}
+
+func (store *ConfigMapLedgerStore) LoadLedger(ns string) (*ReservationLedger, error) {
+	request := &http.Request{
+		Header: make(http.Header),
+		Method: http.MethodGet,
+		URL: &url.URL{
+			Host:   store.address,
+			Path:   fmt.Sprintf(store.configMapsEndpoint, ns) + "/" + LEDGER_CONFIGMAP,
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Accept", "application/json, */*")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return NewReservationLedger(ns), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Ledger: Unexpected HTTP status code: " + resp.Status)
+	}
+	var configMap ConfigMap
+	if err := json.NewDecoder(resp.Body).Decode(&configMap); err != nil {
+		return nil, err
+	}
+	ledger, err := DecodeLedger(ns, configMap.Metadata.ResourceVersion, configMap.Data[LEDGER_KEY])
+	if err != nil {
+		return nil, err
+	}
+	if err := ledger.DecodeHints(configMap.Data[LEDGER_HINTS_KEY]); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+func (store *ConfigMapLedgerStore) LoadLedgers() ([]*ReservationLedger, error) {
+	ledgers := make([]*ReservationLedger, 0)
+	for _, ns := range store.namespaces {
+		ledger, err := store.LoadLedger(ns)
+		if err != nil {
+			return nil, err
+		}
+		if ledger.Version != "" {
+			ledgers = append(ledgers, ledger)
+		}
+	}
+	return ledgers, nil
+}
+
+// creates the config map for a ledger without a version, else replaces it if it is still at that version
+func (store *ConfigMapLedgerStore) SaveLedger(ledger *ReservationLedger) error {
+	data, err := ledger.Encode()
+	if err != nil {
+		return err
+	}
+	hints, err := ledger.EncodeHints()
+	if err != nil {
+		return err
+	}
+	configMap := ConfigMap{ApiVersion: "v1", Kind: "ConfigMap",
+		Metadata: Metadata{Name: LEDGER_CONFIGMAP, Namespace: ledger.Namespace, ResourceVersion: ledger.Version,
+			Labels: map[string]string{LEDGER_LABEL: "true"}},
+		Data: map[string]string{LEDGER_KEY: data, LEDGER_HINTS_KEY: hints}}
+	var b []byte
+	body := bytes.NewBuffer(b)
+	if err := json.NewEncoder(body).Encode(configMap); err != nil {
+		return err
+	}
+
+	method := http.MethodPut
+	path := fmt.Sprintf(store.configMapsEndpoint, ledger.Namespace) + "/" + LEDGER_CONFIGMAP
+	if ledger.Version == "" {
+		method = http.MethodPost
+		path = fmt.Sprintf(store.configMapsEndpoint, ledger.Namespace)
+	}
+	request := &http.Request{
+		Body:          ioutil.NopCloser(body),
+		ContentLength: int64(body.Len()),
+		Header:        make(http.Header),
+		Method:        method,
+		URL: &url.URL{
+			Host:   store.address,
+			Path:   path,
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// a config map created or changed by someone else in between
+	if resp.StatusCode == http.StatusConflict {
+		return ErrLedgerConflict
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return errors.New("Ledger: Unexpected HTTP status code: " + resp.Status)
+	}
+	var saved ConfigMap
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err == nil {
+		ledger.Version = saved.Metadata.ResourceVersion
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package bw_controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// what happened to a migration, as written to the migration file
+const (
+	MIGRATION_STARTED    = "started"    // the pod was evicted
+	MIGRATION_COMPLETED  = "completed"  // a pod replacing it is running
+	MIGRATION_TIMEOUT    = "timeout"    // no pod replaced it within the timeout
+	MIGRATION_BLOCKED    = "blocked"    // a PodDisruptionBudget does not allow the eviction now
+	MIGRATION_FAILED     = "failed"     // the eviction failed
+	MIGRATION_THROTTLED  = "throttled"  // too many migrations in the namespace or from the node, or the api server throttles requests
+	MIGRATION_SKIPPED    = "skipped"    // no node fits the pod or moving it gains too little
+	MIGRATION_SUPPRESSED = "suppressed" // held back to keep the pod from moving back and forth
+	MIGRATION_RETURNED   = "returned"   // the pod replacing it runs on a node the pod left recently
+)
+
+// phase of a pod that replaces an evicted one once it runs
+const POD_RUNNING = "Running"
+
+const MIGRATION_HEADER = "time,pod,namespace,node,outcome,new_node,seconds,detail\n"
+
+var ErrEvictionBlocked = errors.New("eviction would violate a PodDisruptionBudget")
+
+var ErrEvictionThrottled = errors.New("api server throttles requests, eviction not tried")
+
+// MigrationLimits keeps migrations from taking down too much at once, a limit of 0 is no limit
+type MigrationLimits struct {
+	PerNamespace int   // migrations in flight in a namespace
+	PerNode      int   // migrations in flight from a node
+	Interval     int64 // seconds between two migrations in a namespace or from a node
+	Timeout      int64 // seconds a migration may take until a pod replacing it is running
+}
+
+var DEFAULT_MIGRATION_LIMITS = MigrationLimits{PerNamespace: 1, PerNode: 1, Interval: 0, Timeout: 300}
+
+type migration struct {
+	pod     Pod
+	target  string // node planned for the pod
+	started int64
+}
+
+// MigrationTracker follows every migration from the eviction until a pod replacing it is running
+type MigrationTracker struct {
+	limits        MigrationLimits
+	inFlight      map[string]migration // pod id of the evicted pod -> migration
+	lastNamespace map[string]int64     // ns -> time of the last eviction
+	lastNode      map[string]int64     // node -> time of the last eviction
+	file          *os.File
+}
+
+func NewMigrationTracker(limits MigrationLimits, file *os.File) *MigrationTracker {
+	return &MigrationTracker{limits: limits, inFlight: make(map[string]migration, 0), lastNamespace: make(map[string]int64, 0),
+		lastNode: make(map[string]int64, 0), file: file}
+}
+
+// why the pod may not be migrated now, "" if it may
+func (tracker *MigrationTracker) Allowed(pod Pod, now int64) string {
+	inNamespace, fromNode := 0, 0
+	for _, m := range tracker.inFlight {
+		if m.pod.namespace == pod.namespace {
+			inNamespace += 1
+		}
+		if m.pod.deployedNode == pod.deployedNode {
+			fromNode += 1
+		}
+	}
+	limits := tracker.limits
+	if limits.PerNamespace > 0 && inNamespace >= limits.PerNamespace {
+		return fmt.Sprintf("%d migrations in flight in namespace %s", inNamespace, pod.namespace)
+	}
+	if limits.PerNode > 0 && fromNode >= limits.PerNode {
+		return fmt.Sprintf("%d migrations in flight from node %s", fromNode, pod.deployedNode)
+	}
+	if last, exists := tracker.lastNamespace[pod.namespace]; exists && now-last < limits.Interval {
+		return fmt.Sprintf("last migration in namespace %s %ds ago", pod.namespace, now-last)
+	}
+	if last, exists := tracker.lastNode[pod.deployedNode]; exists && now-last < limits.Interval {
+		return fmt.Sprintf("last migration from node %s %ds ago", pod.deployedNode, now-last)
+	}
+	return ""
+}
+
+// the pod was evicted to be placed on target, it is followed until a pod replacing it is running
+func (tracker *MigrationTracker) Start(pod Pod, target string, now int64, detail string) {
+	tracker.inFlight[pod.podId] = migration{pod: pod, target: target, started: now}
+	tracker.lastNamespace[pod.namespace] = now
+	tracker.lastNode[pod.deployedNode] = now
+	tracker.Record(now, pod, MIGRATION_STARTED, target, 0, detail)
+}
+
+// ends the migrations whose pod was replaced by a running pod of the same name created after the eviction, or that
+// took longer than the timeout. Returns the pods that replaced one
+func (tracker *MigrationTracker) Update(running []Pod, now int64) []Pod {
+	replacements := make([]Pod, 0)
+	podIds := make([]string, 0)
+	for podId := range tracker.inFlight {
+		podIds = append(podIds, podId)
+	}
+	sort.Strings(podIds)
+	for _, podId := range podIds {
+		m := tracker.inFlight[podId]
+		seconds := now - m.started
+		if pod, replaced := replacementOf(m, running); replaced {
+			logger(fmt.Sprintf("migration of %s done, %s runs on %s after %ds", podId, pod.podId, pod.deployedNode, seconds))
+			detail := pod.podId
+			if m.target != "" && m.target != pod.deployedNode {
+				detail += " instead of " + m.target
+			}
+			tracker.Record(now, m.pod, MIGRATION_COMPLETED, pod.deployedNode, seconds, detail)
+			delete(tracker.inFlight, podId)
+			replacements = append(replacements, pod)
+			continue
+		}
+		if tracker.limits.Timeout > 0 && seconds > tracker.limits.Timeout {
+			logger(fmt.Sprintf("migration of %s timed out after %ds", podId, seconds))
+			tracker.Record(now, m.pod, MIGRATION_TIMEOUT, "", seconds, "no replacement running")
+			delete(tracker.inFlight, podId)
+		}
+	}
+	return replacements
+}
+
+// the pod the owner created for the evicted one. Replicas of the same name were there before the eviction, they do not replace it
+func replacementOf(m migration, running []Pod) (Pod, bool) {
+	for _, pod := range running {
+		if pod.podName == m.pod.podName && pod.namespace == m.pod.namespace && pod.podId != m.pod.podId && pod.created >= m.started {
+			return pod, true
+		}
+	}
+	return Pod{}, false
+}
+
+// number of migrations waiting for their replacement to run
+func (tracker *MigrationTracker) InFlight() int {
+	return len(tracker.inFlight)
+}
+
+// writes a line to the migration file, commas in detail are replaced so it stays one column
+func (tracker *MigrationTracker) Record(now int64, pod Pod, outcome string, newNode string, seconds int64, detail string) {
+	if tracker.file == nil {
+		return
+	}
+	tracker.file.WriteString(fmt.Sprintf("%d,%s,%s,%s,%s,%s,%d,%s\n", now, pod.podName, pod.namespace, pod.deployedNode, outcome, newNode, seconds,
+		strings.ReplaceAll(detail, ",", ";")))
+}
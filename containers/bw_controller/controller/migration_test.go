@@ -0,0 +1,158 @@
+package bw_controller
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+// lines written to the migration file so far, split into their columns
+func migrationLines(t *testing.T, file *os.File) [][]string {
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if lines[0]+"\n" != MIGRATION_HEADER {
+		t.Fatalf("Want the migration header first, got %s", lines[0])
+	}
+	columns := make([][]string, 0)
+	for _, line := range lines[1:] {
+		columns = append(columns, strings.Split(line, ","))
+	}
+	return columns
+}
+
+func migrationLine(t *testing.T, file *os.File, n int) []string {
+	lines := migrationLines(t, file)
+	if len(lines) < n {
+		t.Fatalf("Want at least %d migration lines, got %v", n, lines)
+	}
+	return lines[n-1]
+}
+
+func migrationOutcomes(t *testing.T, file *os.File) []string {
+	outcomes := make([]string, 0)
+	for _, line := range migrationLines(t, file) {
+		outcomes = append(outcomes, line[4])
+	}
+	return outcomes
+}
+
+func TestMigrationLimits(t *testing.T) {
+	tracker := NewMigrationTracker(MigrationLimits{PerNamespace: 2, PerNode: 1, Interval: 60, Timeout: 300}, nil)
+	front := Pod{podId: "front-5d9c8-x2k4", podName: "front", namespace: "app", deployedNode: "n1"}
+	tracker.Start(front, "n3", 100, "")
+	tests := []struct {
+		name    string
+		pod     Pod
+		now     int64
+		allowed bool
+	}{
+		{"same node", Pod{podId: "web-6b7d4-p9q2", podName: "web", namespace: "other", deployedNode: "n1"}, 200, false},
+		{"within the interval of the namespace", Pod{podId: "back-7f9c-x2k4", podName: "back", namespace: "app", deployedNode: "n2"}, 130, false},
+		{"after the interval", Pod{podId: "back-7f9c-x2k4", podName: "back", namespace: "app", deployedNode: "n2"}, 200, true},
+		{"other namespace and node", Pod{podId: "store-8c2d-m3n4", podName: "store", namespace: "other", deployedNode: "n3"}, 130, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reason := tracker.Allowed(test.pod, test.now); (reason == "") != test.allowed {
+				t.Fatalf("Want allowed %v, got reason %q", test.allowed, reason)
+			}
+		})
+	}
+
+	// the old pod still running and a replica created before the eviction do not complete the migration, its replacement does
+	tracker.Update([]Pod{front, {podId: "front-5d9c8-b3c5", podName: "front", namespace: "app", deployedNode: "n2", created: 90}}, 150)
+	if tracker.InFlight() != 1 {
+		t.Fatalf("Want the migration in flight while only the evicted pod and its replicas run")
+	}
+	tracker.Update([]Pod{{podId: "front-5d9c8-h7j1", podName: "front", namespace: "app", deployedNode: "n3", created: 155}}, 160)
+	if tracker.InFlight() != 0 {
+		t.Fatalf("Want the migration completed once its replacement runs")
+	}
+	tracker.Start(front, "n3", 200, "")
+	tracker.Update([]Pod{}, 501)
+	if tracker.InFlight() != 0 {
+		t.Fatalf("Want the migration timed out")
+	}
+}
+
+func TestMigrateBlocked(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+	controller, kubeClient := getTestController(t, topo, "front", 80)
+	kubeClient.blocked["front-5d9c8-x2k4"] = ErrEvictionBlocked
+	controller.EvaluateDeployment()
+
+	if len(kubeClient.evicted) != 0 || controller.migrations.InFlight() != 0 {
+		t.Fatalf("Want no pod evicted, got %v", kubeClient.evicted)
+	}
+	if len(kubeClient.events) != 1 || kubeClient.events[0].Reason != "EvictionBlocked" {
+		t.Fatalf("Want an EvictionBlocked event, got %v", kubeClient.events)
+	}
+	if outcomes := migrationOutcomes(t, controller.migrationFile); len(outcomes) != 1 || outcomes[0] != MIGRATION_BLOCKED {
+		t.Fatalf("Want the migration logged as blocked, got %v", outcomes)
+	}
+}
+
+func TestMigrateUntilRunning(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+	controller, kubeClient := getTestController(t, topo, "front", 80)
+	controller.EvaluateDeployment()
+	if len(kubeClient.evicted) != 1 || controller.migrations.InFlight() != 1 {
+		t.Fatalf("Want front evicted and its migration in flight, got %v", kubeClient.evicted)
+	}
+	// front is evaluated again before its replacement runs
+	controller.namespaceValuationTime["app"] = 0
+	controller.EvaluateDeployment()
+	if len(kubeClient.evicted) != 1 {
+		t.Fatalf("Want no second eviction while the first is in flight, got %v", kubeClient.evicted)
+	}
+
+	// n3 has no netmon data, back runs on n2
+	if line := migrationLine(t, controller.migrationFile, 1); line[5] != "n2" || line[7] != "gain 0.50" {
+		t.Fatalf("Want front planned on n2 with gain 0.50, got %v", line)
+	}
+	replacement := getTestPod("front-5d9c8-h7j1", "n2", map[string]string{"dependson.back.bw": "100"})
+	replacement.Status.Phase = POD_RUNNING
+	replacement.Metadata.CreationTimestamp = time.Now().UTC().Format(time.RFC3339)
+	kubeClient.pods[0].Items[0] = replacement
+	controller.UpdatePods()
+	if controller.migrations.InFlight() != 0 {
+		t.Fatalf("Want the migration done once front runs on n2")
+	}
+	want := []string{MIGRATION_STARTED, MIGRATION_SUPPRESSED, MIGRATION_COMPLETED}
+	outcomes := migrationOutcomes(t, controller.migrationFile)
+	if strings.Join(outcomes, " ") != strings.Join(want, " ") {
+		t.Fatalf("Want outcomes %v, got %v", want, outcomes)
+	}
+}
+
+func TestEvictionRejection(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"disruption budget cause", `{"kind":"Status","message":"Cannot evict pod as it would violate the pod's disruption budget.",` +
+			`"reason":"TooManyRequests","details":{"causes":[{"reason":"DisruptionBudget","message":"The disruption budget app-pdb needs 1 healthy pods"}]},"code":429}`,
+			ErrEvictionBlocked},
+		{"disruption budget message", `{"kind":"Status","message":"Cannot evict pod as it would violate the pod's disruption budget.","code":429}`, ErrEvictionBlocked},
+		{"api server throttling", `{"kind":"Status","message":"Too many requests, please try again later.","reason":"TooManyRequests","code":429}`, ErrEvictionThrottled},
+		{"no status", "", ErrEvictionThrottled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := evictionRejection(strings.NewReader(test.body)); err != test.want {
+				t.Fatalf("Want %v, got %v", test.want, err)
+			}
+		})
+	}
+}
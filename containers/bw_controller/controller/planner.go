@@ -0,0 +1,240 @@
+package bw_controller
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+)
+
+// a migration is skipped when the pod would get less than this fraction more of the bw it needs on the target
+const DEFAULT_MIGRATION_GAIN = 0.1
+
+// why no node was planned for a pod, the same checks the scheduler makes before it binds a pod
+const (
+	PLAN_NO_NETMON = "no netmon data"
+	PLAN_STALE     = "stale netmon data"
+	PLAN_SEND_BW   = "insufficient send bandwidth"
+	PLAN_RECV_BW   = "insufficient receive bandwidth"
+	PLAN_DEPS      = "dependency bandwidth not satisfied"
+)
+
+// bw a pod needs towards (send) or from a pod it depends on or that depends on it
+type podFlow struct {
+	peer Pod
+	bw   float64
+	used float64 // measured, what the pod sends is already taken off the links of its paths
+	send bool
+}
+
+// MigrationPlan is the node a pod is moved to and the bw it is expected to get there
+type MigrationPlan struct {
+	Pod       Pod
+	From      string
+	To        string
+	Needed    float64 // bw the pod needs towards and from the pods it depends on and that depend on it
+	BwFrom    float64 // part of Needed it gets on From
+	BwTo      float64 // part of Needed it is expected to get on To
+	Colocated int     // pods it depends on or that depend on it running on To
+	flows     []podFlow
+}
+
+// fraction of the bw the pod needs that it gains by moving
+func (plan MigrationPlan) Gain() float64 {
+	if plan.Needed == 0 {
+		return 0
+	}
+	return (plan.BwTo - plan.BwFrom) / plan.Needed
+}
+
+func (controller *Controller) SetMigrationGain(gain float64) {
+	controller.migrationGain = gain
+}
+
+// the peer of the pod if it runs on a known node, pods holds pending and deleted pods as well
+func (controller *Controller) getRunningPeer(pod Pod, peerName string) (Pod, bool) {
+	peer, exists := controller.podsRunning[pod.namespace+"/"+peerName]
+	if !exists || peer.deployedNode == "" {
+		return Pod{}, false
+	}
+	return peer, true
+}
+
+// the annotated bw between the pod and its peers, peers that do not run on a known node are left out
+func (controller *Controller) getPodFlows(pod Pod) []podFlow {
+	flows := make([]podFlow, 0)
+	for dst, dep := range controller.podDepReq[pod.podName] {
+		peer, exists := controller.getRunningPeer(pod, dst)
+		if !exists || dep.Bandwidth <= 0 {
+			continue
+		}
+		flows = append(flows, podFlow{peer: peer, bw: dep.Bandwidth, used: controller.podDepActual[pod.podName][dst].Bandwidth, send: true})
+	}
+	for src, deps := range controller.podDepReq {
+		dep, exists := deps[pod.podName]
+		peer, peerExists := controller.getRunningPeer(pod, src)
+		if !exists || !peerExists || src == pod.podName || dep.Bandwidth <= 0 {
+			continue
+		}
+		flows = append(flows, podFlow{peer: peer, bw: dep.Bandwidth})
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		if flows[i].peer.podName != flows[j].peer.podName {
+			return flows[i].peer.podName < flows[j].peer.podName
+		}
+		return flows[i].send
+	})
+	return flows
+}
+
+// bw left on the path between two nodes without eating into the headroom the pods are evaluated against,
+// used is bw taken off the path that would be freed
+func (controller *Controller) getPathBw(src string, dst string, used float64, links *netmon_client.LinkModel) float64 {
+	return math.Max(0, links.Available(src, dst)+used-controller.headroomReference[src][dst].Bandwidth)
+}
+
+// how much of the bw of its flows the pod gets on node. On the node it runs on what it sends already
+// counts against the links, so it is added back
+func (controller *Controller) getFlowsBw(flows []podFlow, node string, current bool, links *netmon_client.LinkModel) float64 {
+	total := 0.0
+	for _, flow := range flows {
+		peerNode := flow.peer.deployedNode
+		if peerNode == node {
+			total += flow.bw
+			continue
+		}
+		bw := 0.0
+		if flow.send && current {
+			bw = controller.getPathBw(node, peerNode, flow.used, links)
+		} else if flow.send {
+			bw = controller.getPathBw(node, peerNode, 0, links)
+		} else {
+			bw = controller.getPathBw(peerNode, node, 0, links)
+		}
+		total += math.Min(flow.bw, bw)
+	}
+	return total
+}
+
+// why the flows of the pod do not fit on node, "" if they do. Like the scheduler, the bw the node can send
+// and receive is checked first and then every path to and from the peers
+func (controller *Controller) fitFlows(flows []podFlow, node string, links *netmon_client.LinkModel) string {
+	if _, exists := controller.pathsFree[node]; !exists {
+		return PLAN_NO_NETMON
+	}
+	if controller.staleNodes[node] {
+		return PLAN_STALE
+	}
+	sendBw, recvBw := 0.0, 0.0
+	for _, flow := range flows {
+		if flow.peer.deployedNode == node {
+			continue
+		}
+		if flow.send {
+			sendBw += flow.bw
+		} else {
+			recvBw += flow.bw
+		}
+	}
+	if sendBw > links.SendBw(node) {
+		return PLAN_SEND_BW
+	}
+	if recvBw > links.RecvBw(node) {
+		return PLAN_RECV_BW
+	}
+	for _, flow := range flows {
+		peerNode := flow.peer.deployedNode
+		if peerNode == node {
+			continue
+		}
+		src, dst := node, peerNode
+		if !flow.send {
+			src, dst = peerNode, node
+		}
+		if flow.bw > controller.getPathBw(src, dst, 0, links) {
+			return PLAN_DEPS
+		}
+	}
+	return ""
+}
+
+// picks the node the pod gets the most of the bw it needs on, preferring nodes its peers run on. Returns
+// why the pod is not moved if no node fits or the best one does not gain it enough
+func (controller *Controller) planMigration(pod Pod, links *netmon_client.LinkModel) (MigrationPlan, string) {
+	flows := controller.getPodFlows(pod)
+	plan := MigrationPlan{Pod: pod, From: pod.deployedNode, flows: flows}
+	for _, flow := range flows {
+		plan.Needed += flow.bw
+	}
+	plan.BwFrom = controller.getFlowsBw(flows, pod.deployedNode, true, links)
+
+	nodes := controller.getNodes()
+	sort.Strings(nodes)
+	found := false
+	reasons := make([]string, 0)
+	for _, node := range nodes {
+		if node == pod.deployedNode {
+			continue
+		}
+		if reason := controller.fitFlows(flows, node, links); reason != "" {
+			reasons = append(reasons, node+" "+reason)
+			continue
+		}
+		colocated := 0
+		for _, flow := range flows {
+			if flow.peer.deployedNode == node {
+				colocated += 1
+			}
+		}
+		bw := controller.getFlowsBw(flows, node, false, links)
+		if !found || bw > plan.BwTo || (bw == plan.BwTo && colocated > plan.Colocated) {
+			plan.To, plan.BwTo, plan.Colocated = node, bw, colocated
+			found = true
+		}
+	}
+	if !found {
+		return plan, "no node fits: " + strings.Join(reasons, "; ")
+	}
+	logger(fmt.Sprintf("plan for %s: %s -> %s gets %f instead of %f of %f", pod.podName, plan.From, plan.To, plan.BwTo, plan.BwFrom, plan.Needed))
+	if plan.Gain() < controller.migrationGain {
+		return plan, fmt.Sprintf("gain %.2f below %.2f", plan.Gain(), controller.migrationGain)
+	}
+	return plan, ""
+}
+
+// takes the bw of the pod off the paths from the target, so the pods planned after it see less
+func reservePlan(plan MigrationPlan, links *netmon_client.LinkModel) {
+	for _, flow := range plan.flows {
+		peerNode := flow.peer.deployedNode
+		if peerNode == plan.To {
+			continue
+		}
+		if flow.send {
+			links.Reserve(plan.To, peerNode, flow.bw)
+		} else {
+			links.Reserve(peerNode, plan.To, flow.bw)
+		}
+	}
+}
+
+// tells the scheduler where the plan puts the pod, through the ledger of its namespace. Without a
+// plan the hint of the pod is removed
+func (controller *Controller) hintPlacement(plan MigrationPlan, planned bool) {
+	if controller.ledger == nil {
+		return
+	}
+	hint := PlacementHint{Pod: plan.Pod.podName, Node: plan.To, From: plan.From, Time: time.Now().Unix()}
+	err := UpdateLedger(controller.ledger, plan.Pod.namespace, func(ledger *ReservationLedger) bool {
+		if !planned {
+			return ledger.ClearHint(hint.Pod)
+		}
+		ledger.Hint(hint)
+		return true
+	})
+	if err != nil {
+		logger(fmt.Sprintf("could not update the hint of %s: %v", plan.Pod.podName, err))
+	}
+}
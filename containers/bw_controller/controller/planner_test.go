@@ -0,0 +1,103 @@
+package bw_controller
+
+import (
+	"strings"
+	"testing"
+
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+func TestPlanMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		gain     float64  // gain a migration needs
+		evictErr error    // error the eviction fails with, nil if it does not
+		want     []string // pods evicted
+		hint     string   // node hinted for front, "" for none
+		outcome  string
+	}{
+		{"moved next to its dependency", DEFAULT_MIGRATION_GAIN, nil, []string{"front-5d9c8-x2k4"}, "n2", MIGRATION_STARTED},
+		{"gain below the threshold", 0.6, nil, []string{}, "", MIGRATION_SKIPPED},
+		{"eviction blocked", DEFAULT_MIGRATION_GAIN, ErrEvictionBlocked, []string{}, "", MIGRATION_BLOCKED},
+		{"eviction throttled", DEFAULT_MIGRATION_GAIN, ErrEvictionThrottled, []string{}, "", MIGRATION_THROTTLED},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 1000, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+			controller, kubeClient := getTestController(t, topo, "front", 80)
+			store := NewMemoryLedgerStore()
+			controller.SetLedgerStore(store)
+			controller.SetMigrationGain(test.gain)
+			if test.evictErr != nil {
+				kubeClient.blocked["front-5d9c8-x2k4"] = test.evictErr
+			}
+			controller.EvaluateDeployment()
+
+			if len(kubeClient.evicted) != len(test.want) {
+				t.Fatalf("Want %v evicted, got %v", test.want, kubeClient.evicted)
+			}
+			// n3 fits as well, but back runs on n2. 50 of the 100 front needs are left above the headroom on n1
+			line := migrationLine(t, controller.migrationFile, 1)
+			if line[4] != test.outcome || line[5] != "n2" {
+				t.Fatalf("Want %s towards n2, got %v", test.outcome, line)
+			}
+			ledger, _ := store.LoadLedger("app")
+			if hint := ledger.Hints["front"]; hint.Node != test.hint {
+				t.Fatalf("Want front hinted on %q, got %v", test.hint, ledger.Hints)
+			}
+		})
+	}
+}
+
+func TestPlanNoNodeFits(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+	topo.SetBiLink("10.0.0.2", "10.0.0.3", 1000, 1)
+	controller, _ := getTestController(t, topo, "front", 80)
+	controller.staleNodes["n2"] = true
+	controller.UpdatePodMetrics()
+	// towards back on n2, n3 keeps 500 above the headroom of the path
+	controller.podDepReq["front"]["back"] = PodDependency{Source: "front", Destination: "back", Bandwidth: 600}
+
+	links := netmon_client.NewLinkModel(controller.pathsFree, controller.nodes)
+	plan, reason := controller.planMigration(controller.pods["front"], links)
+	if reason != "no node fits: n2 "+PLAN_STALE+"; n3 "+PLAN_DEPS {
+		t.Fatalf("Want neither n2 nor n3 to fit, got %q", reason)
+	}
+	if plan.Needed != 600 {
+		t.Fatalf("Want 600 needed, got %v", plan)
+	}
+}
+
+func TestPlanPeersNotRunning(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetBiLink("10.0.0.1", "10.0.0.3", 1000, 1)
+	topo.SetBiLink("10.0.0.2", "10.0.0.3", 1000, 1)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10)
+	controller, kubeClient := getTestController(t, topo, "front", 80)
+	// front depends on cache, which is pending, and on store, which was deleted after it ran on n3
+	kubeClient.pods[0].Items[0].Metadata.Annotations = map[string]string{"dependson.back.bw": "100", "dependson.cache.bw": "50", "dependson.store.bw": "50"}
+	cache := getTestPod("cache-4f8b-k2l9", "", map[string]string{})
+	cache.Status.Phase = "Pending"
+	kubeClient.pods[0].Items = append(kubeClient.pods[0].Items, cache, getTestPod("store-8c2d-m3n4", "n3", map[string]string{}))
+	controller.UpdatePods()
+	kubeClient.pods[0].Items = kubeClient.pods[0].Items[:3]
+	controller.UpdatePods()
+	controller.UpdatePodMetrics()
+
+	links := netmon_client.NewLinkModel(controller.pathsFree, controller.nodes)
+	plan, reason := controller.planMigration(controller.pods["front"], links)
+	if strings.HasPrefix(reason, "no node fits") || plan.To != "n2" {
+		t.Fatalf("Want front planned next to back on n2, got %q %v", reason, plan)
+	}
+	if plan.Needed != 100 || len(plan.flows) != 1 {
+		t.Fatalf("Want only the flow to back planned, got %v", plan.flows)
+	}
+}
@@ -5,6 +5,9 @@ type Pod struct {
 	deployedNode string
 	podName      string
 	namespace    string
+	uid          string
+	ip           string
+	created      int64 // unix time the api server created it, 0 if unknown
 }
 
 type PodDependency struct {
@@ -28,3 +31,14 @@ type PairList []Pair
 func (p PairList) Len() int           { return len(p) }
 func (p PairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p PairList) Less(i, j int) bool { return p[i].Value > p[j].Value }
+
+type KubeClientIntf interface {
+	GetNodes() (*NodeList, error)
+	GetPods() []PodList
+	EvictPod(podname string, namespace string) error
+	EventClient
+}
+
+type PromClientIntf interface {
+	GetPodMetrics() (PodSet, PodDeps)
+}
@@ -48,6 +48,51 @@ func parseIpMap(filename string) map[string]string {
 	return mappings
 
 }
+
+// migration limits of the config, the defaults for what it leaves out
+func getMigrationLimits(config Config) bw_controller.MigrationLimits {
+	limits := bw_controller.DEFAULT_MIGRATION_LIMITS
+	if config.MaxMigrationsPerNamespace > 0 {
+		limits.PerNamespace = config.MaxMigrationsPerNamespace
+	}
+	if config.MaxMigrationsPerNode > 0 {
+		limits.PerNode = config.MaxMigrationsPerNode
+	}
+	limits.Interval = config.MigrationIntervalSeconds
+	if config.MigrationTimeoutSeconds > 0 {
+		limits.Timeout = config.MigrationTimeoutSeconds
+	}
+	return limits
+}
+
+// damping policy of the config, the defaults for what it leaves out
+func getDampingPolicy(config Config) bw_controller.DampingPolicy {
+	policy := bw_controller.DefaultDampingPolicy(config.UtilChangeThreshold)
+	if config.MigrationCooldownSeconds > 0 {
+		policy.Cooldown = config.MigrationCooldownSeconds
+	}
+	if config.MigrationBudget > 0 {
+		policy.Budget = config.MigrationBudget
+	}
+	if config.MigrationBudgetWindowSeconds > 0 {
+		policy.Window = config.MigrationBudgetWindowSeconds
+	}
+	if config.HighWatermark > 0 {
+		policy.HighWatermark = config.HighWatermark
+		policy.LowWatermark = config.HighWatermark / 2
+	}
+	if config.LowWatermark > 0 {
+		policy.LowWatermark = config.LowWatermark
+	}
+	if config.MigrationRearmSeconds > 0 {
+		policy.Rearm = config.MigrationRearmSeconds
+	}
+	if config.ReturnWindowSeconds > 0 {
+		policy.ReturnWindow = config.ReturnWindowSeconds
+	}
+	return policy
+}
+
 func main() {
 	f, err := os.OpenFile("controller_log", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
@@ -68,9 +113,25 @@ func main() {
 	config := parseConfig(configFile)
 	ipMap := parseIpMap(ipMapFile)
 	promClient := bw_controller.NewPrometheusClient(config.PromAddr, config.PromMetrics)
-	kubeClient := bw_controller.NewKubeClient(config.KubeProxyAddr, config.KubeNodesEndpoint, config.KubePodsEndpoint, config.KubeDeleteEndpoint, config.KubeNamespaces)
+	if config.KubeEventsEndpoint == "" {
+		config.KubeEventsEndpoint = "/api/v1/namespaces/%s/events"
+	}
+	if config.KubeConfigMapsEndpoint == "" {
+		config.KubeConfigMapsEndpoint = "/api/v1/namespaces/%s/configmaps"
+	}
+	if config.KubeEvictEndpoint == "" {
+		config.KubeEvictEndpoint = "/api/v1/namespaces/%s/pods/%s/eviction"
+	}
+	kubeClient := bw_controller.NewKubeClient(config.KubeProxyAddr, config.KubeNodesEndpoint, config.KubePodsEndpoint, config.KubeDeleteEndpoint, config.KubeEvictEndpoint, config.KubeEventsEndpoint, config.KubeNamespaces)
 	netmonClient := netmon_client.NewNetmonClient(config.NetmonAddrs)
 	controller := bw_controller.NewController(promClient, netmonClient, kubeClient, config.ValuationInterval, config.UtilChangeThreshold, bwInfoFile, migrationInfoFile, config.HeadroomThreshold, ipMap)
+	controller.SetLedgerStore(bw_controller.NewConfigMapLedgerStore(config.KubeProxyAddr, config.KubeConfigMapsEndpoint, config.KubeNamespaces))
+	controller.SetQosEnforcement(config.EnforceQos)
+	controller.SetMigrationLimits(getMigrationLimits(config))
+	if config.MigrationGainThreshold > 0 {
+		controller.SetMigrationGain(config.MigrationGainThreshold)
+	}
+	controller.SetDampingPolicy(getDampingPolicy(config))
 
 	monCh := controller.MonitorState(time.Duration(config.MonDurationSeconds) * time.Second)
 	signalChannel := make(chan os.Signal, 2)
@@ -1,18 +1,28 @@
 package main
 
 type Config struct {
-	ApiHost           string
-	BindingsEndpoint  string
-	EventsEndpoint    string
-	NodesEndpoint     string
-	PodsEndpoint      string
-	WatchPodsEndpoint string
-	ConfigEndpoint    string
-	MetricsEndpoint   string
-	NetmonAddrs       []string
-	Namespaces        []string
-	PromAddr          string
-	PromMetrics       []string
-	Tolerance         float64
-	NamespaceEndpoint string
+	ApiHost            string
+	BindingsEndpoint   string
+	EventsEndpoint     string
+	NodesEndpoint      string
+	PodsEndpoint       string
+	WatchPodsEndpoint  string
+	ConfigEndpoint     string
+	MetricsEndpoint    string
+	NetmonAddrs        []string
+	Namespaces         []string
+	PromAddr           string
+	PromMetrics        []string
+	Tolerance          float64
+	NamespaceEndpoint  string
+	Strategy           string
+	ConfigMapsEndpoint string
+	DeletePodEndpoint  string
+	GangTimeout        int    // seconds a partially arrived pod group waits for its missing pods
+	MetricsAddr        string // listen address of the prometheus /metrics and the /explain endpoints
+	SnapshotDir        string // if set, a snapshot of the cluster is written here for every placement
+	NetmonTimeout      int    // seconds a netmon fetch may take before the nodes that did not answer are left out
+	BwAggregate        string // bw netmon reports: last (default), ewma, p5, p50, p95 or min of its measurements
+	BwAggregateWindow  int    // seconds of measurements BwAggregate is over, 0 for all netmon keeps
+	UseBwLowerBound    bool   // place on the lower end of the confidence interval netmon gives for the bw of a link
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+)
+
+// reference to the pod for the events about it
+func podReference(pod Pod) bwcontroller.ObjectReference {
+	return bwcontroller.ObjectReference{ApiVersion: "v1", Kind: "Pod", Name: pod.Metadata.Name, Namespace: pod.Metadata.Namespace, Uid: pod.Metadata.Uid}
+}
+
+// posts an event for the pod, errors are only logged
+func (sched *DagScheduler) emitPodEvent(pod Pod, reason string, message string, eventType string) {
+	if sched.events == nil {
+		return
+	}
+	err := sched.events.Record(podReference(pod), reason, message, eventType)
+	if err != nil {
+		logger(fmt.Sprintf("could not post event %s for pod %s: %v", reason, pod.Metadata.Name, err))
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+	"testing"
+)
+
+// client that records the posted and patched events
+type eventClient struct {
+	DummyClient
+	posted  *[]bwcontroller.Event
+	patched *[]bwcontroller.Event
+}
+
+func (cl eventClient) PostEvent(event bwcontroller.Event, ns string) error {
+	*cl.posted = append(*cl.posted, event)
+	return nil
+}
+
+func (cl eventClient) PatchEvent(event bwcontroller.Event, ns string) error {
+	*cl.patched = append(*cl.patched, event)
+	return nil
+}
+
+func TestEventRecorderDedup(t *testing.T) {
+	posted, patched := make([]bwcontroller.Event, 0), make([]bwcontroller.Event, 0)
+	recorder := bwcontroller.NewEventRecorder(eventClient{posted: &posted, patched: &patched}, schedulerName)
+	pod := Pod{}
+	pod.Metadata.Name = "front-5d9c8-x2k4"
+	pod.Metadata.Namespace = "app"
+	pod.Metadata.Uid = "1234"
+
+	recorder.Record(podReference(pod), "FailedScheduling", "0/3 nodes are available.", bwcontroller.EVENT_WARNING)
+	recorder.Record(podReference(pod), "FailedScheduling", "0/3 nodes are available.", bwcontroller.EVENT_WARNING)
+	recorder.Record(podReference(pod), "FailedScheduling", "0/2 nodes are available.", bwcontroller.EVENT_WARNING)
+	if len(posted) != 2 || len(patched) != 1 {
+		t.Fatalf("Want 2 events posted and 1 patched, got %d and %d", len(posted), len(patched))
+	}
+	if patched[0].Count != 2 || patched[0].Metadata.Name != posted[0].Metadata.Name {
+		t.Fatalf("Got unexpected patched event %v", patched[0])
+	}
+	ref := posted[0].InvolvedObject
+	if ref.Kind != "Pod" || ref.Name != pod.Metadata.Name || ref.Namespace != "app" || ref.Uid != "1234" {
+		t.Fatalf("Got unexpected involved object %v", ref)
+	}
+}
+
+func TestFitFailureMessage(t *testing.T) {
+	state := getMeshTestState()
+	state.addFitFailure("front-5d9c8-x2k4", "n1", FIT_INSUFFICIENT_CPU)
+	state.addFitFailure("front-5d9c8-x2k4", "n2", FIT_INSUFFICIENT_CPU)
+	state.addFitFailure("front-5d9c8-x2k4", "n3", FIT_NO_NETMON)
+	message := state.getFitFailureMessage("front-5d9c8-x2k4")
+	if message != "0/3 nodes are available: 1 No netmon data, 2 Insufficient cpu." {
+		t.Fatalf("Got unexpected message %s", message)
+	}
+}
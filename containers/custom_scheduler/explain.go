@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+// why a dependency path rules out a node
+const (
+	DEP_NO_PATH   = "no path"
+	DEP_BANDWIDTH = "insufficient bandwidth"
+	DEP_LATENCY   = "latency too high"
+)
+
+// Dependency whose path from the candidate node does not meet its requirements
+type DepVerdict struct {
+	Pod         string  `json:"pod"`
+	Node        string  `json:"node"`
+	Reason      string  `json:"reason"`
+	Bandwidth   float64 `json:"bandwidth"`
+	AvailableBw float64 `json:"availableBw"`
+	MaxLatency  float64 `json:"maxLatency"`
+	Latency     float64 `json:"latency"`
+}
+
+// Result of checking one pod against one node
+type NodeVerdict struct {
+	Node            string      `json:"node"`
+	Fits            bool        `json:"fits"`
+	Reason          string      `json:"reason,omitempty"`
+	CpuAvailable    int64       `json:"cpuAvailable"`
+	CpuNeeded       int64       `json:"cpuNeeded"`
+	MemoryAvailable int64       `json:"memoryAvailable"`
+	MemoryNeeded    int64       `json:"memoryNeeded"`
+	SendBwAvailable float64     `json:"sendBwAvailable"`
+	SendBwNeeded    float64     `json:"sendBwNeeded"`
+	RecvBwAvailable float64     `json:"recvBwAvailable"`
+	RecvBwNeeded    float64     `json:"recvBwNeeded"`
+	FailedDep       *DepVerdict `json:"failedDep,omitempty"`
+	Score           float64     `json:"score"`
+}
+
+type PodExplanation struct {
+	Pod     string        `json:"pod"`
+	Node    string        `json:"node,omitempty"` // node chosen by the strategy, empty if none
+	Message string        `json:"message,omitempty"`
+	Nodes   []NodeVerdict `json:"nodes"`
+}
+
+// Dry run of a pod group, nothing is bound
+type Explanation struct {
+	Strategy string           `json:"strategy"`
+	Placed   bool             `json:"placed"` // every pending pod of the group got a node
+	Pods     []PodExplanation `json:"pods"`
+}
+
+// Runs the placement of the pod group like SchedulePods but does not bind the pods, post events or record
+// metrics, the netmon metrics are recorded by SchedulePods only
+func (sched *DagScheduler) ExplainPods(pods map[string]Pod, podGraph map[string]map[string]bool) Explanation {
+	sched.processorLock.Lock()
+	defer sched.processorLock.Unlock()
+	explanation := Explanation{Strategy: sched.strategy.Name(), Pods: make([]PodExplanation, 0)}
+	state := sched.getClusterState()
+	if len(state.nodes.Items) == 0 {
+		logger("ERROR: Cannot find any node for scheduling, skipping")
+		return explanation
+	}
+	_, state.podNetUsages = sched.promClient.GetPodMetrics()
+	state.podReqs = sched.podProcessor.GetPodRequirementsSet(pods)
+	topoOrder := sched.getPendingOrder(podGraph, state)
+	if len(topoOrder) == 0 {
+		return explanation
+	}
+	return sched.explain(pods, topoOrder, state)
+}
+
+func (sched *DagScheduler) explain(pods map[string]Pod, topoOrder []string, state *ClusterState) Explanation {
+	explanation := Explanation{Strategy: sched.strategy.Name(), Pods: make([]PodExplanation, 0)}
+	state.dryRun = true
+	// the strategies update the node resources and deployedApps as they go
+	nodeResources := make(map[string]Resource, 0)
+	for name, res := range state.nodeResources {
+		nodeResources[name] = res
+	}
+	namespace := getPodWithName(topoOrder[0], pods).Metadata.Namespace
+	deployed, exists := sched.deployedApps[namespace]
+	if exists {
+		deployed = make(DeploymentMap, 0)
+		for pod, node := range sched.deployedApps[namespace] {
+			deployed[pod] = node
+		}
+	}
+	podAssignment := sched.strategy.Place(pods, topoOrder, state)
+	if exists {
+		sched.deployedApps[namespace] = deployed
+	} else {
+		delete(sched.deployedApps, namespace)
+	}
+	explanation.Placed = len(podAssignment) == len(topoOrder)
+	// the replay reserves the bw of the pods on links of its own
+	state.linkModel = nil
+
+	// replay the placement so that each pod is checked against what the pods before it left over
+	placed := make(map[string]string, 0)
+	for _, podName := range topoOrder {
+		pod := getPodWithName(podName, pods)
+		podExpl := PodExplanation{Pod: pod.Metadata.Name, Node: podAssignment[pod.Metadata.Name], Nodes: make([]NodeVerdict, 0)}
+		for _, node := range state.nodes.Items {
+			podExpl.Nodes = append(podExpl.Nodes, sched.explainNode(pod, node, nodeResources[node.Metadata.Name], state, placed))
+		}
+		if podExpl.Node == "" {
+			podExpl.Message = state.getFitFailureMessage(pod.Metadata.Name)
+		} else {
+			placed[pod.Metadata.Name] = podExpl.Node
+			sched.reserveDepsBw(pod, getNodeWithName(podExpl.Node, state.nodes), state.nodes, placed, state.getLinkModel())
+			podResource := sched.GetPodResource(pod)
+			nodeRes := nodeResources[podExpl.Node]
+			nodeRes.cpu -= podResource.cpu
+			nodeRes.memory -= podResource.memory
+			nodeResources[podExpl.Node] = nodeRes
+		}
+		explanation.Pods = append(explanation.Pods, podExpl)
+	}
+	return explanation
+}
+
+func (sched *DagScheduler) explainNode(pod Pod, node Node, nodeResource Resource, state *ClusterState, placed map[string]string) NodeVerdict {
+	verdict := sched.fitVerdict(pod, node, nodeResource, state.netResources, state.getLinkModel())
+	if !verdict.Fits {
+		return verdict
+	}
+	if reason := sched.checkStale(pod, node, state); reason != "" {
+		verdict.Fits = false
+		verdict.Reason = reason
+		return verdict
+	}
+	verdict.FailedDep = sched.checkDeps(pod, node, state.nodes, placed, state.netResources, state.getLinkModel())
+	if verdict.FailedDep != nil {
+		verdict.Fits = false
+		verdict.Reason = FIT_DEPS
+		return verdict
+	}
+	numDeps := 0
+	for _, dep := range sched.podProcessor.GetPodRequirements(pod).Deps {
+		if depNode, exists := getAssignedNode(dep.Pod, placed); exists && depNode == node.Metadata.Name {
+			numDeps += 1
+		}
+	}
+	verdict.Score = getVerdictScore(verdict, numDeps)
+	return verdict
+}
+
+// like the greedy node order: one point per dependency already on the node,
+// plus the smallest fraction of cpu, memory or bandwidth that is left after placing the pod
+func getVerdictScore(verdict NodeVerdict, numDeps int) float64 {
+	left := 1.0
+	for _, res := range [][2]float64{
+		{float64(verdict.CpuAvailable), float64(verdict.CpuNeeded)},
+		{float64(verdict.MemoryAvailable), float64(verdict.MemoryNeeded)},
+		{verdict.SendBwAvailable, verdict.SendBwNeeded},
+		{verdict.RecvBwAvailable, verdict.RecvBwNeeded},
+	} {
+		if res[0] > 0 {
+			left = math.Min(left, math.Max(0, (res[0]-res[1])/res[0]))
+		}
+	}
+	return float64(numDeps) + left
+}
+
+// GET /explain dry-runs the pod group that is scheduled next and returns the verdicts as json. The pods
+// are a copy of the queue of the pod processor, the scheduling loop may change the queue meanwhile
+func (sched *DagScheduler) ServeExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	pods, podGraph := sched.podProcessor.GetUnscheduledPods()
+	explanation := Explanation{Strategy: sched.strategy.Name(), Pods: make([]PodExplanation, 0)}
+	if len(pods) > 0 {
+		explanation = sched.ExplainPods(pods, podGraph)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(explanation)
+	if err != nil {
+		logger(fmt.Sprintf("could not write explanation: %v", err))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// custom_scheduler explain [-addr host:port] [-json]
+// asks a running scheduler to dry-run its next pod group and prints the verdicts
+func runExplain(args []string) int {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	addr := flags.String("addr", "localhost"+metricsAddr, "Address of the scheduler's /explain endpoint")
+	raw := flags.Bool("json", false, "Print the raw json")
+	flags.Parse(args)
+
+	client := http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Get("http://" + *addr + "/explain")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "got status %d: %s\n", resp.StatusCode, body)
+		return 1
+	}
+	if *raw {
+		os.Stdout.Write(body)
+		return 0
+	}
+	var explanation Explanation
+	err = json.Unmarshal(body, &explanation)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printExplanation(os.Stdout, explanation)
+	return 0
+}
+
+func printExplanation(out io.Writer, explanation Explanation) {
+	if len(explanation.Pods) == 0 {
+		fmt.Fprintln(out, "no pending pods")
+		return
+	}
+	fmt.Fprintf(out, "strategy %s, group placed: %v\n", explanation.Strategy, explanation.Placed)
+	for _, pod := range explanation.Pods {
+		fmt.Fprintln(out)
+		if pod.Node != "" {
+			fmt.Fprintf(out, "%s -> %s\n", pod.Pod, pod.Node)
+		} else {
+			fmt.Fprintf(out, "%s: %s\n", pod.Pod, pod.Message)
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NODE\tFITS\tCPU\tMEMORY\tSEND BW\tRECV BW\tSCORE\tREASON")
+		for _, v := range pod.Nodes {
+			reason := v.Reason
+			if v.FailedDep != nil {
+				dep := v.FailedDep
+				reason = fmt.Sprintf("%s: %s on %s, %s (bw %.0f/%.0f, latency %.1f/%.1f ms)", reason, dep.Pod, dep.Node, dep.Reason, dep.AvailableBw, dep.Bandwidth, dep.Latency, dep.MaxLatency)
+			}
+			fmt.Fprintf(w, "%s\t%v\t%d/%d\t%d/%d\t%.0f/%.0f\t%.0f/%.0f\t%.2f\t%s\n", v.Node, v.Fits,
+				v.CpuAvailable, v.CpuNeeded, v.MemoryAvailable, v.MemoryNeeded,
+				v.SendBwAvailable, v.SendBwNeeded, v.RecvBwAvailable, v.RecvBwNeeded, v.Score, reason)
+		}
+		w.Flush()
+	}
+}
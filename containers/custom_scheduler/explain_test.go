@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+)
+
+func getExplainTestPods(frontAnnotations map[string]string) map[string]Pod {
+	back := Pod{}
+	back.Metadata.Name = "back-7f9c-x2k4"
+	back.Metadata.Namespace = "app"
+	back.Spec.Containers = []Container{Container{Resources: ResourceRequirements{Requests: ResourceList{"cpu": "2"}}}}
+	front := Pod{}
+	front.Metadata.Name = "front-5d9c8-x2k4"
+	front.Metadata.Namespace = "app"
+	front.Metadata.Annotations = frontAnnotations
+	front.Spec.Containers = []Container{Container{Resources: ResourceRequirements{Requests: ResourceList{"cpu": "1"}}}}
+	return map[string]Pod{back.Metadata.Name: back, front.Metadata.Name: front}
+}
+
+func getExplainTestScheduler() (*DagScheduler, *ClusterState) {
+	sched := &DagScheduler{podProcessor: NewPodProcessor(CLIENT), deployedApps: make(map[string]DeploymentMap, 0)}
+	sched.strategy, _ = NewPlacementStrategy(GREEDY_STRATEGY, sched)
+	state := getMeshTestState()
+	state.nodeResources = sched.getNodeResourcesRemaining(state.nodes, state.nodeMetrics)
+	state.netResources = sched.getNetResourcesRemaining(state.paths, state.traffics)
+	return sched, state
+}
+
+func getNodeVerdict(podExpl PodExplanation, nodeName string) NodeVerdict {
+	for _, verdict := range podExpl.Nodes {
+		if verdict.Node == nodeName {
+			return verdict
+		}
+	}
+	return NodeVerdict{}
+}
+
+func TestExplain(t *testing.T) {
+	sched, state := getExplainTestScheduler()
+	pods := getExplainTestPods(map[string]string{"dependson.back": "yes", "dependson.back.bw": "30"})
+	explanation := sched.explain(pods, []string{"back", "front"}, state)
+	if !explanation.Placed || len(explanation.Pods) != 2 {
+		t.Fatalf("Want both pods placed, got %v", explanation)
+	}
+	if explanation.Pods[0].Node != "n3" || explanation.Pods[1].Node != "n1" {
+		t.Fatalf("Want back on n3 and front on n1, got %s and %s", explanation.Pods[0].Node, explanation.Pods[1].Node)
+	}
+	// back uses up the cpu of n3
+	verdict := getNodeVerdict(explanation.Pods[1], "n3")
+	if verdict.Fits || verdict.Reason != FIT_INSUFFICIENT_CPU || verdict.CpuAvailable != 0 || verdict.CpuNeeded != 1 {
+		t.Fatalf("Got unexpected verdict for n3 %v", verdict)
+	}
+	verdict = getNodeVerdict(explanation.Pods[1], "n2")
+	if verdict.Fits || verdict.Reason != FIT_NO_NETMON {
+		t.Fatalf("Got unexpected verdict for n2 %v", verdict)
+	}
+	verdict = getNodeVerdict(explanation.Pods[1], "n1")
+	// front takes the last cpu of n1
+	if !verdict.Fits || verdict.SendBwAvailable != 50 || verdict.SendBwNeeded != 30 || verdict.Score != 0 {
+		t.Fatalf("Got unexpected verdict for n1 %v", verdict)
+	}
+	if len(sched.deployedApps) != 0 {
+		t.Fatalf("Dry run changed the deployed apps")
+	}
+}
+
+func TestExplainFailedDep(t *testing.T) {
+	sched, state := getExplainTestScheduler()
+	pods := getExplainTestPods(map[string]string{"dependson.back": "yes", "dependson.back.bw": "30", "dependson.back.latency": "5"})
+	explanation := sched.explain(pods, []string{"back", "front"}, state)
+	if explanation.Placed || explanation.Pods[1].Node != "" || explanation.Pods[1].Message == "" {
+		t.Fatalf("Want front not placed, got %v", explanation.Pods[1])
+	}
+	verdict := getNodeVerdict(explanation.Pods[1], "n1")
+	dep := verdict.FailedDep
+	if verdict.Fits || verdict.Reason != FIT_DEPS || dep == nil {
+		t.Fatalf("Got unexpected verdict for n1 %v", verdict)
+	}
+	if dep.Pod != "back" || dep.Node != "n3" || dep.Reason != DEP_LATENCY || dep.Latency != 12 || dep.MaxLatency != 5 {
+		t.Fatalf("Got unexpected failed dep %v", *dep)
+	}
+}
+
+func TestExplainStaleNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantNode    string // node of front, "" if it is not placed
+		wantReason  string // verdict for front on n1
+	}{
+		{"needs bandwidth", map[string]string{"dependson.back": "yes", "dependson.back.bw": "30"}, "", FIT_STALE_NETMON},
+		{"no dependencies", map[string]string{}, "n1", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sched, state := getExplainTestScheduler()
+			state.staleNodes = map[string]netmon_client.NodeError{"10.0.0.1": {Host: "10.0.0.1", Message: "unavailable", Cached: true}}
+			explanation := sched.explain(getExplainTestPods(test.annotations), []string{"back", "front"}, state)
+			if explanation.Pods[0].Node != "n3" || explanation.Pods[1].Node != test.wantNode {
+				t.Fatalf("Want back on n3 and front on %q, got %v", test.wantNode, explanation.Pods)
+			}
+			verdict := getNodeVerdict(explanation.Pods[1], "n1")
+			if verdict.Reason != test.wantReason || verdict.Fits != (test.wantReason == "") {
+				t.Fatalf("Got unexpected verdict for n1 %v", verdict)
+			}
+		})
+	}
+}
+
+func TestVerdictScore(t *testing.T) {
+	verdict := NodeVerdict{CpuAvailable: 4, CpuNeeded: 1, MemoryAvailable: 1024, MemoryNeeded: 256, SendBwAvailable: 100, SendBwNeeded: 50}
+	if score := getVerdictScore(verdict, 1); score != 1.5 {
+		t.Fatalf("Want score 1.5, got %f", score)
+	}
+}
+
+func TestExplainRecordsNoMetrics(t *testing.T) {
+	snapshot := getTestSnapshot()
+	sched, err := NewReplayScheduler(snapshot, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	netmonStaleNodes.Set(42)
+	explanation := sched.ExplainPods(snapshot.Pending, snapshot.PodGraph)
+	if !explanation.Placed {
+		t.Fatalf("Want the pod group placed, got %v", explanation)
+	}
+	if stale := testutil.ToFloat64(netmonStaleNodes); stale != 42 {
+		t.Fatalf("Want the stale node gauge left alone by the dry run, got %f", stale)
+	}
+	sched.SchedulePods(snapshot.Pending, snapshot.PodGraph)
+	if stale := testutil.ToFloat64(netmonStaleNodes); stale != 0 {
+		t.Fatalf("Want the stale node gauge set when scheduling, got %f", stale)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// client that fails to bind failPod and records the deleted pods
+type gangClient struct {
+	DummyClient
+	failPod    string
+	failDelete string
+	bound      *[]string
+	deleted    *[]string
+	pods       []*PodList
+}
+
+func (cl gangClient) Bind(pod Pod, node Node) error {
+	if pod.Metadata.Name == cl.failPod {
+		return errors.New("bind failed")
+	}
+	*cl.bound = append(*cl.bound, pod.Metadata.Name)
+	return nil
+}
+
+func (cl gangClient) DeletePod(pod Pod) error {
+	if pod.Metadata.Name == cl.failDelete {
+		return errors.New("delete failed")
+	}
+	*cl.deleted = append(*cl.deleted, pod.Metadata.Name)
+	return nil
+}
+
+func (cl gangClient) GetPods() ([]*PodList, error) {
+	return cl.pods, nil
+}
+
+func getGangTestPods() map[string]Pod {
+	pods := make(map[string]Pod, 0)
+	for name, annotations := range map[string]map[string]string{
+		"front-5d9c8-x2k4": {"dependson.back": "yes"},
+		"back-7f9c-x2k4":   {"dependson.db": "yes", "dependedby.front": "yes"},
+		"db-6b8d-x2k4":     {"dependedby.back": "yes"},
+	} {
+		pod := Pod{}
+		pod.Metadata.Name = name
+		pod.Metadata.Namespace = "app"
+		pod.Metadata.Annotations = annotations
+		pods[name] = pod
+	}
+	return pods
+}
+
+func TestAssignPodsRollback(t *testing.T) {
+	bound, deleted := make([]string, 0), make([]string, 0)
+	client := gangClient{failPod: "front-5d9c8-x2k4", bound: &bound, deleted: &deleted}
+	sched := &DagScheduler{client: client, podProcessor: NewPodProcessor(client), deployedApps: make(map[string]DeploymentMap, 0)}
+	pods := getGangTestPods()
+	for _, pod := range pods {
+		sched.podProcessor.AddPod(pod)
+	}
+	nodes := getMeshTestState().nodes
+	assignment := map[string]string{"front-5d9c8-x2k4": "n1", "back-7f9c-x2k4": "n2", "db-6b8d-x2k4": "n3"}
+
+	err := sched.AssignPods(assignment, pods, nodes)
+	if err == nil {
+		t.Fatalf("Want bind error")
+	}
+	// front depends on back which depends on db, front is bound last
+	if len(bound) != 2 || bound[0] != "db-6b8d-x2k4" || bound[1] != "back-7f9c-x2k4" {
+		t.Fatalf("Got unexpected bind order %v", bound)
+	}
+	if len(deleted) != 2 {
+		t.Fatalf("Want the 2 bound pods rolled back, got %v", deleted)
+	}
+	if _, exists := sched.podProcessor.unscheduledPods["front-5d9c8-x2k4"]; !exists {
+		t.Fatalf("Want the pod that failed to bind to stay queued")
+	}
+
+	// back could not be rolled back, it is still bound and not marked scheduled
+	client.failDelete = "back-7f9c-x2k4"
+	bound, deleted = bound[:0], deleted[:0]
+	sched.client = client
+	sched.podProcessor.MarkScheduled([]Pod{pods["front-5d9c8-x2k4"], pods["back-7f9c-x2k4"], pods["db-6b8d-x2k4"]})
+	for _, pod := range pods {
+		sched.podProcessor.AddPod(pod)
+	}
+	sched.rollbackPods([]Pod{pods["back-7f9c-x2k4"], pods["db-6b8d-x2k4"]})
+	if len(deleted) != 1 || deleted[0] != "db-6b8d-x2k4" {
+		t.Fatalf("Want only db deleted, got %v", deleted)
+	}
+	if _, exists := sched.podProcessor.unscheduledPods["back-7f9c-x2k4"]; !exists {
+		t.Fatalf("Want back that could not be deleted to stay queued")
+	}
+	if _, exists := sched.podProcessor.unscheduledPods["db-6b8d-x2k4"]; exists {
+		t.Fatalf("Want db that was deleted marked scheduled")
+	}
+
+	client.failPod, client.failDelete = "", ""
+	bound, deleted = bound[:0], deleted[:0]
+	sched.client = client
+	if err := sched.AssignPods(assignment, pods, nodes); err != nil || len(bound) != 3 || len(deleted) != 0 {
+		t.Fatalf("Want all pods bound, got %v err %v", bound, err)
+	}
+}
+
+func TestRejectIncompleteGroups(t *testing.T) {
+	// db is pending but not known to the scheduler yet
+	dbPod := Pod{Status: PodStatus{Phase: "Pending"}}
+	dbPod.Metadata.Name = "db-6b8d-x2k4"
+	client := gangClient{pods: []*PodList{&PodList{Items: []Pod{dbPod}}}}
+	pp := NewPodProcessor(client)
+	pods := getGangTestPods()
+	pp.AddPod(pods["front-5d9c8-x2k4"])
+	pp.AddPod(pods["back-7f9c-x2k4"])
+
+	if rejected := pp.RejectIncompleteGroups(time.Minute); len(rejected) != 0 {
+		t.Fatalf("Want no pods rejected before the timeout, got %d", len(rejected))
+	}
+	pp.arrivals["back-7f9c-x2k4"] = time.Now().Add(-2 * time.Minute)
+	rejected := pp.RejectIncompleteGroups(time.Minute)
+	if len(rejected) != 2 || len(pp.unscheduledPods) != 0 {
+		t.Fatalf("Want front and back rejected, got %d rejected and %d queued", len(rejected), len(pp.unscheduledPods))
+	}
+}
+
+func TestGetUnscheduledPodsSkipping(t *testing.T) {
+	pp := NewPodProcessor(CLIENT)
+	pods := getGangTestPods()
+	solo := Pod{}
+	solo.Metadata.Name = "solo-4c7d-x2k4"
+	solo.Metadata.Namespace = "app"
+	pods[solo.Metadata.Name] = solo
+	for _, pod := range pods {
+		pp.AddPod(pod)
+	}
+
+	_, first := pp.GetUnscheduledPods()
+	if len(first) == 0 {
+		t.Fatalf("Want a pod group to schedule")
+	}
+	// the first group could not be placed, the other one is returned
+	failed := map[string]bool{podGroupKey(first): true}
+	_, second := pp.GetUnscheduledPodsSkipping(failed)
+	if len(second) == 0 || podGroupKey(second) == podGroupKey(first) {
+		t.Fatalf("Want the other pod group after skipping %s, got %v", podGroupKey(first), second)
+	}
+	failed[podGroupKey(second)] = true
+	if _, podGraph := pp.GetUnscheduledPodsSkipping(failed); len(podGraph) != 0 {
+		t.Fatalf("Want no pod group left, got %v", podGraph)
+	}
+}
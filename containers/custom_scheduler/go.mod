@@ -3,19 +3,27 @@ module custom_scheduler
 go 1.19
 
 require (
+	github.com/prometheus/client_golang v1.16.0
 	github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller v0.0.0-00010101000000-000000000000
+	github.gatech.edu/cs-epl/mesh-bw-scheduler/meshscheduler v0.0.0-00010101000000-000000000000
 	github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client v0.0.0-00010101000000-000000000000
 	k8s.io/apimachinery v0.27.1
 )
 
 require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
+	github.com/golang/glog v1.0.0 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
-	github.com/prometheus/client_golang v1.16.0 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
 	github.com/prometheus/common v0.42.0 // indirect
+	github.com/prometheus/procfs v0.10.1 // indirect
 	github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon v0.0.0-00010101000000-000000000000 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
@@ -31,3 +39,5 @@ replace github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client => "/users/mset
 replace github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon => "/users/msethur1/mesh-bw-scheduler//containers/netmon/proto"
 
 replace github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller => /users/msethur1/mesh-bw-scheduler/containers/bw_controller/controller
+
+replace github.gatech.edu/cs-epl/mesh-bw-scheduler/meshscheduler => /users/msethur1/mesh-bw-scheduler/schedulertest/scheduler
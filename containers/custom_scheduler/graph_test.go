@@ -1,6 +1,7 @@
 package main
 
 import (
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
 	"strconv"
 	"testing"
 )
@@ -64,7 +65,7 @@ func TestPodDeps3(t *testing.T) {
 	topo["pod_4"] = make(map[string]bool, 0)
 	topo["pod_4"]["pod_3"] = true
 	topoOrder := topoSort(topo)
-	chainOrder := topoSortWithChain(topo)
+	chainOrder := topoSortWithChain(topo, make(map[string]PodRequirements, 0), bwcontroller.PodDeps{})
 	if len(chainOrder) != len(topo) {
 		t.Fatalf("Got %d chain topo sorted, want %d instead", len(topoOrder), len(topo))
 	}
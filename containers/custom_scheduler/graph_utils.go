@@ -3,8 +3,6 @@ package main
 import (
 	"fmt"
 	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
-	"strconv"
-	"strings"
 )
 
 func computeIndegrees(podDeps map[string]map[string]bool) map[string]int {
@@ -59,7 +57,7 @@ func getUnvisitedVertexIdx(visited map[string]bool, topoOrder []string) int {
 func bfs(podDeps map[string]map[string]bool,
 	startNode string,
 	visitedGraph map[string]map[string]bool,
-	visited map[string]bool, pods map[string]Pod, podNetUsage bwcontroller.PodDeps) (map[string]string, map[string]float64) {
+	visited map[string]bool, podReqs map[string]PodRequirements, podNetUsage bwcontroller.PodDeps) (map[string]string, map[string]float64) {
 	lengthTo := make(map[string]float64, 0)
 	path := make(map[string]string, 0)
 	for dst, _ := range podDeps {
@@ -94,14 +92,11 @@ func bfs(podDeps map[string]map[string]bool,
 			}
 			q = append(q, k)
 			logger(fmt.Sprintf("edge %s -> %s\n", k, curNode))
-			pod := getPodWithName(k, pods)
 			edgeLen := 1.0
-			for ann, val := range pod.Metadata.Annotations {
-				vals := strings.Split(ann, ".")
-				if ("dependson" == vals[0] || "dependedby" == vals[0]) && curNode == vals[1] {
-					if vals[2] == "bw" {
-						edgeLenInt, _ := strconv.Atoi(val)
-						edgeLen = float64(edgeLenInt)
+			for _, dep := range podReqs[k].Deps {
+				if curNode == dep.Pod {
+					if dep.Bandwidth > 0 {
+						edgeLen = dep.Bandwidth
 					}
 					fracUsage := -1.0
 					if podNet, exists := podNetUsage[k]; exists {
@@ -132,7 +127,7 @@ func bfs(podDeps map[string]map[string]bool,
 	return path, lengthTo
 }
 
-func topoSortWithChain(podDeps map[string]map[string]bool, pods map[string]Pod, podNetUsage bwcontroller.PodDeps) []string {
+func topoSortWithChain(podDeps map[string]map[string]bool, podReqs map[string]PodRequirements, podNetUsage bwcontroller.PodDeps) []string {
 	topoOrder := topoSort(podDeps)
 
 	visited := make(map[string]bool, 0)
@@ -156,7 +151,7 @@ func topoSortWithChain(podDeps map[string]map[string]bool, pods map[string]Pod,
 		}
 		startNode := topoOrder[idx]
 		logger("cur node is " + startNode)
-		path, lengthTo := bfs(podDeps, startNode, visitedGraph, visited, pods, podNetUsage)
+		path, lengthTo := bfs(podDeps, startNode, visitedGraph, visited, podReqs, podNetUsage)
 		pathLen := 0.0
 		lastVertex := startNode
 		for k, v := range lengthTo {
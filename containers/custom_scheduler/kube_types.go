@@ -13,37 +13,6 @@
 
 package main
 
-// Event is a report of an event somewhere in the cluster.
-type Event struct {
-	ApiVersion     string          `json:"apiVersion,omitempty"`
-	Count          int64           `json:"count,omitempty"`
-	FirstTimestamp string          `json:"firstTimestamp"`
-	LastTimestamp  string          `json:"lastTimestamp"`
-	InvolvedObject ObjectReference `json:"involvedObject"`
-	Kind           string          `json:"kind,omitempty"`
-	Message        string          `json:"message,omitempty"`
-	Metadata       Metadata        `json:"metadata"`
-	Reason         string          `json:"reason,omitempty"`
-	Source         EventSource     `json:"source,omitempty"`
-	Type           string          `json:"type,omitempty"`
-}
-
-// EventSource contains information for an event.
-type EventSource struct {
-	Component string `json:"component,omitempty"`
-	Host      string `json:"host,omitempty"`
-}
-
-// ObjectReference contains enough information to let you inspect or modify
-// the referred object.
-type ObjectReference struct {
-	ApiVersion string `json:"apiVersion,omitempty"`
-	Kind       string `json:"kind,omitempty"`
-	Name       string `json:"name,omitempty"`
-	Namespace  string `json:"namespace,omitempty"`
-	Uid        string `json:"uid"`
-}
-
 // PodList is a list of Pods.
 type PodList struct {
 	ApiVersion string       `json:"apiVersion"`
@@ -136,6 +105,14 @@ type Metadata struct {
 	Labels          map[string]string `json:"labels"`
 	Annotations     map[string]string `json:"annotations"`
 	Uid             string            `json:"uid"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty"`
+}
+
+type OwnerReference struct {
+	ApiVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Uid        string `json:"uid"`
 }
 
 type NodeMetricsList struct {
@@ -171,3 +148,14 @@ type NamespaceList struct {
 type Namespace struct {
 	Name string
 }
+
+type ConfigMapList struct {
+	ApiVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Items      []ConfigMap `json:"items"`
+}
+
+type ConfigMap struct {
+	Metadata Metadata          `json:"metadata"`
+	Data     map[string]string `json:"data"`
+}
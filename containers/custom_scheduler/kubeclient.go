@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -27,7 +28,7 @@ import (
 type KubeClient struct {
 	apiHost           string
 	bindingsEndpoint  string
-	eventsEndpoint    string
+	events            *bwcontroller.EventPoster
 	nodesEndpoint     string
 	watchPodsEndpoint string
 	configEndpoint    string
@@ -35,6 +36,8 @@ type KubeClient struct {
 	namespaces        []string
 	metricsEndpoint   string
 	nsEndpoint	  string
+	configMapsEndpoint string
+	deletePodEndpoint string
 }
 
 func (client *KubeClient) WaitForProxy() int {
@@ -64,38 +67,12 @@ func (client *KubeClient) WaitForProxy() int {
 	return 0
 }
 
-func (client *KubeClient) PostEvent(event Event, ns string) error {
-	var b []byte
-	body := bytes.NewBuffer(b)
-	err := json.NewEncoder(body).Encode(event)
-	if err != nil {
-		logger(err)
-		return err
-	}
-
-	request := &http.Request{
-		Body:          ioutil.NopCloser(body),
-		ContentLength: int64(body.Len()),
-		Header:        make(http.Header),
-		Method:        http.MethodPost,
-		URL: &url.URL{
-			Host:   client.apiHost,
-			Path:   fmt.Sprintf(client.eventsEndpoint, ns),
-			Scheme: "http",
-		},
-	}
-	request.Header.Set("Content-Type", "application/json")
+func (client *KubeClient) PostEvent(event bwcontroller.Event, ns string) error {
+	return client.events.PostEvent(event, ns)
+}
 
-	resp, err := http.DefaultClient.Do(request)
-	if err != nil {
-		logger(err)
-		return err
-	}
-	if resp.StatusCode != 201 {
-		logger(errors.New("Event: Unexpected HTTP status code" + resp.Status))
-		return errors.New("Event: Unexpected HTTP status code" + resp.Status)
-	}
-	return nil
+func (client *KubeClient) PatchEvent(event bwcontroller.Event, ns string) error {
+	return client.events.PatchEvent(event, ns)
 }
 
 func (client *KubeClient) GetNamespaces() (*NamespaceList, error) {
@@ -358,6 +335,74 @@ func (client *KubeClient) getPodsOne(ns string) (*PodList, error) {
 	return &podList, nil
 }
 
+// config maps matching labelSelector in all the monitored namespaces
+func (client *KubeClient) GetConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	configMaps := make([]ConfigMap, 0)
+	for _, ns := range client.namespaces {
+		cmList, err := client.getConfigMapsOne(ns, labelSelector)
+		if err == nil {
+			configMaps = append(configMaps, cmList.Items...)
+		} else {
+			logger(fmt.Sprintf("Got error %v", err))
+		}
+	}
+	return configMaps, nil
+}
+
+func (client *KubeClient) getConfigMapsOne(ns string, labelSelector string) (*ConfigMapList, error) {
+	var cmList ConfigMapList
+
+	v := url.Values{}
+	v.Set("labelSelector", labelSelector)
+	request := &http.Request{
+		Header: make(http.Header),
+		Method: http.MethodGet,
+		URL: &url.URL{
+			Host:     client.apiHost,
+			Path:     fmt.Sprintf(client.configMapsEndpoint, ns),
+			RawQuery: v.Encode(),
+			Scheme:   "http",
+		},
+	}
+	request.Header.Set("Accept", "application/json, */*")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, errors.New("ConfigMaps: Unexpected HTTP status code: " + resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&cmList)
+	if err != nil {
+		return nil, err
+	}
+	return &cmList, nil
+}
+
+// deletes a pod, pods owned by a controller get recreated as new pending pods
+func (client *KubeClient) DeletePod(pod Pod) error {
+	request := &http.Request{
+		Header: make(http.Header),
+		Method: http.MethodDelete,
+		URL: &url.URL{
+			Host:   client.apiHost,
+			Path:   fmt.Sprintf(client.deletePodEndpoint, pod.Metadata.Namespace, pod.Metadata.Name),
+			Scheme: "http",
+		},
+	}
+	request.Header.Set("Accept", "application/json, */*")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 && resp.StatusCode != 202 {
+		return errors.New("Delete: Unexpected HTTP status code: " + resp.Status)
+	}
+	return nil
+}
+
 func (client *KubeClient) Bind(pod Pod, node Node) error {
 	binding := Binding{
 		ApiVersion: "v1",
@@ -404,24 +449,7 @@ func (client *KubeClient) Bind(pod Pod, node Node) error {
 	message := fmt.Sprintf("Successfully assigned %s to %s", pod.Metadata.Name, node.Metadata.Name)
 	logger(message)
 
-	// // Emit a Kubernetes event that the Pod was scheduled successfully.
-	// timestamp := time.Now().UTC().Format(time.RFC3339)
-	// event := Event{
-	// 	Count:          1,
-	// 	Message:        message,
-	// 	Reason:         "Scheduled",
-	// 	LastTimestamp:  timestamp,
-	// 	FirstTimestamp: timestamp,
-	// 	Type:           "Normal",
-	// 	Source:         EventSource{Component: "hightower-scheduler"},
-	// 	InvolvedObject: ObjectReference{
-	// 		Kind:      "Pod",
-	// 		Name:      pod.Metadata.Name,
-	// 		Namespace: "default",
-	// 		Uid:       pod.Metadata.Uid,
-	// 	},
-	// }
-	// return postEvent(event)
+	// the Scheduled event is posted by the scheduler once the whole pod group is bound
 
 	return nil
 }
@@ -25,22 +25,32 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 )
 
 var (
 	apiHost           = "127.0.0.1:8001"
 	bindingsEndpoint  = "/api/v1/namespaces/%s/pods/%s/binding/"
-	eventsEndpoint    = "/api/v1/watch/namespaces/%s/events"
+	eventsEndpoint    = "/api/v1/namespaces/%s/events"
 	nodesEndpoint     = "/api/v1/nodes"
 	podsEndpoint      = "/api/v1/namespaces/%s/pods/"
 	watchPodsEndpoint = "/api/v1/watch/namespaces/%s/pods"
 	configEndpoint    = "/apis/apps/v1/namespaces/epl/deployments/epl-scheduler"
 	metricsEndpoint   = "/apis/metrics.k8s.io/v1beta1/nodes"
+	configMapsEndpoint = "/api/v1/namespaces/%s/configmaps"
+	deletePodEndpoint = "/api/v1/namespaces/%s/pods/%s"
+	metricsAddr       = ":9101"
 	addrs             = []string{"localhost:50051"}
 )
 
 const schedulerName = "epl-scheduler"
 
+// default time a partially arrived pod group waits for its missing pods
+const GANG_TIMEOUT = 300 * time.Second
+
+// default time a netmon fetch may take
+const NETMON_TIMEOUT = 60 * time.Second
+
 func parseConfig(filename string) Config {
 	content, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -76,6 +86,13 @@ func parseIpMap(filename string) map[string]string {
 
 }
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		// query a running scheduler, must not touch its sched_log
+		os.Exit(runExplain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
 	f, err := os.OpenFile("sched_log", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		log.Fatal(err)
@@ -94,21 +111,72 @@ func main() {
 	flag.Parse()
 	config := parseConfig(configFile)
 	ipMap := parseIpMap(ipMapFile)
+	if config.ConfigMapsEndpoint == "" {
+		config.ConfigMapsEndpoint = configMapsEndpoint
+	}
+	if config.DeletePodEndpoint == "" {
+		config.DeletePodEndpoint = deletePodEndpoint
+	}
+	if config.MetricsAddr == "" {
+		config.MetricsAddr = metricsAddr
+	}
+	gangTimeout := GANG_TIMEOUT
+	if config.GangTimeout > 0 {
+		gangTimeout = time.Duration(config.GangTimeout) * time.Second
+	}
+	netmonTimeout := NETMON_TIMEOUT
+	if config.NetmonTimeout > 0 {
+		netmonTimeout = time.Duration(config.NetmonTimeout) * time.Second
+	}
 	client := KubeClient{apiHost: config.ApiHost,
 		bindingsEndpoint:  config.BindingsEndpoint,
-		eventsEndpoint:    config.EventsEndpoint,
+		events:            bwcontroller.NewEventPoster(config.ApiHost, config.EventsEndpoint),
 		nodesEndpoint:     config.NodesEndpoint,
 		podsEndpoint:      config.PodsEndpoint,
 		watchPodsEndpoint: config.WatchPodsEndpoint,
 		metricsEndpoint:   config.MetricsEndpoint,
 		configEndpoint:    config.ConfigEndpoint,
 		namespaces:        config.Namespaces,
-		nsEndpoint:	   config.NamespaceEndpoint}
+		nsEndpoint:	   config.NamespaceEndpoint,
+		configMapsEndpoint: config.ConfigMapsEndpoint,
+		deletePodEndpoint: config.DeletePodEndpoint}
 
 	done := client.WaitForProxy()
 	promClient := bwcontroller.NewPrometheusClient(config.PromAddr, config.PromMetrics)
 	logger(fmt.Sprintf("Got %d namespaces", len(config.Namespaces)))
-	dagSched := &DagScheduler{client: &client, processorLock: &sync.Mutex{}, podProcessor: NewPodProcessor(&client), netmonClient: netmon_client.NewNetmonClient(config.NetmonAddrs), promClient: promClient, ipMap: ipMap, tolerance: config.Tolerance, deployedApps: make(map[string]DeploymentMap, 0)}
+	var kubeClient KubeClientIntf = &client
+	netmon := netmon_client.NewNetmonClient(config.NetmonAddrs)
+	if config.BwAggregate != "" {
+		if err := netmon.SetBwAggregate(config.BwAggregate, time.Duration(config.BwAggregateWindow)*time.Second); err != nil {
+			log.Fatal(err)
+		}
+		logger(fmt.Sprintf("Placing on the %s of the bw measured over %ds", config.BwAggregate, config.BwAggregateWindow))
+	}
+	if config.UseBwLowerBound {
+		netmon.SetBwLowerBound(true)
+		logger("Placing on the lower bound of the bw netmon estimates")
+	}
+	var netmonClient netmon_client.NetmonClientIntf = netmon
+	var podMetricsClient PromClientIntf = promClient
+	var recorder *SnapshotRecorder
+	if config.SnapshotDir != "" {
+		logger("Recording snapshots to " + config.SnapshotDir)
+		recorder = NewSnapshotRecorder(config.SnapshotDir, kubeClient, netmonClient, podMetricsClient)
+		kubeClient, netmonClient, podMetricsClient = recorder, recorder, recorder
+	}
+	dagSched := &DagScheduler{client: kubeClient, processorLock: &sync.Mutex{}, podProcessor: NewPodProcessor(kubeClient), netmonClient: netmonClient, promClient: podMetricsClient, ipMap: ipMap, tolerance: config.Tolerance, deployedApps: make(map[string]DeploymentMap, 0), gangTimeout: gangTimeout, netmonTimeout: netmonTimeout, recorder: recorder}
+	dagSched.events = bwcontroller.NewEventRecorder(&client, schedulerName)
+	dagSched.ledger = bwcontroller.NewConfigMapLedgerStore(config.ApiHost, config.ConfigMapsEndpoint, config.Namespaces)
+	if recorder != nil {
+		dagSched.ledger = recorder.WrapLedgerStore(dagSched.ledger)
+	}
+	dagSched.strategy, err = NewPlacementStrategy(config.Strategy, dagSched)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger("Using placement strategy " + dagSched.strategy.Name())
+	registerQueueDepth(dagSched.podProcessor)
+	go ServeHttp(config.MetricsAddr, dagSched)
 	if done == 0 {
 		logger("Failed to connect to proxy.")
 		os.Exit(0)
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	meshscheduler "github.gatech.edu/cs-epl/mesh-bw-scheduler/meshscheduler"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// capacity of the loopback link used for pods placed on the same node
+const LOOPBACK_BW = 1e12
+
+func getNodeIp(node Node) string {
+	nodeIp, ipExists := node.Metadata.Annotations["alpha.kubernetes.io/provided-node-ip"]
+	if !ipExists {
+		nodeIp = node.Metadata.Annotations["flannel.alpha.coreos.com/public-ip"]
+	}
+	return nodeIp
+}
+
+func addMeshLink(linkMap meshscheduler.LinkMap, src string, dst string, bw float64) *meshscheduler.LinkBandwidth {
+	_, exists := linkMap[src]
+	if !exists {
+		linkMap[src] = make(map[string]*meshscheduler.LinkBandwidth, 0)
+	}
+	link, exists := linkMap[src][dst]
+	if !exists {
+		link = &meshscheduler.LinkBandwidth{Src: src, Dst: dst, BwCapacity: bw, BwInUse: 0}
+		linkMap[src][dst] = link
+	}
+	return link
+}
+
+// links along hops to dst, false if a hop has no link. hops start with the source and do not include the destination
+func meshPathLinks(linkMap meshscheduler.LinkMap, ipToName map[string]string, hops []string, dst string) ([]*meshscheduler.LinkBandwidth, bool) {
+	hops = append(append(make([]string, 0), hops...), dst)
+	pathBw := make([]*meshscheduler.LinkBandwidth, 0)
+	for i := 0; i < len(hops)-1; i++ {
+		hopSrc, srcExists := ipToName[hops[i]]
+		hopDst, dstExists := ipToName[hops[i+1]]
+		if !srcExists || !dstExists {
+			return pathBw, false
+		}
+		link, exists := linkMap[hopSrc][hopDst]
+		if !exists {
+			return pathBw, false
+		}
+		pathBw = append(pathBw, link)
+	}
+	return pathBw, true
+}
+
+// parses a cpu or memory quantity of a node, false if it is missing or invalid
+func parseNodeQuantity(nodeName string, name string, value string) (resource.Quantity, bool) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		logger(fmt.Sprintf("node %s has no valid %s %q: %v", nodeName, name, value, err))
+		return quantity, false
+	}
+	return quantity, true
+}
+
+// converts the cluster state (node ips) into the node/route/link maps used by meshscheduler (node names)
+func MakeMeshTopology(state *ClusterState) (meshscheduler.NodeMap, meshscheduler.RouteMap, meshscheduler.LinkMap) {
+	nodeMap := make(meshscheduler.NodeMap, 0)
+	routeMap := make(meshscheduler.RouteMap, 0)
+	linkMap := make(meshscheduler.LinkMap, 0)
+	ipToName := make(map[string]string, 0)
+
+	for _, node := range state.nodes.Items {
+		// nodes without allocatable resources can not take pods, they are left out
+		cpu, cpuValid := parseNodeQuantity(node.Metadata.Name, "allocatable cpu", node.Status.Allocatable["cpu"])
+		memory, memoryValid := parseNodeQuantity(node.Metadata.Name, "allocatable memory", node.Status.Allocatable["memory"])
+		if !cpuValid || !memoryValid {
+			continue
+		}
+		ipToName[getNodeIp(node)] = node.Metadata.Name
+		nodeMap[node.Metadata.Name] = meshscheduler.Node{NodeId: node.Metadata.Name,
+			CpuCapacity:    int(cpu.MilliValue()),
+			MemoryCapacity: int(memory.Value() / (1024 * 1024))}
+	}
+	for _, node := range state.nodeMetrics.Items {
+		n, exists := nodeMap[node.Metadata.Name]
+		if !exists {
+			continue
+		}
+		// without valid usage the node is taken as idle
+		cpu, _ := parseNodeQuantity(node.Metadata.Name, "cpu usage", node.Usage.Cpu)
+		memory, _ := parseNodeQuantity(node.Metadata.Name, "memory usage", node.Usage.Memory)
+		n.CpuInUse = int(cpu.MilliValue())
+		n.MemoryInUse = int(memory.Value() / (1024 * 1024))
+		nodeMap[node.Metadata.Name] = n
+	}
+
+	for src, dstLinks := range state.links {
+		srcName, srcExists := ipToName[src]
+		for dst, l := range dstLinks {
+			dstName, dstExists := ipToName[dst]
+			if !srcExists || !dstExists {
+				continue
+			}
+			link := addMeshLink(linkMap, srcName, dstName, l.Bandwidth)
+			link.Latency = l.Latency
+			if traf, exists := state.traffics[src][dst]; exists {
+				link.BwInUse = traf.Bytes
+			}
+		}
+	}
+
+	for src, dstPaths := range state.paths {
+		srcName, exists := ipToName[src]
+		if !exists {
+			continue
+		}
+		for dst, path := range dstPaths {
+			dstName, exists := ipToName[dst]
+			if !exists || srcName == dstName {
+				continue
+			}
+			_, exists = routeMap[srcName]
+			if !exists {
+				routeMap[srcName] = make(map[string]meshscheduler.Route, 0)
+			}
+			route := meshscheduler.Route{Src: srcName, Dst: dstName, BwCapacity: path.Bandwidth}
+			if traf, exists := state.traffics[src][dst]; exists {
+				route.BwInUse = traf.Bytes
+			}
+			pathBw, complete := meshPathLinks(linkMap, ipToName, path.Hops, dst)
+			route.PathBw = pathBw
+			if !complete || len(route.PathBw) == 0 {
+				// no per hop info, treat the path as a single link
+				logger(fmt.Sprintf("no link info for path %s -> %s, using path bw %f", srcName, dstName, path.Bandwidth))
+				link := addMeshLink(linkMap, srcName, dstName, path.Bandwidth)
+				link.BwInUse = route.BwInUse
+				link.Latency = path.Latency
+				route.PathBw = []*meshscheduler.LinkBandwidth{link}
+			}
+			// traffic spread over several routes, only the ones with link info for every hop
+			if len(path.Routes) > 1 {
+				paths := make([]meshscheduler.WeightedPath, 0)
+				for _, r := range path.Routes {
+					if pathBw, complete := meshPathLinks(linkMap, ipToName, r.Hops, dst); complete && len(pathBw) > 0 {
+						paths = append(paths, meshscheduler.WeightedPath{PathBw: pathBw, Weight: r.Weight})
+					}
+				}
+				if len(paths) > 1 {
+					route.Paths = paths
+				}
+			}
+			// prefer the measured end to end latency
+			route.Latency = path.Latency
+			if route.Latency == 0 {
+				route.Latency = route.ComputeLatency()
+			}
+			routeMap[srcName][dstName] = route
+		}
+	}
+
+	// bw reserved for pods placed earlier that their traffic does not show yet
+	for src, dstBws := range state.reserved {
+		for dst, bw := range dstBws {
+			if route, exists := routeMap[src][dst]; exists {
+				reserveMeshRoute(&route, bw)
+				routeMap[src][dst] = route
+			}
+		}
+	}
+
+	for nodeName, _ := range nodeMap {
+		link := addMeshLink(linkMap, nodeName, nodeName, LOOPBACK_BW)
+		_, exists := routeMap[nodeName]
+		if !exists {
+			routeMap[nodeName] = make(map[string]meshscheduler.Route, 0)
+		}
+		routeMap[nodeName][nodeName] = meshscheduler.Route{Src: nodeName, Dst: nodeName, BwCapacity: LOOPBACK_BW,
+			PathBw: []*meshscheduler.LinkBandwidth{link}}
+	}
+	return nodeMap, routeMap, linkMap
+}
+
+// adds bw to the use of every link of the route, a multipath route spreads it over its paths
+func reserveMeshRoute(route *meshscheduler.Route, bw float64) {
+	if route.IsMultipath() {
+		route.Reserve(bw)
+		return
+	}
+	route.BwInUse += bw
+	for _, link := range route.PathBw {
+		link.BwInUse += bw
+	}
+}
+
+// builds a meshscheduler application out of the pending pods of a pod group, components are keyed by the short pod name.
+// Deps on pods placed earlier are kept with the node of the pod in placed, deps on other pods are left out
+func MakeMeshApplication(pods map[string]Pod, podOrder []string, podReqs map[string]PodRequirements, placed DeploymentMap) meshscheduler.Application {
+	app := meshscheduler.Application{Components: make(meshscheduler.ComponentMap, 0), Placed: make(map[string]string, 0)}
+	inApp := make(map[string]bool, 0)
+	for _, podName := range podOrder {
+		if getPodWithName(podName, pods).Metadata.Name != "" {
+			inApp[podName] = true
+		}
+	}
+	for _, podName := range podOrder {
+		pod := getPodWithName(podName, pods)
+		if pod.Metadata.Name == "" {
+			continue
+		}
+		app.AppId = pod.Metadata.Namespace
+		comp := meshscheduler.Component{ComponentId: podName, Bandwidth: make(meshscheduler.ComponentBw, 0),
+			MaxLatency: make(meshscheduler.ComponentLatency, 0)}
+		for _, container := range pod.Spec.Containers {
+			if cpu, exists := container.Resources.Requests["cpu"]; exists {
+				res := resource.MustParse(cpu)
+				comp.Cpu += int(res.MilliValue())
+			}
+			if memory, exists := container.Resources.Requests["memory"]; exists {
+				res := resource.MustParse(memory)
+				comp.Memory += int(res.Value() / (1024 * 1024))
+			}
+		}
+		for _, dep := range podReqs[podName].Deps {
+			if !inApp[dep.Pod] {
+				node, exists := placed[dep.Pod]
+				if !exists {
+					logger(fmt.Sprintf("pod %s depends on %s that is neither pending nor placed, leaving the dep out", podName, dep.Pod))
+					continue
+				}
+				app.Placed[dep.Pod] = node
+			}
+			if dep.SendBw() > 0 {
+				comp.Bandwidth[dep.Pod] += dep.SendBw()
+				comp.TotalBw += dep.SendBw()
+			}
+			if dep.MaxLatency > 0 && dep.Relationship == DEPENDS_ON {
+				comp.MaxLatency[dep.Pod] = dep.MaxLatency
+			}
+		}
+		app.Components[podName] = comp
+	}
+	return app
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+	"testing"
+)
+
+func getMeshTestNode(name string, ip string) Node {
+	node := Node{}
+	node.Metadata.Name = name
+	node.Metadata.Annotations = map[string]string{"alpha.kubernetes.io/provided-node-ip": ip}
+	node.Status.Allocatable = ResourceList{"cpu": "2", "memory": "2Gi"}
+	return node
+}
+
+func getMeshTestState() *ClusterState {
+	nodes := &NodeList{Items: []Node{getMeshTestNode("n1", "10.0.0.1"), getMeshTestNode("n2", "10.0.0.2"), getMeshTestNode("n3", "10.0.0.3")}}
+	metrics := &NodeMetricsList{Items: []NodeMetric{NodeMetric{Metadata: NodeMetadata{Name: "n1"}, Usage: UsageData{Cpu: "500m", Memory: "1Gi"}}}}
+	links := netmon_client.LinkSet{
+		"10.0.0.1": {"10.0.0.2": netmon_client.Link{Source: "10.0.0.1", Destination: "10.0.0.2", Bandwidth: 100}},
+		"10.0.0.2": {"10.0.0.3": netmon_client.Link{Source: "10.0.0.2", Destination: "10.0.0.3", Bandwidth: 50}},
+	}
+	paths := netmon_client.PathSet{
+		"10.0.0.1": {
+			"10.0.0.3": netmon_client.Path{Source: "10.0.0.1", Destination: "10.0.0.3", Hops: []string{"10.0.0.1", "10.0.0.2"}, Bandwidth: 50, Latency: 12},
+		},
+		"10.0.0.3": {
+			"10.0.0.1": netmon_client.Path{Source: "10.0.0.3", Destination: "10.0.0.1", Hops: []string{"10.0.0.3"}, Bandwidth: 20},
+		},
+	}
+	traffics := netmon_client.TrafficSet{"10.0.0.1": {"10.0.0.2": netmon_client.Traffic{Source: "10.0.0.1", Destination: "10.0.0.2", Bytes: 10}}}
+	return &ClusterState{nodes: nodes, nodeMetrics: metrics, links: links, paths: paths, traffics: traffics}
+}
+
+func TestMakeMeshTopology(t *testing.T) {
+	nodeMap, routeMap, linkMap := MakeMeshTopology(getMeshTestState())
+	if len(nodeMap) != 3 {
+		t.Fatalf("Want 3 nodes, got %d instead", len(nodeMap))
+	}
+	if nodeMap["n1"].CpuCapacity != 2000 || nodeMap["n1"].CpuInUse != 500 || nodeMap["n1"].MemoryInUse != 1024 {
+		t.Fatalf("Got unexpected node state %v", nodeMap["n1"])
+	}
+	if linkMap["n1"]["n2"].BwInUse != 10 {
+		t.Fatalf("Want link n1->n2 in use 10, got %f", linkMap["n1"]["n2"].BwInUse)
+	}
+	route := routeMap["n1"]["n3"]
+	if len(route.PathBw) != 2 || route.PathBw[0] != linkMap["n1"]["n2"] || route.PathBw[1] != linkMap["n2"]["n3"] {
+		t.Fatalf("Route n1->n3 does not go over n1->n2->n3")
+	}
+	if route.Latency != 12 {
+		t.Fatalf("Want route n1->n3 latency 12, got %f", route.Latency)
+	}
+	// no link info for n3 -> n1
+	route = routeMap["n3"]["n1"]
+	if len(route.PathBw) != 1 || route.PathBw[0].BwCapacity != 20 {
+		t.Fatalf("Want single link route with bw 20 for n3->n1")
+	}
+	for nodeName, _ := range nodeMap {
+		route, exists := routeMap[nodeName][nodeName]
+		if !exists || len(route.PathBw) != 1 {
+			t.Fatalf("Missing loopback route for %s", nodeName)
+		}
+	}
+}
+
+func TestMakeMeshTopologyMultipath(t *testing.T) {
+	state := getMeshTestState()
+	state.links["10.0.0.1"]["10.0.0.3"] = netmon_client.Link{Source: "10.0.0.1", Destination: "10.0.0.3", Bandwidth: 30}
+	path := state.paths["10.0.0.1"]["10.0.0.3"]
+	path.Bandwidth = 80
+	path.Routes = []netmon_client.Route{
+		netmon_client.Route{Hops: []string{"10.0.0.1", "10.0.0.2"}, Weight: 0.6},
+		netmon_client.Route{Hops: []string{"10.0.0.1"}, Weight: 0.3},
+		netmon_client.Route{Hops: []string{"10.0.0.1", "10.0.0.9"}, Weight: 0.1},
+	}
+	state.paths["10.0.0.1"]["10.0.0.3"] = path
+	_, routeMap, linkMap := MakeMeshTopology(state)
+	route := routeMap["n1"]["n3"]
+	if len(route.Paths) != 2 {
+		t.Fatalf("Want 2 paths for n1->n3 (one route has an unknown hop), got %d", len(route.Paths))
+	}
+	if route.Paths[1].Weight != 0.3 || len(route.Paths[1].PathBw) != 1 || route.Paths[1].PathBw[0] != linkMap["n1"]["n3"] {
+		t.Fatalf("Want direct path n1->n3 with weight 0.3, got %v", route.Paths[1])
+	}
+	// 50 over n2 and 30 direct
+	if bw := route.AvailableBw(); bw != 80 {
+		t.Fatalf("Want 80 available on n1->n3, got %f", bw)
+	}
+	if len(routeMap["n3"]["n1"].Paths) != 0 {
+		t.Fatalf("Want no paths for single route n3->n1")
+	}
+}
+
+func TestMakeMeshTopologyInvalidQuantities(t *testing.T) {
+	state := getMeshTestState()
+	delete(state.nodes.Items[2].Status.Allocatable, "memory")
+	state.nodeMetrics.Items[0].Usage = UsageData{Cpu: "", Memory: "lots"}
+	nodeMap, routeMap, _ := MakeMeshTopology(state)
+	if _, exists := nodeMap["n3"]; exists || len(nodeMap) != 2 {
+		t.Fatalf("Want n3 without allocatable memory left out, got %v", nodeMap)
+	}
+	if nodeMap["n1"].CpuInUse != 0 || nodeMap["n1"].MemoryInUse != 0 {
+		t.Fatalf("Want n1 without valid usage taken as idle, got %v", nodeMap["n1"])
+	}
+	if _, exists := routeMap["n1"]["n3"]; exists {
+		t.Fatalf("Want no route to n3")
+	}
+}
+
+func TestMakeMeshApplication(t *testing.T) {
+	pods := make(map[string]Pod, 0)
+	pod := Pod{}
+	pod.Metadata.Name = "front-5d9c8-x2k4"
+	pod.Metadata.Namespace = "app"
+	pod.Metadata.Annotations = map[string]string{"dependson.back.bw": "100", "dependson.back": "yes", "dependson.back.latency": "5"}
+	pod.Spec.Containers = []Container{Container{Resources: ResourceRequirements{Requests: ResourceList{"cpu": "250m", "memory": "64Mi"}}}}
+	pods[pod.Metadata.Name] = pod
+
+	podReqs, _ := ParsePodAnnotations(pod.Metadata.Annotations)
+	app := MakeMeshApplication(pods, []string{"front"}, map[string]PodRequirements{"front": podReqs}, DeploymentMap{"back": "n2"})
+	if app.AppId != "app" {
+		t.Fatalf("Want app id app, got %s", app.AppId)
+	}
+	if len(app.Components) != 1 || app.Placed["back"] != "n2" {
+		t.Fatalf("Want back placed on n2 and not a component, got %v", app)
+	}
+	comp, exists := app.Components["front"]
+	if !exists {
+		t.Fatalf("Missing component front")
+	}
+	if comp.Cpu != 250 || comp.Memory != 64 || comp.Bandwidth["back"] != 100 || comp.TotalBw != 100 || comp.MaxLatency["back"] != 5 {
+		t.Fatalf("Got unexpected component %v", comp)
+	}
+}
+
+func TestMakeMeshApplicationUnknownDep(t *testing.T) {
+	pod := Pod{}
+	pod.Metadata.Name = "front-5d9c8-x2k4"
+	pod.Metadata.Namespace = "app"
+	podReqs, _ := ParsePodAnnotations(map[string]string{"dependson.back.bw": "100", "dependson.back": "yes", "dependson.back.latency": "5"})
+	app := MakeMeshApplication(map[string]Pod{pod.Metadata.Name: pod}, []string{"front"}, map[string]PodRequirements{"front": podReqs}, DeploymentMap{})
+	comp := app.Components["front"]
+	if len(comp.Bandwidth) != 0 || comp.TotalBw != 0 || len(comp.MaxLatency) != 0 || len(app.Placed) != 0 {
+		t.Fatalf("Want the dep on back that is neither pending nor placed left out, got %v", app)
+	}
+}
+
+func TestMeshStrategyPlacedPeer(t *testing.T) {
+	front := Pod{}
+	front.Metadata.Name = "front-5d9c8-x2k4"
+	front.Metadata.Namespace = "app"
+	front.Metadata.Annotations = map[string]string{"dependson.back.bw": "10", "dependson.back": "yes"}
+	// front takes a whole node, n1 has some of its cpu in use
+	front.Spec.Containers = []Container{Container{Resources: ResourceRequirements{Requests: ResourceList{"cpu": "2"}}}}
+	pods := map[string]Pod{front.Metadata.Name: front}
+	podReqs, _ := ParsePodAnnotations(front.Metadata.Annotations)
+
+	for _, name := range []string{OPTIMAL_STRATEGY, MAXBW_STRATEGY} {
+		t.Run(name, func(t *testing.T) {
+			// back was placed on n1 earlier, only n3 has a route to it
+			sched := &DagScheduler{deployedApps: map[string]DeploymentMap{"app": {"back": "n1"}}}
+			strategy, _ := NewPlacementStrategy(name, sched)
+			state := getMeshTestState()
+			state.podReqs = map[string]PodRequirements{"front": podReqs}
+			podAssignment := strategy.Place(pods, []string{"front"}, state)
+			if len(podAssignment) != 1 || podAssignment["front-5d9c8-x2k4"] != "n3" {
+				t.Fatalf("Want front on n3 with the route to back on n1, got %v", podAssignment)
+			}
+		})
+	}
+}
+
+func TestNewPlacementStrategy(t *testing.T) {
+	for _, name := range []string{"", GREEDY_STRATEGY, OPTIMAL_STRATEGY, MAXBW_STRATEGY, TABU_STRATEGY, ANNEALING_STRATEGY} {
+		_, err := NewPlacementStrategy(name, &DagScheduler{})
+		if err != nil {
+			t.Fatalf("Got error %v for strategy %s", err, name)
+		}
+	}
+	_, err := NewPlacementStrategy("random", &DagScheduler{})
+	if err == nil {
+		t.Fatalf("Want error for unknown strategy")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const METRICS_NAMESPACE = "epl_scheduler"
+
+// scheduling phases timed in schedulingDuration
+const (
+	PHASE_GRAPH_SORT = "graph_sort"
+	PHASE_POD_LOOP   = "pod_loop"
+	PHASE_PLACEMENT  = "placement"
+)
+
+var (
+	schedulingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "scheduling_duration_seconds",
+		Help:      "Time spent scheduling a pod group, by phase.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+	}, []string{"phase"})
+	podsScheduled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "pods_scheduled_total",
+		Help:      "Pods bound to a node.",
+	})
+	podsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "pods_failed_total",
+		Help:      "Pods that could not be scheduled, by reason.",
+	}, []string{"reason"})
+	netmonFetchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "netmon_fetch_duration_seconds",
+		Help:      "Time taken to fetch link, path and traffic stats from netmon.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+	bindErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "bind_errors_total",
+		Help:      "Failed pod bindings.",
+	})
+	netmonStaleNodes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "netmon_stale_nodes",
+		Help:      "Nodes whose netmon could not be reached in the last fetch.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(schedulingDuration, podsScheduled, podsFailed, netmonFetchDuration, bindErrors, netmonStaleNodes)
+}
+
+// exposes the number of pods waiting in the pod processor
+func registerQueueDepth(pp *PodProcessor) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "queue_depth",
+		Help:      "Pods waiting to be scheduled.",
+	}, func() float64 {
+		return float64(pp.GetQueueDepth())
+	}))
+}
+
+// records how the netmon stats of the cluster state were fetched, only for states that pods are scheduled on
+func recordNetmonMetrics(state *ClusterState) {
+	netmonFetchDuration.Observe(state.netmonFetch.Seconds())
+	netmonStaleNodes.Set(float64(len(state.staleNodes)))
+}
+
+// turns a FailedScheduling reason like "Insufficient cpu" into a label value like "insufficient_cpu"
+func metricReason(reason string) string {
+	return strings.ReplaceAll(strings.ToLower(reason), " ", "_")
+}
+
+// serves /metrics and /explain on addr, runs until the process exits
+func ServeHttp(addr string, sched *DagScheduler) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/explain", sched.ServeExplain)
+	logger("serving metrics on " + addr)
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		logger(err)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFitFailureReason(t *testing.T) {
+	state := &ClusterState{}
+	if reason := state.getFitFailureReason("front"); reason != "no placement" {
+		t.Fatalf("Want no placement, got %s", reason)
+	}
+	state.addFitFailure("front", "n1", FIT_INSUFFICIENT_CPU)
+	state.addFitFailure("front", "n2", FIT_NO_NETMON)
+	state.addFitFailure("front", "n3", FIT_INSUFFICIENT_CPU)
+	reason := state.getFitFailureReason("front")
+	if reason != FIT_INSUFFICIENT_CPU {
+		t.Fatalf("Want %s, got %s", FIT_INSUFFICIENT_CPU, reason)
+	}
+	if metricReason(reason) != "insufficient_cpu" {
+		t.Fatalf("Got unexpected label %s", metricReason(reason))
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A PodGroup describes an application graph: its components and the network requirements between them.
+// It is read from a ConfigMap labelled with PODGROUP_LABEL, the document is stored under PODGROUP_KEY, e.g.
+//
+//	{"apiVersion": "epl.gatech.edu/v1", "kind": "PodGroup", "metadata": {"name": "camera"},
+//	 "spec": {"components": [{"name": "frontend"}, {"name": "backend"}],
+//	          "edges": [{"from": "frontend", "to": "backend", "bandwidth": 1000000, "maxLatency": 20, "direction": "both"}]}}
+//
+// Components that are not listed in a PodGroup of their namespace fall back to the dependson/dependedby/neighbor annotations.
+const (
+	PODGROUP_KIND  = "PodGroup"
+	PODGROUP_LABEL = "epl/podgroup"
+	PODGROUP_KEY   = "podgroup.json"
+
+	DEPENDS_ON  = "dependson"
+	DEPENDED_BY = "dependedby"
+	NEIGHBOR    = "neighbor"
+
+	// traffic direction of an edge, relative to the "from" component
+	DIRECTION_SEND = "send"
+	DIRECTION_RECV = "recv"
+	DIRECTION_BOTH = "both"
+
+	NEIGHBOR_ALL = "all"
+	NEIGHBOR_ANY = "any"
+)
+
+type PodGroup struct {
+	ApiVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Metadata   Metadata     `json:"metadata"`
+	Spec       PodGroupSpec `json:"spec"`
+}
+
+type PodGroupSpec struct {
+	Components []ComponentSpec `json:"components"`
+	Edges      []EdgeSpec      `json:"edges"`
+}
+
+type ComponentSpec struct {
+	Name      string         `json:"name"`
+	Neighbors []NeighborSpec `json:"neighbors,omitempty"`
+}
+
+// bandwidth needed towards all (or at least one) of the other nodes, regardless of where the dependencies are placed
+type NeighborSpec struct {
+	Scope     string  `json:"scope"`     // all or any
+	Direction string  `json:"direction"` // send or recv
+	Bandwidth float64 `json:"bandwidth"`
+}
+
+// From depends on To
+type EdgeSpec struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Bandwidth  float64 `json:"bandwidth"`            // bps
+	MaxLatency float64 `json:"maxLatency,omitempty"` // ms, 0 means no bound
+	Direction  string  `json:"direction,omitempty"`  // defaults to send
+}
+
+// Dependency of a pod on another pod, as seen from the pod
+type PodDependency struct {
+	Pod          string
+	Relationship string // dependson or dependedby
+	Bandwidth    float64
+	MaxLatency   float64
+	Direction    string
+}
+
+// Typed network requirements of one pod, built either from a PodGroup or from the pod annotations
+type PodRequirements struct {
+	Deps      []PodDependency
+	Neighbors []NeighborSpec
+}
+
+type PodGroupSet map[string]map[string]PodGroup // ns -> pod group name -> pod group
+
+// bandwidth this pod sends to the other pod
+func (dep PodDependency) SendBw() float64 {
+	if dep.Direction == DIRECTION_BOTH ||
+		(dep.Relationship == DEPENDS_ON && dep.Direction == DIRECTION_SEND) ||
+		(dep.Relationship == DEPENDED_BY && dep.Direction == DIRECTION_RECV) {
+		return dep.Bandwidth
+	}
+	return 0.0
+}
+
+// bandwidth this pod receives from the other pod
+func (dep PodDependency) RecvBw() float64 {
+	if dep.Direction == DIRECTION_BOTH ||
+		(dep.Relationship == DEPENDS_ON && dep.Direction == DIRECTION_RECV) ||
+		(dep.Relationship == DEPENDED_BY && dep.Direction == DIRECTION_SEND) {
+		return dep.Bandwidth
+	}
+	return 0.0
+}
+
+func (reqs PodRequirements) GetDeps(relationship string) []PodDependency {
+	deps := make([]PodDependency, 0)
+	for _, dep := range reqs.Deps {
+		if dep.Relationship == relationship {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+func (reqs PodRequirements) GetDep(podName string, relationship string) (PodDependency, bool) {
+	for _, dep := range reqs.Deps {
+		if dep.Pod == podName && dep.Relationship == relationship {
+			return dep, true
+		}
+	}
+	return PodDependency{}, false
+}
+
+func ParsePodGroup(content []byte) (PodGroup, error) {
+	var podGroup PodGroup
+	err := json.Unmarshal(content, &podGroup)
+	if err != nil {
+		return podGroup, err
+	}
+	return podGroup, podGroup.Validate()
+}
+
+func isValidDirection(direction string) bool {
+	return direction == DIRECTION_SEND || direction == DIRECTION_RECV || direction == DIRECTION_BOTH
+}
+
+func validateNeighbor(neighbor NeighborSpec) error {
+	if neighbor.Scope != NEIGHBOR_ALL && neighbor.Scope != NEIGHBOR_ANY {
+		return errors.New("unknown neighbor scope " + neighbor.Scope)
+	}
+	if neighbor.Direction != DIRECTION_SEND && neighbor.Direction != DIRECTION_RECV {
+		return errors.New("unknown neighbor direction " + neighbor.Direction)
+	}
+	if neighbor.Bandwidth < 0 {
+		return fmt.Errorf("negative neighbor bandwidth %f", neighbor.Bandwidth)
+	}
+	return nil
+}
+
+// Checks that the pod group is well formed: known kind, unique components, edges between known components and no dependency cycles
+func (podGroup *PodGroup) Validate() error {
+	if podGroup.Kind != PODGROUP_KIND {
+		return errors.New("unexpected kind " + podGroup.Kind)
+	}
+	if podGroup.Metadata.Name == "" {
+		return errors.New("pod group has no name")
+	}
+	if len(podGroup.Spec.Components) == 0 {
+		return errors.New("pod group " + podGroup.Metadata.Name + " has no components")
+	}
+	graph := make(map[string]map[string]bool, 0)
+	for _, comp := range podGroup.Spec.Components {
+		if comp.Name == "" || strings.Contains(comp.Name, ".") {
+			return errors.New("invalid component name \"" + comp.Name + "\"")
+		}
+		if _, exists := graph[comp.Name]; exists {
+			return errors.New("duplicate component " + comp.Name)
+		}
+		graph[comp.Name] = make(map[string]bool, 0)
+		for _, neighbor := range comp.Neighbors {
+			if err := validateNeighbor(neighbor); err != nil {
+				return fmt.Errorf("component %s: %v", comp.Name, err)
+			}
+		}
+	}
+	for i, edge := range podGroup.Spec.Edges {
+		if _, exists := graph[edge.From]; !exists {
+			return fmt.Errorf("edge %d: unknown component %s", i, edge.From)
+		}
+		if _, exists := graph[edge.To]; !exists {
+			return fmt.Errorf("edge %d: unknown component %s", i, edge.To)
+		}
+		if edge.From == edge.To {
+			return fmt.Errorf("edge %d: %s depends on itself", i, edge.From)
+		}
+		if graph[edge.From][edge.To] || graph[edge.To][edge.From] {
+			return fmt.Errorf("edge %d: duplicate edge between %s and %s", i, edge.From, edge.To)
+		}
+		if edge.Bandwidth < 0 || edge.MaxLatency < 0 {
+			return fmt.Errorf("edge %d: negative bandwidth or latency", i)
+		}
+		if edge.Direction != "" && !isValidDirection(edge.Direction) {
+			return fmt.Errorf("edge %d: unknown direction %s", i, edge.Direction)
+		}
+		graph[edge.From][edge.To] = true
+	}
+	if order := topoSort(graph); len(order) != len(graph) {
+		return errors.New("pod group " + podGroup.Metadata.Name + " has a dependency cycle")
+	}
+	return nil
+}
+
+func (podGroup *PodGroup) HasComponent(podName string) bool {
+	for _, comp := range podGroup.Spec.Components {
+		if comp.Name == podName {
+			return true
+		}
+	}
+	return false
+}
+
+// Requirements of one component of the pod group
+func (podGroup *PodGroup) GetRequirements(podName string) PodRequirements {
+	reqs := PodRequirements{Deps: make([]PodDependency, 0), Neighbors: make([]NeighborSpec, 0)}
+	for _, comp := range podGroup.Spec.Components {
+		if comp.Name == podName {
+			reqs.Neighbors = append(reqs.Neighbors, comp.Neighbors...)
+		}
+	}
+	for _, edge := range podGroup.Spec.Edges {
+		direction := edge.Direction
+		if direction == "" {
+			direction = DIRECTION_SEND
+		}
+		if edge.From == podName {
+			reqs.Deps = append(reqs.Deps, PodDependency{Pod: edge.To, Relationship: DEPENDS_ON, Bandwidth: edge.Bandwidth, MaxLatency: edge.MaxLatency, Direction: direction})
+		} else if edge.To == podName {
+			reqs.Deps = append(reqs.Deps, PodDependency{Pod: edge.From, Relationship: DEPENDED_BY, Bandwidth: edge.Bandwidth, MaxLatency: edge.MaxLatency, Direction: direction})
+		}
+	}
+	return reqs
+}
+
+// Requirements from the annotations, supported formats:
+// dependson.<pod>, dependson.<pod>.bw, dependson.<pod>.latency (same for dependedby) and neighbor.<all|any>.bw.<send|rcv>
+func ParsePodAnnotations(annotations map[string]string) (PodRequirements, []error) {
+	reqs := PodRequirements{Deps: make([]PodDependency, 0), Neighbors: make([]NeighborSpec, 0)}
+	errs := make([]error, 0)
+	deps := make(map[string]PodDependency, 0)
+	depKeys := make([]string, 0)
+	for k, v := range annotations {
+		vals := strings.Split(k, ".")
+		switch vals[0] {
+		case DEPENDS_ON, DEPENDED_BY:
+			if len(vals) < 2 || len(vals) > 3 || vals[1] == "" {
+				errs = append(errs, errors.New("incorrect annotation format for pod dependency "+k))
+				continue
+			}
+			depKey := vals[0] + "." + vals[1]
+			dep, exists := deps[depKey]
+			if !exists {
+				dep = PodDependency{Pod: vals[1], Relationship: vals[0], Direction: DIRECTION_SEND}
+				depKeys = append(depKeys, depKey)
+			}
+			if len(vals) == 3 {
+				qty, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("incorrect value %s for %s: %v", v, k, err))
+				} else if vals[2] == "bw" {
+					dep.Bandwidth = qty
+				} else if vals[2] == "latency" {
+					dep.MaxLatency = qty
+				} else {
+					errs = append(errs, errors.New("unknown quantity "+vals[2]+" in "+k))
+				}
+			}
+			deps[depKey] = dep
+		case NEIGHBOR:
+			if len(vals) != 4 || vals[2] != "bw" {
+				errs = append(errs, errors.New("incorrect annotation format for neighbor requirement "+k))
+				continue
+			}
+			direction := vals[3]
+			if direction == "rcv" {
+				direction = DIRECTION_RECV
+			}
+			bw, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("incorrect value %s for %s: %v", v, k, err))
+				continue
+			}
+			neighbor := NeighborSpec{Scope: vals[1], Direction: direction, Bandwidth: bw}
+			if err := validateNeighbor(neighbor); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", k, err))
+				continue
+			}
+			reqs.Neighbors = append(reqs.Neighbors, neighbor)
+		default:
+			if strings.HasPrefix(strings.ToLower(vals[0]), "depend") || strings.ToLower(vals[0]) == NEIGHBOR {
+				errs = append(errs, errors.New("unknown dependency annotation "+k))
+			}
+		}
+	}
+	for _, depKey := range depKeys {
+		reqs.Deps = append(reqs.Deps, deps[depKey])
+	}
+	return reqs, errs
+}
+
+// pod group of the namespace that already has one of the components of podGroup
+func (podGroups PodGroupSet) findComponent(namespace string, podGroup PodGroup) (string, string, bool) {
+	for name, other := range podGroups[namespace] {
+		for _, comp := range podGroup.Spec.Components {
+			if other.HasComponent(comp.Name) {
+				return name, comp.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Parses and validates the pod groups found in config maps, invalid ones are skipped. So that every pod
+// gets its requirements from one pod group, a pod group whose name or components are taken by an
+// earlier one of the same namespace is skipped too
+func GetPodGroupSet(configMaps []ConfigMap) (PodGroupSet, []error) {
+	podGroups := make(PodGroupSet, 0)
+	errs := make([]error, 0)
+	for _, cm := range configMaps {
+		content, exists := cm.Data[PODGROUP_KEY]
+		if !exists {
+			errs = append(errs, errors.New("config map "+cm.Metadata.Name+" has no "+PODGROUP_KEY))
+			continue
+		}
+		podGroup, err := ParsePodGroup([]byte(content))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config map %s/%s: %v", cm.Metadata.Namespace, cm.Metadata.Name, err))
+			continue
+		}
+		_, exists = podGroups[cm.Metadata.Namespace]
+		if !exists {
+			podGroups[cm.Metadata.Namespace] = make(map[string]PodGroup, 0)
+		}
+		// the first pod group with a name or component in the namespace is kept
+		if _, exists := podGroups[cm.Metadata.Namespace][podGroup.Metadata.Name]; exists {
+			errs = append(errs, fmt.Errorf("config map %s/%s: pod group %s is defined more than once", cm.Metadata.Namespace, cm.Metadata.Name, podGroup.Metadata.Name))
+			continue
+		}
+		if other, comp, overlaps := podGroups.findComponent(cm.Metadata.Namespace, podGroup); overlaps {
+			errs = append(errs, fmt.Errorf("config map %s/%s: component %s of pod group %s is already in pod group %s", cm.Metadata.Namespace, cm.Metadata.Name, comp, podGroup.Metadata.Name, other))
+			continue
+		}
+		podGroups[cm.Metadata.Namespace][podGroup.Metadata.Name] = podGroup
+	}
+	return podGroups, errs
+}
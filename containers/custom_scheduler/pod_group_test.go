@@ -0,0 +1,129 @@
+package main
+
+import (
+	"testing"
+)
+
+const testPodGroup = `{
+	"apiVersion": "epl.gatech.edu/v1",
+	"kind": "PodGroup",
+	"metadata": {"name": "camera", "namespace": "camera"},
+	"spec": {
+		"components": [{"name": "frontend", "neighbors": [{"scope": "all", "direction": "send", "bandwidth": 10}]}, {"name": "backend"}, {"name": "db"}],
+		"edges": [
+			{"from": "frontend", "to": "backend", "bandwidth": 1000, "maxLatency": 20},
+			{"from": "backend", "to": "db", "bandwidth": 500, "direction": "both"}
+		]
+	}
+}`
+
+func TestParsePodGroup(t *testing.T) {
+	podGroup, err := ParsePodGroup([]byte(testPodGroup))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	reqs := podGroup.GetRequirements("backend")
+	if len(reqs.Deps) != 2 {
+		t.Fatalf("Want 2 deps for backend, got %d", len(reqs.Deps))
+	}
+	dep, exists := reqs.GetDep("frontend", DEPENDED_BY)
+	if !exists || dep.RecvBw() != 1000 || dep.SendBw() != 0 || dep.MaxLatency != 20 {
+		t.Fatalf("Got unexpected dep %v", dep)
+	}
+	dep, exists = reqs.GetDep("db", DEPENDS_ON)
+	if !exists || dep.RecvBw() != 500 || dep.SendBw() != 500 {
+		t.Fatalf("Got unexpected dep %v", dep)
+	}
+	reqs = podGroup.GetRequirements("frontend")
+	if len(reqs.Neighbors) != 1 || reqs.Deps[0].SendBw() != 1000 {
+		t.Fatalf("Got unexpected requirements %v", reqs)
+	}
+}
+
+func TestInvalidPodGroup(t *testing.T) {
+	specs := map[string]string{
+		"unknown component": `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}], "edges": [{"from": "p0", "to": "p1"}]}}`,
+		"duplicate":         `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}, {"name": "p0"}]}}`,
+		"cycle":             `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}, {"name": "p1"}, {"name": "p2"}], "edges": [{"from": "p0", "to": "p1"}, {"from": "p1", "to": "p2"}, {"from": "p2", "to": "p0"}]}}`,
+		"direction":         `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}, {"name": "p1"}], "edges": [{"from": "p0", "to": "p1", "direction": "up"}]}}`,
+		"negative bw":       `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}, {"name": "p1"}], "edges": [{"from": "p0", "to": "p1", "bandwidth": -1}]}}`,
+		"neighbor":          `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0", "neighbors": [{"scope": "some", "direction": "send"}]}]}}`,
+		"kind":              `{"kind": "Deployment", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}]}}`,
+		"typo":              `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}]`,
+	}
+	for name, spec := range specs {
+		_, err := ParsePodGroup([]byte(spec))
+		if err == nil {
+			t.Fatalf("Want error for %s spec", name)
+		}
+	}
+}
+
+func TestParsePodAnnotations(t *testing.T) {
+	annotations := map[string]string{"dependson.db": "yes", "dependson.db.bw": "100", "dependson.db.latency": "5",
+		"dependedby.frontend.bw": "200", "neighbor.any.bw.rcv": "10", "preferredNode": "n1"}
+	reqs, errs := ParsePodAnnotations(annotations)
+	if len(errs) != 0 {
+		t.Fatalf("Got errors %v", errs)
+	}
+	if len(reqs.Deps) != 2 || len(reqs.Neighbors) != 1 || reqs.Neighbors[0].Direction != DIRECTION_RECV {
+		t.Fatalf("Got unexpected requirements %v", reqs)
+	}
+	dep, _ := reqs.GetDep("db", DEPENDS_ON)
+	if dep.SendBw() != 100 || dep.MaxLatency != 5 {
+		t.Fatalf("Got unexpected dep %v", dep)
+	}
+	dep, _ = reqs.GetDep("frontend", DEPENDED_BY)
+	if dep.RecvBw() != 200 {
+		t.Fatalf("Got unexpected dep %v", dep)
+	}
+
+	annotations = map[string]string{"dependson.db.bw": "lots", "dependson.db.bandwidth": "1", "dependsOn.db.bw": "1", "neighbor.all.bw": "1"}
+	_, errs = ParsePodAnnotations(annotations)
+	if len(errs) != len(annotations) {
+		t.Fatalf("Want %d errors, got %d", len(annotations), len(errs))
+	}
+}
+
+func TestPodGroupOverridesAnnotations(t *testing.T) {
+	pp := NewPodProcessor(CLIENT)
+	podGroup, _ := ParsePodGroup([]byte(testPodGroup))
+	pp.podGroups = PodGroupSet{"camera": {"camera": podGroup}}
+
+	pod := Pod{}
+	pod.Metadata.Name = "backend-7f9c-x2k4"
+	pod.Metadata.Namespace = "camera"
+	pod.Metadata.Annotations = map[string]string{"dependson.cache.bw": "100"}
+	reqs := pp.GetPodRequirements(pod)
+	if _, exists := reqs.GetDep("cache", DEPENDS_ON); exists || len(reqs.Deps) != 2 {
+		t.Fatalf("Want requirements from the pod group, got %v", reqs)
+	}
+	pod.Metadata.Namespace = "other"
+	reqs = pp.GetPodRequirements(pod)
+	if _, exists := reqs.GetDep("cache", DEPENDS_ON); !exists {
+		t.Fatalf("Want requirements from the annotations, got %v", reqs)
+	}
+}
+
+func TestPodGroupSetConflicts(t *testing.T) {
+	getConfigMap := func(name string, spec string) ConfigMap {
+		cm := ConfigMap{Data: map[string]string{PODGROUP_KEY: spec}}
+		cm.Metadata.Name = name
+		cm.Metadata.Namespace = "app"
+		return cm
+	}
+	configMaps := []ConfigMap{
+		getConfigMap("a", `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p0"}, {"name": "p1"}]}}`),
+		getConfigMap("a-copy", `{"kind": "PodGroup", "metadata": {"name": "a"}, "spec": {"components": [{"name": "p2"}]}}`),
+		getConfigMap("b", `{"kind": "PodGroup", "metadata": {"name": "b"}, "spec": {"components": [{"name": "p1"}, {"name": "p3"}]}}`),
+		getConfigMap("c", `{"kind": "PodGroup", "metadata": {"name": "c"}, "spec": {"components": [{"name": "p4"}]}}`),
+	}
+	podGroups, errs := GetPodGroupSet(configMaps)
+	if len(errs) != 2 {
+		t.Fatalf("Want errors for the duplicate name and the shared component, got %v", errs)
+	}
+	a, c := podGroups["app"]["a"], podGroups["app"]["c"]
+	if len(podGroups["app"]) != 2 || !a.HasComponent("p0") || !c.HasComponent("p4") {
+		t.Fatalf("Want the first pod groups a and c kept, got %v", podGroups["app"])
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 //var processorLock = &sync.Mutex{}
@@ -12,12 +14,14 @@ type PodProcessor struct {
 	unscheduledPods map[string]Pod // pod name to pod mapping
 	podLock         *sync.Mutex
 	client          KubeClientIntf
+	podGroups       PodGroupSet
+	arrivals        map[string]time.Time // pod name -> time the pod was added
 }
 
 func NewPodProcessor(kcl KubeClientIntf) *PodProcessor {
 	mu := &sync.Mutex{}
 	unscheduledPods := make(map[string]Pod, 0)
-	pp := &PodProcessor{unscheduledPods: unscheduledPods, podLock: mu, client: kcl}
+	pp := &PodProcessor{unscheduledPods: unscheduledPods, podLock: mu, client: kcl, podGroups: make(PodGroupSet, 0), arrivals: make(map[string]time.Time, 0)}
 	logger("Created pod processor")
 	return pp
 }
@@ -33,11 +37,76 @@ func getPodName(podId string) string {
 	}
 	return pName
 }
+
+// Reload the pod groups from the config maps, invalid pod groups are logged and ignored
+func (pp *PodProcessor) RefreshPodGroups() {
+	configMaps, err := pp.client.GetConfigMaps(PODGROUP_LABEL)
+	if err != nil {
+		logger(fmt.Sprintf("Got error: %v", err))
+		return
+	}
+	podGroups, errs := GetPodGroupSet(configMaps)
+	for _, err := range errs {
+		logger(fmt.Sprintf("ERROR: invalid pod group: %v", err))
+	}
+	pp.podLock.Lock()
+	pp.podGroups = podGroups
+	pp.podLock.Unlock()
+}
+
+// Network requirements of the pod, from its pod group if there is one, otherwise from the annotations.
+// GetPodGroupSet keeps a component in at most one pod group of a namespace
+func (pp *PodProcessor) GetPodRequirements(pod Pod) PodRequirements {
+	podName := getPodName(pod.Metadata.Name)
+	pp.podLock.Lock()
+	podGroups := pp.podGroups[pod.Metadata.Namespace]
+	pp.podLock.Unlock()
+	for _, podGroup := range podGroups {
+		if podGroup.HasComponent(podName) {
+			return podGroup.GetRequirements(podName)
+		}
+	}
+	reqs, errs := ParsePodAnnotations(pod.Metadata.Annotations)
+	for _, err := range errs {
+		logger(fmt.Sprintf("ERROR: pod %s: %v", pod.Metadata.Name, err))
+	}
+	return reqs
+}
+
+// Requirements of all the pods, keyed by pod name without the replica set/pod suffix
+func (pp *PodProcessor) GetPodRequirementsSet(pods map[string]Pod) map[string]PodRequirements {
+	podReqs := make(map[string]PodRequirements, 0)
+	for _, pod := range pods {
+		podReqs[getPodName(pod.Metadata.Name)] = pp.GetPodRequirements(pod)
+	}
+	return podReqs
+}
+
+// copy of the queued pods, so they can be read while pods are added and scheduled
+func (pp *PodProcessor) copyUnscheduledPods() map[string]Pod {
+	pp.podLock.Lock()
+	defer pp.podLock.Unlock()
+	podList := make(map[string]Pod, len(pp.unscheduledPods))
+	for name, pod := range pp.unscheduledPods {
+		podList[name] = pod
+	}
+	return podList
+}
+
+func (pp *PodProcessor) GetQueueDepth() int {
+	pp.podLock.Lock()
+	defer pp.podLock.Unlock()
+	return len(pp.unscheduledPods)
+}
+
 func (pp *PodProcessor) AddPod(pod Pod) {
 	pp.podLock.Lock()
 	pName := pod.Metadata.Name //getPodName(pod.Metadata.Name)
 
 	pp.unscheduledPods[pName] = pod
+	if _, exists := pp.arrivals[pName]; !exists {
+		pp.arrivals[pName] = time.Now()
+	}
 
 	pp.podLock.Unlock()
 	logger("Added pod " + pName + " to unscheduled pods")
@@ -67,37 +136,25 @@ func (pp *PodProcessor) IsPodInList(podList []*PodList, podName string) bool {
 func (pp *PodProcessor) AreAllRelatedPodsPresent(pod Pod, relationship string) bool {
 	// Dependson: for a pod, check if all  the pods that THIS pod depends on are present
 	// Dependedby: for a pod, check if all pods that depend on THIS pod are present
-	pp.podLock.Lock()
-	podList := pp.unscheduledPods
-	pp.podLock.Unlock()
-	annotations := pod.Metadata.Annotations
-	// format: dependedby.PODNAME
+	podList := pp.copyUnscheduledPods()
+	reqs := pp.GetPodRequirements(pod)
 	allPods, err := pp.client.GetPods()
 	if err != nil {
 		logger(fmt.Sprintf("Got error: %v", err))
 	}
 	//logger(fmt.Sprintf("find %s for pod %s", relationship, pod.Metadata.Name))
-	for k, _ := range annotations {
-		if strings.Contains(k, relationship) {
-			vals := strings.Split(k, ".")
-			if len(vals) < 2 {
-				logger(fmt.Sprintf("ERROR: Incorrect annotation format for pod dependency %s", k))
-			}
-			if relationship != vals[0] {
-				continue
-			}
-			podName := vals[1]
-			pod := getPodWithName(podName, podList)
-			isPodPresent := true
-			//logger("pd meta name is " + getPodName(pod.Metadata.Name) + " pd name is" + podName)
-			if getPodName(pod.Metadata.Name) != podName {
-				isPodPresent = false
-			}
-			podAlreadyScheduled := pp.IsPodInList(allPods, podName)
-			if !isPodPresent && !podAlreadyScheduled {
-				logger("Pod " + podName + " not found")
-				return false
-			}
+	for _, dep := range reqs.GetDeps(relationship) {
+		podName := dep.Pod
+		pod := getPodWithName(podName, podList)
+		isPodPresent := true
+		//logger("pd meta name is " + getPodName(pod.Metadata.Name) + " pd name is" + podName)
+		if getPodName(pod.Metadata.Name) != podName {
+			isPodPresent = false
+		}
+		podAlreadyScheduled := pp.IsPodInList(allPods, podName)
+		if !isPodPresent && !podAlreadyScheduled {
+			logger("Pod " + podName + " not found")
+			return false
 		}
 	}
 	//logger(fmt.Sprintf("POd %s has all %s ", getPodName(pod.Metadata.Name), relationship))
@@ -105,10 +162,10 @@ func (pp *PodProcessor) AreAllRelatedPodsPresent(pod Pod, relationship string) b
 }
 
 func (pp *PodProcessor) AreAllDependersPresent(pod Pod) bool {
-	return pp.AreAllRelatedPodsPresent(pod, "dependedby")
+	return pp.AreAllRelatedPodsPresent(pod, DEPENDED_BY)
 }
 func (pp *PodProcessor) AreAllDependeesPresent(pod Pod) bool {
-	return pp.AreAllRelatedPodsPresent(pod, "dependson")
+	return pp.AreAllRelatedPodsPresent(pod, DEPENDS_ON)
 }
 
 func (pp *PodProcessor) IsPodSpecComplete(pod Pod) bool {
@@ -120,17 +177,8 @@ func (pp *PodProcessor) GetPodDependencyGraph(podList []Pod) map[string]map[stri
 	podGraph := make(map[string]map[string]bool, 0)
 
 	for _, pod := range podList {
-		annotations := pod.Metadata.Annotations
-		for k, _ := range annotations {
-			vals := strings.Split(k, ".")
-			if len(vals) < 2 {
-				logger(fmt.Sprintf("ERROR: Incorrect annotation format for pod dependency %s", k))
-				continue
-			}
-			relationship, podName := vals[0], vals[1]
-			if relationship != "dependson" {
-				continue
-			}
+		for _, dep := range pp.GetPodRequirements(pod).GetDeps(DEPENDS_ON) {
+			podName := dep.Pod
 			_, exists := podGraph[getPodName(pod.Metadata.Name)]
 			if !exists {
 				podGraph[getPodName(pod.Metadata.Name)] = make(map[string]bool, 0)
@@ -146,9 +194,7 @@ func (pp *PodProcessor) GetPodDependencyGraph(podList []Pod) map[string]map[stri
 // Build an undirected graph of pod dependencies from all unscheduled pods
 // A pod is added to the graph iff all its dependencies are met, and all the pods that are dependent on this pod are also in the list of unscheduled pods
 func (pp *PodProcessor) GetPodGraph() (map[string]map[string]bool, []string) {
-	pp.podLock.Lock()
-	podList := pp.unscheduledPods
-	pp.podLock.Unlock()
+	podList := pp.copyUnscheduledPods()
 	podGraph := make(map[string]map[string]bool, 0)
 
 	skippedPods := make([]string, 0)
@@ -159,31 +205,17 @@ func (pp *PodProcessor) GetPodGraph() (map[string]map[string]bool, []string) {
 			skippedPods = append(skippedPods, pod.Metadata.Name)
 		}
 
-		annotations := pod.Metadata.Annotations
-		annCt := 0
-		for k, _ := range annotations {
-			if strings.Contains(k, "dependson") || strings.Contains(k, "dependedby") {
-				annCt += 1
-			}
-		}
-		if len(annotations) == 0 || annCt == 0 {
+		reqs := pp.GetPodRequirements(pod)
+		if len(reqs.Deps) == 0 {
 			_, exists := podGraph[getPodName(pod.Metadata.Name)]
 			if !exists {
 				podGraph[getPodName(pod.Metadata.Name)] = make(map[string]bool, 0)
 			}
 			continue
 		}
-		for k, _ := range annotations {
-			vals := strings.Split(k, ".")
-			if len(vals) < 2 {
-				logger(fmt.Sprintf("ERROR: Incorrect annotation format for pod dependency %s", k))
-				continue
-			}
-			rel, podName := vals[0], vals[1]
-			//logger(fmt.Sprintf("pod = %s rel = %s other pod = %s", pod.Metadata.Name, rel, podName))
-			if "dependson" != rel && "dependedby" != rel {
-				continue
-			}
+		for _, dep := range reqs.Deps {
+			podName := dep.Pod
+			//logger(fmt.Sprintf("pod = %s rel = %s other pod = %s", pod.Metadata.Name, dep.Relationship, podName))
 			_, exists := podGraph[getPodName(pod.Metadata.Name)]
 			if !exists {
 				podGraph[getPodName(pod.Metadata.Name)] = make(map[string]bool, 0)
@@ -243,24 +275,21 @@ func (pp *PodProcessor) GetPodGroup(podName string, podGraph map[string]map[stri
 		}
 	}
 	//podList := make([]string, 0)
+	podList := pp.copyUnscheduledPods()
 	podSubgraph := make(map[string]map[string]bool, 0)
 	for pod, v := range visited {
 		if v == true {
 			//podList = append(podList, pod)
 			podSubgraph[pod] = make(map[string]bool, 0)
 			for neighbor, _ := range podGraph[pod] {
-				podInfo := getPodWithName(pod, pp.unscheduledPods)
+				podInfo := getPodWithName(pod, podList)
 				//podInfo, _ := pp.unscheduledPods[pod]
 				if getPodName(podInfo.Metadata.Name) != pod {
 					continue
 				}
-				for ann, _ := range podInfo.Metadata.Annotations {
-					vals := strings.Split(ann, ".")
-					if vals[0] == "dependson" && neighbor == vals[1] {
-						podSubgraph[pod][neighbor] = true
-						//logger(fmt.Sprintf("added %s -> %s", pod, neighbor))
-						break
-					}
+				if _, exists := pp.GetPodRequirements(podInfo).GetDep(neighbor, DEPENDS_ON); exists {
+					podSubgraph[pod][neighbor] = true
+					//logger(fmt.Sprintf("added %s -> %s", pod, neighbor))
 				}
 			}
 
@@ -314,10 +343,47 @@ func (pp *PodProcessor) MarkScheduled(pods []Pod) {
 		if exists {
 			delete(pp.unscheduledPods, podName)
 		}
+		delete(pp.arrivals, podName)
 	}
 	pp.podLock.Unlock()
 }
 
+// Drop the pod groups that are still missing pods after waiting for longer than timeout, returns the dropped pods
+func (pp *PodProcessor) RejectIncompleteGroups(timeout time.Duration) []Pod {
+	rejected := make([]Pod, 0)
+	podGraph, skippedPods := pp.GetPodGraph()
+	expired := make([]string, 0)
+	pp.podLock.Lock()
+	for _, podName := range skippedPods {
+		arrival, exists := pp.arrivals[podName]
+		if exists && time.Since(arrival) > timeout {
+			expired = append(expired, getPodName(podName))
+		}
+	}
+	pp.podLock.Unlock()
+
+	podList := pp.copyUnscheduledPods()
+	for _, podName := range expired {
+		if _, exists := podGraph[podName]; !exists {
+			// already dropped with an earlier group
+			continue
+		}
+		podGroup := pp.GetPodGroup(podName, podGraph)
+		members := make([]Pod, 0)
+		for p, _ := range podGroup {
+			delete(podGraph, p)
+			pod := getPodWithName(p, podList)
+			if getPodName(pod.Metadata.Name) == p {
+				members = append(members, pod)
+			}
+		}
+		logger(fmt.Sprintf("pod group of %s is incomplete after %v, dropping %d pods", podName, timeout, len(members)))
+		pp.MarkScheduled(members)
+		rejected = append(rejected, members...)
+	}
+	return rejected
+}
+
 func getPodWithName(podName string, pods map[string]Pod) Pod {
 	var pod Pod
 	for p, podInfo := range pods {
@@ -330,20 +396,40 @@ func getPodWithName(podName string, pods map[string]Pod) Pod {
 
 }
 
+// identifies a pod group by its sorted pod names
+func podGroupKey(podGroup map[string]map[string]bool) string {
+	podNames := make([]string, 0)
+	for podName, _ := range podGroup {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+	return strings.Join(podNames, ",")
+}
+
 // return first pod group that is unscheduled
 func (pp *PodProcessor) GetUnscheduledPods() (map[string]Pod, map[string]map[string]bool) {
-	pp.podLock.Lock()
-	podList := pp.unscheduledPods
-	pp.podLock.Unlock()
+	return pp.GetUnscheduledPodsSkipping(nil)
+}
+
+// return first pod group that is unscheduled and whose key is not in skip, so a group that can not
+// be placed does not keep the groups after it from being scheduled
+func (pp *PodProcessor) GetUnscheduledPodsSkipping(skip map[string]bool) (map[string]Pod, map[string]map[string]bool) {
+	podList := pp.copyUnscheduledPods()
 	logger(fmt.Sprintf("Pod list has %d pods", len(podList)))
 	unscheduled := make(map[string]Pod, 0)
 	podGroup := make(map[string]map[string]bool, 0)
+	pp.RefreshPodGroups()
 	podGraph, skippedPods := pp.GetPodGraph()
 	logger(fmt.Sprintf("Pod graph has %d pods", len(podGraph)))
 	if len(podGraph) == 0 {
 		return unscheduled, podGroup
 	}
-	podGroups := pp.GetPodGroups(podGraph, skippedPods)
+	podGroups := make([]map[string]map[string]bool, 0)
+	for _, group := range pp.GetPodGroups(podGraph, skippedPods) {
+		if !skip[podGroupKey(group)] {
+			podGroups = append(podGroups, group)
+		}
+	}
 	if len(podGroups) == 0 {
 		return unscheduled, podGroup
 
@@ -367,5 +453,5 @@ func (pp *PodProcessor) GetUnscheduledPods() (map[string]Pod, map[string]map[str
 	for _, pod := range unknownPods {
 		delete(podGroups[0], pod)
 	}
-	return pp.unscheduledPods, podGroups[0]
+	return podList, podGroups[0]
 }
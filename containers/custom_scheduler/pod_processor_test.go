@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
 	"testing"
 )
 
@@ -35,6 +36,26 @@ func (cl DummyClient) Bind(pod Pod, node Node) error {
 	return nil
 }
 
+func (cl DummyClient) GetNamespaces() (*NamespaceList, error) {
+	return nil, nil
+}
+
+func (cl DummyClient) GetConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	return nil, nil
+}
+
+func (cl DummyClient) DeletePod(pod Pod) error {
+	return nil
+}
+
+func (cl DummyClient) PostEvent(event bwcontroller.Event, ns string) error {
+	return nil
+}
+
+func (cl DummyClient) PatchEvent(event bwcontroller.Event, ns string) error {
+	return nil
+}
+
 var CLIENT DummyClient
 
 func getPodSimpleTopo() map[string]Pod {
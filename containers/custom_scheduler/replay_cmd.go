@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// custom_scheduler replay [-strategy name] [-v] snapshot.json
+// places the recorded pod group again and prints the recorded and the new placement
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	strategy := flags.String("strategy", "", "Placement strategy, defaults to the recorded one")
+	verbose := flags.Bool("v", false, "Print the scheduler log to stderr")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: custom_scheduler replay [-strategy name] [-v] snapshot.json")
+		return 2
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	snapshot, err := LoadSnapshot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	podAssignment, err := ReplaySnapshot(snapshot, *strategy)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	pods := make([]string, 0)
+	for pod, _ := range snapshot.Pending {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	fmt.Printf("snapshot of %v, recorded with strategy %s\n", snapshot.Time, snapshot.Strategy)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tRECORDED\tREPLAYED")
+	for _, pod := range pods {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pod, snapshot.Assignment[pod], podAssignment[pod])
+	}
+	w.Flush()
+	return 0
+}
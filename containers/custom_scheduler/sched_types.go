@@ -3,6 +3,8 @@ package main
 import (
 	"sort"
 	"strings"
+
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
 )
 
 type Resource struct {
@@ -82,5 +84,13 @@ type KubeClientIntf interface {
 	GetNodeMetrics() (*NodeMetricsList, error)
 	GetUnscheduledPods() ([]*Pod, error)
 	GetPods() ([]*PodList, error)
+	GetConfigMaps(labelSelector string) ([]ConfigMap, error)
 	Bind(pod Pod, node Node) error
+	DeletePod(pod Pod) error
+	PostEvent(event bwcontroller.Event, ns string) error
+	PatchEvent(event bwcontroller.Event, ns string) error
+}
+
+type PromClientIntf interface {
+	GetPodMetrics() (bwcontroller.PodSet, bwcontroller.PodDeps)
 }
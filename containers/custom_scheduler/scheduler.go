@@ -14,13 +14,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
 	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
 	"k8s.io/apimachinery/pkg/api/resource"
 	//"sort"
-	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -28,33 +27,50 @@ import (
 type DeploymentMap	map[string]string // pod -> node 
 type DagScheduler struct {
 	client            KubeClientIntf
-	netmonClient      *netmon_client.NetmonClient
+	netmonClient      netmon_client.NetmonClientIntf
 	podProcessor      *PodProcessor
 	processorLock     *sync.Mutex
-	promClient        *bwcontroller.PromClient
+	promClient        PromClientIntf
 	ipMap             map[string]string
 	tolerance         float64
 	deployedApps 	  map[string]DeploymentMap	// ns -> deployment
+	strategy          PlacementStrategy
+	gangTimeout       time.Duration // how long a partially arrived pod group may wait
+	netmonTimeout     time.Duration // how long fetching the netmon stats may take, 0 for no limit
+	events            *bwcontroller.EventRecorder
+	recorder          *SnapshotRecorder // writes a snapshot of every placement when set
+	ledger            bwcontroller.LedgerStore // bw reserved for the pods placed, shared with the controller, nil to not keep it
 }
 
 func (sched *DagScheduler) ReconcileUnscheduledPods(interval int, done chan struct{}, wg *sync.WaitGroup) {
 	for {
 		select {
 		case <-time.After(time.Duration(interval) * time.Second):
+			for _, pod := range sched.podProcessor.RejectIncompleteGroups(sched.gangTimeout) {
+				podsFailed.WithLabelValues(metricReason("pod group timeout")).Inc()
+				sched.emitPodEvent(pod, "FailedScheduling",
+					fmt.Sprintf("pod group is still missing pods after %v, not scheduling it", sched.gangTimeout), bwcontroller.EVENT_WARNING)
+			}
+			// groups that could not be placed are skipped until the next round
+			failed := make(map[string]bool, 0)
 			for {
 				// schedule the pending pods
-				pods, podGraph := sched.podProcessor.GetUnscheduledPods()
-				if len(pods) == 0 {
+				pods, podGraph := sched.podProcessor.GetUnscheduledPodsSkipping(failed)
+				if len(pods) == 0 || len(podGraph) == 0 {
 					logger("no pods to schedule")
 					break
 				}
+				groupKey := podGroupKey(podGraph)
 				assignment, pods, nodes := sched.SchedulePods(pods, podGraph)
-				if len(pods) == 0 {
-					logger("Could not schedule any NEW pod")
+				if len(assignment) == 0 {
+					logger("Could not schedule any NEW pod of group " + groupKey)
+					failed[groupKey] = true
+					continue
 				}
 				err := sched.AssignPods(assignment, pods, nodes)
 				if err != nil {
 					logger(err)
+					failed[groupKey] = true
 				}
 			}
 		case <-done:
@@ -94,12 +110,11 @@ func (sched *DagScheduler) MonitorUnscheduledPods(done chan struct{}, wg *sync.W
 
 func (sched *DagScheduler) SchedulePod(pod Pod, node Node) error {
 	err := sched.client.Bind(pod, node)
-	pods := []Pod{pod}
-	sched.podProcessor.MarkScheduled(pods)
 	if err != nil {
 		logger(err)
 		return err
 	}
+	sched.podProcessor.MarkScheduled([]Pod{pod})
 	return nil
 }
 
@@ -198,69 +213,65 @@ func (sched *DagScheduler) EvalPredicate(pod Pod, node Node, availableBw netmon_
 	}
 	sndreq := 0.0
 	rcvreq := 0.0
-	annotations := pod.Metadata.Annotations
-	for k, v := range annotations {
-		logger("ann = " + k)
-		vals := strings.Split(k, ".")
-		if "neighbor" == vals[0] && "bw" == vals[2] {
-			if "all" == vals[1] {
-				nodeCt := 0
-				if vals[3] == "send" {
-					req, _ := strconv.Atoi(v)
-					// send bw check for this node to all neighbors
-					for _, bw := range nodeBws {
-						if bw.Bandwidth < float64(sndreq) {
+	for _, neighbor := range sched.podProcessor.GetPodRequirements(pod).Neighbors {
+		logger(fmt.Sprintf("neighbor %s %s bw %f", neighbor.Scope, neighbor.Direction, neighbor.Bandwidth))
+		if NEIGHBOR_ALL == neighbor.Scope {
+			nodeCt := 0
+			if neighbor.Direction == DIRECTION_SEND {
+				req := neighbor.Bandwidth
+				// send bw check for this node to all neighbors
+				for _, bw := range nodeBws {
+					if bw.Bandwidth < float64(sndreq) {
+						return false, 0.0, 0.0
+					}
+					nodeCt += 1
+				}
+				logger(fmt.Sprintf("Node %s passed send bw check", nodeIp))
+				sndreq = req * float64(nodeCt)
+			} else if neighbor.Direction == DIRECTION_RECV {
+				req := neighbor.Bandwidth
+				// recv bw check for all neighbors to this node
+				for _, bws := range availableBw {
+					nodeBw, exists := bws[nodeIp]
+					if exists {
+						if nodeBw.Bandwidth < float64(rcvreq) {
 							return false, 0.0, 0.0
 						}
 						nodeCt += 1
 					}
-					logger(fmt.Sprintf("Node %s passed send bw check", nodeIp))
-					sndreq = float64(req) * float64(nodeCt)
-				} else if vals[3] == "rcv" {
-					req, _ := strconv.Atoi(v)
-					// recv bw check for all neighbors to this node
-					for _, bws := range availableBw {
-						nodeBw, exists := bws[nodeIp]
-						if exists {
-							if nodeBw.Bandwidth < float64(rcvreq) {
-								return false, 0.0, 0.0
-							}
-							nodeCt += 1
-						}
-					}
-					logger(fmt.Sprintf("Node %s passed recv bw check", nodeIp))
-					rcvreq = float64(req) * float64(nodeCt)
 				}
-				//return true, float64(sndreq) * float64(nodeCt), float64(rcvreq)* float64(nodeCt)
-			} else if "any" == vals[1] {
-				if vals[3] == "send" {
-					found := false
-					sndreq, _ := strconv.Atoi(v)
-					for _, bw := range nodeBws {
-						if bw.Bandwidth >= float64(sndreq) {
-							found = true
-							break
-						}
-					}
-					if found == false {
-						return false, 0.0, 0.0
-					}
-				} else if vals[3] == "rcv" {
-					found := false
-					rcvreq, _ := strconv.Atoi(v)
-					for _, bws := range availableBw {
-						bw, exists := bws[nodeIp]
-						if exists && bw.Bandwidth > float64(rcvreq) {
-							found = true
-							break
-						}
+				logger(fmt.Sprintf("Node %s passed recv bw check", nodeIp))
+				rcvreq = req * float64(nodeCt)
+			}
+			//return true, float64(sndreq) * float64(nodeCt), float64(rcvreq)* float64(nodeCt)
+		} else if NEIGHBOR_ANY == neighbor.Scope {
+			if neighbor.Direction == DIRECTION_SEND {
+				found := false
+				sndreq := neighbor.Bandwidth
+				for _, bw := range nodeBws {
+					if bw.Bandwidth >= float64(sndreq) {
+						found = true
+						break
 					}
-					if found == false {
-						return false, 0.0, 0.0
+				}
+				if found == false {
+					return false, 0.0, 0.0
+				}
+			} else if neighbor.Direction == DIRECTION_RECV {
+				found := false
+				rcvreq := neighbor.Bandwidth
+				for _, bws := range availableBw {
+					bw, exists := bws[nodeIp]
+					if exists && bw.Bandwidth > float64(rcvreq) {
+						found = true
+						break
 					}
 				}
-				//return true, float64(sndreq), float64(rcvreq)
+				if found == false {
+					return false, 0.0, 0.0
+				}
 			}
+			//return true, float64(sndreq), float64(rcvreq)
 		}
 	}
 	return true, float64(sndreq), float64(rcvreq)
@@ -269,19 +280,32 @@ func (sched *DagScheduler) EvalPredicate(pod Pod, node Node, availableBw netmon_
 func (sched *DagScheduler) Fit(pod Pod, node Node,
 	nodeResource Resource,
 	availableBw netmon_client.PathSet) bool {
+	return sched.FitReason(pod, node, nodeResource, availableBw) == ""
+}
+
+// same as Fit, returns why the pod does not fit on the node or "" if it does
+func (sched *DagScheduler) FitReason(pod Pod, node Node,
+	nodeResource Resource,
+	availableBw netmon_client.PathSet) string {
+	return sched.fitVerdict(pod, node, nodeResource, availableBw, netmon_client.NewLinkModel(availableBw, nil)).Reason
+}
+
+// checks the pod against the cpu, memory and bandwidth left on the node, the verdict has the headroom that was compared.
+// The bandwidth the node can send and receive is what is left on the links its paths start or end with, so paths
+// that share their first hop are not counted twice
+func (sched *DagScheduler) fitVerdict(pod Pod, node Node,
+	nodeResource Resource,
+	availableBw netmon_client.PathSet,
+	links *netmon_client.LinkModel) NodeVerdict {
 	podResource := sched.GetPodResource(pod)
+	verdict := NodeVerdict{Node: node.Metadata.Name,
+		CpuAvailable: nodeResource.cpu, CpuNeeded: podResource.cpu,
+		MemoryAvailable: nodeResource.memory, MemoryNeeded: podResource.memory}
 	podBwSnd := 0.0
 	podBwRcv := 0.0
-	for k, v := range pod.Metadata.Annotations {
-		vals := strings.Split(k, ".")
-		if ("dependedby" == vals[0] || "dependson" == vals[0]) && "bw" == vals[2] {
-			bw, _ := strconv.Atoi(v)
-			if vals[0] == "dependedby" {
-				podBwRcv += float64(bw)
-			} else {
-				podBwSnd += float64(bw)
-			}
-		}
+	for _, dep := range sched.podProcessor.GetPodRequirements(pod).Deps {
+		podBwSnd += dep.SendBw()
+		podBwRcv += dep.RecvBw()
 	}
 
 	nodeBwSnd := 0.0
@@ -291,55 +315,72 @@ func (sched *DagScheduler) Fit(pod Pod, node Node,
 		nodeIp = node.Metadata.Annotations["flannel.alpha.coreos.com/public-ip"]
 	}
 	logger(fmt.Sprintf("node name %s ip %s", node.Metadata.Name, nodeIp))
-	nodeBws, exists := availableBw[nodeIp]
+	_, exists := availableBw[nodeIp]
 	if !exists {
 		logger("Error: No bw info for node " + node.Metadata.Name)
-		return false
-	}
-	for _, bw := range nodeBws {
-		nodeBwSnd += bw.Bandwidth
-	}
-	for _, bws := range availableBw {
-		bw, exists := bws[nodeIp]
-		if exists {
-			nodeBwRcv += bw.Bandwidth
-		}
+		verdict.Reason = FIT_NO_NETMON
+		return verdict
 	}
+	nodeBwSnd = links.SendBw(nodeIp)
+	nodeBwRcv = links.RecvBw(nodeIp)
+	verdict.SendBwAvailable, verdict.RecvBwAvailable = nodeBwSnd, nodeBwRcv
+	verdict.SendBwNeeded, verdict.RecvBwNeeded = podBwSnd, podBwRcv
 	exists, podBwSndAdd, podBwRcvAdd := sched.EvalPredicate(pod, node, availableBw)
 	if !exists {
 		logger("node " + node.Metadata.Name + " does not have sufficient bw for predicate")
-		return false
+		verdict.Reason = FIT_PREDICATE
+		return verdict
 	}
 	podBwSnd += podBwSndAdd
 	podBwRcv += podBwRcvAdd
+	verdict.SendBwNeeded, verdict.RecvBwNeeded = podBwSnd, podBwRcv
 
 	logger(fmt.Sprintf("Pod %s cpu = %d memory = %d pod send bw = %f pod recv bw = %f  Node %s cpu = %d memory = %d send bw = %f rcv bw = %f", pod.Metadata.Name, podResource.cpu, podResource.memory, podBwSnd, podBwRcv, node.Metadata.Name, nodeResource.cpu, nodeResource.memory, nodeBwSnd, nodeBwRcv))
 	if podResource.cpu > nodeResource.cpu {
 		logger(fmt.Sprintf("pod %s node %s insufficient CPU", pod.Metadata.Name, node.Metadata.Name))
-		return false
+		verdict.Reason = FIT_INSUFFICIENT_CPU
+		return verdict
 	}
 	if podResource.memory > nodeResource.memory {
 		logger(fmt.Sprintf("pod %s node %s insufficient memory", pod.Metadata.Name, node.Metadata.Name))
-		return false
+		verdict.Reason = FIT_INSUFFICIENT_MEMORY
+		return verdict
+	}
+	if nodeBwSnd < podBwSnd*(1-sched.tolerance) {
+		logger(fmt.Sprintf("pod %s node %s insufficient send bw", pod.Metadata.Name, node.Metadata.Name))
+		verdict.Reason = FIT_INSUFFICIENT_SEND_BW
+		return verdict
 	}
-	if nodeBwSnd < podBwSnd*(1-sched.tolerance) ||  nodeBwRcv < podBwRcv*(1-sched.tolerance) {
-		logger(fmt.Sprintf("pod %s node %s insufficient bw", pod.Metadata.Name, node.Metadata.Name))
-		return false
+	if nodeBwRcv < podBwRcv*(1-sched.tolerance) {
+		logger(fmt.Sprintf("pod %s node %s insufficient recv bw", pod.Metadata.Name, node.Metadata.Name))
+		verdict.Reason = FIT_INSUFFICIENT_RECV_BW
+		return verdict
 	}
-	return true
+	verdict.Fits = true
+	return verdict
+}
 
+// pods with bandwidth or latency dependencies are not placed on a node whose netmon could not be reached,
+// returns FIT_STALE_NETMON for those or "" if the pod may go there
+func (sched *DagScheduler) checkStale(pod Pod, node Node, state *ClusterState) string {
+	nodeErr, stale := state.staleNodes[getNodeIp(node)]
+	if !stale {
+		return ""
+	}
+	for _, dep := range sched.podProcessor.GetPodRequirements(pod).Deps {
+		if dep.SendBw() > 0 || dep.RecvBw() > 0 || dep.MaxLatency > 0 {
+			logger(fmt.Sprintf("pod %s needs bandwidth, %v", pod.Metadata.Name, nodeErr))
+			return FIT_STALE_NETMON
+		}
+	}
+	return ""
 }
 
 func (sched *DagScheduler) GetNodesForDeps(currentPod Pod, assignments map[string]string, nodeResources []Resource) []string {
 	nodeDepCount := make(map[string]int, 0)
-	for k, _ := range currentPod.Metadata.Annotations {
-		vals := strings.Split(k, ".")
-		//logger("k = " + k + "v = " + v)
-		if "dependson" != vals[0] && "dependedby" != vals[0] {
-			continue
-		}
-		//logger(fmt.Sprintf("other pod = %s\n", vals[1]))
-		node, exists := assignments[vals[1]]
+	for _, dep := range sched.podProcessor.GetPodRequirements(currentPod).Deps {
+		//logger(fmt.Sprintf("other pod = %s\n", dep.Pod))
+		node, exists := getAssignedNode(dep.Pod, assignments)
 		if exists {
 			_, nodeExists := nodeDepCount[node]
 			if !nodeExists {
@@ -375,51 +416,82 @@ func (sched *DagScheduler) GetNodesForDeps(currentPod Pod, assignments map[strin
 	return nodeNames
 }
 
+// assignments are keyed by the full pod name, deps by the short one
+// takes the bandwidth the pod needs to its dependencies placed on other nodes off the links of their paths
+func (sched *DagScheduler) reserveDepsBw(pod Pod, node Node, nodes *NodeList, assignments map[string]string, links *netmon_client.LinkModel) {
+	nodeIp := getNodeIp(node)
+	for _, dep := range sched.podProcessor.GetPodRequirements(pod).Deps {
+		dstNode, exists := getAssignedNode(dep.Pod, assignments)
+		if !exists || dstNode == node.Metadata.Name {
+			continue
+		}
+		dstNodeIp := getNodeIp(getNodeWithName(dstNode, nodes))
+		links.Reserve(nodeIp, dstNodeIp, dep.SendBw())
+		links.Reserve(dstNodeIp, nodeIp, dep.RecvBw())
+		logger(fmt.Sprintf("reserved %f to and %f from %s for pod %s on %s", dep.SendBw(), dep.RecvBw(), dstNode, pod.Metadata.Name, node.Metadata.Name))
+	}
+}
+
+// assignments are keyed by the full pod name, deps by the short one
+func getAssignedNode(podName string, assignments map[string]string) (string, bool) {
+	for pname, nodeName := range assignments {
+		if getPodName(pname) == podName {
+			return nodeName, true
+		}
+	}
+	return "", false
+}
+
 func (sched *DagScheduler) AreDepsSatisfied(currentPod Pod, currentNode Node, nodes *NodeList,
 	assignments map[string]string,
 	availableBws netmon_client.PathSet) bool {
+	return sched.checkDeps(currentPod, currentNode, nodes, assignments, availableBws, netmon_client.NewLinkModel(availableBws, nil)) == nil
+}
+
+// returns the first dependency of the pod whose path from the node is missing, too slow or too narrow, nil if there is none.
+// The bandwidth of a path is what is left on its narrowest link after the pods placed so far
+func (sched *DagScheduler) checkDeps(currentPod Pod, currentNode Node, nodes *NodeList,
+	assignments map[string]string,
+	availableBws netmon_client.PathSet,
+	links *netmon_client.LinkModel) *DepVerdict {
 	logger("pod name is " + currentPod.Metadata.Name)
-	for k, v := range currentPod.Metadata.Annotations {
-		vals := strings.Split(k, ".")
-		//logger("k = " + k + "v = " + v)
-		if "dependson" != vals[0] && "dependedby" != vals[0] {
-			continue
-		}
-		logger(fmt.Sprintf("ann = %s val = %s\n", k, v))
-		qtyName, podName := vals[2], vals[1]
-		bw := 0.0
-		if qtyName == "bw" {
-			bwVal, _ := strconv.Atoi(v)
-			bw = float64(bwVal)
-		} else {
+	// the paths start at the candidate node, pods carry no node ip annotations
+	nodeIp := getNodeIp(currentNode)
+	nodeBws := availableBws[nodeIp]
+	for _, dep := range sched.podProcessor.GetPodRequirements(currentPod).Deps {
+		podName, bw := dep.Pod, dep.Bandwidth
+		if bw == 0 && dep.MaxLatency == 0 {
 			continue
 		}
-		logger(fmt.Sprintf("pod %s -> %s needs %f", currentPod.Metadata.Name, podName, bw))
+		logger(fmt.Sprintf("pod %s -> %s needs %f bw %f ms", currentPod.Metadata.Name, podName, bw, dep.MaxLatency))
 
-		nodeIp, ipExists := currentPod.Metadata.Annotations["alpha.kubernetes.io/provided-node-ip"]
-		if !ipExists {
-			nodeIp = currentPod.Metadata.Annotations["flannel.alpha.coreos.com/public-ip"]
-		}
-		nodeBws, exists := availableBws[nodeIp]
-		dstNode, exists := assignments[podName]
-		if !exists {
+		dstNode, exists := getAssignedNode(podName, assignments)
+		if !exists || dstNode == currentNode.Metadata.Name {
+			// not placed yet or on the same node
 			continue
 		}
-		dstNodeInfo := getNodeWithName(dstNode, nodes)
-		dstNodeIp, ipExists := dstNodeInfo.Metadata.Annotations["alpha.kubernetes.io/provided-node-ip"]
-		if !exists {
-			dstNodeIp = dstNodeInfo.Metadata.Annotations["flannel.alpha.coreos.com/public-ip"]
-		}
+		dstNodeIp := getNodeIp(getNodeWithName(dstNode, nodes))
 		path, dExists := nodeBws[dstNodeIp]
+		failed := &DepVerdict{Pod: podName, Node: dstNode, Bandwidth: bw, AvailableBw: links.Available(nodeIp, dstNodeIp), MaxLatency: dep.MaxLatency, Latency: path.Latency}
 		if !dExists {
-			return false
-		}
-		if bw > path.Bandwidth {
-			return false
+			failed.Reason = DEP_NO_PATH
+			return failed
+		}
+		if bw > failed.AvailableBw {
+			failed.Reason = DEP_BANDWIDTH
+			return failed
+		}
+		if dep.MaxLatency > 0 {
+			if path.Latency == 0 {
+				logger(fmt.Sprintf("no latency info for %s -> %s", nodeIp, dstNodeIp))
+			} else if path.Latency > dep.MaxLatency {
+				logger(fmt.Sprintf("latency %s -> %s is %f ms, %s needs %f ms", nodeIp, dstNodeIp, path.Latency, podName, dep.MaxLatency))
+				failed.Reason = DEP_LATENCY
+				return failed
+			}
 		}
-
 	}
-	return true
+	return nil
 }
 
 func (sched *DagScheduler) getNextPod(currentPod string, assignedPods map[string]string, topoOrder []string, podGraph map[string]map[string]bool) ([]string, bool) {
@@ -469,13 +541,21 @@ func getNodeWithName(nodeName string, nodes *NodeList) Node {
 	return node
 }
 
-func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string]map[string]bool) (map[string]string, map[string]Pod, *NodeList) {
-	// returns the dag of unscheduled pods
-	sched.processorLock.Lock()
-	defer sched.processorLock.Unlock()
-	logger(fmt.Sprintf("got %d pods and %d podgraph", len(pods), len(podGraph)))
-
-	_, paths, traffics := sched.netmonClient.GetStats(sched.ipMap, false)
+func (sched *DagScheduler) getClusterState() *ClusterState {
+	startTime := time.Now()
+	ctx := context.Background()
+	if sched.netmonTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sched.netmonTimeout)
+		defer cancel()
+	}
+	links, paths, traffics, nodeErrors := sched.netmonClient.GetStats(ctx, sched.ipMap, false)
+	netmonFetch := time.Since(startTime)
+	staleNodes := make(map[string]netmon_client.NodeError, 0)
+	for _, nodeErr := range nodeErrors {
+		logger(nodeErr.Error())
+		staleNodes[nodeErr.Host] = nodeErr
+	}
 	for src, trafs := range traffics{
 		for dst, traf := range trafs{
 			logger(fmt.Sprintf("src %s dst %s traf %f", src, dst, traf.Bytes))
@@ -485,55 +565,122 @@ func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string
 	nodes, _ := sched.client.GetNodes()
 	nodeMetrics, _ := sched.client.GetNodeMetrics()
 	logger(fmt.Sprintf("Got %d nodes", len(nodes.Items)))
+	state := &ClusterState{nodes: nodes, nodeMetrics: nodeMetrics, links: links, paths: paths, traffics: traffics, staleNodes: staleNodes, netmonFetch: netmonFetch}
+	if len(nodes.Items) == 0 {
+		return state
+	}
+	state.nodeResources = sched.getNodeResourcesRemaining(nodes, nodeMetrics)
+	state.netResources = sched.getNetResourcesRemaining(paths, traffics)
+	state.reserved, state.hints = sched.getReservations()
+	logger(fmt.Sprintf("got %d paths and %d traffics", len(paths), len(traffics)))
+	return state
+}
+
+func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string]map[string]bool) (map[string]string, map[string]Pod, *NodeList) {
+	// returns the dag of unscheduled pods
+	sched.processorLock.Lock()
+	defer sched.processorLock.Unlock()
+	logger(fmt.Sprintf("got %d pods and %d podgraph", len(pods), len(podGraph)))
+
+	state := sched.getClusterState()
+	recordNetmonMetrics(state)
+	nodes := state.nodes
 	podAssignment := make(map[string]string, 0)
 	
 	if len(nodes.Items) == 0 {
 		logger("ERROR: Cannot find any node for scheduling, skipping")
 		return podAssignment, pods, nodes
 	}
-	nodeResources := sched.getNodeResourcesRemaining(nodes, nodeMetrics)
-	netResources := sched.getNetResourcesRemaining(paths, traffics)
 
 	_, podNetUsages := sched.promClient.GetPodMetrics()
-	logger(fmt.Sprintf("got %d paths and %d traffics", len(paths), len(traffics)))
-	topoOrder := topoSortWithChain(podGraph, pods, podNetUsages)
-	logger(fmt.Sprintf("topo order has %d pods", len(topoOrder)))
-	nodeResList := make([]Resource, 0)
-	nodePreference := make([]string, 0)
-
-	for _, nr := range nodeResources {
-		nodeResList = append(nodeResList, nr)
-		nodePreference = append(nodePreference, nr.name)
-	}
+	state.podNetUsages = podNetUsages
+	state.podReqs = sched.podProcessor.GetPodRequirementsSet(pods)
 	startTime := time.Now()
-	sortNodes(nodeResList)
-	podIdx := 0
-	madeAssignment := false
-
+	topoOrder := sched.getPendingOrder(podGraph, state)
 	if len(topoOrder) == 0 {
 		logger("No pods to schedule..")
 
 		return podAssignment, pods, nodes
 	}
-	allPods, _ := sched.client.GetPods()
-	candidateNodeIdx := 0
-	//#unscheduledNeighbors := make([]string, 0)
-	podsToSchedule := make([]string, 0)
+	endTime := time.Now()
+	logger(fmt.Sprintf("graph sort took %v\n", endTime.Sub(startTime)))
+	schedulingDuration.WithLabelValues(PHASE_GRAPH_SORT).Observe(endTime.Sub(startTime).Seconds())
+	namespace := getPodWithName(topoOrder[0], pods).Metadata.Namespace
+	deployed := make(DeploymentMap, 0)
+	for pod, node := range sched.deployedApps[namespace] {
+		deployed[pod] = node
+	}
+	var snapshot *Snapshot
+	if sched.recorder != nil {
+		snapshot = sched.recorder.Begin(sched, pods, podGraph)
+	}
+	startTime = time.Now()
+	podAssignment = sched.strategy.Place(pods, topoOrder, state)
+	schedulingDuration.WithLabelValues(PHASE_PLACEMENT).Observe(time.Since(startTime).Seconds())
+	if snapshot != nil {
+		sched.recorder.Save(snapshot, podAssignment)
+	}
+	if len(podAssignment) < len(topoOrder) {
+		// gang semantics, the group is placed only if every pending pod has a node
+		logger(fmt.Sprintf("found nodes for %d of %d pods, not placing the group", len(podAssignment), len(topoOrder)))
+		sched.deployedApps[namespace] = deployed
+		for _, podName := range topoOrder {
+			pod := getPodWithName(podName, pods)
+			message := state.getFitFailureMessage(pod.Metadata.Name)
+			reason := state.getFitFailureReason(pod.Metadata.Name)
+			if _, placed := podAssignment[pod.Metadata.Name]; placed {
+				message = "other pods of the pod group do not fit"
+				reason = "pod group"
+			}
+			podsFailed.WithLabelValues(metricReason(reason)).Inc()
+			sched.emitPodEvent(pod, "FailedScheduling", message, bwcontroller.EVENT_WARNING)
+		}
+		return make(map[string]string, 0), pods, nodes
+	}
+	return podAssignment, pods, nodes
+}
 
+// pending pods of the pod graph, in the order they are placed
+func (sched *DagScheduler) getPendingOrder(podGraph map[string]map[string]bool, state *ClusterState) []string {
+	topoOrder := topoSortWithChain(podGraph, state.podReqs, state.podNetUsages)
+	logger(fmt.Sprintf("topo order has %d pods", len(topoOrder)))
+	podsToSchedule := make([]string, 0)
+	if len(topoOrder) == 0 {
+		return podsToSchedule
+	}
+	allPods, _ := sched.client.GetPods()
 	for _, p := range topoOrder {
 		if !sched.podProcessor.IsPodInList(allPods, p) {
 			logger("pod " + p + " is pending, add to list")
 			podsToSchedule = append(podsToSchedule, p)
 		}
 	}
-	topoOrder = podsToSchedule
-	if len(topoOrder) == 0 {
-		logger("No pods to schedule..")
+	return podsToSchedule
+}
 
-		return podAssignment, pods, nodes
+// greedy placement: walk the pods in topological order and pick the first node in preference order that fits
+func (sched *DagScheduler) scheduleGreedy(pods map[string]Pod, topoOrder []string, state *ClusterState) map[string]string {
+	nodes := state.nodes
+	nodeResources := state.nodeResources
+	netResources := state.netResources
+	links := state.getLinkModel()
+	podAssignment := make(map[string]string, 0)
+	nodeResList := make([]Resource, 0)
+	nodePreference := make([]string, 0)
+
+	// in node list order so that the same state always gives the same placement
+	for _, node := range nodes.Items {
+		if nr, exists := nodeResources[node.Metadata.Name]; exists {
+			nodeResList = append(nodeResList, nr)
+		}
 	}
-	endTime := time.Now()
-	logger(fmt.Sprintf("graph sort took %v\n", endTime.Sub(startTime)))
+	sortNodes(nodeResList)
+	for _, nr := range nodeResList {
+		nodePreference = append(nodePreference, nr.name)
+	}
+	podIdx := 0
+	madeAssignment := false
+	candidateNodeIdx := 0
 	podToSchedule := topoOrder[podIdx]
 	logger("schedule pod " + podToSchedule)
 	for {
@@ -576,13 +723,18 @@ func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string
 				break
 			}
 		}
+		// the node the controller planned when it moved the pod
+		if hint, exists := state.hints[podMeta.Metadata.Namespace][podToSchedule]; exists {
+			logger(fmt.Sprintf("trying node %s the controller planned for %s", hint, podToSchedule))
+			candidateNodeName = hint
+		}
 		fit := false
 		if candidateNodeName != "" {
 			candidateNode = getNodeWithName(candidateNodeName, nodes)
 			candidateNodeRes, nodeIdx = getResourceByNodeName(nodeResList, candidateNodeName)
 
-			if sched.Fit(podMeta, candidateNode, candidateNodeRes, netResources) && sched.AreDepsSatisfied(podMeta, candidateNode, nodes,
-			podAssignment, netResources) {
+			if sched.fitVerdict(podMeta, candidateNode, candidateNodeRes, netResources, links).Fits && sched.checkStale(podMeta, candidateNode, state) == "" &&
+			sched.checkDeps(podMeta, candidateNode, nodes, podAssignment, netResources, links) == nil {
 				fit = true
 			}
 		} 
@@ -596,20 +748,28 @@ func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string
 			logger("pod for " + podToSchedule + " does not exist")
 			break
 		}
-		if sched.Fit(podMeta, candidateNode, candidateNodeRes, netResources) && sched.AreDepsSatisfied(podMeta, candidateNode, nodes,
-			podAssignment, netResources) {
+		reason := sched.fitVerdict(podMeta, candidateNode, candidateNodeRes, netResources, links).Reason
+		if reason == "" {
+			reason = sched.checkStale(podMeta, candidateNode, state)
+		}
+		if reason == "" && sched.checkDeps(podMeta, candidateNode, nodes, podAssignment, netResources, links) != nil {
+			reason = FIT_DEPS
+		}
+		if reason == "" {
 			podAssignment[podMeta.Metadata.Name] = candidateNode.Metadata.Name
 			podResource := sched.GetPodResource(podMeta)
 			candidateNodeRes.cpu -= podResource.cpu
 			candidateNodeRes.memory -= podResource.memory
 			nodeResources[candidateNodeRes.name] = candidateNodeRes
 			nodeResList[nodeIdx] = candidateNodeRes
+			sched.reserveDepsBw(podMeta, candidateNode, nodes, podAssignment, links)
 			logger(fmt.Sprintf("Found node %s for pod %s meta =%s pod needs %d cpu and %d memory", candidateNode.Metadata.Name, podToSchedule, podMeta.Metadata.Name, podResource.cpu, podResource.memory))
 			logger(fmt.Sprintf("node %s now has cpu %d mem %d", candidateNodeRes.name, candidateNodeRes.cpu, candidateNodeRes.memory))
 			madeAssignment = true
 
 		} else {
 			logger(fmt.Sprintf("%s does not fit on %s", podToSchedule, candidateNodeRes.name))
+			state.addFitFailure(podMeta.Metadata.Name, candidateNodeName, reason)
 			candidateNodeIdx += 1
 			madeAssignment = false
 		}
@@ -622,19 +782,149 @@ func (sched *DagScheduler) SchedulePods(pods map[string]Pod, podGraph map[string
 //		time.Sleep(10*time.Second)
 		endTime := time.Now()
 		logger(fmt.Sprintf("loop took %v\n", endTime.Sub(startTime)))
+		if !state.dryRun {
+			schedulingDuration.WithLabelValues(PHASE_POD_LOOP).Observe(endTime.Sub(startTime).Seconds())
+		}
 	}
-	return podAssignment, pods, nodes
+	return podAssignment
 }
 
+// Binds the pods of a group in topological order. If a bind fails the pods bound so far are deleted
+// so that their controllers recreate them and the whole group is scheduled again
 func (sched *DagScheduler) AssignPods(podAssignment map[string]string, pods map[string]Pod, nodes *NodeList) error {
-	for pod, nodeName := range podAssignment {
-		node := getNodeWithName(nodeName, nodes)
-		logger("pod = " + pod + " pod name= " + pods[pod].Metadata.Name)
-		logger("Assign pod " + pods[pod].Metadata.Name + " to node " + node.Metadata.Name)
-		err := sched.SchedulePod(pods[pod], node)
+	bound := make([]Pod, 0)
+	for _, pod := range sched.getBindOrder(podAssignment, pods) {
+		node := getNodeWithName(podAssignment[pod.Metadata.Name], nodes)
+		logger("Assign pod " + pod.Metadata.Name + " to node " + node.Metadata.Name)
+		err := sched.client.Bind(pod, node)
 		if err != nil {
-			logger(fmt.Sprintf("Got error %v", err))
+			logger(fmt.Sprintf("Got error %v binding %s, rolling back %d pods", err, pod.Metadata.Name, len(bound)))
+			sched.emitPodEvent(pod, "FailedScheduling", fmt.Sprintf("binding to %s failed: %v", node.Metadata.Name, err), bwcontroller.EVENT_WARNING)
+			bindErrors.Inc()
+			podsFailed.WithLabelValues(metricReason("bind")).Inc()
+			sched.rollbackPods(bound)
+			if app, exists := sched.deployedApps[pod.Metadata.Namespace]; exists {
+				for p, _ := range podAssignment {
+					delete(app, getPodName(p))
+				}
+			}
+			return err
 		}
+		bound = append(bound, pod)
+	}
+	sched.podProcessor.MarkScheduled(bound)
+	podsScheduled.Add(float64(len(bound)))
+	sched.recordReservations(bound, podAssignment)
+	for _, pod := range bound {
+		sched.emitPodEvent(pod, "Scheduled", fmt.Sprintf("Successfully assigned %s/%s to %s", pod.Metadata.Namespace, pod.Metadata.Name, podAssignment[pod.Metadata.Name]), bwcontroller.EVENT_NORMAL)
 	}
 	return nil
 }
+
+// bw reserved in the ledgers that the pods it was reserved for do not use yet, and the nodes the controller planned for the pods it moved
+func (sched *DagScheduler) getReservations() (map[string]map[string]float64, map[string]map[string]string) {
+	if sched.ledger == nil {
+		return nil, nil
+	}
+	ledgers, err := sched.ledger.LoadLedgers()
+	if err != nil {
+		logger(fmt.Sprintf("could not load the reservations: %v", err))
+		return nil, nil
+	}
+	return bwcontroller.PendingReservations(ledgers), bwcontroller.PlacementHints(ledgers)
+}
+
+// writes the bw the bound pods were placed with towards their dependencies on other nodes to the ledger of their namespace.
+// The hints of the bound pods are used up
+func (sched *DagScheduler) recordReservations(bound []Pod, podAssignment map[string]string) {
+	if sched.ledger == nil || len(bound) == 0 {
+		return
+	}
+	ns := bound[0].Metadata.Namespace
+	now := time.Now().Unix()
+	reservations := make([]bwcontroller.Reservation, 0)
+	for _, pod := range bound {
+		podName, node := getPodName(pod.Metadata.Name), podAssignment[pod.Metadata.Name]
+		for _, dep := range sched.podProcessor.GetPodRequirements(pod).Deps {
+			depNode, exists := sched.deployedApps[ns][dep.Pod]
+			if !exists || depNode == node {
+				continue
+			}
+			if bw := dep.SendBw(); bw > 0 {
+				reservations = append(reservations, bwcontroller.Reservation{Pod: podName, Dep: dep.Pod, SrcNode: node, DstNode: depNode, Bandwidth: bw, Time: now})
+			}
+			if bw := dep.RecvBw(); bw > 0 {
+				reservations = append(reservations, bwcontroller.Reservation{Pod: dep.Pod, Dep: podName, SrcNode: depNode, DstNode: node, Bandwidth: bw, Time: now})
+			}
+		}
+	}
+	err := bwcontroller.UpdateLedger(sched.ledger, ns, func(ledger *bwcontroller.ReservationLedger) bool {
+		changed := len(reservations) > 0
+		for _, r := range reservations {
+			ledger.Reserve(r)
+		}
+		for _, pod := range bound {
+			if ledger.ClearHint(getPodName(pod.Metadata.Name)) {
+				changed = true
+			}
+		}
+		return changed
+	})
+	if err != nil {
+		logger(fmt.Sprintf("could not record the reservations of %s: %v", ns, err))
+		return
+	}
+	logger(fmt.Sprintf("recorded %d reservations in %s", len(reservations), ns))
+}
+
+// pods of the assignment, dependencies first
+func (sched *DagScheduler) getBindOrder(podAssignment map[string]string, pods map[string]Pod) []Pod {
+	assigned := make([]Pod, 0)
+	podGraph := make(map[string]map[string]bool, 0)
+	for pod, _ := range podAssignment {
+		assigned = append(assigned, pods[pod])
+		podGraph[getPodName(pod)] = make(map[string]bool, 0)
+	}
+	// only the deps within the group matter
+	for _, pod := range assigned {
+		for _, dep := range sched.podProcessor.GetPodRequirements(pod).GetDeps(DEPENDS_ON) {
+			if _, exists := podGraph[dep.Pod]; exists {
+				podGraph[getPodName(pod.Metadata.Name)][dep.Pod] = true
+			}
+		}
+	}
+	order := make([]Pod, 0)
+	added := make(map[string]bool, 0)
+	for _, podName := range topoSort(podGraph) {
+		pod := getPodWithName(podName, pods)
+		if _, exists := podAssignment[pod.Metadata.Name]; exists && !added[pod.Metadata.Name] {
+			order = append(order, pod)
+			added[pod.Metadata.Name] = true
+		}
+	}
+	for _, pod := range assigned {
+		if !added[pod.Metadata.Name] {
+			order = append(order, pod)
+		}
+	}
+	return order
+}
+
+// bound pods cannot be moved, delete them so that they come back as new pending pods
+func (sched *DagScheduler) rollbackPods(bound []Pod) {
+	deleted := make([]Pod, 0)
+	for _, pod := range bound {
+		if len(pod.Metadata.OwnerReferences) == 0 {
+			logger(fmt.Sprintf("WARNING: pod %s has no owner and will not be recreated", pod.Metadata.Name))
+		}
+		err := sched.client.DeletePod(pod)
+		if err != nil {
+			logger(fmt.Sprintf("could not roll back pod %s: %v", pod.Metadata.Name, err))
+			continue
+		}
+		logger("rolled back pod " + pod.Metadata.Name)
+		deleted = append(deleted, pod)
+	}
+	// the recreated pods show up with new names, pods that could not be deleted stay in the queue
+	sched.podProcessor.MarkScheduled(deleted)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+	"testing"
+)
+
+func TestAreDepsSatisfiedLatency(t *testing.T) {
+	sched := &DagScheduler{podProcessor: NewPodProcessor(CLIENT), deployedApps: make(map[string]DeploymentMap, 0)}
+	nodes := getMeshTestState().nodes
+	availableBws := netmon_client.PathSet{
+		"10.0.0.1": {"10.0.0.2": netmon_client.Path{Bandwidth: 100, Latency: 3}},
+		"10.0.0.3": {"10.0.0.2": netmon_client.Path{Bandwidth: 100, Latency: 30}},
+	}
+	pod := Pod{}
+	pod.Metadata.Name = "front-5d9c8-x2k4"
+	pod.Metadata.Namespace = "app"
+	pod.Metadata.Annotations = map[string]string{"dependson.back": "yes", "dependson.back.bw": "10", "dependson.back.latency": "5"}
+	assignments := map[string]string{"back-7f9c-x2k4": "n2"}
+
+	if !sched.AreDepsSatisfied(pod, nodes.Items[0], nodes, assignments, availableBws) {
+		t.Fatalf("Want deps satisfied on n1")
+	}
+	if sched.AreDepsSatisfied(pod, nodes.Items[2], nodes, assignments, availableBws) {
+		t.Fatalf("Want latency n3 -> n2 to be too high")
+	}
+	// same node as the dependency
+	if !sched.AreDepsSatisfied(pod, nodes.Items[1], nodes, assignments, availableBws) {
+		t.Fatalf("Want deps satisfied on n2")
+	}
+}
+
+func TestAreDepsSatisfiedBandwidth(t *testing.T) {
+	sched := &DagScheduler{podProcessor: NewPodProcessor(CLIENT), deployedApps: make(map[string]DeploymentMap, 0)}
+	nodes := getMeshTestState().nodes
+	availableBws := netmon_client.PathSet{
+		"10.0.0.1": {"10.0.0.2": netmon_client.Path{Bandwidth: 100}},
+		"10.0.0.3": {"10.0.0.2": netmon_client.Path{Bandwidth: 5}},
+	}
+	pod := Pod{}
+	pod.Metadata.Name = "front-5d9c8-x2k4"
+	pod.Metadata.Namespace = "app"
+	pod.Metadata.Annotations = map[string]string{"dependson.back": "yes", "dependson.back.bw": "10"}
+	// assigned under its full name, the dependency names the short one
+	assignments := map[string]string{"back-7f9c-x2k4": "n2"}
+	tests := []struct {
+		name string
+		node Node
+		want bool
+	}{
+		{"enough bandwidth from the candidate node", nodes.Items[0], true},
+		{"too little bandwidth from the candidate node", nodes.Items[2], false},
+		{"same node as the dependency, no path needed", nodes.Items[1], true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if satisfied := sched.AreDepsSatisfied(pod, test.node, nodes, assignments, availableBws); satisfied != test.want {
+				t.Fatalf("Want deps satisfied %v on %s, got %v", test.want, test.node.Metadata.Name, satisfied)
+			}
+		})
+	}
+}
+
+func TestCheckDepsSharedLink(t *testing.T) {
+	sched := &DagScheduler{podProcessor: NewPodProcessor(CLIENT), deployedApps: make(map[string]DeploymentMap, 0)}
+	nodes := getMeshTestState().nodes
+	// n1 reaches n3 through n2, both paths from n1 start with the link n1 -> n2
+	availableBws := netmon_client.PathSet{
+		"10.0.0.1": {
+			"10.0.0.2": netmon_client.Path{Hops: []string{"10.0.0.1"}, Bandwidth: 100},
+			"10.0.0.3": netmon_client.Path{Hops: []string{"10.0.0.1", "10.0.0.2"}, Bandwidth: 100},
+		},
+		"10.0.0.2": {"10.0.0.3": netmon_client.Path{Hops: []string{"10.0.0.2"}, Bandwidth: 200}},
+	}
+	links := netmon_client.NewLinkModel(availableBws, nil)
+	front := Pod{}
+	front.Metadata.Name = "front-5d9c8-x2k4"
+	front.Metadata.Annotations = map[string]string{"dependson.cache": "yes", "dependson.cache.bw": "60"}
+	api := Pod{}
+	api.Metadata.Name = "api-6b7d4-p9q2"
+	api.Metadata.Annotations = map[string]string{"dependson.db": "yes", "dependson.db.bw": "60"}
+	assignments := map[string]string{"cache-7f9c-x2k4": "n2", "db-8c2d-m3n4": "n3"}
+
+	if dep := sched.checkDeps(front, nodes.Items[0], nodes, assignments, availableBws, links); dep != nil {
+		t.Fatalf("Want front to fit on n1, got %v", dep)
+	}
+	sched.reserveDepsBw(front, nodes.Items[0], nodes, assignments, links)
+	// the path n1 -> n3 has 100 but its first link only 40 left
+	dep := sched.checkDeps(api, nodes.Items[0], nodes, assignments, availableBws, links)
+	if dep == nil || dep.Reason != DEP_BANDWIDTH || dep.AvailableBw != 40 {
+		t.Fatalf("Want api not to fit on n1 with 40 left, got %v", dep)
+	}
+	verdict := sched.fitVerdict(api, nodes.Items[0], Resource{cpu: 1000, memory: 1024}, availableBws, links)
+	if verdict.SendBwAvailable != 40 {
+		t.Fatalf("Want n1 to have 40 to send on its only link, got %f", verdict.SendBwAvailable)
+	}
+}
+
+func TestRecordReservations(t *testing.T) {
+	bound, deleted := make([]string, 0), make([]string, 0)
+	client := gangClient{bound: &bound, deleted: &deleted}
+	ledger := bwcontroller.NewMemoryLedgerStore()
+	sched := &DagScheduler{client: client, podProcessor: NewPodProcessor(client), ledger: ledger,
+		deployedApps: map[string]DeploymentMap{"app": {"front": "n1", "back": "n2", "cache": "n1"}}}
+	front := Pod{}
+	front.Metadata.Name = "front-5d9c8-x2k4"
+	front.Metadata.Namespace = "app"
+	front.Metadata.Annotations = map[string]string{"dependson.back": "yes", "dependson.back.bw": "30", "dependson.cache": "yes", "dependson.cache.bw": "50"}
+	sched.podProcessor.AddPod(front)
+	nodes := getMeshTestState().nodes
+
+	if err := sched.AssignPods(map[string]string{front.Metadata.Name: "n1"}, map[string]Pod{front.Metadata.Name: front}, nodes); err != nil {
+		t.Fatalf("Want front bound, got %v", err)
+	}
+	// cache is on the same node, nothing to reserve towards it
+	appLedger, _ := ledger.LoadLedger("app")
+	if r, exists := appLedger.Reservations["front/back"]; len(appLedger.Reservations) != 1 || !exists || r.SrcNode != "n1" || r.DstNode != "n2" || r.Bandwidth != 30 {
+		t.Fatalf("Want 30 reserved from front on n1 to back on n2, got %v", appLedger.Reservations)
+	}
+
+	// the next placement sees the reservation on the link until front uses it
+	state := getMeshTestState()
+	state.reserved, _ = sched.getReservations()
+	state.paths["10.0.0.1"]["10.0.0.2"] = netmon_client.Path{Hops: []string{"10.0.0.1"}, Bandwidth: 100}
+	state.netResources = netmon_client.PathSet{"10.0.0.1": {"10.0.0.2": state.paths["10.0.0.1"]["10.0.0.2"]}}
+	if bw := state.getLinkModel().Available("10.0.0.1", "10.0.0.2"); bw != 70 {
+		t.Fatalf("Want 70 left on n1 -> n2, got %f", bw)
+	}
+	_, _, linkMap := MakeMeshTopology(state)
+	if linkMap["n1"]["n2"].BwInUse != 40 {
+		t.Fatalf("Want 10 traffic and 30 reserved in use on link n1->n2, got %f", linkMap["n1"]["n2"].BwInUse)
+	}
+}
+
+func TestPlacementHint(t *testing.T) {
+	tests := []struct {
+		name  string
+		hints map[string]map[string]string
+		want  string // node of front
+	}{
+		// n1 has the least cpu left, front only goes there when the controller planned it
+		{"no hint", nil, "n3"},
+		{"hinted", map[string]map[string]string{"app": {"front": "n1"}}, "n1"},
+		{"hinted node without netmon", map[string]map[string]string{"app": {"front": "n2"}}, "n3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sched, state := getExplainTestScheduler()
+			state.hints = test.hints
+			explanation := sched.explain(getExplainTestPods(map[string]string{}), []string{"front"}, state)
+			if !explanation.Placed || explanation.Pods[0].Node != test.want {
+				t.Fatalf("Want front on %s, got %v", test.want, explanation)
+			}
+		})
+	}
+}
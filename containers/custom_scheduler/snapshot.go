@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+)
+
+// number of snapshot files kept in the snapshot dir, older ones are deleted
+const SNAPSHOT_KEEP = 100
+
+const SNAPSHOT_PREFIX = "snapshot-"
+
+// Everything SchedulePods saw when it placed a pod group
+type Snapshot struct {
+	Time         time.Time                               `json:"time"`
+	Strategy     string                                  `json:"strategy"`
+	Tolerance    float64                                 `json:"tolerance"`
+	Nodes        *NodeList                               `json:"nodes"`
+	NodeMetrics  *NodeMetricsList                        `json:"nodeMetrics"`
+	Pods         []*PodList                              `json:"pods"` // pods already in the cluster
+	ConfigMaps   []ConfigMap                             `json:"configMaps"`
+	Links        netmon_client.LinkSet                   `json:"links"`
+	Paths        netmon_client.PathSet                   `json:"paths"`
+	Traffics     netmon_client.TrafficSet                `json:"traffics"`
+	NetmonErrors []netmon_client.NodeError               `json:"netmonErrors"` // nodes whose netmon could not be reached
+	PodDeps      bwcontroller.PodDeps                    `json:"podDeps"`
+	DeployedApps map[string]DeploymentMap                `json:"deployedApps"`
+	Pending      map[string]Pod                          `json:"pending"` // pods passed to SchedulePods
+	PodGraph     map[string]map[string]bool              `json:"podGraph"`
+	Assignment   map[string]string                       `json:"assignment"`   // placement made when the snapshot was recorded
+	Reservations map[string][]bwcontroller.Reservation   `json:"reservations"` // ns -> reservations of its ledger
+	Hints        map[string][]bwcontroller.PlacementHint `json:"hints"`        // ns -> hints of its ledger
+}
+
+// SnapshotRecorder sits between the scheduler and its kube, netmon and prometheus clients and
+// remembers the last answer of each, so that a placement can be saved with the inputs it was made from
+type SnapshotRecorder struct {
+	KubeClientIntf
+	netmonClient netmon_client.NetmonClientIntf
+	promClient   PromClientIntf
+	dir          string
+	lock         *sync.Mutex
+	last         Snapshot
+}
+
+func NewSnapshotRecorder(dir string, client KubeClientIntf, netmonClient netmon_client.NetmonClientIntf, promClient PromClientIntf) *SnapshotRecorder {
+	return &SnapshotRecorder{KubeClientIntf: client, netmonClient: netmonClient, promClient: promClient, dir: dir, lock: &sync.Mutex{}}
+}
+
+func (rec *SnapshotRecorder) GetNodes() (*NodeList, error) {
+	nodes, err := rec.KubeClientIntf.GetNodes()
+	rec.lock.Lock()
+	rec.last.Nodes = nodes
+	rec.lock.Unlock()
+	return nodes, err
+}
+
+func (rec *SnapshotRecorder) GetNodeMetrics() (*NodeMetricsList, error) {
+	nodeMetrics, err := rec.KubeClientIntf.GetNodeMetrics()
+	rec.lock.Lock()
+	rec.last.NodeMetrics = nodeMetrics
+	rec.lock.Unlock()
+	return nodeMetrics, err
+}
+
+func (rec *SnapshotRecorder) GetPods() ([]*PodList, error) {
+	pods, err := rec.KubeClientIntf.GetPods()
+	rec.lock.Lock()
+	rec.last.Pods = pods
+	rec.lock.Unlock()
+	return pods, err
+}
+
+func (rec *SnapshotRecorder) GetConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	configMaps, err := rec.KubeClientIntf.GetConfigMaps(labelSelector)
+	if labelSelector == PODGROUP_LABEL {
+		rec.lock.Lock()
+		rec.last.ConfigMaps = configMaps
+		rec.lock.Unlock()
+	}
+	return configMaps, err
+}
+
+func (rec *SnapshotRecorder) Close() {
+	rec.netmonClient.Close()
+}
+
+func (rec *SnapshotRecorder) GetStats(ctx context.Context, ipMap map[string]string, bwUpdate bool) (netmon_client.LinkSet, netmon_client.PathSet, netmon_client.TrafficSet, []netmon_client.NodeError) {
+	links, paths, traffics, nodeErrors := rec.netmonClient.GetStats(ctx, ipMap, bwUpdate)
+	rec.lock.Lock()
+	rec.last.Links, rec.last.Paths, rec.last.Traffics, rec.last.NetmonErrors = links, paths, traffics, nodeErrors
+	rec.lock.Unlock()
+	return links, paths, traffics, nodeErrors
+}
+
+func (rec *SnapshotRecorder) GetPodMetrics() (bwcontroller.PodSet, bwcontroller.PodDeps) {
+	podSet, podDeps := rec.promClient.GetPodMetrics()
+	rec.lock.Lock()
+	rec.last.PodDeps = podDeps
+	rec.lock.Unlock()
+	return podSet, podDeps
+}
+
+// ledger store that remembers the reservations and hints it loaded last
+type snapshotLedgerStore struct {
+	bwcontroller.LedgerStore
+	rec *SnapshotRecorder
+}
+
+// the ledger store the scheduler loads its reservations from, seen by the recorder
+func (rec *SnapshotRecorder) WrapLedgerStore(store bwcontroller.LedgerStore) bwcontroller.LedgerStore {
+	return &snapshotLedgerStore{LedgerStore: store, rec: rec}
+}
+
+func (store *snapshotLedgerStore) LoadLedgers() ([]*bwcontroller.ReservationLedger, error) {
+	ledgers, err := store.LedgerStore.LoadLedgers()
+	reservations := make(map[string][]bwcontroller.Reservation, 0)
+	hints := make(map[string][]bwcontroller.PlacementHint, 0)
+	for _, ledger := range ledgers {
+		for _, r := range ledger.Reservations {
+			reservations[ledger.Namespace] = append(reservations[ledger.Namespace], r)
+		}
+		for _, hint := range ledger.Hints {
+			hints[ledger.Namespace] = append(hints[ledger.Namespace], hint)
+		}
+	}
+	store.rec.lock.Lock()
+	store.rec.last.Reservations, store.rec.last.Hints = reservations, hints
+	store.rec.lock.Unlock()
+	return ledgers, err
+}
+
+// snapshot of the inputs of the placement that is about to run, has to be called before the strategy updates deployedApps
+func (rec *SnapshotRecorder) Begin(sched *DagScheduler, pods map[string]Pod, podGraph map[string]map[string]bool) *Snapshot {
+	rec.lock.Lock()
+	snapshot := rec.last
+	rec.lock.Unlock()
+	snapshot.Time = time.Now()
+	snapshot.Strategy = sched.strategy.Name()
+	snapshot.Tolerance = sched.tolerance
+	snapshot.Pending = pods
+	snapshot.PodGraph = podGraph
+	snapshot.DeployedApps = make(map[string]DeploymentMap, 0)
+	for ns, app := range sched.deployedApps {
+		snapshot.DeployedApps[ns] = make(DeploymentMap, 0)
+		for pod, node := range app {
+			snapshot.DeployedApps[ns][pod] = node
+		}
+	}
+	return &snapshot
+}
+
+// writes the snapshot with the placement that was made to the snapshot dir
+func (rec *SnapshotRecorder) Save(snapshot *Snapshot, podAssignment map[string]string) {
+	snapshot.Assignment = podAssignment
+	filename := filepath.Join(rec.dir, fmt.Sprintf("%s%d.json", SNAPSHOT_PREFIX, snapshot.Time.UnixNano()))
+	err := WriteSnapshot(filename, snapshot)
+	if err != nil {
+		logger(fmt.Sprintf("could not write snapshot: %v", err))
+		return
+	}
+	logger("wrote snapshot " + filename)
+	rec.prune()
+}
+
+// keeps the newest SNAPSHOT_KEEP snapshots
+func (rec *SnapshotRecorder) prune() {
+	entries, err := os.ReadDir(rec.dir)
+	if err != nil {
+		logger(err)
+		return
+	}
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), SNAPSHOT_PREFIX) {
+			names = append(names, entry.Name())
+		}
+	}
+	// same length timestamps, the names sort by time
+	sort.Strings(names)
+	for i := 0; i < len(names)-SNAPSHOT_KEEP; i++ {
+		err := os.Remove(filepath.Join(rec.dir, names[i]))
+		if err != nil {
+			logger(err)
+		}
+	}
+}
+
+func WriteSnapshot(filename string, snapshot *Snapshot) error {
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, content, 0644)
+}
+
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	err = json.Unmarshal(content, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Nodes == nil || len(snapshot.Pending) == 0 {
+		return nil, errors.New("snapshot " + filename + " has no nodes or no pending pods")
+	}
+	return &snapshot, nil
+}
+
+// Kube, netmon and prometheus client that answer from a snapshot. Binds are only remembered
+type snapshotClient struct {
+	snapshot *Snapshot
+	bound    map[string]string // pod -> node
+}
+
+func (cl *snapshotClient) GetNodes() (*NodeList, error) {
+	return cl.snapshot.Nodes, nil
+}
+
+func (cl *snapshotClient) GetNamespaces() (*NamespaceList, error) {
+	return &NamespaceList{}, nil
+}
+
+func (cl *snapshotClient) WatchUnscheduledPods() (<-chan Pod, <-chan error) {
+	return make(chan Pod), make(chan error)
+}
+
+func (cl *snapshotClient) WaitForProxy() int {
+	return 1
+}
+
+func (cl *snapshotClient) GetNodeMetrics() (*NodeMetricsList, error) {
+	if cl.snapshot.NodeMetrics == nil {
+		return &NodeMetricsList{}, nil
+	}
+	return cl.snapshot.NodeMetrics, nil
+}
+
+func (cl *snapshotClient) GetUnscheduledPods() ([]*Pod, error) {
+	pods := make([]*Pod, 0)
+	for _, pod := range cl.snapshot.Pending {
+		p := pod
+		pods = append(pods, &p)
+	}
+	return pods, nil
+}
+
+func (cl *snapshotClient) GetPods() ([]*PodList, error) {
+	return cl.snapshot.Pods, nil
+}
+
+func (cl *snapshotClient) GetConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	return cl.snapshot.ConfigMaps, nil
+}
+
+func (cl *snapshotClient) Bind(pod Pod, node Node) error {
+	cl.bound[pod.Metadata.Name] = node.Metadata.Name
+	return nil
+}
+
+func (cl *snapshotClient) DeletePod(pod Pod) error {
+	return nil
+}
+
+func (cl *snapshotClient) PostEvent(event bwcontroller.Event, ns string) error {
+	return nil
+}
+
+func (cl *snapshotClient) PatchEvent(event bwcontroller.Event, ns string) error {
+	return nil
+}
+
+func (cl *snapshotClient) Close() {
+}
+
+func (cl *snapshotClient) GetStats(ctx context.Context, ipMap map[string]string, bwUpdate bool) (netmon_client.LinkSet, netmon_client.PathSet, netmon_client.TrafficSet, []netmon_client.NodeError) {
+	return cl.snapshot.Links, cl.snapshot.Paths, cl.snapshot.Traffics, cl.snapshot.NetmonErrors
+}
+
+func (cl *snapshotClient) GetPodMetrics() (bwcontroller.PodSet, bwcontroller.PodDeps) {
+	return make(bwcontroller.PodSet, 0), cl.snapshot.PodDeps
+}
+
+// Scheduler that sees the cluster as it was when the snapshot was recorded, strategy "" uses the recorded one
+func NewReplayScheduler(snapshot *Snapshot, strategy string) (*DagScheduler, error) {
+	client := &snapshotClient{snapshot: snapshot, bound: make(map[string]string, 0)}
+	sched := &DagScheduler{client: client, netmonClient: client, promClient: client,
+		podProcessor: NewPodProcessor(client), processorLock: &sync.Mutex{},
+		ipMap: make(map[string]string, 0), tolerance: snapshot.Tolerance,
+		deployedApps: make(map[string]DeploymentMap, 0)}
+	for ns, app := range snapshot.DeployedApps {
+		sched.deployedApps[ns] = make(DeploymentMap, 0)
+		for pod, node := range app {
+			sched.deployedApps[ns][pod] = node
+		}
+	}
+	// the reservations and hints are kept in memory, the placement reserves on top of them like the recorded one did
+	ledgers := make(map[string]*bwcontroller.ReservationLedger, 0)
+	getLedger := func(ns string) *bwcontroller.ReservationLedger {
+		if _, exists := ledgers[ns]; !exists {
+			ledgers[ns] = bwcontroller.NewReservationLedger(ns)
+		}
+		return ledgers[ns]
+	}
+	for ns, reservations := range snapshot.Reservations {
+		for _, r := range reservations {
+			getLedger(ns).Reserve(r)
+		}
+	}
+	for ns, hints := range snapshot.Hints {
+		for _, hint := range hints {
+			getLedger(ns).Hint(hint)
+		}
+	}
+	ledger := bwcontroller.NewMemoryLedgerStore()
+	for _, nsLedger := range ledgers {
+		err := ledger.SaveLedger(nsLedger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sched.ledger = ledger
+	if strategy == "" {
+		strategy = snapshot.Strategy
+	}
+	var err error
+	sched.strategy, err = NewPlacementStrategy(strategy, sched)
+	if err != nil {
+		return nil, err
+	}
+	sched.podProcessor.RefreshPodGroups()
+	for _, pod := range snapshot.Pending {
+		sched.podProcessor.AddPod(pod)
+	}
+	return sched, nil
+}
+
+// Runs SchedulePods on the recorded pod group and returns the placement, nothing is bound
+func ReplaySnapshot(snapshot *Snapshot, strategy string) (map[string]string, error) {
+	sched, err := NewReplayScheduler(snapshot, strategy)
+	if err != nil {
+		return nil, err
+	}
+	podAssignment, _, _ := sched.SchedulePods(snapshot.Pending, snapshot.PodGraph)
+	return podAssignment, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+)
+
+func getTestSnapshot() *Snapshot {
+	state := getMeshTestState()
+	pods := getExplainTestPods(map[string]string{"dependson.back": "yes", "dependson.back.bw": "30"})
+	podGraph := map[string]map[string]bool{"front": {"back": true}, "back": {}}
+	// the pending pods are listed by the api server too
+	podList := &PodList{Items: make([]Pod, 0)}
+	for _, pod := range pods {
+		pod.Status.Phase = "Pending"
+		podList.Items = append(podList.Items, pod)
+	}
+	return &Snapshot{Strategy: GREEDY_STRATEGY, Nodes: state.nodes, NodeMetrics: state.nodeMetrics, Pods: []*PodList{podList},
+		Links: state.links, Paths: state.paths, Traffics: state.traffics, Pending: pods, PodGraph: podGraph}
+}
+
+func TestReplaySnapshot(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "snapshot.json")
+	err := WriteSnapshot(filename, getTestSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := LoadSnapshot(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		podAssignment, err := ReplaySnapshot(snapshot, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(podAssignment) != 2 || podAssignment["back-7f9c-x2k4"] != "n3" || podAssignment["front-5d9c8-x2k4"] != "n1" {
+			t.Fatalf("Got unexpected placement %v", podAssignment)
+		}
+	}
+	_, err = ReplaySnapshot(snapshot, "unknown")
+	if err == nil {
+		t.Fatalf("Want error for unknown strategy")
+	}
+}
+
+func TestSnapshotRecorder(t *testing.T) {
+	dir := t.TempDir()
+	cluster := &snapshotClient{snapshot: getTestSnapshot(), bound: make(map[string]string, 0)}
+	sched, _ := NewReplayScheduler(cluster.snapshot, "")
+	recorder := NewSnapshotRecorder(dir, cluster, cluster, cluster)
+	sched.client, sched.netmonClient, sched.promClient, sched.recorder = recorder, recorder, recorder, recorder
+	sched.deployedApps["other"] = DeploymentMap{"db": "n2"}
+
+	podAssignment, _, _ := sched.SchedulePods(cluster.snapshot.Pending, cluster.snapshot.PodGraph)
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("Want 1 snapshot, got %d", len(entries))
+	}
+	snapshot, err := LoadSnapshot(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Nodes.Items) != 3 || len(snapshot.Paths) != 2 || snapshot.DeployedApps["other"]["db"] != "n2" {
+		t.Fatalf("Snapshot is missing the cluster state")
+	}
+	// deployedApps as it was before the placement
+	if _, exists := snapshot.DeployedApps["app"]; exists {
+		t.Fatalf("Snapshot has the deployed apps after the placement")
+	}
+	replayed, _ := ReplaySnapshot(snapshot, "")
+	if len(podAssignment) != 2 || len(snapshot.Assignment) != 2 {
+		t.Fatalf("Want both pods placed, got %v", podAssignment)
+	}
+	for pod, node := range snapshot.Assignment {
+		if podAssignment[pod] != node || replayed[pod] != node {
+			t.Fatalf("Replay placed %s on %s, recorded %s", pod, replayed[pod], node)
+		}
+	}
+}
+
+func TestSnapshotReservations(t *testing.T) {
+	dir := t.TempDir()
+	cluster := &snapshotClient{snapshot: getTestSnapshot(), bound: make(map[string]string, 0)}
+	sched, _ := NewReplayScheduler(cluster.snapshot, "")
+	recorder := NewSnapshotRecorder(dir, cluster, cluster, cluster)
+	sched.client, sched.netmonClient, sched.promClient, sched.recorder = recorder, recorder, recorder, recorder
+	// bw reserved for a pod of another app takes most of n1->n3, the only route front and back could use
+	ledger := bwcontroller.NewMemoryLedgerStore()
+	other := bwcontroller.NewReservationLedger("other")
+	other.Reserve(bwcontroller.Reservation{Pod: "db", Dep: "cache", SrcNode: "n1", DstNode: "n3", Bandwidth: 40})
+	ledger.SaveLedger(other)
+	sched.ledger = recorder.WrapLedgerStore(ledger)
+
+	podAssignment, _, _ := sched.SchedulePods(cluster.snapshot.Pending, cluster.snapshot.PodGraph)
+	if len(podAssignment) != 0 {
+		t.Fatalf("Want no placement with n1->n3 reserved, got %v", podAssignment)
+	}
+	entries, _ := os.ReadDir(dir)
+	snapshot, err := LoadSnapshot(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Reservations["other"]) != 1 || snapshot.Reservations["other"][0].Bandwidth != 40 {
+		t.Fatalf("Want the reservation of other in the snapshot, got %v", snapshot.Reservations)
+	}
+	replayed, _ := ReplaySnapshot(snapshot, "")
+	if len(replayed) != 0 {
+		t.Fatalf("Replay placed %v without the reserved bw", replayed)
+	}
+}
+
+func TestSnapshotHints(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := getTestSnapshot()
+	front := getExplainTestPods(map[string]string{})["front-5d9c8-x2k4"]
+	snapshot.Pending = map[string]Pod{front.Metadata.Name: front}
+	snapshot.PodGraph = map[string]map[string]bool{"front": {}}
+	cluster := &snapshotClient{snapshot: snapshot, bound: make(map[string]string, 0)}
+	sched, _ := NewReplayScheduler(cluster.snapshot, "")
+	recorder := NewSnapshotRecorder(dir, cluster, cluster, cluster)
+	sched.client, sched.netmonClient, sched.promClient, sched.recorder = recorder, recorder, recorder, recorder
+	// n1 has the least cpu left, front only goes there when the controller planned it
+	ledger := bwcontroller.NewMemoryLedgerStore()
+	app := bwcontroller.NewReservationLedger("app")
+	app.Hint(bwcontroller.PlacementHint{Pod: "front", Node: "n1", From: "n2"})
+	ledger.SaveLedger(app)
+	sched.ledger = recorder.WrapLedgerStore(ledger)
+
+	podAssignment, _, _ := sched.SchedulePods(cluster.snapshot.Pending, cluster.snapshot.PodGraph)
+	if podAssignment["front-5d9c8-x2k4"] != "n1" {
+		t.Fatalf("Want front on the hinted n1, got %v", podAssignment)
+	}
+	entries, _ := os.ReadDir(dir)
+	recorded, err := LoadSnapshot(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded.Hints["app"]) != 1 || recorded.Hints["app"][0].Node != "n1" {
+		t.Fatalf("Want the hint of front in the snapshot, got %v", recorded.Hints)
+	}
+	replayed, _ := ReplaySnapshot(recorded, "")
+	if replayed["front-5d9c8-x2k4"] != "n1" {
+		t.Fatalf("Replay placed front on %s, recorded n1", replayed["front-5d9c8-x2k4"])
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bwcontroller "github.gatech.edu/cs-epl/mesh-bw-scheduler/bwcontroller"
+	meshscheduler "github.gatech.edu/cs-epl/mesh-bw-scheduler/meshscheduler"
+	netmon_client "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client"
+)
+
+const (
+	GREEDY_STRATEGY    = "greedy"
+	OPTIMAL_STRATEGY   = "optimal"
+	MAXBW_STRATEGY     = "maxbw"
+	TABU_STRATEGY      = "tabu"
+	ANNEALING_STRATEGY = "annealing"
+)
+
+// Snapshot of the cluster that a placement strategy works on
+type ClusterState struct {
+	nodes         *NodeList
+	nodeMetrics   *NodeMetricsList
+	nodeResources map[string]Resource // node name -> cpu/mem remaining
+	links         netmon_client.LinkSet
+	paths         netmon_client.PathSet
+	traffics      netmon_client.TrafficSet
+	netResources  netmon_client.PathSet              // paths minus traffic
+	linkModel     *netmon_client.LinkModel           // bw left on the links of netResources after the pods placed so far
+	staleNodes    map[string]netmon_client.NodeError // node ip -> why its netmon data is stale or missing
+	netmonFetch   time.Duration                      // how long fetching the netmon stats took
+	reserved      map[string]map[string]float64      // src node name -> dst node name -> bw reserved for placed pods that they do not use yet
+	hints         map[string]map[string]string       // ns -> pod name -> node the controller planned for it when it moved the pod
+	podNetUsages  bwcontroller.PodDeps
+	podReqs       map[string]PodRequirements   // pod name -> network requirements
+	fitFailures   map[string]map[string]string // pod id -> node name -> why the pod does not fit
+	dryRun        bool                         // explain request, nothing is bound or recorded
+}
+
+// why pods do not fit on a node, reported in FailedScheduling events
+const (
+	FIT_INSUFFICIENT_CPU     = "Insufficient cpu"
+	FIT_INSUFFICIENT_MEMORY  = "Insufficient memory"
+	FIT_INSUFFICIENT_SEND_BW = "Insufficient send bandwidth"
+	FIT_INSUFFICIENT_RECV_BW = "Insufficient receive bandwidth"
+	FIT_PREDICATE            = "Neighbor bandwidth predicate failed"
+	FIT_DEPS                 = "Dependency bandwidth or latency not satisfied"
+	FIT_NO_NETMON            = "No netmon data"
+	FIT_STALE_NETMON         = "Stale netmon data"
+)
+
+// the link model of the state, made from netResources the first time it is needed. The bw reserved for pods
+// placed earlier is taken off it, their traffic does not show in netResources yet
+func (state *ClusterState) getLinkModel() *netmon_client.LinkModel {
+	if state.linkModel == nil {
+		state.linkModel = netmon_client.NewLinkModel(state.netResources, nil)
+		for src, dstBws := range state.reserved {
+			for dst, bw := range dstBws {
+				state.linkModel.Reserve(getNodeIp(getNodeWithName(src, state.nodes)), getNodeIp(getNodeWithName(dst, state.nodes)), bw)
+			}
+		}
+	}
+	return state.linkModel
+}
+
+func (state *ClusterState) addFitFailure(podId string, nodeName string, reason string) {
+	if state.fitFailures == nil {
+		state.fitFailures = make(map[string]map[string]string, 0)
+	}
+	if _, exists := state.fitFailures[podId]; !exists {
+		state.fitFailures[podId] = make(map[string]string, 0)
+	}
+	state.fitFailures[podId][nodeName] = reason
+}
+
+// the most common reason the pod did not fit on a node
+func (state *ClusterState) getFitFailureReason(podId string) string {
+	counts := make(map[string]int, 0)
+	for _, r := range state.fitFailures[podId] {
+		counts[r] += 1
+	}
+	reason := "no placement"
+	for r, count := range counts {
+		if count > counts[reason] || (count == counts[reason] && r < reason) {
+			reason = r
+		}
+	}
+	return reason
+}
+
+// summary in the style of the default scheduler, e.g. "0/3 nodes are available: 2 Insufficient cpu, 1 No netmon data."
+func (state *ClusterState) getFitFailureMessage(podId string) string {
+	counts := make(map[string]int, 0)
+	for _, reason := range state.fitFailures[podId] {
+		counts[reason] += 1
+	}
+	reasons := make([]string, 0)
+	for reason, count := range counts {
+		reasons = append(reasons, fmt.Sprintf("%d %s", count, reason))
+	}
+	sort.Strings(reasons)
+	message := fmt.Sprintf("0/%d nodes are available", len(state.nodes.Items))
+	if len(reasons) > 0 {
+		message += ": " + strings.Join(reasons, ", ")
+	}
+	return message + "."
+}
+
+// A PlacementStrategy assigns pending pods to nodes.
+// podOrder contains the pending pods of a pod group in topological order, the returned map is pod id -> node name
+type PlacementStrategy interface {
+	Name() string
+	Place(pods map[string]Pod, podOrder []string, state *ClusterState) map[string]string
+}
+
+type GreedyStrategy struct {
+	sched *DagScheduler
+}
+
+func (strategy *GreedyStrategy) Name() string {
+	return GREEDY_STRATEGY
+}
+
+func (strategy *GreedyStrategy) Place(pods map[string]Pod, podOrder []string, state *ClusterState) map[string]string {
+	return strategy.sched.scheduleGreedy(pods, podOrder, state)
+}
+
+// MeshStrategy runs one of the offline meshscheduler algorithms against the live cluster state
+type MeshStrategy struct {
+	name         string
+	sched        *DagScheduler
+	newScheduler func() meshscheduler.Scheduler
+}
+
+func (strategy *MeshStrategy) Name() string {
+	return strategy.name
+}
+
+func (strategy *MeshStrategy) Place(pods map[string]Pod, podOrder []string, state *ClusterState) map[string]string {
+	podAssignment := make(map[string]string, 0)
+	nodeMap, routeMap, linkMap := MakeMeshTopology(state)
+	app := MakeMeshApplication(pods, podOrder, state.podReqs, strategy.getPlaced(pods, podOrder))
+	logger(fmt.Sprintf("%s: scheduling app %s with %d components on %d nodes", strategy.name, app.AppId, len(app.Components), len(nodeMap)))
+
+	opt := strategy.newScheduler()
+	opt.InitScheduler(nodeMap, routeMap, linkMap)
+	opt.Schedule(app)
+	compAssignment, exists := opt.GetAssignments()[app.AppId]
+	if !exists {
+		logger(fmt.Sprintf("%s: could not find a placement for app %s", strategy.name, app.AppId))
+		return podAssignment
+	}
+	for compId, nodeName := range compAssignment {
+		pod := getPodWithName(compId, pods)
+		if pod.Metadata.Name == "" {
+			logger("pod for " + compId + " does not exist")
+			continue
+		}
+		podAssignment[pod.Metadata.Name] = nodeName
+		_, exists := strategy.sched.deployedApps[pod.Metadata.Namespace]
+		if !exists {
+			strategy.sched.deployedApps[pod.Metadata.Namespace] = make(DeploymentMap, 0)
+		}
+		strategy.sched.deployedApps[pod.Metadata.Namespace][compId] = nodeName
+		logger(fmt.Sprintf("%s: found node %s for pod %s", strategy.name, nodeName, pod.Metadata.Name))
+	}
+	return podAssignment
+}
+
+// nodes of the pods of the namespace of the pending pods that were placed earlier
+func (strategy *MeshStrategy) getPlaced(pods map[string]Pod, podOrder []string) DeploymentMap {
+	placed := make(DeploymentMap, 0)
+	if len(podOrder) == 0 {
+		return placed
+	}
+	namespace := getPodWithName(podOrder[0], pods).Metadata.Namespace
+	for pod, node := range strategy.sched.deployedApps[namespace] {
+		placed[pod] = node
+	}
+	return placed
+}
+
+func NewPlacementStrategy(name string, sched *DagScheduler) (PlacementStrategy, error) {
+	switch name {
+	case "", GREEDY_STRATEGY:
+		return &GreedyStrategy{sched: sched}, nil
+	case OPTIMAL_STRATEGY:
+		return &MeshStrategy{name: name, sched: sched, newScheduler: func() meshscheduler.Scheduler { return meshscheduler.NewOptimalScheduler() }}, nil
+	case MAXBW_STRATEGY:
+		return &MeshStrategy{name: name, sched: sched, newScheduler: func() meshscheduler.Scheduler { return meshscheduler.NewMaxBwScheduler() }}, nil
+	case TABU_STRATEGY:
+		return &MeshStrategy{name: name, sched: sched, newScheduler: func() meshscheduler.Scheduler { return meshscheduler.NewTabuSearchScheduler() }}, nil
+	case ANNEALING_STRATEGY:
+		return &MeshStrategy{name: name, sched: sched, newScheduler: func() meshscheduler.Scheduler { return meshscheduler.NewSimulatedAnnealingScheduler() }}, nil
+	}
+	return nil, errors.New("unknown placement strategy " + name)
+}
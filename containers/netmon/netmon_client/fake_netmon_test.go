@@ -0,0 +1,325 @@
+package netmon_client
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+var FAKE_HOSTS = []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}
+
+// client for the fake netmon of every host, the ip map maps each host to itself
+func newFakeClient(t *testing.T, topo *netmontest.Topology) (*NetmonClient, map[string]string) {
+	fake := netmontest.NewFakeNetmon(topo, FAKE_HOSTS...)
+	client := NewNetmonClientWithOptions(fake.Addresses(), fake.DialOption())
+	t.Cleanup(func() {
+		client.Close()
+		fake.Close()
+	})
+	ipMap := make(map[string]string, 0)
+	for _, host := range FAKE_HOSTS {
+		ipMap[host] = host
+	}
+	return client, ipMap
+}
+
+type pathBw struct {
+	src string
+	dst string
+	bw  float64
+}
+
+func TestGetStatsPathBw(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(topo *netmontest.Topology)
+		want  []pathBw
+	}{
+		{"direct links", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 80, 1)
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 100}, {"10.0.0.1", "10.0.0.3", 80}, {"10.0.0.3", "10.0.0.1", 80}}},
+		{"two hops", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 50, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 100}, {"10.0.0.1", "10.0.0.3", 50}}},
+		{"three hops, bottleneck in the middle", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 30, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.4", 60, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+			topo.SetRoute("10.0.0.1", "10.0.0.4", "10.0.0.2", "10.0.0.3")
+		}, []pathBw{{"10.0.0.1", "10.0.0.3", 30}, {"10.0.0.1", "10.0.0.4", 30}}},
+		{"three hops, bottleneck at the end", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 90, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.4", 20, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+			topo.SetRoute("10.0.0.1", "10.0.0.4", "10.0.0.2", "10.0.0.3")
+		}, []pathBw{{"10.0.0.1", "10.0.0.3", 90}, {"10.0.0.1", "10.0.0.4", 20}}},
+		{"ecmp over disjoint routes", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 50, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.2", 30, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.4", 40, 1)
+			topo.SetBiLink("10.0.0.4", "10.0.0.2", 60, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.2", "10.0.0.3")
+			topo.AddRoute("10.0.0.1", "10.0.0.2", 0.5, "10.0.0.4")
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 70}, {"10.0.0.1", "10.0.0.3", 50}}},
+		{"ecmp over a shared link", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 50, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.2", 30, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.4", 100, 1)
+			topo.SetBiLink("10.0.0.4", "10.0.0.2", 60, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.2", "10.0.0.3")
+			topo.AddRoute("10.0.0.1", "10.0.0.2", 0.5, "10.0.0.3", "10.0.0.4")
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 50}}},
+		{"ecmp over an unknown link", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 50, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.2", 30, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.4", 40, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.2", "10.0.0.3")
+			topo.AddRoute("10.0.0.1", "10.0.0.2", 0.5, "10.0.0.4")
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 30}}},
+		{"asymmetric routes", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 50, 1)
+			topo.SetBiLink("10.0.0.3", "10.0.0.2", 30, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.4", 60, 1)
+			topo.SetBiLink("10.0.0.4", "10.0.0.1", 40, 1)
+			topo.SetRoute("10.0.0.1", "10.0.0.2", "10.0.0.3")
+			topo.SetRoute("10.0.0.2", "10.0.0.1", "10.0.0.4")
+		}, []pathBw{{"10.0.0.1", "10.0.0.2", 30}, {"10.0.0.2", "10.0.0.1", 40}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			test.setup(topo)
+			client, ipMap := newFakeClient(t, topo)
+			_, paths, _, _ := client.GetStats(context.Background(), ipMap, false)
+			for _, want := range test.want {
+				path, exists := paths[want.src][want.dst]
+				if !exists || path.Bandwidth != want.bw {
+					t.Fatalf("Want path %s -> %s with bw %f, got %v", want.src, want.dst, want.bw, path)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStatsRoutes(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.3", 50, 1)
+	topo.SetBiLink("10.0.0.3", "10.0.0.2", 30, 1)
+	topo.SetBiLink("10.0.0.1", "10.0.0.4", 40, 1)
+	topo.SetBiLink("10.0.0.4", "10.0.0.2", 60, 1)
+	topo.SetRoute("10.0.0.1", "10.0.0.2", "10.0.0.3")
+	topo.AddRoute("10.0.0.1", "10.0.0.2", 0.25, "10.0.0.4")
+	client, ipMap := newFakeClient(t, topo)
+	_, paths, _, _ := client.GetStats(context.Background(), ipMap, false)
+
+	want := []Route{{Hops: []string{"10.0.0.1", "10.0.0.3"}, Weight: 0.75, Bandwidth: 30}, {Hops: []string{"10.0.0.1", "10.0.0.4"}, Weight: 0.25, Bandwidth: 40}}
+	if routes := paths["10.0.0.1"]["10.0.0.2"].Routes; !reflect.DeepEqual(routes, want) {
+		t.Fatalf("Want routes %v, got %v", want, routes)
+	}
+	want = []Route{{Hops: []string{"10.0.0.1"}, Weight: 1, Bandwidth: 50}}
+	if routes := paths["10.0.0.1"]["10.0.0.3"].Routes; !reflect.DeepEqual(routes, want) {
+		t.Fatalf("Want the single route %v, got %v", want, routes)
+	}
+}
+
+func TestGetStatsTraffic(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 4)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10, 40)
+	client, ipMap := newFakeClient(t, topo)
+
+	for _, want := range []float64{10, 40, 40} {
+		links, paths, traffics, _ := client.GetStats(context.Background(), ipMap, true)
+		if traffics["10.0.0.1"]["10.0.0.2"].Bytes != want {
+			t.Fatalf("Want traffic %f, got %v", want, traffics["10.0.0.1"]["10.0.0.2"])
+		}
+		if links["10.0.0.1"]["10.0.0.2"].Bandwidth != 100 || paths["10.0.0.1"]["10.0.0.2"].Latency != 4 {
+			t.Fatalf("Got unexpected link %v and path %v", links["10.0.0.1"]["10.0.0.2"], paths["10.0.0.1"]["10.0.0.2"])
+		}
+		topo.Step()
+	}
+	if topo.BwUpdates("10.0.0.1") != 3 {
+		t.Fatalf("Want 3 bw updates, got %d", topo.BwUpdates("10.0.0.1"))
+	}
+}
+
+func TestGetHeadroomStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		request float32
+		traffic []float64
+		want    []float64 // headroom at each step
+	}{
+		{"capped at request", 60, []float64{10}, []float64{60, 60}},
+		{"traffic grows", 60, []float64{10, 70, 100}, []float64{60, 30, 0}},
+		{"not requested", 0, []float64{10}, []float64{0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", test.traffic...)
+			client, ipMap := newFakeClient(t, topo)
+			bwReq := map[string]map[string]float32{"10.0.0.1": {}}
+			if test.request > 0 {
+				bwReq["10.0.0.1"]["10.0.0.2"] = test.request
+			}
+			for step, want := range test.want {
+				_, paths, _, _ := client.GetHeadroomStats(context.Background(), ipMap, bwReq)
+				if paths["10.0.0.1"]["10.0.0.2"].Bandwidth != want {
+					t.Fatalf("Want headroom %f at step %d, got %v", want, step, paths["10.0.0.1"]["10.0.0.2"])
+				}
+				topo.Step()
+			}
+			req, exists := topo.HeadroomRequest("10.0.0.1", "10.0.0.2")
+			if (test.request > 0) != exists || req != float64(test.request) {
+				t.Fatalf("Want headroom request %f, got %f", test.request, req)
+			}
+		})
+	}
+}
+
+func TestGetStatsKernelTraffic(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100000, 1)
+	topo.SetBiLink("10.0.0.2", "10.0.0.3", 100000, 1)
+	topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 8000, 16000)
+	topo.SetTraffic("10.0.0.2", "10.0.0.3", 8000)
+	client, ipMap := newFakeClient(t, topo)
+
+	tests := []struct {
+		src     string
+		dst     string
+		bytes   float64 // traffic along the paths that end with the link
+		kBytes  uint64
+		packets uint64
+		rate    float64
+	}{
+		{"10.0.0.1", "10.0.0.2", 8000, 10000, 10, 8000},
+		{"10.0.0.1", "10.0.0.2", 16000, 30000, 30, 16000},
+		// the path 10.0.0.1 -> 10.0.0.3 adds to the traffic of the last link, the XDP counts are the link's own
+		{"10.0.0.2", "10.0.0.3", 24000, 20000, 20, 8000},
+	}
+	for i, test := range tests {
+		if i == 1 {
+			topo.Step()
+		}
+		_, _, traffics, _ := client.GetStats(context.Background(), ipMap, false)
+		traffic := traffics[test.src][test.dst]
+		if traffic.Bytes != test.bytes || traffic.KernelBytes != test.kBytes || traffic.KernelPackets != test.packets {
+			t.Fatalf("%d: want %f used, %d bytes and %d packets counted, got %v", i, test.bytes, test.kBytes, test.packets, traffic)
+		}
+		want := []TrafficRate{{Window: netmontest.STEP_SECONDS * time.Second, BitRate: test.rate, PacketRate: test.rate / 8 / netmontest.PACKET_SIZE}}
+		if !reflect.DeepEqual(traffic.KernelRates, want) {
+			t.Fatalf("%d: want rates %v, got %v", i, want, traffic.KernelRates)
+		}
+		// the sender's egress hook counts the same packets
+		if traffic.KernelSentBytes != test.kBytes || traffic.KernelSentPackets != test.packets || !reflect.DeepEqual(traffic.KernelSentRates, want) {
+			t.Fatalf("%d: want the sent counts to match the received ones, got %v", i, traffic)
+		}
+	}
+}
+
+func TestGetStatsBandwidthEstimate(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetEstimate("10.0.0.1", "10.0.0.2", "train", 0.8, 1.25)
+	client, ipMap := newFakeClient(t, topo)
+
+	links, _, _, _ := client.GetStats(context.Background(), ipMap, false)
+	tests := []struct {
+		src  string
+		dst  string
+		want Link
+	}{
+		{"10.0.0.1", "10.0.0.2", Link{Source: "10.0.0.1", Destination: "10.0.0.2", Latency: 1, Bandwidth: 100, BandwidthLow: 80, BandwidthHigh: 125, Estimator: "train"}},
+		// no interval from netmon, the bw is taken as exact
+		{"10.0.0.2", "10.0.0.1", Link{Source: "10.0.0.2", Destination: "10.0.0.1", Latency: 1, Bandwidth: 100, BandwidthLow: 100, BandwidthHigh: 100}},
+	}
+	for _, test := range tests {
+		if link := links[test.src][test.dst]; link != test.want {
+			t.Fatalf("Want %v, got %v", test.want, link)
+		}
+	}
+}
+
+func TestGetStatsBwAggregate(t *testing.T) {
+	tests := []struct {
+		aggregate string
+		want      float64
+	}{
+		{"last", 70},
+		{"min", 40},
+		{"p5", 40},
+		{"p50", 70},
+		{"p95", 100},
+		{"ewma", 0.3*70 + 0.7*(0.3*40+0.7*100)},
+	}
+	for _, test := range tests {
+		t.Run(test.aggregate, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			for _, bw := range []float64{100, 40, 70} {
+				topo.SetLink("10.0.0.1", "10.0.0.2", bw, 1)
+			}
+			client, ipMap := newFakeClient(t, topo)
+			if err := client.SetBwAggregate(test.aggregate, time.Minute); err != nil {
+				t.Fatalf("%v", err)
+			}
+			links, _, _, _ := client.GetStats(context.Background(), ipMap, false)
+			if bw := links["10.0.0.1"]["10.0.0.2"].Bandwidth; math.Abs(bw-test.want) > 1e-3 {
+				t.Fatalf("Want %f, got %f", test.want, bw)
+			}
+			watch := client.Watch(context.Background(), ipMap)
+			defer watch.Close()
+			waitForWatch(t, watch, "the aggregated bw", func() bool {
+				links, _, _, _ := watch.Current()
+				return math.Abs(links["10.0.0.1"]["10.0.0.2"].Bandwidth-test.want) <= 1e-3
+			})
+		})
+	}
+	client, _ := newFakeClient(t, netmontest.NewTopology())
+	if err := client.SetBwAggregate("p99", 0); err == nil {
+		t.Fatalf("Want an error for an unknown aggregate")
+	}
+}
+
+func TestGetStatsBwLowerBound(t *testing.T) {
+	tests := []struct {
+		lowerBound bool
+		want       []pathBw
+	}{
+		{false, []pathBw{{"10.0.0.1", "10.0.0.2", 100}, {"10.0.0.1", "10.0.0.3", 50}, {"10.0.0.2", "10.0.0.1", 100}}},
+		// 2 to 1 has no interval and keeps its bw
+		{true, []pathBw{{"10.0.0.1", "10.0.0.2", 80}, {"10.0.0.1", "10.0.0.3", 25}, {"10.0.0.2", "10.0.0.1", 100}}},
+	}
+	for _, test := range tests {
+		topo := netmontest.NewTopology()
+		topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+		topo.SetBiLink("10.0.0.2", "10.0.0.3", 50, 1)
+		topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+		topo.SetEstimate("10.0.0.1", "10.0.0.2", "train", 0.8, 1.25)
+		topo.SetEstimate("10.0.0.2", "10.0.0.3", "train", 0.5, 1.5)
+		client, ipMap := newFakeClient(t, topo)
+		client.SetBwLowerBound(test.lowerBound)
+
+		links, paths, _, _ := client.GetStats(context.Background(), ipMap, false)
+		if link := links["10.0.0.1"]["10.0.0.2"]; link.Bandwidth != test.want[0].bw || link.BandwidthLow != 80 || link.BandwidthHigh != 125 {
+			t.Fatalf("Want the link from 1 to 2 at %f within 80 to 125, got %v", test.want[0].bw, link)
+		}
+		for _, want := range test.want {
+			if bw := paths[want.src][want.dst].Bandwidth; math.Abs(bw-want.bw) > 1e-3 {
+				t.Fatalf("Want %f from %s to %s with the lower bound %v, got %f", want.bw, want.src, want.dst, test.lowerBound, bw)
+			}
+		}
+	}
+}
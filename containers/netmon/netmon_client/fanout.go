@@ -0,0 +1,148 @@
+package netmon_client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// nodes asked at the same time by GetStats and GetHeadroomStats
+const FETCH_WORKERS = 16
+
+// number of slowest nodes logged after every fetch
+const SLOW_NODES_LOGGED = 3
+
+// reply of one node, links, paths, traffic and flows are nil if there was none
+type nodeStats struct {
+	address  string
+	links    LinkSet
+	paths    PathSet
+	traffic  TrafficSet
+	flows    []Flow
+	qos      *QosStatus
+	err      *NodeError
+	duration time.Duration
+}
+
+type nodeFetch func(ctx context.Context, address string) nodeStats
+
+// runs fetch for every node on at most workers goroutines, the results are in the order of the addresses
+func (netmonClient *NetmonClient) fetchAll(ctx context.Context, fetch nodeFetch) []nodeStats {
+	startTime := time.Now()
+	addresses := netmonClient.addresses
+	results := make([]nodeStats, len(addresses))
+	workers := netmonClient.workers
+	if workers > len(addresses) {
+		workers = len(addresses)
+	}
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				nodeStart := time.Now()
+				result := fetch(ctx, addresses[i])
+				result.address = addresses[i]
+				result.duration = time.Since(nodeStart)
+				results[i] = result
+			}
+		}()
+	}
+	for i := range addresses {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	netmonClient.recordTimings(results, time.Since(startTime))
+	return results
+}
+
+// keeps the time each node took in its health and logs the slowest ones
+func (netmonClient *NetmonClient) recordTimings(results []nodeStats, total time.Duration) {
+	netmonClient.lock.Lock()
+	for _, result := range results {
+		health := netmonClient.health[result.address]
+		health.LastDuration = result.duration
+		netmonClient.health[result.address] = health
+	}
+	netmonClient.lock.Unlock()
+
+	slowest := make([]nodeStats, len(results))
+	copy(slowest, results)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].duration > slowest[j].duration })
+	msg := fmt.Sprintf("fetched %d nodes in %v, slowest:", len(results), total)
+	for i := 0; i < len(slowest) && i < SLOW_NODES_LOGGED; i++ {
+		msg += fmt.Sprintf(" %s %v", getHost(slowest[i].address), slowest[i].duration)
+	}
+	logger(msg)
+}
+
+// both ends report on the traffic between them, the receiver the used bw and its XDP counts and the
+// sender what its egress hook counted. What one end did not report is taken from the other
+func mergeTraffic(traffic Traffic, other Traffic) Traffic {
+	if traffic.Bytes == 0 {
+		traffic.Bytes = other.Bytes
+	}
+	if traffic.KernelRates == nil {
+		traffic.KernelPackets, traffic.KernelBytes, traffic.KernelRates = other.KernelPackets, other.KernelBytes, other.KernelRates
+	}
+	if traffic.KernelSentRates == nil {
+		traffic.KernelSentPackets, traffic.KernelSentBytes, traffic.KernelSentRates = other.KernelSentPackets, other.KernelSentBytes, other.KernelSentRates
+	}
+	return traffic
+}
+
+// merges the replies in the order of the results, so the same replies always give the same sets
+func mergeStats(results []nodeStats) (LinkSet, PathSet, TrafficSet, []NodeError) {
+	nodeErrors := make([]NodeError, 0)
+	links := make(LinkSet, 0)
+	paths := make(PathSet, 0)
+	traffics := make(TrafficSet, 0)
+	for _, result := range results {
+		if result.err != nil {
+			logger(result.err.Error())
+			nodeErrors = append(nodeErrors, *result.err)
+		}
+		if result.links == nil || result.paths == nil {
+			continue
+		}
+		host := getHost(result.address)
+		srcPaths, existsP := result.paths[host]
+		srcLinks, existsL := result.links[host]
+		if !existsP || !existsL {
+			logger("No links or paths from " + host)
+			continue
+		}
+		_, exists := links[host]
+		if !exists {
+			links[host] = make(map[string]Link, 0)
+		}
+		_, exists = paths[host]
+		if !exists {
+			paths[host] = make(map[string]Path, 0)
+		}
+		for src, tSrcInfo := range result.traffic {
+			for dst, traffic := range tSrcInfo {
+				_, exists := traffics[dst]
+				if !exists {
+					traffics[dst] = make(map[string]Traffic, 0)
+				}
+				if other, exists := traffics[dst][src]; exists {
+					traffic = mergeTraffic(other, traffic)
+				}
+				traffics[dst][src] = traffic
+			}
+		}
+		for dst, path := range srcPaths {
+			paths[host][dst] = path
+		}
+		for dst, link := range srcLinks {
+			links[host][dst] = link
+		}
+	}
+	return links, paths, traffics, nodeErrors
+}
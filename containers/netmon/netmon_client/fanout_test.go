@@ -0,0 +1,108 @@
+package netmon_client
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+func getFanoutTopology() *netmontest.Topology {
+	topo := netmontest.NewTopology()
+	for i, src := range FAKE_HOSTS {
+		for _, dst := range FAKE_HOSTS[i+1:] {
+			topo.SetBiLink(src, dst, 100, 1)
+		}
+	}
+	return topo
+}
+
+func TestFetchWorkers(t *testing.T) {
+	delay := 300 * time.Millisecond
+	tests := []struct {
+		name    string
+		workers int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"one at a time", 1, 4 * delay, 8 * delay},
+		{"two at a time", 2, 2 * delay, 4*delay - delay/2},
+		{"all at once", FETCH_WORKERS, delay, 2*delay - delay/4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := getFanoutTopology()
+			for _, host := range FAKE_HOSTS {
+				topo.SetDelay(host, delay)
+			}
+			client, ipMap := newFakeClient(t, topo)
+			client.workers = test.workers
+			startTime := time.Now()
+			_, paths, _, nodeErrors := client.GetStats(context.Background(), ipMap, false)
+			elapsed := time.Since(startTime)
+			if elapsed < test.min || elapsed > test.max {
+				t.Fatalf("Want GetStats to take %v to %v, took %v", test.min, test.max, elapsed)
+			}
+			if len(nodeErrors) != 0 || len(paths) != len(FAKE_HOSTS) {
+				t.Fatalf("Want paths from every node, got %d and errors %v", len(paths), nodeErrors)
+			}
+		})
+	}
+}
+
+func TestFetchDeadline(t *testing.T) {
+	topo := getFanoutTopology()
+	topo.SetDelay("10.0.0.2", time.Hour)
+	client, ipMap := newFakeClient(t, topo)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	_, paths, _, nodeErrors := client.GetStats(ctx, ipMap, false)
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Fatalf("GetStats did not stop at the deadline, took %v", elapsed)
+	}
+	if len(nodeErrors) != 1 || nodeErrors[0].Host != "10.0.0.2" || !strings.HasPrefix(nodeErrors[0].Message, "cancelled") {
+		t.Fatalf("Want 10.0.0.2 cancelled, got %v", nodeErrors)
+	}
+	if _, exists := paths["10.0.0.2"]; exists || len(paths) != len(FAKE_HOSTS)-1 {
+		t.Fatalf("Want paths from every node but 10.0.0.2, got %v", paths)
+	}
+	health := client.Health()
+	// the deadline is the caller's, not the node's fault
+	if !health["10.0.0.2:"+netmontest.PORT].Healthy() {
+		t.Fatalf("Want 10.0.0.2 healthy after a cancelled call, got %v", health["10.0.0.2:"+netmontest.PORT])
+	}
+	if health["10.0.0.2:"+netmontest.PORT].LastDuration < 150*time.Millisecond || health["10.0.0.1:"+netmontest.PORT].LastDuration > 150*time.Millisecond {
+		t.Fatalf("Got unexpected node timings %v", health)
+	}
+}
+
+func TestFetchDeterministic(t *testing.T) {
+	topo := getFanoutTopology()
+	topo.SetDown("10.0.0.2", true)
+	topo.SetDown("10.0.0.4", true)
+	client, ipMap := newFakeClient(t, topo)
+	client.retryBackoff, client.nodeBackoff = time.Millisecond, 0
+
+	var firstLinks LinkSet
+	var firstPaths PathSet
+	for i := 0; i < 5; i++ {
+		// a different node is the slowest every round, so the replies arrive in a different order
+		for j, host := range FAKE_HOSTS {
+			topo.SetDelay(host, time.Duration((i+j)%len(FAKE_HOSTS))*10*time.Millisecond)
+		}
+		links, paths, _, nodeErrors := client.GetStats(context.Background(), ipMap, false)
+		if len(nodeErrors) != 2 || nodeErrors[0].Host != "10.0.0.2" || nodeErrors[1].Host != "10.0.0.4" {
+			t.Fatalf("Want errors in address order, got %v", nodeErrors)
+		}
+		if i == 0 {
+			firstLinks, firstPaths = links, paths
+		} else if !reflect.DeepEqual(links, firstLinks) || !reflect.DeepEqual(paths, firstPaths) {
+			t.Fatalf("Round %d merged to different stats", i)
+		}
+	}
+}
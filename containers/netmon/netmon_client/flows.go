@@ -0,0 +1,78 @@
+package netmon_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// flows every node counted from or to hosts, e.g. pod ips, every flow if hosts is empty. Flows are not
+// cached, the nodes that could not be reached are left out and listed in the errors
+func (netmonClient *NetmonClient) GetFlows(ctx context.Context, hosts []string) ([]Flow, []NodeError) {
+	results := netmonClient.fetchAll(ctx, func(ctx context.Context, address string) nodeStats {
+		response, nodeErr := netmonClient.callNode(ctx, address, make(map[string]cachedReply, 0), 15*time.Second, func(ctx context.Context, client pb.NetMonitorClient) (*pb.NetInfoReply, error) {
+			return client.GetFlowInfo(ctx, &pb.FlowInfoRequest{Hosts: hosts})
+		})
+		if response == nil {
+			return nodeStats{err: nodeErr}
+		}
+		return nodeStats{flows: getFlows(response, getHost(address)), err: nodeErr}
+	})
+	flows := make([]Flow, 0)
+	nodeErrors := make([]NodeError, 0)
+	for _, result := range results {
+		if result.err != nil {
+			logger(result.err.Error())
+			nodeErrors = append(nodeErrors, *result.err)
+		}
+		flows = append(flows, result.flows...)
+	}
+	logger(fmt.Sprintf("Got %d flows for %d hosts", len(flows), len(hosts)))
+	return flows, nodeErrors
+}
+
+func getFlows(response *pb.NetInfoReply, node string) []Flow {
+	flows := make([]Flow, 0)
+	for _, flowInfo := range response.FlowInfo {
+		flows = append(flows, Flow{Node: node, Source: flowInfo.Src, Destination: flowInfo.Dst, DstPort: int(flowInfo.DstPort), Protocol: int(flowInfo.Protocol),
+			Egress: flowInfo.Egress, Packets: flowInfo.Packets, Bytes: flowInfo.Bytes, Rates: getRates(flowInfo.Rates)})
+	}
+	return flows
+}
+
+// bit rate over window between every pair of ips, source -> destination -> rate. A flow is seen by the
+// egress hook of the sender and by XDP at the receiver, the egress counts of a pair are used if there
+// are any so nothing is counted twice. Flows without a rate over window are left out
+func PodPairRates(flows []Flow, window time.Duration) map[string]map[string]float64 {
+	sent := make(map[string]map[string]float64, 0)
+	received := make(map[string]map[string]float64, 0)
+	for _, flow := range flows {
+		rates := received
+		if flow.Egress {
+			rates = sent
+		}
+		for _, rate := range flow.Rates {
+			if rate.Window != window {
+				continue
+			}
+			if _, exists := rates[flow.Source]; !exists {
+				rates[flow.Source] = make(map[string]float64, 0)
+			}
+			rates[flow.Source][flow.Destination] += rate.BitRate
+		}
+	}
+	for src, dsts := range received {
+		for dst, rate := range dsts {
+			if _, exists := sent[src][dst]; exists {
+				continue
+			}
+			if _, exists := sent[src]; !exists {
+				sent[src] = make(map[string]float64, 0)
+			}
+			sent[src][dst] = rate
+		}
+	}
+	return sent
+}
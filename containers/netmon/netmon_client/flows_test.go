@@ -0,0 +1,70 @@
+package netmon_client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+func TestGetFlows(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetFlow("10.0.0.1", "10.0.0.2", "10.244.1.5", "10.244.2.7", 8080, 8000)
+	topo.SetFlow("10.0.0.3", "10.0.0.2", "10.244.3.2", "10.244.2.7", 5432, 16000)
+	topo.SetFlow("10.0.0.3", "10.0.0.4", "10.244.3.2", "10.244.4.9", 80, 24000)
+	client, _ := newFakeClient(t, topo)
+	client.retryBackoff = time.Millisecond
+	window := netmontest.STEP_SECONDS * time.Second
+
+	flows, nodeErrors := client.GetFlows(context.Background(), []string{"10.244.2.7"})
+	if len(nodeErrors) != 0 {
+		t.Fatalf("Want no errors, got %v", nodeErrors)
+	}
+	// every flow is seen by the egress hook of the sender and by XDP at the receiver
+	if len(flows) != 4 {
+		t.Fatalf("Want 4 flows to or from 10.244.2.7, got %v", flows)
+	}
+	for _, flow := range flows {
+		wantNode := "10.0.0.2"
+		if flow.Egress {
+			wantNode = map[string]string{"10.244.1.5": "10.0.0.1", "10.244.3.2": "10.0.0.3"}[flow.Source]
+		}
+		if flow.Node != wantNode || flow.Destination != "10.244.2.7" || flow.Protocol != 6 {
+			t.Fatalf("Want the flow counted on %s, got %v", wantNode, flow)
+		}
+	}
+
+	tests := []struct {
+		name string
+		down string
+		want map[string]map[string]float64
+	}{
+		{"all up", "", map[string]map[string]float64{"10.244.1.5": {"10.244.2.7": 8000}, "10.244.3.2": {"10.244.2.7": 16000, "10.244.4.9": 24000}}},
+		// the receiver's counts are used while the sender is down
+		{"sender down", "10.0.0.3", map[string]map[string]float64{"10.244.1.5": {"10.244.2.7": 8000}, "10.244.3.2": {"10.244.2.7": 16000, "10.244.4.9": 24000}}},
+		{"receiver down", "10.0.0.2", map[string]map[string]float64{"10.244.1.5": {"10.244.2.7": 8000}, "10.244.3.2": {"10.244.2.7": 16000, "10.244.4.9": 24000}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.down != "" {
+				topo.SetDown(test.down, true)
+				defer topo.SetDown(test.down, false)
+			}
+			// a fresh client, the last one still has the node it saw down marked as down
+			client, _ := newFakeClient(t, topo)
+			client.retryBackoff = time.Millisecond
+			flows, nodeErrors := client.GetFlows(context.Background(), nil)
+			if test.down != "" && (len(nodeErrors) != 1 || nodeErrors[0].Host != test.down) {
+				t.Fatalf("Want an error for %s, got %v", test.down, nodeErrors)
+			}
+			if rates := PodPairRates(flows, window); !reflect.DeepEqual(rates, test.want) {
+				t.Fatalf("Want rates %v, got %v", test.want, rates)
+			}
+		})
+	}
+	if rates := PodPairRates(flows, time.Minute); len(rates) != 0 {
+		t.Fatalf("Want no rates over a window that is not measured, got %v", rates)
+	}
+}
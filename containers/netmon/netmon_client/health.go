@@ -0,0 +1,157 @@
+package netmon_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// attempts of one rpc before the node counts as failed
+const RPC_ATTEMPTS = 3
+
+// wait before the first retry of an rpc, doubled for every retry after that
+const RETRY_BACKOFF = 200 * time.Millisecond
+
+// a node that failed is not asked again before its backoff is over, the backoff doubles
+// with every failed round up to NODE_BACKOFF_MAX
+const NODE_BACKOFF = 5 * time.Second
+
+const NODE_BACKOFF_MAX = 5 * time.Minute
+
+// Health of the netmon daemon of one node
+type NodeHealth struct {
+	Address      string
+	Failures     int // failed rounds in a row, 0 if the last round succeeded
+	LastError    string
+	LastSuccess  time.Time
+	RetryAt      time.Time     // the node is not asked before this
+	LastDuration time.Duration // time the node took in the last fetch, retries included
+}
+
+func (health NodeHealth) Healthy() bool {
+	return health.Failures == 0
+}
+
+// NodeError says why the stats of a node are missing or stale. If Cached is set the last good
+// reply of the node was used instead, Age is how old it is
+type NodeError struct {
+	Host    string        `json:"host"`
+	Message string        `json:"message"`
+	Cached  bool          `json:"cached"`
+	Age     time.Duration `json:"age"`
+}
+
+func (err NodeError) Error() string {
+	if err.Cached {
+		return fmt.Sprintf("netmon %s: %s, using data from %v ago", err.Host, err.Message, err.Age.Round(time.Second))
+	}
+	return fmt.Sprintf("netmon %s: %s, no data", err.Host, err.Message)
+}
+
+type cachedReply struct {
+	reply *pb.NetInfoReply
+	time  time.Time
+}
+
+type nodeRpc func(ctx context.Context, client pb.NetMonitorClient) (*pb.NetInfoReply, error)
+
+// calls rpc on the node at address, retrying with exponential backoff. If the node is in backoff or
+// every attempt fails, the last good reply in cache is returned together with the error.
+// Each attempt is limited by timeout and ctx, a node is not counted as failed when ctx is done
+func (netmonClient *NetmonClient) callNode(ctx context.Context, address string, cache map[string]cachedReply, timeout time.Duration, rpc nodeRpc) (*pb.NetInfoReply, *NodeError) {
+	host := getHost(address)
+	client, exists := netmonClient.clients[address]
+	netmonClient.lock.Lock()
+	health := netmonClient.health[address]
+	netmonClient.lock.Unlock()
+	if !exists {
+		return netmonClient.getCached(address, cache, "not connected: "+health.LastError)
+	}
+	if time.Now().Before(health.RetryAt) {
+		return netmonClient.getCached(address, cache, fmt.Sprintf("down after %d failures (%s)", health.Failures, health.LastError))
+	}
+
+	var response *pb.NetInfoReply
+	var err error
+	backoff := netmonClient.retryBackoff
+	for attempt := 0; attempt < netmonClient.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+			}
+			backoff *= 2
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		response, err = rpc(callCtx, client)
+		cancel()
+		if err == nil {
+			break
+		}
+		logger(fmt.Sprintf("netmon %s attempt %d failed: %v", host, attempt+1, err))
+	}
+
+	if err != nil && ctx.Err() != nil {
+		return netmonClient.getCached(address, cache, "cancelled: "+err.Error())
+	}
+	netmonClient.lock.Lock()
+	defer netmonClient.lock.Unlock()
+	health = netmonClient.health[address]
+	health.Address = address
+	if err == nil {
+		health.Failures = 0
+		health.LastError = ""
+		health.LastSuccess = time.Now()
+		health.RetryAt = time.Time{}
+		netmonClient.health[address] = health
+		cache[address] = cachedReply{reply: response, time: health.LastSuccess}
+		return response, nil
+	}
+	health.Failures += 1
+	health.LastError = err.Error()
+	nodeBackoff := netmonClient.nodeBackoff
+	for i := 1; i < health.Failures && nodeBackoff < netmonClient.nodeBackoffMax; i++ {
+		nodeBackoff *= 2
+	}
+	if nodeBackoff > netmonClient.nodeBackoffMax {
+		nodeBackoff = netmonClient.nodeBackoffMax
+	}
+	health.RetryAt = time.Now().Add(nodeBackoff)
+	netmonClient.health[address] = health
+	logger(fmt.Sprintf("netmon %s failed %d times, next attempt in %v", host, health.Failures, nodeBackoff))
+	return netmonClient.getCachedLocked(address, cache, err.Error())
+}
+
+func (netmonClient *NetmonClient) getCached(address string, cache map[string]cachedReply, message string) (*pb.NetInfoReply, *NodeError) {
+	netmonClient.lock.Lock()
+	defer netmonClient.lock.Unlock()
+	return netmonClient.getCachedLocked(address, cache, message)
+}
+
+func (netmonClient *NetmonClient) getCachedLocked(address string, cache map[string]cachedReply, message string) (*pb.NetInfoReply, *NodeError) {
+	nodeErr := &NodeError{Host: getHost(address), Message: message}
+	cached, exists := cache[address]
+	if !exists {
+		return nil, nodeErr
+	}
+	nodeErr.Cached = true
+	nodeErr.Age = time.Since(cached.time)
+	return cached.reply, nodeErr
+}
+
+// health of every node, keyed by address
+func (netmonClient *NetmonClient) Health() map[string]NodeHealth {
+	netmonClient.lock.Lock()
+	defer netmonClient.lock.Unlock()
+	health := make(map[string]NodeHealth, 0)
+	for address, h := range netmonClient.health {
+		health[address] = h
+	}
+	return health
+}
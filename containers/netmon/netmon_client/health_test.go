@@ -0,0 +1,98 @@
+package netmon_client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+// one GetStats round with 10.0.0.2 up or down
+type healthRound struct {
+	down      bool
+	wantCalls int  // calls that reach the netmon of 10.0.0.2 in this round
+	wantErr   bool // 10.0.0.2 is in the errors
+	wantData  bool // paths from 10.0.0.2 are returned, fresh or cached
+}
+
+func TestUnreachableNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeBackoff time.Duration
+		rounds      []healthRound
+	}{
+		{"down from the start", 0, []healthRound{
+			{true, RPC_ATTEMPTS, true, false},
+		}},
+		{"cached after a good round", 0, []healthRound{
+			{false, 1, false, true},
+			{true, RPC_ATTEMPTS, true, true},
+		}},
+		{"not asked during backoff", time.Hour, []healthRound{
+			{false, 1, false, true},
+			{true, RPC_ATTEMPTS, true, true},
+			{false, 0, true, true},
+		}},
+		{"recovers", 0, []healthRound{
+			{true, RPC_ATTEMPTS, true, false},
+			{false, 1, false, true},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.1", "10.0.0.3", 100, 1)
+			client, ipMap := newFakeClient(t, topo)
+			client.retryBackoff, client.nodeBackoff = time.Millisecond, test.nodeBackoff
+
+			for i, round := range test.rounds {
+				topo.SetDown("10.0.0.2", round.down)
+				calls := topo.Calls("10.0.0.2")
+				_, paths, _, nodeErrors := client.GetStats(context.Background(), ipMap, false)
+				if topo.Calls("10.0.0.2")-calls != round.wantCalls {
+					t.Fatalf("round %d: want %d calls, got %d", i, round.wantCalls, topo.Calls("10.0.0.2")-calls)
+				}
+				if round.wantErr != (len(nodeErrors) == 1) || (round.wantErr && nodeErrors[0].Host != "10.0.0.2") {
+					t.Fatalf("round %d: want error %v, got %v", i, round.wantErr, nodeErrors)
+				}
+				if round.wantErr && nodeErrors[0].Cached != round.wantData {
+					t.Fatalf("round %d: want cached %v, got %v", i, round.wantData, nodeErrors[0])
+				}
+				if _, exists := paths["10.0.0.2"]["10.0.0.1"]; exists != round.wantData {
+					t.Fatalf("round %d: want paths from 10.0.0.2 %v, got %v", i, round.wantData, paths["10.0.0.2"])
+				}
+				// the other nodes are not affected
+				if paths["10.0.0.1"]["10.0.0.3"].Bandwidth != 100 {
+					t.Fatalf("round %d: missing path 10.0.0.1 -> 10.0.0.3, got %v", i, paths["10.0.0.1"])
+				}
+				health := client.Health()["10.0.0.2:"+netmontest.PORT]
+				if health.Healthy() == round.wantErr {
+					t.Fatalf("round %d: want healthy %v, got %v", i, !round.wantErr, health)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeBackoff(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetDown("10.0.0.1", true)
+	client, ipMap := newFakeClient(t, topo)
+	client.retryBackoff, client.nodeBackoff, client.nodeBackoffMax = time.Millisecond, time.Second, 4*time.Second
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		client.GetHeadroomStats(context.Background(), ipMap, map[string]map[string]float32{})
+		health := client.Health()["10.0.0.1:"+netmontest.PORT]
+		backoff := time.Until(health.RetryAt)
+		if backoff > want || backoff < want-time.Second/2 {
+			t.Fatalf("Want backoff %v after %d failures, got %v", want, health.Failures, backoff)
+		}
+		// skip the wait
+		client.lock.Lock()
+		health.RetryAt = time.Time{}
+		client.health["10.0.0.1:"+netmontest.PORT] = health
+		client.lock.Unlock()
+	}
+}
@@ -0,0 +1,199 @@
+package netmon_client
+
+import (
+	"math"
+)
+
+// LinkModel is the bw left on the links between nodes. A path goes over the links of its hops and bw
+// reserved on it is taken off each of them, so paths that share a link share its bw. The links are the
+// paths between neighbors (no hop in between). A path with a hop that has no such path is a link of its own
+type LinkModel struct {
+	paths    PathSet
+	names    map[string]string             // hop ip -> key of paths, nil if paths are keyed by ip
+	free     map[string]map[string]float64 // src -> dst -> bw left on the link
+	pathFree map[string]map[string]float64 // src -> dst -> bw left on the path itself
+}
+
+type linkKey struct {
+	src string
+	dst string
+}
+
+// one of the link chains traffic of a path takes, with its share of the traffic
+type linkChain struct {
+	links  []linkKey
+	weight float64
+}
+
+func NewLinkModel(paths PathSet, names map[string]string) *LinkModel {
+	model := &LinkModel{paths: paths, names: names,
+		free: make(map[string]map[string]float64, 0), pathFree: make(map[string]map[string]float64, 0)}
+	for src, dstPaths := range paths {
+		model.pathFree[src] = make(map[string]float64, 0)
+		for dst, path := range dstPaths {
+			model.pathFree[src][dst] = path.Bandwidth
+			if model.isLink(src, dst) {
+				model.setFree(linkKey{src, dst}, path.Bandwidth)
+			}
+		}
+	}
+	// paths that do not go over links are links of their own
+	for src, dstPaths := range paths {
+		for dst, path := range dstPaths {
+			if _, complete := model.pathLinks(src, dst, path.Hops); !complete {
+				model.setFree(linkKey{src, dst}, path.Bandwidth)
+			}
+		}
+	}
+	return model
+}
+
+func (model *LinkModel) setFree(link linkKey, bw float64) {
+	if _, exists := model.free[link.src]; !exists {
+		model.free[link.src] = make(map[string]float64, 0)
+	}
+	model.free[link.src][link.dst] = bw
+}
+
+// whether the path from src to dst has no hop in between
+func (model *LinkModel) isLink(src string, dst string) bool {
+	path, exists := model.paths[src][dst]
+	return exists && len(path.Hops) <= 1 && src != dst
+}
+
+func (model *LinkModel) hopKey(hop string) string {
+	if model.names == nil {
+		return hop
+	}
+	if key, exists := model.names[hop]; exists {
+		return key
+	}
+	return hop
+}
+
+// links from src over hops to dst, false if a hop is not a link. hops start with the source and do not include the destination
+func (model *LinkModel) pathLinks(src string, dst string, hops []string) ([]linkKey, bool) {
+	nodes := []string{src}
+	for i := 1; i < len(hops); i++ {
+		nodes = append(nodes, model.hopKey(hops[i]))
+	}
+	nodes = append(nodes, dst)
+	links := make([]linkKey, 0)
+	for i := 0; i < len(nodes)-1; i++ {
+		if !model.isLink(nodes[i], nodes[i+1]) {
+			return links, false
+		}
+		links = append(links, linkKey{nodes[i], nodes[i+1]})
+	}
+	return links, true
+}
+
+// the link chains of the path from src to dst. Every route of a multipath path if they all go over links,
+// else the route it took last, or the path as a link of its own
+func (model *LinkModel) chains(src string, dst string) []linkChain {
+	path, exists := model.paths[src][dst]
+	if !exists {
+		return nil
+	}
+	if len(path.Routes) > 1 {
+		chains := make([]linkChain, 0)
+		total := 0.0
+		for _, route := range path.Routes {
+			links, complete := model.pathLinks(src, dst, route.Hops)
+			if !complete {
+				chains = nil
+				break
+			}
+			chains = append(chains, linkChain{links: links, weight: route.Weight})
+			total += route.Weight
+		}
+		if chains != nil {
+			// without weights the traffic is split equally
+			for i := range chains {
+				chains[i].weight = 1.0 / float64(len(chains))
+				if total > 0 {
+					chains[i].weight = path.Routes[i].Weight / total
+				}
+			}
+			return chains
+		}
+	}
+	if links, complete := model.pathLinks(src, dst, path.Hops); complete {
+		return []linkChain{linkChain{links: links, weight: 1}}
+	}
+	return []linkChain{linkChain{links: []linkKey{linkKey{src, dst}}, weight: 1}}
+}
+
+// bw left from src to dst, the narrowest link of the path or the max flow over its routes. 0 if there is no path
+func (model *LinkModel) Available(src string, dst string) float64 {
+	chains := model.chains(src, dst)
+	if len(chains) == 0 {
+		return 0
+	}
+	bw := model.pathFree[src][dst]
+	if len(chains) == 1 {
+		for _, link := range chains[0].links {
+			bw = math.Min(bw, model.free[link.src][link.dst])
+		}
+		return math.Max(0, bw)
+	}
+	capacity := make(map[string]map[string]float64, 0)
+	for _, chain := range chains {
+		for _, link := range chain.links {
+			if _, exists := capacity[link.src]; !exists {
+				capacity[link.src] = make(map[string]float64, 0)
+			}
+			capacity[link.src][link.dst] = math.Max(0, model.free[link.src][link.dst])
+		}
+	}
+	return math.Max(0, math.Min(bw, maxFlow(capacity, src, dst)))
+}
+
+// takes bw off the path from src to dst and off every link it goes over, spread over the routes by their weights
+func (model *LinkModel) Reserve(src string, dst string, bw float64) {
+	chains := model.chains(src, dst)
+	if len(chains) == 0 || bw == 0 {
+		return
+	}
+	model.pathFree[src][dst] -= bw
+	for _, chain := range chains {
+		for _, link := range chain.links {
+			model.free[link.src][link.dst] -= chain.weight * bw
+		}
+	}
+}
+
+// bw left on the links the paths from node start with, each link counted once
+func (model *LinkModel) SendBw(node string) float64 {
+	seen := make(map[linkKey]bool, 0)
+	bw := 0.0
+	for dst := range model.paths[node] {
+		for _, chain := range model.chains(node, dst) {
+			link := chain.links[0]
+			if !seen[link] {
+				seen[link] = true
+				bw += math.Max(0, model.free[link.src][link.dst])
+			}
+		}
+	}
+	return bw
+}
+
+// bw left on the links the paths to node end with, each link counted once
+func (model *LinkModel) RecvBw(node string) float64 {
+	seen := make(map[linkKey]bool, 0)
+	bw := 0.0
+	for src, dstPaths := range model.paths {
+		if _, exists := dstPaths[node]; !exists {
+			continue
+		}
+		for _, chain := range model.chains(src, node) {
+			link := chain.links[len(chain.links)-1]
+			if !seen[link] {
+				seen[link] = true
+				bw += math.Max(0, model.free[link.src][link.dst])
+			}
+		}
+	}
+	return bw
+}
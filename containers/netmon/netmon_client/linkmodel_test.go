@@ -0,0 +1,96 @@
+package netmon_client
+
+import (
+	"testing"
+)
+
+// n1 reaches n3 and n4 through n2, the link n1->n2 is their shared bottleneck
+func getLinkModelTestPaths() PathSet {
+	return PathSet{
+		"n1": {
+			"n2": Path{Source: "n1", Destination: "n2", Hops: []string{"10.0.0.1"}, Bandwidth: 100},
+			"n3": Path{Source: "n1", Destination: "n3", Hops: []string{"10.0.0.1", "10.0.0.2"}, Bandwidth: 100},
+			"n4": Path{Source: "n1", Destination: "n4", Hops: []string{"10.0.0.1", "10.0.0.2"}, Bandwidth: 100},
+			"n5": Path{Source: "n1", Destination: "n5", Hops: []string{"10.0.0.1", "10.0.0.9"}, Bandwidth: 30},
+		},
+		"n2": {
+			"n3": Path{Source: "n2", Destination: "n3", Hops: []string{"10.0.0.2"}, Bandwidth: 200},
+			"n4": Path{Source: "n2", Destination: "n4", Hops: []string{"10.0.0.2"}, Bandwidth: 200},
+		},
+	}
+}
+
+func TestLinkModel(t *testing.T) {
+	names := map[string]string{"10.0.0.1": "n1", "10.0.0.2": "n2", "10.0.0.3": "n3", "10.0.0.4": "n4"}
+	tests := []struct {
+		name        string
+		reserve     [][]interface{} // src, dst, bw
+		want        map[string]float64
+		wantSend    float64
+		wantRecvOn4 float64
+	}{
+		{
+			name:        "no reservations",
+			want:        map[string]float64{"n3": 100, "n4": 100, "n5": 30},
+			wantSend:    130, // n1->n2 once, and the path to n5 whose hop is not known
+			wantRecvOn4: 200,
+		},
+		{
+			name:        "shared first hop",
+			reserve:     [][]interface{}{{"n1", "n3", 60.0}},
+			want:        map[string]float64{"n2": 40, "n3": 40, "n4": 40, "n5": 30},
+			wantSend:    70,
+			wantRecvOn4: 200,
+		},
+		{
+			name:        "second hop",
+			reserve:     [][]interface{}{{"n2", "n4", 150.0}, {"n1", "n4", 20.0}},
+			want:        map[string]float64{"n2": 80, "n3": 80, "n4": 30},
+			wantSend:    110,
+			wantRecvOn4: 30,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := NewLinkModel(getLinkModelTestPaths(), names)
+			for _, r := range test.reserve {
+				model.Reserve(r[0].(string), r[1].(string), r[2].(float64))
+			}
+			for dst, want := range test.want {
+				if got := model.Available("n1", dst); got != want {
+					t.Fatalf("Want %f left from n1 to %s, got %f", want, dst, got)
+				}
+			}
+			if got := model.SendBw("n1"); got != test.wantSend {
+				t.Fatalf("Want send bw %f on n1, got %f", test.wantSend, got)
+			}
+			if got := model.RecvBw("n4"); got != test.wantRecvOn4 {
+				t.Fatalf("Want recv bw %f on n4, got %f", test.wantRecvOn4, got)
+			}
+		})
+	}
+}
+
+func TestLinkModelMultipath(t *testing.T) {
+	paths := PathSet{
+		"a": {
+			"b": Path{Hops: []string{"a"}, Bandwidth: 40},
+			"c": Path{Hops: []string{"a"}, Bandwidth: 30},
+			"d": Path{Hops: []string{"a", "b"}, Bandwidth: 70, Routes: []Route{
+				Route{Hops: []string{"a", "b"}, Weight: 0.5}, Route{Hops: []string{"a", "c"}, Weight: 0.5}}},
+		},
+		"b": {"d": Path{Hops: []string{"b"}, Bandwidth: 100}},
+		"c": {"d": Path{Hops: []string{"c"}, Bandwidth: 100}},
+	}
+	model := NewLinkModel(paths, nil)
+	if got := model.Available("a", "d"); got != 70 {
+		t.Fatalf("Want 70 over both routes, got %f", got)
+	}
+	model.Reserve("a", "d", 40)
+	if got := model.Available("a", "b"); got != 20 {
+		t.Fatalf("Want half of the reservation on a->b, got %f left", got)
+	}
+	if got := model.Available("a", "d"); got != 30 {
+		t.Fatalf("Want 30 left over both routes, got %f", got)
+	}
+}
@@ -0,0 +1,100 @@
+package netmon_client
+
+import (
+	"math"
+	"sort"
+)
+
+// path with the bw of each of its routes. With more than one route the bw of the path is the max flow
+// over their links, the sum of their bw if they share no link. It is left as it is if a link of a route is not known
+func multipathBw(links LinkSet, path Path) Path {
+	routes := make([]Route, len(path.Routes))
+	copy(routes, path.Routes)
+	path.Routes = routes
+	if len(routes) == 1 {
+		routes[0].Bandwidth = path.Bandwidth
+		return path
+	}
+	capacity := make(map[string]map[string]float64, 0)
+	complete := len(routes) > 0
+	for i, route := range routes {
+		routes[i].Bandwidth = 0
+		if len(route.Hops) == 0 {
+			complete = false
+			continue
+		}
+		hops := append(append(make([]string, 0), route.Hops...), path.Destination)
+		bw := math.Inf(1)
+		for j := 0; j < len(hops)-1; j++ {
+			link, exists := links[hops[j]][hops[j+1]]
+			if !exists || link.Bandwidth <= 0 {
+				bw = 0
+				break
+			}
+			bw = math.Min(bw, link.Bandwidth)
+			if _, exists := capacity[hops[j]]; !exists {
+				capacity[hops[j]] = make(map[string]float64, 0)
+			}
+			capacity[hops[j]][hops[j+1]] = link.Bandwidth
+		}
+		routes[i].Bandwidth = bw
+		complete = complete && bw > 0
+	}
+	if complete {
+		path.Bandwidth = maxFlow(capacity, path.Source, path.Destination)
+	}
+	return path
+}
+
+// max flow from src to dst over the directed capacities, found by augmenting along the shortest paths first
+func maxFlow(capacity map[string]map[string]float64, src string, dst string) float64 {
+	residual := make(map[string]map[string]float64, 0)
+	addResidual := func(from string, to string, bw float64) {
+		if _, exists := residual[from]; !exists {
+			residual[from] = make(map[string]float64, 0)
+		}
+		residual[from][to] += bw
+	}
+	for from, tos := range capacity {
+		for to, bw := range tos {
+			addResidual(from, to, bw)
+			addResidual(to, from, 0)
+		}
+	}
+	flow := 0.0
+	for {
+		// breadth first search, neighbors in order so the same capacities always give the same flow
+		prev := map[string]string{src: src}
+		queue := []string{src}
+		for len(queue) > 0 {
+			if _, found := prev[dst]; found {
+				break
+			}
+			node := queue[0]
+			queue = queue[1:]
+			next := make([]string, 0)
+			for to, bw := range residual[node] {
+				if _, seen := prev[to]; !seen && bw > 0 {
+					next = append(next, to)
+				}
+			}
+			sort.Strings(next)
+			for _, to := range next {
+				prev[to] = node
+				queue = append(queue, to)
+			}
+		}
+		if _, found := prev[dst]; !found || src == dst {
+			return flow
+		}
+		bw := math.Inf(1)
+		for node := dst; node != src; node = prev[node] {
+			bw = math.Min(bw, residual[prev[node]][node])
+		}
+		for node := dst; node != src; node = prev[node] {
+			residual[prev[node]][node] -= bw
+			residual[node][prev[node]] += bw
+		}
+		flow += bw
+	}
+}
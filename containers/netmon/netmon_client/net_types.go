@@ -1,32 +1,114 @@
 package netmon_client
 
+import (
+	"context"
+	"time"
+)
+
 type Link struct {
 	Source      string
 	Destination string
-	Latency     float64
+	Latency     float64 // ms, round trip
 	Bandwidth   float64
+	// confidence interval of Bandwidth, both equal to it if netmon's estimator gave none
+	BandwidthLow  float64
+	BandwidthHigh float64
+	Estimator     string // probe, train or adaptive, empty if the netmon did not say
 }
 
 type LinkSet map[string]map[string]Link
 
 type Path struct {
-	Source      string
-	Destination string
-	Hops        []string
-	Bandwidth   float64
-	Latency     float64
+	Source       string
+	Destination  string
+	Hops         []string
+	Bandwidth    float64
+	Latency      float64 // ms, round trip between source and destination
+	RouteVersion uint64  // increased by the netmon of Source every time the route changes, 0 if it sent none
+	// every route traffic from Source to Destination takes, most taken first. Hops is the one taken last.
+	// With more than one, Bandwidth is the max flow over the links of all of them
+	Routes []Route
+}
+
+// one of the routes between two nodes, e.g. with ECMP
+type Route struct {
+	Hops      []string // like the hops of a Path
+	Weight    float64  // share of the traceroutes netmon ran last that took it
+	Bandwidth float64  // of its narrowest link, 0 if a link is not known
 }
 type PathSet map[string]map[string]Path
 
 type Traffic struct {
+	Source        string
+	Destination   string
+	Bytes         float64
+	KernelPackets uint64 // packets the XDP program of the destination counted from the source since netmon started
+	KernelBytes   uint64
+	KernelRates   []TrafficRate // shortest window first, nil if netmon sent no XDP counts
+	// what the tc egress hook of the source counted towards the destination
+	KernelSentPackets uint64
+	KernelSentBytes   uint64
+	KernelSentRates   []TrafficRate
+}
+
+// rate of the XDP counts over the last Window
+type TrafficRate struct {
+	Window     time.Duration
+	BitRate    float64
+	PacketRate float64
+}
+
+type TrafficSet map[string]map[string]Traffic
+
+// traffic of one flow counted by the netmon of Node, flows in flannel's vxlan by their pod ips
+type Flow struct {
+	Node        string
 	Source      string
 	Destination string
-	Bytes       float64
+	DstPort     int // 0 if not tcp or udp
+	Protocol    int
+	Egress      bool // counted by the tc egress hook when Node sent it, by XDP when Node received it
+	Packets     uint64
+	Bytes       uint64
+	Rates       []TrafficRate
 }
 
-type TrafficSet map[string]map[string]Traffic
+// limit on the bits per second a pod on a node sends to another pod, by their ips
+type QosLimit struct {
+	Id          string // what the limit is for, e.g. the reservation pod/dep
+	Source      string
+	Destination string
+	Rate        float64
+}
+
+type QosLimitStatus struct {
+	Limit    QosLimit
+	Enforced bool
+	Error    string // why the limit is not enforced
+	Bytes    uint64 // sent under the limit since it was set
+	Drops    uint64 // packets the limit dropped
+}
+
+// limits the netmon of Node enforces. Error is set if it does not enforce any, e.g. it runs without -qos
+type QosStatus struct {
+	Node    string
+	Version int64 // of the last policy the node got
+	Backend string
+	Error   string
+	Limits  []QosLimitStatus
+}
+
+// the route netmon on Source traced to Destination changed, hops are nodes like the hops of a Path
+type RouteChange struct {
+	Source      string
+	Destination string
+	Version     uint64 // of the route after the change
+	OldHops     []string
+	NewHops     []string
+	Time        time.Time
+}
 
 type NetmonClientIntf interface {
 	Close()
-	GetStats(nodeMap map[string]string) (LinkSet, PathSet, TrafficSet)
+	GetStats(ctx context.Context, nodeMap map[string]string, bwUpdate bool) (LinkSet, PathSet, TrafficSet, []NodeError)
 }
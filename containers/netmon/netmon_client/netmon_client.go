@@ -3,8 +3,8 @@ package netmon_client
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
+	"sync"
 	"time"
 
 	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
@@ -13,24 +13,72 @@ import (
 )
 
 type NetmonClient struct {
-	clients     map[string]pb.NetMonitorClient
-	clientConns map[string]*grpc.ClientConn
+	addresses      []string
+	clients        map[string]pb.NetMonitorClient
+	clientConns    map[string]*grpc.ClientConn
+	lock           *sync.Mutex
+	health         map[string]NodeHealth  // address -> health of the node
+	netInfoCache   map[string]cachedReply // address -> last good GetNetInfo reply
+	headroomCache  map[string]cachedReply // address -> last good GetHeadroomInfo reply
+	attempts       int
+	retryBackoff   time.Duration
+	nodeBackoff    time.Duration
+	nodeBackoffMax time.Duration
+	workers        int // nodes asked at the same time
+	bwAggregate    pb.Aggregate
+	bwWindow       int32 // seconds of bw history netmon aggregates, 0 for all of it
+	bwLowerBound   bool  // links and paths get the lower end of the bw confidence interval
 }
 
 func NewNetmonClient(addresses []string) *NetmonClient {
-	conns := make(map[string]*grpc.ClientConn, 0)
-	clients := make(map[string]pb.NetMonitorClient, 0)
+	return NewNetmonClientWithOptions(addresses)
+}
+
+// opts are added to the default dial options, e.g. a context dialer for an in-process server
+func NewNetmonClientWithOptions(addresses []string, opts ...grpc.DialOption) *NetmonClient {
+	netmonClient := &NetmonClient{addresses: addresses, clients: make(map[string]pb.NetMonitorClient, 0), clientConns: make(map[string]*grpc.ClientConn, 0),
+		lock: &sync.Mutex{}, health: make(map[string]NodeHealth, 0),
+		netInfoCache: make(map[string]cachedReply, 0), headroomCache: make(map[string]cachedReply, 0),
+		attempts: RPC_ATTEMPTS, retryBackoff: RETRY_BACKOFF, nodeBackoff: NODE_BACKOFF, nodeBackoffMax: NODE_BACKOFF_MAX,
+		workers: FETCH_WORKERS}
+	dialOpts := append([]grpc.DialOption{grpc.WithTimeout(300 * time.Second), grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
 	for _, address := range addresses {
-		conn, err := grpc.Dial(address, grpc.WithTimeout(300 * time.Second),  grpc.WithTransportCredentials(insecure.NewCredentials()))
+		health := NodeHealth{Address: address}
+		conn, err := grpc.Dial(address, dialOpts...)
 		if err != nil {
-			log.Fatalf("did not connect: %v", err)
+			// the node is reported as not connected on every call
+			logger(fmt.Sprintf("did not connect to %s: %v", address, err))
+			health.Failures, health.LastError = 1, err.Error()
+			netmonClient.health[address] = health
+			continue
 		}
-		client := pb.NewNetMonitorClient(conn)
-		clients[address] = client
-		conns[address] = conn
+		netmonClient.clients[address] = pb.NewNetMonitorClient(conn)
+		netmonClient.clientConns[address] = conn
+		netmonClient.health[address] = health
+	}
+	return netmonClient
+}
+
+// the bw netmon reports from now on: the last measurement (last, the default), or the ewma, p5, p50, p95
+// or min of its measurements over the last window, all it keeps if window is 0. Call it before any stats are fetched
+func (netmonClient *NetmonClient) SetBwAggregate(aggregate string, window time.Duration) error {
+	value, exists := pb.Aggregate_value[strings.ToUpper(aggregate)]
+	if !exists {
+		return fmt.Errorf("unknown bw aggregate %s, want last, ewma, p5, p50, p95 or min", aggregate)
 	}
-	return &NetmonClient{clients: clients, clientConns: conns}
+	netmonClient.bwAggregate = pb.Aggregate(value)
+	netmonClient.bwWindow = int32(window / time.Second)
+	return nil
+}
+
+// whether links and paths from now on get the lower end of the confidence interval netmon gives for the bw
+// of a link instead of its estimate. Links without an interval keep their bw
+func (netmonClient *NetmonClient) SetBwLowerBound(lowerBound bool) {
+	netmonClient.bwLowerBound = lowerBound
+}
 
+func getHost(address string) string {
+	return strings.Split(address, ":")[0]
 }
 
 func (netmonClient *NetmonClient) Close() {
@@ -71,7 +119,10 @@ func (netmonClient *NetmonClient) ComputePathTraffic(traffic TrafficSet, pathsIn
 				cumulative_traffic += bytes_to_add
 				//logger(fmt.Sprintf("i=%d hop=  %s traffic=%f cumul=%f\n", i, path.Hops[i], bytes_to_add, cumulative_traffic))
 				if exists{
-					traffic[cursrc][curdst] = Traffic{Source:cursrc, Destination:curdst, Bytes:cumulative_traffic}
+					// the XDP counts are per link and stay as they are
+					hopTraffic := traffic[cursrc][curdst]
+					hopTraffic.Bytes = cumulative_traffic
+					traffic[cursrc][curdst] = hopTraffic
 				}
 			}
 			cursrc = path.Hops[len(path.Hops)-1]
@@ -82,7 +133,9 @@ func (netmonClient *NetmonClient) ComputePathTraffic(traffic TrafficSet, pathsIn
 			if exists{
 				bytes_to_add = traffic[cursrc][curdst].Bytes
 				cumulative_traffic += bytes_to_add
-				traffic[cursrc][curdst] = Traffic{Source:cursrc, Destination:curdst, Bytes:cumulative_traffic}
+				hopTraffic := traffic[cursrc][curdst]
+				hopTraffic.Bytes = cumulative_traffic
+				traffic[cursrc][curdst] = hopTraffic
 			
 			}
 
@@ -96,38 +149,33 @@ func (netmonClient *NetmonClient) ComputePathTraffic(traffic TrafficSet, pathsIn
 func (netmonClient *NetmonClient) ComputePathBw(links LinkSet, pathsInput PathSet) PathSet {
 	maxPLen := 1
 	paths := make(PathSet, 0)
-	for _, pSet := range pathsInput {
-		for _, path := range pSet {
+	for src, pSetIn := range pathsInput {
+		pSet := make(map[string]Path, 0)
+		for dst, path := range pSetIn {
+			pSet[dst] = path
 			if len(path.Hops) > maxPLen {
 				maxPLen = len(path.Hops)
 			}
 		}
+		paths[src] = pSet
 	}
 	pLen := 2
 	logger(fmt.Sprintf("Max p len = %d\n", maxPLen))
+	// shorter paths first, the bw of a path is the min of the path to its last hop and the link from the last hop
 	for {
 		if pLen > maxPLen {
 			break
 		}
-		for src, pSetIn := range pathsInput {
-			pSet := make(map[string]Path, 0)
-			paths[src] = pSet
-			for dst, path := range pSetIn {
-				pSet[dst] = path
-			}
+		for src, pSet := range paths {
 			for dst, path := range pSet {
 				curPLen := len(path.Hops)
 				if curPLen != pLen {
 					continue
 				}
 				lastHop := path.Hops[curPLen-1]
-				curHop := path.Hops[curPLen-2]
-				logger(fmt.Sprintf("src = %s dst=%s lasthop %s curHop %s cur plen = %d pLen = %d", src, dst, lastHop, curHop, curPLen, pLen))
+				logger(fmt.Sprintf("src = %s dst=%s lasthop %s cur plen = %d pLen = %d", src, dst, lastHop, curPLen, pLen))
 				lastBws, lExists := links[lastHop]
-				if curPLen == 2{
-					curHop = lastHop
-				}
-				prevPath, pExists := pSet[curHop]
+				prevPath, pExists := pSet[lastHop]
 				if lExists && pExists {
 					dstBw, dExists := lastBws[dst]
 					if dExists {
@@ -141,66 +189,30 @@ func (netmonClient *NetmonClient) ComputePathBw(links LinkSet, pathsInput PathSe
 					}
 				}
 			}
-			paths[src] = pSet
 		}
 		pLen += 1
 
 	}
+	for _, pSet := range paths {
+		for dst, path := range pSet {
+			pSet[dst] = multipathBw(links, path)
+		}
+	}
 	return paths
 }
 
-func (netmonClient *NetmonClient) GetHeadroomStats(ipMap map[string]string, bwReq map[string]map[string]float32) (LinkSet, PathSet, TrafficSet) {
-	links := make(LinkSet, 0)
-	paths := make(PathSet, 0)
-	traffics := make(TrafficSet, 0)
+// nodes that could not be reached are left out or answered from their last good reply, they are listed in the returned errors
+func (netmonClient *NetmonClient) GetHeadroomStats(ctx context.Context, ipMap map[string]string, bwReq map[string]map[string]float32) (LinkSet, PathSet, TrafficSet, []NodeError) {
 	logger(fmt.Sprintf("Got %d headroom req\n", len(bwReq)))
 	for src, reqs := range bwReq {
 		for dst, bw := range reqs {
 			logger(fmt.Sprintf("src = %s dst  = %s bwreq = %f", src, dst, bw))
 		}
 	}
-	for addr, _ := range netmonClient.clients {
-		host := strings.Split(addr, ":")[0]
-
-		logger(fmt.Sprintf("addr = %s", host))	
-		curLinks, curPaths, curTraffic := netmonClient.getStatsOneHeadroom(addr, ipMap, bwReq[host])
-		if curLinks != nil && curPaths != nil {
-			host := strings.Split(addr, ":")[0]
-			srcPaths, existsP := curPaths[host]
-			srcLinks, existsL := curLinks[host]
-			if !existsP || !existsL {
-				fmt.Printf("No links or paths\n")
-				continue
-			}
-			_, exists := links[host]
-			if !exists {
-				links[host] = make(map[string]Link, 0)
-			}
-			_, exists = paths[host]
-			if !exists {
-				paths[host] = make(map[string]Path, 0)
-			}
-
-			for src, tSrcInfo := range curTraffic {
-				for dst, traffic := range tSrcInfo {
-					_, exists := traffics[dst]
-					if !exists {
-						traffics[dst] = make(map[string]Traffic, 0)
-					}
-					traffics[dst][src] = traffic
-				}
-			}
-			for dst, path := range srcPaths {
-				paths[host][dst] = path
-				logger(fmt.Sprintf("added path %s to %s with bw = %f and %d hops\n", host, dst, path.Bandwidth, len(path.Hops)))
-			}
-			for dst, link := range srcLinks {
-				links[host][dst] = link
-			}
-
-			//fmt.Printf("add %d links to %s \n", len(curLinks[host]), host)
-		}
-	}
+	results := netmonClient.fetchAll(ctx, func(ctx context.Context, address string) nodeStats {
+		return netmonClient.getStatsOneHeadroom(ctx, address, ipMap, bwReq[getHost(address)])
+	})
+	links, paths, traffics, nodeErrors := mergeStats(results)
 	pathsOut  := netmonClient.ComputePathBw(links, paths)
 	traffics = netmonClient.ComputePathTraffic(traffics, paths)
 	for src, dstPaths := range pathsOut {
@@ -208,52 +220,16 @@ func (netmonClient *NetmonClient) GetHeadroomStats(ipMap map[string]string, bwRe
 			logger(fmt.Sprintf("src = %s dst = %s bw = %f\n", src, dst, path.Bandwidth))
 		} 
 	}
-	return links, pathsOut, traffics
+	return links, pathsOut, traffics, nodeErrors
 }
 
 
-func (netmonClient *NetmonClient) GetStats(ipMap map[string]string, bwUpdate bool) (LinkSet, PathSet, TrafficSet) {
-	links := make(LinkSet, 0)
-	paths := make(PathSet, 0)
-	traffics := make(TrafficSet, 0)
-	for addr, _ := range netmonClient.clients {
-		curLinks, curPaths, curTraffic := netmonClient.getStatsOne(addr, ipMap, bwUpdate)
-		if curLinks != nil && curPaths != nil {
-			host := strings.Split(addr, ":")[0]
-			srcPaths, existsP := curPaths[host]
-			srcLinks, existsL := curLinks[host]
-			if !existsP || !existsL {
-				fmt.Printf("No links or paths\n")
-				continue
-			}
-			_, exists := links[host]
-			if !exists {
-				links[host] = make(map[string]Link, 0)
-			}
-			_, exists = paths[host]
-			if !exists {
-				paths[host] = make(map[string]Path, 0)
-			}
-			for src, tSrcInfo := range curTraffic {
-				for dst, traffic := range tSrcInfo {
-					_, exists := traffics[dst]
-					if !exists {
-						traffics[dst] = make(map[string]Traffic, 0)
-					}
-					traffics[dst][src] = traffic
-				}
-			}
-			for dst, path := range srcPaths {
-				paths[host][dst] = path
-				//logger(fmt.Sprintf("added path %s to %s with bw = %f and %d hops\n", host, dst, path.Bandwidth, len(path.Hops)))
-			}
-			for dst, link := range srcLinks {
-				links[host][dst] = link
-			}
-
-			//fmt.Printf("add %d links to %s \n", len(curLinks[host]), host)
-		}
-	}
+// nodes that could not be reached are left out or answered from their last good reply, they are listed in the returned errors
+func (netmonClient *NetmonClient) GetStats(ctx context.Context, ipMap map[string]string, bwUpdate bool) (LinkSet, PathSet, TrafficSet, []NodeError) {
+	results := netmonClient.fetchAll(ctx, func(ctx context.Context, address string) nodeStats {
+		return netmonClient.getStatsOne(ctx, address, ipMap, bwUpdate)
+	})
+	links, paths, traffics, nodeErrors := mergeStats(results)
 	pathsOut := netmonClient.ComputePathBw(links, paths)
 	traffics = netmonClient.ComputePathTraffic(traffics, paths)
 	
@@ -267,53 +243,73 @@ func (netmonClient *NetmonClient) GetStats(ipMap map[string]string, bwUpdate boo
 	//		logger(fmt.Sprintf("src = %s dst = %s bw = %f\n", src, dst, path.Bandwidth))
 	//	} 
 	//}
-	return links, pathsOut, traffics
+	return links, pathsOut, traffics, nodeErrors
 }
 
-func (netmonClient *NetmonClient) getStatsOne(address string, ipMap map[string]string, bwUpdate bool) (LinkSet, PathSet, TrafficSet) {
-	//fmt.Printf("host= " + address)
-	host := strings.Split(address, ":")[0]
+func (netmonClient *NetmonClient) getStatsOne(ctx context.Context, address string, ipMap map[string]string, bwUpdate bool) nodeStats {
+	host := getHost(address)
 	logger(fmt.Sprintf("address = %s", host))
-	client, exists := netmonClient.clients[address]
-	if !exists {
-		var links LinkSet
-		var paths PathSet
-		traffic := make(TrafficSet, 0)
-		return links, paths, traffic
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-	response, err := client.GetNetInfo(ctx, &pb.NetInfoRequest{ShouldUpdate:bwUpdate})
-	if err != nil {
-		log.Fatalf("could not greet: %v", err)
+	response, nodeErr := netmonClient.callNode(ctx, address, netmonClient.netInfoCache, 60*time.Second, func(ctx context.Context, client pb.NetMonitorClient) (*pb.NetInfoReply, error) {
+		return client.GetNetInfo(ctx, &pb.NetInfoRequest{ShouldUpdate:bwUpdate, Aggregate: netmonClient.bwAggregate, Window: netmonClient.bwWindow})
+	})
+	if response == nil {
+		return nodeStats{traffic: make(TrafficSet, 0), err: nodeErr}
 	}
-	return netmonClient.ProcessResponse(response, host, ipMap)
+	links, paths, traffic := netmonClient.ProcessResponse(response, host, ipMap)
+	return nodeStats{links: links, paths: paths, traffic: traffic, err: nodeErr}
 }
 
-func (netmonClient *NetmonClient) getStatsOneHeadroom(address string, ipMap map[string]string, bwReq map[string]float32) (LinkSet, PathSet, TrafficSet) {
-	//fmt.Printf("host= " + address)
-	host := strings.Split(address, ":")[0]
+func (netmonClient *NetmonClient) getStatsOneHeadroom(ctx context.Context, address string, ipMap map[string]string, bwReq map[string]float32) nodeStats {
+	host := getHost(address)
 	logger(fmt.Sprintf("address = %s got %d req", host, len(bwReq)))
-	client, exists := netmonClient.clients[address]
-	if !exists {
-		var links LinkSet
-		var paths PathSet
-		traffic := make(TrafficSet, 0)
-		return links, paths, traffic
-	}
 	bwInfos := make([]*pb.BandwidthInfo, 0)
 	for h, bw := range bwReq {
 		logger(fmt.Sprintf("added %s with req %f", h, bw))
 		bwInfos = append(bwInfos, &pb.BandwidthInfo{Host:h, SendBw: bw})
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 15 * time.Second)
-	defer cancel()
-	response, err := client.GetHeadroomInfo(ctx, &pb.HeadroomInfoRequest{BwInfo:bwInfos})
-	if err != nil {
-		log.Fatalf("could not greet: %v", err)
+	response, nodeErr := netmonClient.callNode(ctx, address, netmonClient.headroomCache, 15*time.Second, func(ctx context.Context, client pb.NetMonitorClient) (*pb.NetInfoReply, error) {
+		return client.GetHeadroomInfo(ctx, &pb.HeadroomInfoRequest{BwInfo:bwInfos, Aggregate: netmonClient.bwAggregate, Window: netmonClient.bwWindow})
+	})
+	if response == nil {
+		return nodeStats{traffic: make(TrafficSet, 0), err: nodeErr}
 	}
-	return netmonClient.ProcessResponse(response, host, ipMap)
+	links, paths, traffic := netmonClient.ProcessResponse(response, host, ipMap)
+	return nodeStats{links: links, paths: paths, traffic: traffic, err: nodeErr}
+}
+
+func getRates(rates []*pb.TrafficRate) []TrafficRate {
+	trafficRates := make([]TrafficRate, 0)
+	for _, rate := range rates {
+		trafficRates = append(trafficRates, TrafficRate{Window: time.Duration(rate.Window) * time.Second, BitRate: float64(rate.BitRate), PacketRate: float64(rate.PacketRate)})
+	}
+	return trafficRates
+}
+
+// the nodes a traceroute from host to dst went through, starting with host. Hops that did not answer
+// or are not in ipMap are left out
+func nodeHops(host string, dst string, hops []string, ipMap map[string]string) []string {
+	pathActual := make([]string, 0)
+	for _, p := range hops {
+		//logger(fmt.Sprintf("src = %s dst = %s hop = %s actual = %s\n", host, dst, p, ipMap[p]))
+		if len(pathActual) < 1{
+			pathActual = append(pathActual, host)
+		}
+		if strings.Contains(p, "*"){
+			continue
+		}
+		ipActual, exists := ipMap[p]
+		if !exists {
+			continue
+			//ipActual = p
+		}
+		if len(pathActual) >= 1 && ipActual != dst && ipActual != host {
+			pathActual = append(pathActual, ipActual)
+		} 
+		if ipActual == dst {
+			break
+		}
+	}
+	return pathActual
 }
 
 func (netmonClient *NetmonClient) ProcessResponse(response *pb.NetInfoReply, host string, ipMap map[string]string) (LinkSet, PathSet, TrafficSet) {
@@ -325,41 +321,28 @@ func (netmonClient *NetmonClient) ProcessResponse(response *pb.NetInfoReply, hos
 	//logger(fmt.Sprintf("Got %d bw stats\n", len(bwInfos)))
 	trInfo := response.TrInfo
 	//logger(fmt.Sprintf("Got %d traceroutes\n", len(trInfo)))
+	latencies := make(map[string]float64, 0)
+	for _, lat := range response.LatInfo {
+		latencies[lat.Host] = float64(lat.Latency)
+	}
 
 	paths = make(PathSet, 0)
 	pMap := make(map[string]Path, 0)
 	paths[host] = pMap
 
 	for _, tr := range trInfo {
-		pathActual := make([]string, 0)
 	//	for _, hop := range tr.Hops {
 	//		logger(fmt.Sprintf("src = %s dst = %s hop = %s\n", host, tr.Host, hop))
 	//	}
-		path := Path{Source: host, Destination: tr.Host, Hops: tr.Hops}
-		for _, p := range path.Hops {
-			//logger(fmt.Sprintf("src = %s dst = %s hop = %s actual = %s\n", path.Source, path.Destination, p, ipMap[p]))
-			//for _, h := range pathActual {
-			//	logger(fmt.Sprintf("%s ", h))
-			//}
-			if len(pathActual) < 1{
-				pathActual = append(pathActual, host)
-			}
-			if strings.Contains(p, "*"){
-				continue
-			}
-			ipActual, exists := ipMap[p]
-			if !exists {
-				continue
-				//ipActual = p
-			}
-			if len(pathActual) >= 1 && ipActual != tr.Host && ipActual != host {
-				pathActual = append(pathActual, ipActual)
-			} 
-			if ipActual == tr.Host {
-				break
+		path := Path{Source: host, Destination: tr.Host, Hops: tr.Hops, Latency: latencies[tr.Host], RouteVersion: tr.Version}
+		path.Hops = nodeHops(host, tr.Host, tr.Hops, ipMap)
+		path.Routes = []Route{{Hops: path.Hops, Weight: 1}}
+		if len(tr.Routes) > 0 {
+			path.Routes = make([]Route, 0)
+			for _, route := range tr.Routes {
+				path.Routes = append(path.Routes, Route{Hops: nodeHops(host, tr.Host, route.Hops, ipMap), Weight: float64(route.Weight)})
 			}
 		}
-		path.Hops = pathActual
 		//logger(fmt.Sprintf("actual plen = %d", len(path.Hops)))
 		_, exists := pMap[tr.Host]
 		if !exists {
@@ -373,7 +356,17 @@ func (netmonClient *NetmonClient) ProcessResponse(response *pb.NetInfoReply, hos
 
 	links[host] = lMap
 	for _, bw := range bwInfos {
-		link := Link{Source: host, Destination: bw.Host, Bandwidth: float64(bw.ReceiveBw)}
+		link := Link{Source: host, Destination: bw.Host, Bandwidth: float64(bw.ReceiveBw), Latency: latencies[bw.Host],
+			BandwidthLow: float64(bw.ReceiveBwLow), BandwidthHigh: float64(bw.ReceiveBwHigh), Estimator: bw.Estimator}
+		if bw.ReceiveBwLow == 0 && bw.ReceiveBwHigh == 0 {
+			link.BandwidthLow, link.BandwidthHigh = link.Bandwidth, link.Bandwidth
+		}
+		// the interval is of the receive bw, the send bw is taken down by the same share
+		sendBw := float64(bw.SendBw)
+		if netmonClient.bwLowerBound && link.BandwidthLow < link.Bandwidth {
+			sendBw *= link.BandwidthLow / link.Bandwidth
+			link.Bandwidth = link.BandwidthLow
+		}
 		_, exists := lMap[bw.Host]
 		if !exists {
 			lMap[bw.Host] = link
@@ -411,11 +404,46 @@ func (netmonClient *NetmonClient) ProcessResponse(response *pb.NetInfoReply, hos
 		path.Hops = pathActual
 		logger(fmt.Sprintf("no. of hops = %d\n", len(path.Hops)))*/
 		if exists && len(path.Hops) <= 1 {
-			path.Bandwidth = float64(bw.SendBw)
+			path.Bandwidth = sendBw
+		}
+		if !exists {
+			path = Path{Source: host, Destination: bw.Host, Latency: latencies[bw.Host]}
 		}
 		pMap[bw.Host] = path
 		//logger(fmt.Sprintf("Got bw for %s to %s = %f\n", host, bw.Host, path.Bandwidth))
 	}
+	// the XDP program counts what host received, the peer is the source
+	for _, bpfInfo := range response.BpfInfo {
+		tMap, exists := traffic[bpfInfo.Host]
+		if !exists {
+			tMap = make(map[string]Traffic, 0)
+			traffic[bpfInfo.Host] = tMap
+		}
+		tr, exists := tMap[host]
+		if !exists {
+			tr = Traffic{Source: bpfInfo.Host, Destination: host}
+		}
+		tr.KernelPackets = bpfInfo.Packets
+		tr.KernelBytes = bpfInfo.Bytes
+		tr.KernelRates = getRates(bpfInfo.Rates)
+		tMap[host] = tr
+	}
+	// the egress hook counts what host sent, the peer is the destination
+	for _, bpfInfo := range response.BpfSentInfo {
+		tMap, exists := traffic[host]
+		if !exists {
+			tMap = make(map[string]Traffic, 0)
+			traffic[host] = tMap
+		}
+		tr, exists := tMap[bpfInfo.Host]
+		if !exists {
+			tr = Traffic{Source: host, Destination: bpfInfo.Host}
+		}
+		tr.KernelSentPackets = bpfInfo.Packets
+		tr.KernelSentBytes = bpfInfo.Bytes
+		tr.KernelSentRates = getRates(bpfInfo.Rates)
+		tMap[bpfInfo.Host] = tr
+	}
 	links[host] = lMap
 	paths[host] = pMap
 	return links, paths, traffic
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
 )
 
 func GetNetInfo(maxHops int) (LinkSet, PathSet) {
@@ -90,7 +92,7 @@ func GetNetInfo(maxHops int) (LinkSet, PathSet) {
 func TestUpdatePaths1(t *testing.T) {
 	links, paths := GetNetInfo(2)
 	dummyClient := NetmonClient{}
-	dummyClient.ComputePathBw(links, &paths)
+	paths = dummyClient.ComputePathBw(links, paths)
 	if len(paths) != 2 {
 		t.Fatalf("Expected 2 nodes, got %d\n", len(paths))
 	}
@@ -99,7 +101,7 @@ func TestUpdatePaths1(t *testing.T) {
 func TestUpdatePaths2(t *testing.T) {
 	links, paths := GetNetInfo(3)
 	dummyClient := NetmonClient{}
-	dummyClient.ComputePathBw(links, &paths)
+	paths = dummyClient.ComputePathBw(links, paths)
 	if len(paths) != 3 {
 		t.Fatalf("Expected 3 nodes, got %d\n", len(paths))
 	}
@@ -114,3 +116,23 @@ func TestUpdatePaths2(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessResponseLatency(t *testing.T) {
+	response := &pb.NetInfoReply{
+		BwInfo:  []*pb.BandwidthInfo{&pb.BandwidthInfo{Host: "1", SendBw: 10, ReceiveBw: 20}, &pb.BandwidthInfo{Host: "2", SendBw: 5, ReceiveBw: 5}},
+		TrInfo:  []*pb.TracerouteInfo{&pb.TracerouteInfo{Host: "1", Hops: []string{"1"}}},
+		LatInfo: []*pb.LatencyInfo{&pb.LatencyInfo{Host: "1", Latency: 2.5}, &pb.LatencyInfo{Host: "2", Latency: 7}},
+	}
+	dummyClient := NetmonClient{}
+	links, paths, _ := dummyClient.ProcessResponse(response, "0", map[string]string{"1": "1", "2": "2"})
+	if links["0"]["1"].Latency != 2.5 || links["0"]["2"].Latency != 7 {
+		t.Fatalf("Got unexpected link latencies %v", links["0"])
+	}
+	if paths["0"]["1"].Latency != 2.5 || paths["0"]["1"].Bandwidth != 10 {
+		t.Fatalf("Got unexpected path %v", paths["0"]["1"])
+	}
+	// no traceroute for 2
+	if paths["0"]["2"].Latency != 7 {
+		t.Fatalf("Got unexpected path %v", paths["0"]["2"])
+	}
+}
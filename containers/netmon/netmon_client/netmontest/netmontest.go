@@ -0,0 +1,797 @@
+// Package netmontest runs fake netmon daemons in-process for tests.
+//
+// A Topology describes the network (directed links with bandwidth and latency, routes and traffic
+// that changes step by step, nodes that are down or slow), a FakeNetmon serves a NetMonitor for every node over
+// bufconn and answers from that node's point of view the way netmon_main does.
+package netmontest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const PORT = "50051"
+
+const BUF_SIZE = 1024 * 1024
+
+// each step of traffic lasts this long for the XDP counts, which are also the window of the one rate reported
+const STEP_SECONDS = 10
+
+// size of every packet in the XDP counts
+const PACKET_SIZE = 1000
+
+// weight of the newest bw in the EWMA, as in netmon
+const EWMA_ALPHA = 0.3
+
+type Topology struct {
+	lock      *sync.Mutex
+	bandwidth map[string]map[string]float64   // src -> dst -> bw measured from src to dst
+	latency   map[string]map[string]float64   // src -> dst -> rtt in ms
+	routes    map[string]map[string][]string  // src -> dst -> hops in between
+	traffic   map[string]map[string][]float64 // src -> dst -> traffic sent at each step
+	step      int
+	headroom  map[string]map[string]float64 // src -> dst -> last headroom requested
+	updates   map[string]int                // src -> GetNetInfo calls that asked for a bw update
+	down      map[string]bool               // hosts whose netmon fails every call
+	calls     map[string]int                // host -> calls answered or failed
+	delay     map[string]time.Duration      // host -> time its netmon takes to answer
+	watchers  map[chan bool]bool            // WatchNetInfo streams, woken up on every change
+	flows     []fakeFlow
+	estimates map[string]map[string]fakeEstimate // src -> dst -> how the bw to dst is estimated
+	bwHistory map[string]map[string][]float64    // src -> dst -> every bw set for the link, oldest first
+	versions  map[string]map[string]uint64       // src -> dst -> route changes so far
+	reroutes  map[string][]*pb.RouteChange       // src -> every route change, oldest first
+	// src -> dst -> routes taken besides the one of SetRoute
+	multipath map[string]map[string][]*pb.RouteInfo
+	qos       map[string]*pb.QosPolicy // host -> last policy set
+	qosOff    map[string]bool          // hosts whose netmon runs without -qos
+}
+
+// confidence interval of a bw estimate as fractions of the bw
+type fakeEstimate struct {
+	estimator string
+	low       float64
+	high      float64
+}
+
+// flow between two ips, e.g. pods, carried from srcNode to dstNode
+type fakeFlow struct {
+	srcNode string
+	dstNode string
+	src     string
+	dst     string
+	dstPort int
+	traffic []float64 // one value per step like SetTraffic
+}
+
+func NewTopology() *Topology {
+	return &Topology{lock: &sync.Mutex{},
+		bandwidth: make(map[string]map[string]float64, 0),
+		latency:   make(map[string]map[string]float64, 0),
+		routes:    make(map[string]map[string][]string, 0),
+		traffic:   make(map[string]map[string][]float64, 0),
+		headroom:  make(map[string]map[string]float64, 0),
+		updates:   make(map[string]int, 0),
+		down:      make(map[string]bool, 0),
+		calls:     make(map[string]int, 0),
+		delay:     make(map[string]time.Duration, 0),
+		watchers:  make(map[chan bool]bool, 0),
+		flows:     make([]fakeFlow, 0),
+		estimates: make(map[string]map[string]fakeEstimate, 0),
+		bwHistory: make(map[string]map[string][]float64, 0),
+		versions:  make(map[string]map[string]uint64, 0),
+		reroutes:  make(map[string][]*pb.RouteChange, 0),
+		multipath: make(map[string]map[string][]*pb.RouteInfo, 0),
+		qos:       make(map[string]*pb.QosPolicy, 0),
+		qosOff:    make(map[string]bool, 0)}
+}
+
+// link from src to dst, latency is the round trip time in ms, 0 if unknown
+func (topo *Topology) SetLink(src string, dst string, bw float64, latency float64) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	if _, exists := topo.bandwidth[src]; !exists {
+		topo.bandwidth[src] = make(map[string]float64, 0)
+		topo.latency[src] = make(map[string]float64, 0)
+		topo.bwHistory[src] = make(map[string][]float64, 0)
+	}
+	topo.bandwidth[src][dst] = bw
+	topo.bwHistory[src][dst] = append(topo.bwHistory[src][dst], bw)
+	topo.latency[src][dst] = latency
+}
+
+// src reports the bw it measures towards dst as estimated by estimator, with the confidence interval
+// from low to high times the bw
+func (topo *Topology) SetEstimate(src string, dst string, estimator string, low float64, high float64) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	if _, exists := topo.estimates[src]; !exists {
+		topo.estimates[src] = make(map[string]fakeEstimate, 0)
+	}
+	topo.estimates[src][dst] = fakeEstimate{estimator: estimator, low: low, high: high}
+}
+
+// same link in both directions
+func (topo *Topology) SetBiLink(a string, b string, bw float64, latency float64) {
+	topo.SetLink(a, b, bw, latency)
+	topo.SetLink(b, a, bw, latency)
+}
+
+// traceroute from src to dst goes over hops, without src and dst. Routes start out direct, src
+// reports a route change if the hops differ from the route before
+func (topo *Topology) SetRoute(src string, dst string, hops ...string) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	if _, exists := topo.routes[src]; !exists {
+		topo.routes[src] = make(map[string][]string, 0)
+		topo.versions[src] = make(map[string]uint64, 0)
+	}
+	oldHops := topo.traceHops(src, dst)
+	topo.routes[src][dst] = hops
+	newHops := topo.traceHops(src, dst)
+	if len(oldHops) == len(newHops) {
+		same := true
+		for i, hop := range oldHops {
+			same = same && hop == newHops[i]
+		}
+		if same {
+			return
+		}
+	}
+	topo.versions[src][dst] += 1
+	change := &pb.RouteChange{Seq: uint64(len(topo.reroutes[src]) + 1), Host: dst, Version: topo.routeVersion(src, dst),
+		OldHops: oldHops, NewHops: newHops, Time: time.Now().Unix()}
+	topo.reroutes[src] = append(topo.reroutes[src], change)
+}
+
+// traffic from src to dst also takes a route over hops, e.g. with ECMP. src reports it with the share
+// weight of its traceroutes and the route of SetRoute with what is left
+func (topo *Topology) AddRoute(src string, dst string, weight float64, hops ...string) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	if _, exists := topo.multipath[src]; !exists {
+		topo.multipath[src] = make(map[string][]*pb.RouteInfo, 0)
+	}
+	route := &pb.RouteInfo{Hops: append(append([]string{}, hops...), dst), Weight: float32(weight)}
+	topo.multipath[src][dst] = append(topo.multipath[src][dst], route)
+}
+
+// every route from src to dst most taken first, none without AddRoute. The lock has to be held
+func (topo *Topology) traceRoutes(src string, dst string) []*pb.RouteInfo {
+	others := topo.multipath[src][dst]
+	if len(others) == 0 {
+		return nil
+	}
+	routes := []*pb.RouteInfo{{Hops: topo.traceHops(src, dst), Weight: 1}}
+	for _, route := range others {
+		routes[0].Weight -= route.Weight
+		routes = append(routes, route)
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Weight > routes[j].Weight })
+	return routes
+}
+
+// hops of the traceroute from src to dst, dst included, the lock has to be held
+func (topo *Topology) traceHops(src string, dst string) []string {
+	return append(append([]string{}, topo.routes[src][dst]...), dst)
+}
+
+// version of the route from src to dst like netmon reports it, the lock has to be held
+func (topo *Topology) routeVersion(src string, dst string) uint64 {
+	return topo.versions[src][dst] + 1
+}
+
+// traffic sent from src to dst, one value per step, the last value is kept after that
+func (topo *Topology) SetTraffic(src string, dst string, traffic ...float64) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	if _, exists := topo.traffic[src]; !exists {
+		topo.traffic[src] = make(map[string][]float64, 0)
+	}
+	topo.traffic[src][dst] = traffic
+}
+
+// tcp flow from src to dst:dstPort sent by srcNode and received by dstNode, one value per step.
+// srcNode counts it on egress and dstNode on ingress
+func (topo *Topology) SetFlow(srcNode string, dstNode string, src string, dst string, dstPort int, traffic ...float64) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	topo.flows = append(topo.flows, fakeFlow{srcNode: srcNode, dstNode: dstNode, src: src, dst: dst, dstPort: dstPort, traffic: traffic})
+}
+
+// moves the traffic to the next step
+func (topo *Topology) Step() {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	topo.step += 1
+}
+
+// netmon on host fails every call with Unavailable until it is brought up again
+func (topo *Topology) SetDown(host string, down bool) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	defer topo.notifyLocked()
+	topo.down[host] = down
+}
+
+// netmon on host waits delay before it answers, or until the call is cancelled
+func (topo *Topology) SetDelay(host string, delay time.Duration) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	topo.delay[host] = delay
+}
+
+// number of calls that reached the netmon of host, failed ones included
+func (topo *Topology) Calls(host string) int {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	return topo.calls[host]
+}
+
+// wakes up every WatchNetInfo stream, the lock has to be held
+func (topo *Topology) notifyLocked() {
+	for changed, _ := range topo.watchers {
+		select {
+		case changed <- true:
+		default:
+		}
+	}
+}
+
+// counts the call and fails it if host is down, the lock has to be held
+func (topo *Topology) checkDown(host string) error {
+	topo.calls[host] += 1
+	if topo.down[host] {
+		return status.Error(codes.Unavailable, "netmon on "+host+" is down")
+	}
+	return nil
+}
+
+// bw of the link from src to dst aggregated over every bw it was set to, the window of the request is
+// ignored. The lock has to be held
+func (topo *Topology) bandwidthFor(src string, dst string, agg pb.Aggregate) (float64, bool) {
+	bw, exists := topo.bandwidth[src][dst]
+	if !exists || agg == pb.Aggregate_LAST {
+		return bw, exists
+	}
+	values := append([]float64{}, topo.bwHistory[src][dst]...)
+	switch agg {
+	case pb.Aggregate_EWMA:
+		ewma := values[0]
+		for _, value := range values[1:] {
+			ewma = EWMA_ALPHA*value + (1-EWMA_ALPHA)*ewma
+		}
+		return ewma, true
+	case pb.Aggregate_P5:
+		return percentile(values, 5), true
+	case pb.Aggregate_P50:
+		return percentile(values, 50), true
+	case pb.Aggregate_P95:
+		return percentile(values, 95), true
+	}
+	return percentile(values, 0), true
+}
+
+// nearest rank as netmon computes it, values are sorted in place
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
+
+// last headroom src was asked to measure towards dst
+func (topo *Topology) HeadroomRequest(src string, dst string) (float64, bool) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	bw, exists := topo.headroom[src][dst]
+	return bw, exists
+}
+
+// number of GetNetInfo calls to src that asked for a new bw measurement
+func (topo *Topology) BwUpdates(src string) int {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	return topo.updates[src]
+}
+
+func (topo *Topology) currentTraffic(traffic []float64) float64 {
+	if len(traffic) == 0 {
+		return 0
+	}
+	if topo.step >= len(traffic) {
+		return traffic[len(traffic)-1]
+	}
+	return traffic[topo.step]
+}
+
+// XDP or tc counts for traffic that changes by step, nil if there was never traffic. Host is not set
+func (topo *Topology) kernelCounts(traffic []float64) *pb.TrafficInfo {
+	if len(traffic) == 0 {
+		return nil
+	}
+	bits := 0.0
+	for step := 0; step <= topo.step; step++ {
+		rate := traffic[len(traffic)-1]
+		if step < len(traffic) {
+			rate = traffic[step]
+		}
+		bits += STEP_SECONDS * rate
+	}
+	rate := topo.currentTraffic(traffic)
+	return &pb.TrafficInfo{Bytes: uint64(bits / 8), Packets: uint64(bits / 8 / PACKET_SIZE),
+		Rates: []*pb.TrafficRate{{Window: STEP_SECONDS, BitRate: float32(rate), PacketRate: float32(rate / 8 / PACKET_SIZE)}}}
+}
+
+// flows src counted that are from or to hosts, all of them if hosts is empty
+func (topo *Topology) flowInfos(src string, hosts []string) []*pb.FlowInfo {
+	wanted := make(map[string]bool, 0)
+	for _, host := range hosts {
+		wanted[host] = true
+	}
+	flowInfos := make([]*pb.FlowInfo, 0)
+	for _, flow := range topo.flows {
+		if len(wanted) > 0 && !wanted[flow.src] && !wanted[flow.dst] {
+			continue
+		}
+		for _, egress := range []bool{false, true} {
+			if (egress && flow.srcNode != src) || (!egress && flow.dstNode != src) {
+				continue
+			}
+			counts := topo.kernelCounts(flow.traffic)
+			flowInfos = append(flowInfos, &pb.FlowInfo{Src: flow.src, Dst: flow.dst, DstPort: uint32(flow.dstPort), Protocol: 6, Egress: egress,
+				Packets: counts.Packets, Bytes: counts.Bytes, Rates: counts.Rates})
+		}
+	}
+	return flowInfos
+}
+
+// nodes src has a link, a route or traffic to, sorted
+func (topo *Topology) destinations(src string) []string {
+	dsts := make(map[string]bool, 0)
+	for dst, _ := range topo.bandwidth[src] {
+		dsts[dst] = true
+	}
+	for dst, _ := range topo.routes[src] {
+		dsts[dst] = true
+	}
+	for dst, _ := range topo.traffic[src] {
+		dsts[dst] = true
+	}
+	dstList := make([]string, 0)
+	for dst, _ := range dsts {
+		dstList = append(dstList, dst)
+	}
+	sort.Strings(dstList)
+	return dstList
+}
+
+// reply of src, bwFor returns the bandwidth to report towards dst and whether to report it
+func (topo *Topology) reply(src string, bwFor func(dst string) (float64, bool)) *pb.NetInfoReply {
+	reply := &pb.NetInfoReply{BwInfo: make([]*pb.BandwidthInfo, 0), TrInfo: make([]*pb.TracerouteInfo, 0), LatInfo: make([]*pb.LatencyInfo, 0),
+		BpfInfo: make([]*pb.TrafficInfo, 0), BpfSentInfo: make([]*pb.TrafficInfo, 0)}
+	for _, dst := range topo.destinations(src) {
+		_, isLink := topo.bandwidth[src][dst]
+		bwInfo := &pb.BandwidthInfo{Host: dst, RecvBwUsed: float32(topo.currentTraffic(topo.traffic[src][dst]))}
+		if bw, exists := bwFor(dst); exists {
+			bwInfo.SendBw, bwInfo.ReceiveBw = float32(bw), float32(bw)
+			if estimate, exists := topo.estimates[src][dst]; exists {
+				bwInfo.ReceiveBwLow, bwInfo.ReceiveBwHigh = float32(estimate.low*bw), float32(estimate.high*bw)
+				bwInfo.Estimator = estimate.estimator
+			}
+		}
+		if isLink || bwInfo.RecvBwUsed > 0 {
+			reply.BwInfo = append(reply.BwInfo, bwInfo)
+		}
+		reply.TrInfo = append(reply.TrInfo, &pb.TracerouteInfo{Host: dst, Hops: topo.traceHops(src, dst), Version: topo.routeVersion(src, dst),
+			Routes: topo.traceRoutes(src, dst)})
+		if latency := topo.latency[src][dst]; latency > 0 {
+			reply.LatInfo = append(reply.LatInfo, &pb.LatencyInfo{Host: dst, Latency: float32(latency)})
+		}
+		if bpfInfo := topo.kernelCounts(topo.traffic[src][dst]); bpfInfo != nil {
+			bpfInfo.Host = dst
+			reply.BpfInfo = append(reply.BpfInfo, bpfInfo)
+		}
+	}
+	// what src sent is what the others received from it
+	dsts := make([]string, 0)
+	for dst, _ := range topo.traffic {
+		if _, exists := topo.traffic[dst][src]; exists {
+			dsts = append(dsts, dst)
+		}
+	}
+	sort.Strings(dsts)
+	for _, dst := range dsts {
+		if bpfInfo := topo.kernelCounts(topo.traffic[dst][src]); bpfInfo != nil {
+			bpfInfo.Host = dst
+			reply.BpfSentInfo = append(reply.BpfSentInfo, bpfInfo)
+		}
+	}
+	return reply
+}
+
+// NetMonitor of one node
+type nodeServer struct {
+	pb.UnimplementedNetMonitorServer
+	host string
+	topo *Topology
+}
+
+// sleeps for the delay of the node, fails if the call is cancelled first
+func (s *nodeServer) wait(ctx context.Context) error {
+	s.topo.lock.Lock()
+	delay := s.topo.delay[s.host]
+	s.topo.lock.Unlock()
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (s *nodeServer) GetNetInfo(ctx context.Context, in *pb.NetInfoRequest) (*pb.NetInfoReply, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	topo := s.topo
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	if err := topo.checkDown(s.host); err != nil {
+		return nil, err
+	}
+	if in.ShouldUpdate {
+		topo.updates[s.host] += 1
+	}
+	return topo.reply(s.host, func(dst string) (float64, bool) {
+		return topo.bandwidthFor(s.host, dst, in.Aggregate)
+	}), nil
+}
+
+// headroom measured for a request is what is left of the link after the current traffic, capped at the request
+func (s *nodeServer) GetHeadroomInfo(ctx context.Context, in *pb.HeadroomInfoRequest) (*pb.NetInfoReply, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	topo := s.topo
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	if err := topo.checkDown(s.host); err != nil {
+		return nil, err
+	}
+	if _, exists := topo.headroom[s.host]; !exists {
+		topo.headroom[s.host] = make(map[string]float64, 0)
+	}
+	for _, bwInfo := range in.BwInfo {
+		topo.headroom[s.host][bwInfo.Host] = float64(bwInfo.SendBw)
+	}
+	// the headroom is measured right away
+	topo.notifyLocked()
+	return topo.reply(s.host, func(dst string) (float64, bool) {
+		return topo.headroomFor(s.host, dst)
+	}), nil
+}
+
+// headroom src measures towards dst, only if it was requested
+func (topo *Topology) headroomFor(src string, dst string) (float64, bool) {
+	req, requested := topo.headroom[src][dst]
+	bw, exists := topo.bandwidth[src][dst]
+	if !requested || !exists {
+		return 0, false
+	}
+	return math.Min(req, math.Max(0, bw-topo.currentTraffic(topo.traffic[src][dst]))), true
+}
+
+func (s *nodeServer) GetFlowInfo(ctx context.Context, in *pb.FlowInfoRequest) (*pb.NetInfoReply, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	topo := s.topo
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	if err := topo.checkDown(s.host); err != nil {
+		return nil, err
+	}
+	return &pb.NetInfoReply{FlowInfo: topo.flowInfos(s.host, in.Hosts)}, nil
+}
+
+// the netmon of host does not enforce limits, as if it ran without -qos
+func (topo *Topology) SetQosOff(host string, off bool) {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	topo.qosOff[host] = off
+}
+
+// limits of the last policy set on host, nil if none was set
+func (topo *Topology) QosPolicy(host string) *pb.QosPolicy {
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	return topo.qos[host]
+}
+
+// every limit of the policy is enforced, the lock has to be held
+func (topo *Topology) qosStatus(host string) *pb.QosStatus {
+	if topo.qosOff[host] {
+		return &pb.QosStatus{Error: "qos enforcement is off"}
+	}
+	status := &pb.QosStatus{Backend: "htb"}
+	if policy, exists := topo.qos[host]; exists {
+		status.Version = policy.Version
+		for _, limit := range policy.Limits {
+			status.Limits = append(status.Limits, &pb.QosLimitStatus{Limit: limit, Enforced: true})
+		}
+	}
+	return status
+}
+
+func (s *nodeServer) SetQosPolicy(ctx context.Context, in *pb.QosPolicy) (*pb.QosStatus, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	topo := s.topo
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	if err := topo.checkDown(s.host); err != nil {
+		return nil, err
+	}
+	if !topo.qosOff[s.host] {
+		topo.qos[s.host] = in
+	}
+	return topo.qosStatus(s.host), nil
+}
+
+func (s *nodeServer) GetQosStatus(ctx context.Context, in *pb.QosStatusRequest) (*pb.QosStatus, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	topo := s.topo
+	topo.lock.Lock()
+	defer topo.lock.Unlock()
+	if err := topo.checkDown(s.host); err != nil {
+		return nil, err
+	}
+	return topo.qosStatus(s.host), nil
+}
+
+// full update of a WatchNetInfo stream with the state of the node, the lock has to be held
+func (s *nodeServer) watchState(in *pb.WatchRequest) *pb.NetInfoUpdate {
+	topo := s.topo
+	netInfo := topo.reply(s.host, func(dst string) (float64, bool) {
+		return topo.bandwidthFor(s.host, dst, in.Aggregate)
+	})
+	headroom := topo.reply(s.host, func(dst string) (float64, bool) {
+		return topo.headroomFor(s.host, dst)
+	})
+	return &pb.NetInfoUpdate{Full: true, BwInfo: netInfo.BwInfo, HeadroomInfo: headroom.BwInfo, TrInfo: netInfo.TrInfo, LatInfo: netInfo.LatInfo, BpfInfo: netInfo.BpfInfo, BpfSentInfo: netInfo.BpfSentInfo}
+}
+
+// sends the state of the node when the stream starts and the hosts that changed after every change of the topology,
+// fails when the node goes down
+func (s *nodeServer) WatchNetInfo(in *pb.WatchRequest, stream pb.NetMonitor_WatchNetInfoServer) error {
+	if err := s.wait(stream.Context()); err != nil {
+		return err
+	}
+	topo := s.topo
+	changed := make(chan bool, 1)
+	topo.lock.Lock()
+	topo.watchers[changed] = true
+	topo.lock.Unlock()
+	defer func() {
+		topo.lock.Lock()
+		delete(topo.watchers, changed)
+		topo.lock.Unlock()
+	}()
+	var last *pb.NetInfoUpdate
+	for {
+		topo.lock.Lock()
+		err := topo.checkDown(s.host)
+		cur := s.watchState(in)
+		topo.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if update := diffUpdates(last, cur); update != nil {
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+		last = cur
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// sends the route changes of the node after the one in asks for and every later one, fails when the node goes down
+func (s *nodeServer) WatchRouteChanges(in *pb.RouteChangeRequest, stream pb.NetMonitor_WatchRouteChangesServer) error {
+	if err := s.wait(stream.Context()); err != nil {
+		return err
+	}
+	topo := s.topo
+	changed := make(chan bool, 1)
+	topo.lock.Lock()
+	topo.watchers[changed] = true
+	topo.lock.Unlock()
+	defer func() {
+		topo.lock.Lock()
+		delete(topo.watchers, changed)
+		topo.lock.Unlock()
+	}()
+	after := in.After
+	for {
+		topo.lock.Lock()
+		err := topo.checkDown(s.host)
+		changes := topo.reroutes[s.host]
+		topo.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if after > uint64(len(changes)) {
+			after = 0
+		}
+		for _, change := range changes[after:] {
+			if err := stream.Send(change); err != nil {
+				return err
+			}
+		}
+		after = uint64(len(changes))
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// what changed from last to cur, both full updates, the way netmon_main computes it. nil if nothing changed, cur itself if there
+// was no last update or a host went away
+func diffUpdates(last *pb.NetInfoUpdate, cur *pb.NetInfoUpdate) *pb.NetInfoUpdate {
+	if last == nil {
+		return cur
+	}
+	delta := &pb.NetInfoUpdate{}
+	removed := false
+	delta.BwInfo, removed = diffBandwidth(last.BwInfo, cur.BwInfo)
+	if removed {
+		return cur
+	}
+	delta.HeadroomInfo, removed = diffBandwidth(last.HeadroomInfo, cur.HeadroomInfo)
+	if removed {
+		return cur
+	}
+	lastTr := make(map[string]*pb.TracerouteInfo, 0)
+	for _, tr := range last.TrInfo {
+		lastTr[tr.Host] = tr
+	}
+	for _, tr := range cur.TrInfo {
+		if prev, exists := lastTr[tr.Host]; !exists || !proto.Equal(prev, tr) {
+			delta.TrInfo = append(delta.TrInfo, tr)
+		}
+		delete(lastTr, tr.Host)
+	}
+	lastLat := make(map[string]*pb.LatencyInfo, 0)
+	for _, lat := range last.LatInfo {
+		lastLat[lat.Host] = lat
+	}
+	for _, lat := range cur.LatInfo {
+		if prev, exists := lastLat[lat.Host]; !exists || !proto.Equal(prev, lat) {
+			delta.LatInfo = append(delta.LatInfo, lat)
+		}
+		delete(lastLat, lat.Host)
+	}
+	if len(lastTr) > 0 || len(lastLat) > 0 {
+		return cur
+	}
+	delta.BpfInfo, removed = diffTraffic(last.BpfInfo, cur.BpfInfo)
+	if removed {
+		return cur
+	}
+	delta.BpfSentInfo, removed = diffTraffic(last.BpfSentInfo, cur.BpfSentInfo)
+	if removed {
+		return cur
+	}
+	if len(delta.BwInfo)+len(delta.HeadroomInfo)+len(delta.TrInfo)+len(delta.LatInfo)+len(delta.BpfInfo)+len(delta.BpfSentInfo) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// hosts of cur that are new or changed, and whether a host of last is gone
+func diffBandwidth(last []*pb.BandwidthInfo, cur []*pb.BandwidthInfo) ([]*pb.BandwidthInfo, bool) {
+	lastBw := make(map[string]*pb.BandwidthInfo, 0)
+	for _, bw := range last {
+		lastBw[bw.Host] = bw
+	}
+	changed := make([]*pb.BandwidthInfo, 0)
+	for _, bw := range cur {
+		if prev, exists := lastBw[bw.Host]; !exists || !proto.Equal(prev, bw) {
+			changed = append(changed, bw)
+		}
+		delete(lastBw, bw.Host)
+	}
+	return changed, len(lastBw) > 0
+}
+
+// hosts of cur whose counts are new or changed, and whether a host of last is gone
+func diffTraffic(last []*pb.TrafficInfo, cur []*pb.TrafficInfo) ([]*pb.TrafficInfo, bool) {
+	lastTraffic := make(map[string]*pb.TrafficInfo, 0)
+	for _, traffic := range last {
+		lastTraffic[traffic.Host] = traffic
+	}
+	changed := make([]*pb.TrafficInfo, 0)
+	for _, traffic := range cur {
+		if prev, exists := lastTraffic[traffic.Host]; !exists || !proto.Equal(prev, traffic) {
+			changed = append(changed, traffic)
+		}
+		delete(lastTraffic, traffic.Host)
+	}
+	return changed, len(lastTraffic) > 0
+}
+
+// FakeNetmon serves a NetMonitor for every node of a topology over in-memory connections
+type FakeNetmon struct {
+	Topology  *Topology
+	addresses []string
+	listeners map[string]*bufconn.Listener // address -> listener
+	servers   []*grpc.Server
+}
+
+// starts a server for each host, reachable at host:PORT through DialOption
+func NewFakeNetmon(topo *Topology, hosts ...string) *FakeNetmon {
+	fake := &FakeNetmon{Topology: topo, addresses: make([]string, 0), listeners: make(map[string]*bufconn.Listener, 0), servers: make([]*grpc.Server, 0)}
+	for _, host := range hosts {
+		address := net.JoinHostPort(host, PORT)
+		listener := bufconn.Listen(BUF_SIZE)
+		server := grpc.NewServer()
+		pb.RegisterNetMonitorServer(server, &nodeServer{host: host, topo: topo})
+		go server.Serve(listener)
+		fake.addresses = append(fake.addresses, address)
+		fake.listeners[address] = listener
+		fake.servers = append(fake.servers, server)
+	}
+	return fake
+}
+
+func (fake *FakeNetmon) Addresses() []string {
+	return fake.addresses
+}
+
+// dial option that connects the netmon client to the fake servers
+func (fake *FakeNetmon) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+		listener, exists := fake.listeners[address]
+		if !exists {
+			return nil, errors.New("no fake netmon at " + address)
+		}
+		return listener.DialContext(ctx)
+	})
+}
+
+func (fake *FakeNetmon) Close() {
+	for _, server := range fake.servers {
+		server.Stop()
+	}
+}
@@ -0,0 +1,81 @@
+package netmon_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// time a qos call may take, setting a policy runs tc for every limit that changed
+const QOS_TIMEOUT = 15 * time.Second
+
+type qosRpc func(ctx context.Context, client pb.NetMonitorClient) (*pb.QosStatus, error)
+
+// sends the policy of every node, node ip -> limits on what the pods on the node send. Nodes without
+// limits get an empty policy, which removes the limits they had. A policy is not retried, it is meant
+// to be sent again with the next version. Nodes that could not be reached are listed in the errors
+func (netmonClient *NetmonClient) SetQosPolicies(ctx context.Context, version int64, limits map[string][]QosLimit) ([]QosStatus, []NodeError) {
+	return netmonClient.qosAll(ctx, func(host string) qosRpc {
+		policy := &pb.QosPolicy{Version: version, Limits: make([]*pb.QosLimit, 0)}
+		for _, limit := range limits[host] {
+			policy.Limits = append(policy.Limits, &pb.QosLimit{Id: limit.Id, Src: limit.Source, Dst: limit.Destination, Rate: float32(limit.Rate)})
+		}
+		return func(ctx context.Context, client pb.NetMonitorClient) (*pb.QosStatus, error) {
+			return client.SetQosPolicy(ctx, policy)
+		}
+	})
+}
+
+// the limits every node enforces
+func (netmonClient *NetmonClient) GetQosStatus(ctx context.Context) ([]QosStatus, []NodeError) {
+	return netmonClient.qosAll(ctx, func(host string) qosRpc {
+		return func(ctx context.Context, client pb.NetMonitorClient) (*pb.QosStatus, error) {
+			return client.GetQosStatus(ctx, &pb.QosStatusRequest{})
+		}
+	})
+}
+
+func (netmonClient *NetmonClient) qosAll(ctx context.Context, rpcFor func(host string) qosRpc) ([]QosStatus, []NodeError) {
+	results := netmonClient.fetchAll(ctx, func(ctx context.Context, address string) nodeStats {
+		host := getHost(address)
+		client, exists := netmonClient.clients[address]
+		if !exists {
+			return nodeStats{err: &NodeError{Host: host, Message: "not connected"}}
+		}
+		callCtx, cancel := context.WithTimeout(ctx, QOS_TIMEOUT)
+		defer cancel()
+		response, err := rpcFor(host)(callCtx, client)
+		if err != nil {
+			return nodeStats{err: &NodeError{Host: host, Message: err.Error()}}
+		}
+		status := getQosStatus(response, host)
+		return nodeStats{qos: &status}
+	})
+	statuses := make([]QosStatus, 0)
+	nodeErrors := make([]NodeError, 0)
+	for _, result := range results {
+		if result.err != nil {
+			logger(result.err.Error())
+			nodeErrors = append(nodeErrors, *result.err)
+			continue
+		}
+		statuses = append(statuses, *result.qos)
+	}
+	logger(fmt.Sprintf("Got the qos status of %d nodes", len(statuses)))
+	return statuses, nodeErrors
+}
+
+func getQosStatus(response *pb.QosStatus, node string) QosStatus {
+	status := QosStatus{Node: node, Version: response.Version, Backend: response.Backend, Error: response.Error, Limits: make([]QosLimitStatus, 0)}
+	for _, limitStatus := range response.Limits {
+		limit := QosLimit{}
+		if limitStatus.Limit != nil {
+			limit = QosLimit{Id: limitStatus.Limit.Id, Source: limitStatus.Limit.Src, Destination: limitStatus.Limit.Dst, Rate: float64(limitStatus.Limit.Rate)}
+		}
+		status.Limits = append(status.Limits, QosLimitStatus{Limit: limit, Enforced: limitStatus.Enforced, Error: limitStatus.Error,
+			Bytes: limitStatus.Bytes, Drops: limitStatus.Drops})
+	}
+	return status
+}
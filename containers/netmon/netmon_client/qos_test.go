@@ -0,0 +1,46 @@
+package netmon_client
+
+import (
+	"context"
+	"testing"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+func TestSetQosPolicies(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetQosOff("10.0.0.3", true)
+	topo.SetDown("10.0.0.4", true)
+	client, _ := newFakeClient(t, topo)
+	limits := map[string][]QosLimit{"10.0.0.1": {{Id: "front/back", Source: "10.244.1.5", Destination: "10.244.2.7", Rate: 1e6}}}
+
+	statuses, nodeErrors := client.SetQosPolicies(context.Background(), 3, limits)
+	if len(nodeErrors) != 1 || nodeErrors[0].Host != "10.0.0.4" {
+		t.Fatalf("Want only 10.0.0.4 to fail, got %v", nodeErrors)
+	}
+	if len(statuses) != len(FAKE_HOSTS)-1 {
+		t.Fatalf("Want a status from every node up, got %v", statuses)
+	}
+	for _, status := range statuses {
+		switch status.Node {
+		case "10.0.0.1":
+			if status.Version != 3 || len(status.Limits) != 1 || !status.Limits[0].Enforced || status.Limits[0].Limit != limits["10.0.0.1"][0] {
+				t.Fatalf("Want front/back enforced on 10.0.0.1, got %v", status)
+			}
+		case "10.0.0.3":
+			if status.Error == "" || len(status.Limits) != 0 {
+				t.Fatalf("Want 10.0.0.3 to report that it does not enforce limits, got %v", status)
+			}
+		default:
+			// nodes without limits get an empty policy
+			if status.Version != 3 || len(status.Limits) != 0 {
+				t.Fatalf("Want no limits on %s, got %v", status.Node, status)
+			}
+		}
+	}
+
+	statuses, _ = client.GetQosStatus(context.Background())
+	if statuses[0].Node != "10.0.0.1" || len(statuses[0].Limits) != 1 {
+		t.Fatalf("Want the limit of 10.0.0.1 reported, got %v", statuses)
+	}
+}
@@ -0,0 +1,73 @@
+package netmon_client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// route changes that can wait for the reader before the streams stop receiving
+const ROUTE_CHANGES_BUFFERED = 100
+
+// RouteWatch gets the route changes every netmon finds. A stream that fails is opened again with backoff
+// and asks for the changes after the last one it got, so changes netmon still keeps are not missed
+type RouteWatch struct {
+	changes chan RouteChange
+	cancel  context.CancelFunc
+	wg      *sync.WaitGroup
+}
+
+// starts watching the routes of every node until ctx is done or the watch is closed, hops are mapped
+// to nodes with ipMap
+func (netmonClient *NetmonClient) WatchRoutes(ctx context.Context, ipMap map[string]string) *RouteWatch {
+	ctx, cancel := context.WithCancel(ctx)
+	watch := &RouteWatch{changes: make(chan RouteChange, ROUTE_CHANGES_BUFFERED), cancel: cancel, wg: &sync.WaitGroup{}}
+	for _, address := range netmonClient.addresses {
+		watch.wg.Add(1)
+		go func(address string) {
+			defer watch.wg.Done()
+			host := getHost(address)
+			var after uint64
+			netmonClient.keepStreaming(ctx, address, "route watch", func(ctx context.Context, client pb.NetMonitorClient) (bool, error) {
+				received := false
+				stream, err := client.WatchRouteChanges(ctx, &pb.RouteChangeRequest{After: after})
+				if err != nil {
+					return received, err
+				}
+				for {
+					change, err := stream.Recv()
+					if err != nil {
+						return received, err
+					}
+					received = true
+					after = change.Seq
+					select {
+					case watch.changes <- getRouteChange(change, host, ipMap):
+					case <-ctx.Done():
+						return received, ctx.Err()
+					}
+				}
+			}, func(message string) {})
+		}(address)
+	}
+	return watch
+}
+
+func getRouteChange(change *pb.RouteChange, host string, ipMap map[string]string) RouteChange {
+	return RouteChange{Source: host, Destination: change.Host, Version: change.Version,
+		OldHops: nodeHops(host, change.Host, change.OldHops, ipMap), NewHops: nodeHops(host, change.Host, change.NewHops, ipMap),
+		Time: time.Unix(change.Time, 0)}
+}
+
+// gets every route change in the order each node found them
+func (watch *RouteWatch) Changes() <-chan RouteChange {
+	return watch.changes
+}
+
+// stops every stream and waits for them to end
+func (watch *RouteWatch) Close() {
+	watch.cancel()
+	watch.wg.Wait()
+}
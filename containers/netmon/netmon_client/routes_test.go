@@ -0,0 +1,59 @@
+package netmon_client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+// next route change of the watch, fails if there is none in time
+func nextRouteChange(t *testing.T, watch *RouteWatch) RouteChange {
+	select {
+	case change := <-watch.Changes():
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a route change")
+	}
+	return RouteChange{}
+}
+
+func TestWatchRoutes(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetBiLink("10.0.0.2", "10.0.0.3", 100, 1)
+	client, ipMap := newFakeClient(t, topo)
+	client.retryBackoff = time.Millisecond
+	watch := client.WatchRoutes(context.Background(), ipMap)
+	defer watch.Close()
+
+	topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+	// same hops again are no change
+	topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+	change := nextRouteChange(t, watch)
+	want := RouteChange{Source: "10.0.0.1", Destination: "10.0.0.3", Version: 2, OldHops: []string{"10.0.0.1"}, NewHops: []string{"10.0.0.1", "10.0.0.2"}, Time: change.Time}
+	if !reflect.DeepEqual(change, want) {
+		t.Fatalf("Want %v, got %v", want, change)
+	}
+
+	// a change while the stream is down comes once it is back, the ones before are not sent again
+	topo.SetDown("10.0.0.1", true)
+	topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.4", "10.0.0.2")
+	topo.SetDown("10.0.0.1", false)
+	change = nextRouteChange(t, watch)
+	if change.Version != 3 || !reflect.DeepEqual(change.NewHops, []string{"10.0.0.1", "10.0.0.4", "10.0.0.2"}) {
+		t.Fatalf("Want version 3 over 10.0.0.4, got %v", change)
+	}
+	select {
+	case change := <-watch.Changes():
+		t.Fatalf("Want no more changes, got %v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, paths, _, _ := client.GetStats(context.Background(), ipMap, false)
+	if paths["10.0.0.1"]["10.0.0.3"].RouteVersion != 3 || paths["10.0.0.1"]["10.0.0.2"].RouteVersion != 1 {
+		t.Fatalf("Want route versions 3 and 1, got %v", paths["10.0.0.1"])
+	}
+}
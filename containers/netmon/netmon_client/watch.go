@@ -0,0 +1,276 @@
+package netmon_client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// what one node sent on its WatchNetInfo stream so far, by destination host
+type nodeView struct {
+	bw       map[string]*pb.BandwidthInfo
+	headroom map[string]*pb.BandwidthInfo
+	tr       map[string]*pb.TracerouteInfo
+	lat      map[string]*pb.LatencyInfo
+	bpf      map[string]*pb.TrafficInfo
+	bpfSent  map[string]*pb.TrafficInfo
+	time     time.Time // last update
+}
+
+func newNodeView() *nodeView {
+	return &nodeView{bw: make(map[string]*pb.BandwidthInfo, 0), headroom: make(map[string]*pb.BandwidthInfo, 0),
+		tr: make(map[string]*pb.TracerouteInfo, 0), lat: make(map[string]*pb.LatencyInfo, 0), bpf: make(map[string]*pb.TrafficInfo, 0),
+		bpfSent: make(map[string]*pb.TrafficInfo, 0)}
+}
+
+func (view *nodeView) apply(update *pb.NetInfoUpdate) {
+	if update.Full {
+		*view = *newNodeView()
+	}
+	for _, bw := range update.BwInfo {
+		view.bw[bw.Host] = bw
+	}
+	for _, hr := range update.HeadroomInfo {
+		view.headroom[hr.Host] = hr
+	}
+	for _, tr := range update.TrInfo {
+		view.tr[tr.Host] = tr
+	}
+	for _, lat := range update.LatInfo {
+		view.lat[lat.Host] = lat
+	}
+	for _, bpf := range update.BpfInfo {
+		view.bpf[bpf.Host] = bpf
+	}
+	for _, bpf := range update.BpfSentInfo {
+		view.bpfSent[bpf.Host] = bpf
+	}
+	view.time = time.Now()
+}
+
+// the view as a GetNetInfo reply, or a GetHeadroomInfo reply if headroom is set. Hosts are sorted so
+// the same view always gives the same reply
+func (view *nodeView) reply(headroom bool) *pb.NetInfoReply {
+	bws := view.bw
+	if headroom {
+		bws = view.headroom
+	}
+	reply := &pb.NetInfoReply{BwInfo: make([]*pb.BandwidthInfo, 0), TrInfo: make([]*pb.TracerouteInfo, 0), LatInfo: make([]*pb.LatencyInfo, 0), BpfInfo: make([]*pb.TrafficInfo, 0),
+		BpfSentInfo: make([]*pb.TrafficInfo, 0)}
+	for _, bw := range bws {
+		reply.BwInfo = append(reply.BwInfo, bw)
+	}
+	for _, tr := range view.tr {
+		reply.TrInfo = append(reply.TrInfo, tr)
+	}
+	for _, lat := range view.lat {
+		reply.LatInfo = append(reply.LatInfo, lat)
+	}
+	sort.Slice(reply.BwInfo, func(i, j int) bool { return reply.BwInfo[i].Host < reply.BwInfo[j].Host })
+	sort.Slice(reply.TrInfo, func(i, j int) bool { return reply.TrInfo[i].Host < reply.TrInfo[j].Host })
+	for _, bpf := range view.bpf {
+		reply.BpfInfo = append(reply.BpfInfo, bpf)
+	}
+	for _, bpf := range view.bpfSent {
+		reply.BpfSentInfo = append(reply.BpfSentInfo, bpf)
+	}
+	sort.Slice(reply.LatInfo, func(i, j int) bool { return reply.LatInfo[i].Host < reply.LatInfo[j].Host })
+	sort.Slice(reply.BpfInfo, func(i, j int) bool { return reply.BpfInfo[i].Host < reply.BpfInfo[j].Host })
+	sort.Slice(reply.BpfSentInfo, func(i, j int) bool { return reply.BpfSentInfo[i].Host < reply.BpfSentInfo[j].Host })
+	return reply
+}
+
+// NetWatch keeps the stats of every node up to date from the WatchNetInfo stream of its netmon, so
+// they can be read at any time without waiting for an rpc. A stream that fails is opened again with backoff
+type NetWatch struct {
+	netmonClient *NetmonClient
+	ipMap        map[string]string
+	lock         *sync.Mutex
+	nodes        map[string]*nodeView // address -> what the node sent, nil before its first update
+	errors       map[string]string    // address -> why the stream of the node is down
+	links        LinkSet
+	paths        PathSet
+	traffic      TrafficSet
+	hrLinks      LinkSet
+	hrPaths      PathSet
+	hrTraffic    TrafficSet
+	changed      chan bool
+	cancel       context.CancelFunc
+	wg           *sync.WaitGroup
+}
+
+// starts watching every node until ctx is done or the watch is closed
+func (netmonClient *NetmonClient) Watch(ctx context.Context, ipMap map[string]string) *NetWatch {
+	ctx, cancel := context.WithCancel(ctx)
+	watch := &NetWatch{netmonClient: netmonClient, ipMap: ipMap, lock: &sync.Mutex{},
+		nodes: make(map[string]*nodeView, 0), errors: make(map[string]string, 0),
+		changed: make(chan bool, 1), cancel: cancel, wg: &sync.WaitGroup{}}
+	for _, address := range netmonClient.addresses {
+		watch.errors[address] = "no update yet"
+	}
+	watch.rebuild()
+	for _, address := range netmonClient.addresses {
+		watch.wg.Add(1)
+		go func(address string) {
+			defer watch.wg.Done()
+			watch.watchNode(ctx, address)
+		}(address)
+	}
+	return watch
+}
+
+// stops every stream and waits for them to end
+func (watch *NetWatch) Close() {
+	watch.cancel()
+	watch.wg.Wait()
+}
+
+// gets a value after the view changed, several changes may be signalled only once
+func (watch *NetWatch) Changed() <-chan bool {
+	return watch.changed
+}
+
+// current links, paths and traffic like GetStats returns them, and the nodes whose stream is down.
+// The sets are shared with other readers and must not be modified
+func (watch *NetWatch) Current() (LinkSet, PathSet, TrafficSet, []NodeError) {
+	watch.lock.Lock()
+	defer watch.lock.Unlock()
+	return watch.links, watch.paths, watch.traffic, watch.nodeErrorsLocked()
+}
+
+// current headroom last measured by every node, like GetHeadroomStats returns it for the last headroom requested
+func (watch *NetWatch) CurrentHeadroom() (LinkSet, PathSet, TrafficSet, []NodeError) {
+	watch.lock.Lock()
+	defer watch.lock.Unlock()
+	return watch.hrLinks, watch.hrPaths, watch.hrTraffic, watch.nodeErrorsLocked()
+}
+
+// errors in the order of the addresses, the data of a node is cached if it sent anything before
+func (watch *NetWatch) nodeErrorsLocked() []NodeError {
+	nodeErrors := make([]NodeError, 0)
+	for _, address := range watch.netmonClient.addresses {
+		message, exists := watch.errors[address]
+		if !exists {
+			continue
+		}
+		nodeErr := NodeError{Host: getHost(address), Message: message}
+		if view := watch.nodes[address]; view != nil {
+			nodeErr.Cached = true
+			nodeErr.Age = time.Since(view.time)
+		}
+		nodeErrors = append(nodeErrors, nodeErr)
+	}
+	return nodeErrors
+}
+
+// keeps a stream open to the node until ctx is done, the backoff between attempts doubles up to the node
+// backoff max and starts over once something came through. stream returns whether it received anything,
+// failed gets why the node could not be streamed from
+func (netmonClient *NetmonClient) keepStreaming(ctx context.Context, address string, what string,
+	stream func(ctx context.Context, client pb.NetMonitorClient) (bool, error), failed func(message string)) {
+	client, exists := netmonClient.clients[address]
+	if !exists {
+		failed("not connected")
+		return
+	}
+	backoff := netmonClient.retryBackoff
+	for {
+		received, err := stream(ctx, client)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = netmonClient.retryBackoff
+		}
+		logger(fmt.Sprintf("netmon %s %s failed: %v, retrying in %v", getHost(address), what, err, backoff))
+		failed(err.Error())
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > netmonClient.nodeBackoffMax {
+			backoff = netmonClient.nodeBackoffMax
+		}
+	}
+}
+
+func (watch *NetWatch) watchNode(ctx context.Context, address string) {
+	watch.netmonClient.keepStreaming(ctx, address, "watch", func(ctx context.Context, client pb.NetMonitorClient) (bool, error) {
+		return watch.stream(ctx, address, client)
+	}, func(message string) {
+		watch.setError(address, message)
+	})
+}
+
+// applies the updates of one stream until it fails, received is set if any update came through
+func (watch *NetWatch) stream(ctx context.Context, address string, client pb.NetMonitorClient) (bool, error) {
+	stream, err := client.WatchNetInfo(ctx, &pb.WatchRequest{Aggregate: watch.netmonClient.bwAggregate, Window: watch.netmonClient.bwWindow})
+	if err != nil {
+		return false, err
+	}
+	received := false
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		watch.lock.Lock()
+		view := watch.nodes[address]
+		if view == nil {
+			view = newNodeView()
+			watch.nodes[address] = view
+		}
+		view.apply(update)
+		delete(watch.errors, address)
+		watch.lock.Unlock()
+		watch.rebuild()
+	}
+}
+
+func (watch *NetWatch) setError(address string, message string) {
+	watch.lock.Lock()
+	watch.errors[address] = message
+	watch.lock.Unlock()
+	watch.notify()
+}
+
+// merges the views of all nodes into new sets, the same way GetStats and GetHeadroomStats merge replies
+func (watch *NetWatch) rebuild() {
+	watch.lock.Lock()
+	defer watch.lock.Unlock()
+	netmonClient := watch.netmonClient
+	results := make([]nodeStats, 0)
+	hrResults := make([]nodeStats, 0)
+	for _, address := range netmonClient.addresses {
+		view := watch.nodes[address]
+		if view == nil {
+			continue
+		}
+		host := getHost(address)
+		result := nodeStats{address: address}
+		result.links, result.paths, result.traffic = netmonClient.ProcessResponse(view.reply(false), host, watch.ipMap)
+		results = append(results, result)
+		hrResult := nodeStats{address: address}
+		hrResult.links, hrResult.paths, hrResult.traffic = netmonClient.ProcessResponse(view.reply(true), host, watch.ipMap)
+		hrResults = append(hrResults, hrResult)
+	}
+	links, paths, traffic, _ := mergeStats(results)
+	watch.links, watch.paths, watch.traffic = links, netmonClient.ComputePathBw(links, paths), netmonClient.ComputePathTraffic(traffic, paths)
+	links, paths, traffic, _ = mergeStats(hrResults)
+	watch.hrLinks, watch.hrPaths, watch.hrTraffic = links, netmonClient.ComputePathBw(links, paths), netmonClient.ComputePathTraffic(traffic, paths)
+	watch.notify()
+}
+
+func (watch *NetWatch) notify() {
+	select {
+	case watch.changed <- true:
+	default:
+	}
+}
@@ -0,0 +1,115 @@
+package netmon_client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon_client/netmontest"
+)
+
+// waits until check passes on the watch, checked again after every change
+func waitForWatch(t *testing.T, watch *NetWatch, what string, check func() bool) {
+	timeout := time.After(5 * time.Second)
+	for !check() {
+		select {
+		case <-watch.Changed():
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+	}
+}
+
+// the watch has the same view as polling every node
+func sameAsPolled(client *NetmonClient, watch *NetWatch, ipMap map[string]string) bool {
+	links, paths, traffic, nodeErrors := watch.Current()
+	wantLinks, wantPaths, wantTraffic, _ := client.GetStats(context.Background(), ipMap, false)
+	return len(nodeErrors) == 0 && reflect.DeepEqual(links, wantLinks) && reflect.DeepEqual(paths, wantPaths) && reflect.DeepEqual(traffic, wantTraffic)
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(topo *netmontest.Topology)
+	}{
+		{"traffic changes", func(topo *netmontest.Topology) {
+			topo.Step()
+		}},
+		{"link bw changes", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 40, 1)
+		}},
+		{"new link", func(topo *netmontest.Topology) {
+			topo.SetBiLink("10.0.0.3", "10.0.0.4", 70, 2)
+		}},
+		{"route changes", func(topo *netmontest.Topology) {
+			topo.SetRoute("10.0.0.1", "10.0.0.3", "10.0.0.2")
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topo := netmontest.NewTopology()
+			topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+			topo.SetBiLink("10.0.0.2", "10.0.0.3", 50, 1)
+			topo.SetLink("10.0.0.1", "10.0.0.3", 80, 1)
+			topo.SetTraffic("10.0.0.1", "10.0.0.2", 10, 40)
+			client, ipMap := newFakeClient(t, topo)
+			watch := client.Watch(context.Background(), ipMap)
+			defer watch.Close()
+
+			waitForWatch(t, watch, "the first updates", func() bool { return sameAsPolled(client, watch, ipMap) })
+			links, paths, traffic, _ := watch.Current()
+			test.change(topo)
+			waitForWatch(t, watch, "the change", func() bool { return sameAsPolled(client, watch, ipMap) })
+			newLinks, newPaths, newTraffic, _ := watch.Current()
+			if reflect.DeepEqual(links, newLinks) && reflect.DeepEqual(paths, newPaths) && reflect.DeepEqual(traffic, newTraffic) {
+				t.Fatalf("Want the view to change")
+			}
+		})
+	}
+}
+
+func TestWatchHeadroom(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	topo.SetTraffic("10.0.0.1", "10.0.0.2", 10, 70)
+	client, ipMap := newFakeClient(t, topo)
+	watch := client.Watch(context.Background(), ipMap)
+	defer watch.Close()
+
+	client.GetHeadroomStats(context.Background(), ipMap, map[string]map[string]float32{"10.0.0.1": {"10.0.0.2": 60}})
+	for _, want := range []float64{60, 30} {
+		waitForWatch(t, watch, "the headroom", func() bool {
+			_, paths, _, _ := watch.CurrentHeadroom()
+			return paths["10.0.0.1"]["10.0.0.2"].Bandwidth == want
+		})
+		topo.Step()
+	}
+}
+
+func TestWatchReconnect(t *testing.T) {
+	topo := netmontest.NewTopology()
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 100, 1)
+	client, ipMap := newFakeClient(t, topo)
+	client.retryBackoff = time.Millisecond
+	watch := client.Watch(context.Background(), ipMap)
+	defer watch.Close()
+	waitForWatch(t, watch, "the first updates", func() bool { return sameAsPolled(client, watch, ipMap) })
+
+	// the last view of a node stays while its stream is down
+	topo.SetDown("10.0.0.2", true)
+	waitForWatch(t, watch, "10.0.0.2 down", func() bool {
+		_, _, _, nodeErrors := watch.Current()
+		return len(nodeErrors) == 1 && nodeErrors[0].Host == "10.0.0.2" && nodeErrors[0].Cached
+	})
+	if _, paths, _, _ := watch.Current(); paths["10.0.0.2"]["10.0.0.1"].Bandwidth != 100 {
+		t.Fatalf("Want the cached paths of 10.0.0.2, got %v", paths["10.0.0.2"])
+	}
+
+	topo.SetBiLink("10.0.0.1", "10.0.0.2", 30, 1)
+	topo.SetDown("10.0.0.2", false)
+	waitForWatch(t, watch, "10.0.0.2 back", func() bool { return sameAsPolled(client, watch, ipMap) })
+	if _, paths, _, _ := watch.Current(); paths["10.0.0.2"]["10.0.0.1"].Bandwidth != 30 {
+		t.Fatalf("Want the paths of 10.0.0.2 after it came back, got %v", paths["10.0.0.2"])
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// the egress program is pinned here so tc can attach it
+const EGRESS_PIN = "/sys/fs/bpf/netmon_egress"
+
+const BPF_OBJ_PIN = 6
+
+// pins the bpf object fd at path, path has to be on a bpf filesystem
+func pinObject(fd int, path string) error {
+	pathPtr, err := unix.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attr := struct {
+		pathname  uint64
+		bpfFd     uint32
+		fileFlags uint32
+	}{pathname: uint64(uintptr(unsafe.Pointer(pathPtr))), bpfFd: uint32(fd)}
+	_, _, errno := unix.Syscall(unix.SYS_BPF, BPF_OBJ_PIN, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func runTc(args ...string) error {
+	out, err := exec.Command("tc", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tc %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// attaches the sched_cls program fd to the egress of device through a clsact qdisc
+func attachEgress(fd int, device string) error {
+	os.Remove(EGRESS_PIN)
+	if err := pinObject(fd, EGRESS_PIN); err != nil {
+		return fmt.Errorf("pin %s: %v", EGRESS_PIN, err)
+	}
+	if err := runTc("qdisc", "replace", "dev", device, "clsact"); err != nil {
+		return err
+	}
+	return runTc("filter", "replace", "dev", device, "egress", "prio", "1", "handle", "1", "bpf", "direct-action", "pinned", EGRESS_PIN)
+}
+
+// removes the filter, the clsact qdisc stays in case others use it
+func detachEgress(device string) {
+	if err := runTc("filter", "del", "dev", device, "egress", "prio", "1", "handle", "1", "bpf"); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove tc egress prog from %s: %v\n", device, err)
+	}
+	os.Remove(EGRESS_PIN)
+}
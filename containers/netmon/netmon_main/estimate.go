@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// packet trains per capacity estimate
+const TRAIN_COUNT = 10
+
+// packets per train, sent back to back
+const TRAIN_LENGTH = 16
+
+const TRAIN_PACKET_SIZE = 1400
+
+// ip and udp headers, counted in the dispersion
+const TRAIN_HEADER_OVERHEAD = 28
+
+// between trains so the queue one train built up is gone before the next
+const TRAIN_GAP = 50 * time.Millisecond
+
+// a train whose report did not come back after this long is lost
+const TRAIN_TIMEOUT = 500 * time.Millisecond
+
+// the receiver forgets trains it did not see the end of after this long
+const TRAIN_STATE_TIMEOUT = 10 * time.Second
+
+// train packet: session, train, sequence number, train length
+const TRAIN_HEADER_LEN = 16
+
+// report of a train: session, train, packets received, first and last sequence number, dispersion in ns
+const TRAIN_REPORT_LEN = 32
+
+// rates of the headroom probes as fractions of the requested headroom
+var ADAPTIVE_STEPS = []float64{0.25, 0.5, 1}
+
+const ADAPTIVE_STEP_DURATION = 300 * time.Millisecond
+
+// a rate the path carries gets at least this fraction of it through
+const ADAPTIVE_DELIVERED = 0.9
+
+// for a 95% confidence interval
+const CONFIDENCE_Z = 1.96
+
+var trainSessions uint64 = uint64(time.Now().UnixNano())
+
+// TrainMeasurer estimates bandwidth with little traffic of its own. Capacity comes from the dispersion
+// of short packet trains, headroom from short probes at rising rates up to the requested headroom that
+// stop at the first rate the path does not carry. Latency and traceroute are the GoMeasurer's
+type TrainMeasurer struct {
+	*GoMeasurer
+}
+
+func NewTrainMeasurer(probePort int) *TrainMeasurer {
+	return &TrainMeasurer{GoMeasurer: NewGoMeasurer(probePort)}
+}
+
+func (m *TrainMeasurer) Bandwidth(ctx context.Context, host string, limit float64) (Bandwidth, error) {
+	if err := probeJitter(ctx); err != nil {
+		return Bandwidth{}, err
+	}
+	if limit > 0 {
+		return m.adaptive(ctx, host, limit)
+	}
+	return m.trains(ctx, host)
+}
+
+// capacity from TRAIN_COUNT trains, the mean of the estimates of the trains that came back and its 95% confidence interval
+func (m *TrainMeasurer) trains(ctx context.Context, host string) (Bandwidth, error) {
+	raddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(m.probePort)))
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	defer conn.Close()
+	session := atomic.AddUint64(&trainSessions, 1)
+	packet := make([]byte, TRAIN_PACKET_SIZE)
+	samples := make([]float64, 0)
+	sent := 0
+	for train := 0; train < TRAIN_COUNT; train++ {
+		if err := ctx.Err(); err != nil {
+			return Bandwidth{}, err
+		}
+		binary.BigEndian.PutUint64(packet[0:8], session)
+		binary.BigEndian.PutUint32(packet[8:12], uint32(train))
+		binary.BigEndian.PutUint16(packet[14:16], TRAIN_LENGTH)
+		for seq := 0; seq < TRAIN_LENGTH; seq++ {
+			binary.BigEndian.PutUint16(packet[12:14], uint16(seq))
+			n, err := conn.Write(packet)
+			if err != nil {
+				return Bandwidth{}, fmt.Errorf("train to %s: %v", host, err)
+			}
+			sent += n
+		}
+		if rate, ok := readTrainReport(conn, session, uint32(train)); ok {
+			samples = append(samples, rate)
+		}
+		time.Sleep(TRAIN_GAP)
+	}
+	if len(samples) == 0 {
+		return Bandwidth{}, fmt.Errorf("no train to %s came back", host)
+	}
+	mean, halfWidth := meanInterval(samples)
+	log.Printf("trains to %s: %d of %d back, capacity %f +- %f", host, len(samples), TRAIN_COUNT, mean, halfWidth)
+	return Bandwidth{Host: host, Snd: float64(sent), SndBw: mean, RcvBw: mean, RcvBwLow: math.Max(0, mean-halfWidth), RcvBwHigh: mean + halfWidth, Estimator: "train"}, nil
+}
+
+// bits/s the receiver saw the train arrive at, false if the report did not come back or the train was too short to tell
+func readTrainReport(conn *net.UDPConn, session uint64, train uint32) (float64, bool) {
+	conn.SetReadDeadline(time.Now().Add(TRAIN_TIMEOUT))
+	report := make([]byte, TRAIN_REPORT_LEN)
+	for {
+		n, err := conn.Read(report)
+		if err != nil {
+			return 0, false
+		}
+		if n < TRAIN_REPORT_LEN || binary.BigEndian.Uint64(report[0:8]) != session || binary.BigEndian.Uint32(report[8:12]) != train {
+			continue
+		}
+		first := binary.BigEndian.Uint32(report[16:20])
+		last := binary.BigEndian.Uint32(report[20:24])
+		dispersion := time.Duration(binary.BigEndian.Uint64(report[24:32]))
+		if last <= first || dispersion <= 0 {
+			return 0, false
+		}
+		return float64(8*int(last-first)*(TRAIN_PACKET_SIZE+TRAIN_HEADER_OVERHEAD)) / dispersion.Seconds(), true
+	}
+}
+
+// mean and half the width of its 95% confidence interval, a single sample is only trusted to within itself
+func meanInterval(samples []float64) (float64, float64) {
+	sum := 0.0
+	for _, sample := range samples {
+		sum += sample
+	}
+	mean := sum / float64(len(samples))
+	if len(samples) == 1 {
+		return mean, mean
+	}
+	squares := 0.0
+	for _, sample := range samples {
+		squares += (sample - mean) * (sample - mean)
+	}
+	stddev := math.Sqrt(squares / float64(len(samples)-1))
+	return mean, CONFIDENCE_Z * stddev / math.Sqrt(float64(len(samples)))
+}
+
+// probes at every step of limit until one does not get through. The headroom is what arrived at the
+// last rate probed, the interval goes from what the last carried rate delivered to the first rate that
+// was not carried, or to limit if every rate was
+func (m *TrainMeasurer) adaptive(ctx context.Context, host string, limit float64) (Bandwidth, error) {
+	result := Bandwidth{Host: host, Estimator: "adaptive"}
+	low, high := 0.0, limit
+	for _, step := range ADAPTIVE_STEPS {
+		rate := step * limit
+		bw, err := m.probe(ctx, host, rate, ADAPTIVE_STEP_DURATION)
+		if err != nil {
+			return Bandwidth{}, err
+		}
+		result.Snd += bw.Snd
+		result.Rcv += bw.Rcv
+		result.SndBw, result.RcvBw = bw.SndBw, bw.RcvBw
+		if bw.RcvBw < ADAPTIVE_DELIVERED*rate {
+			high = rate
+			break
+		}
+		low = bw.RcvBw
+	}
+	result.RcvBwLow = math.Min(low, result.RcvBw)
+	result.RcvBwHigh = math.Max(high, result.RcvBw)
+	log.Printf("adaptive probe to %s up to %f: headroom %f in [%f, %f]", host, limit, result.RcvBw, result.RcvBwLow, result.RcvBwHigh)
+	return result, nil
+}
+
+type trainKey struct {
+	addr    string
+	session uint64
+	train   uint32
+}
+
+type trainState struct {
+	first     uint32
+	last      uint32
+	received  uint32
+	firstTime time.Time
+	lastTime  time.Time
+}
+
+// answers the packet trains of the other nodes, a train is reported when its last packet arrives
+func serveTrains(conn net.PacketConn) {
+	trains := make(map[trainKey]*trainState, 0)
+	buf := make([]byte, 65536)
+	lastCleanup := time.Now()
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		now := time.Now()
+		if err != nil {
+			log.Printf("train server: %v", err)
+			return
+		}
+		if n < TRAIN_HEADER_LEN {
+			continue
+		}
+		key := trainKey{addr: addr.String(), session: binary.BigEndian.Uint64(buf[0:8]), train: binary.BigEndian.Uint32(buf[8:12])}
+		seq := uint32(binary.BigEndian.Uint16(buf[12:14]))
+		length := uint32(binary.BigEndian.Uint16(buf[14:16]))
+		state, exists := trains[key]
+		if !exists {
+			state = &trainState{first: seq, firstTime: now}
+			trains[key] = state
+		}
+		state.received += 1
+		state.last = seq
+		state.lastTime = now
+		if seq+1 >= length {
+			report := make([]byte, TRAIN_REPORT_LEN)
+			binary.BigEndian.PutUint64(report[0:8], key.session)
+			binary.BigEndian.PutUint32(report[8:12], key.train)
+			binary.BigEndian.PutUint32(report[12:16], state.received)
+			binary.BigEndian.PutUint32(report[16:20], state.first)
+			binary.BigEndian.PutUint32(report[20:24], state.last)
+			binary.BigEndian.PutUint64(report[24:32], uint64(state.lastTime.Sub(state.firstTime)))
+			if _, err := conn.WriteTo(report, addr); err != nil {
+				log.Printf("train report to %v: %v", addr, err)
+			}
+			delete(trains, key)
+		}
+		if now.Sub(lastCleanup) > TRAIN_STATE_TIMEOUT {
+			for k, st := range trains {
+				if now.Sub(st.lastTime) > TRAIN_STATE_TIMEOUT {
+					delete(trains, k)
+				}
+			}
+			lastCleanup = now
+		}
+	}
+}
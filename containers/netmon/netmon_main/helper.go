@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// HelperMeasurer asks the python net_helper for every measurement
+type HelperMeasurer struct {
+	address   string // ip:port of the helper
+	netClient http.Client
+}
+
+func NewHelperMeasurer(address string) *HelperMeasurer {
+	return &HelperMeasurer{address: address, netClient: http.Client{}}
+}
+
+func (m *HelperMeasurer) query(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	reqURL := "http://" + m.address + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.netClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not read response body: %v", path, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: status code %d: %s", path, res.StatusCode, resBody)
+	}
+	log.Printf("helper %s: %s", path, resBody)
+	return resBody, nil
+}
+
+func (m *HelperMeasurer) Bandwidth(ctx context.Context, host string, limit float64) (Bandwidth, error) {
+	query := url.Values{"host": {host}}
+	if limit > 0 {
+		query.Set("bwmax", fmt.Sprintf("%f", limit))
+	}
+	bwResponse, err := m.query(ctx, "/bw", query)
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	bwInfo, err := GetBwResults(bwResponse)
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	if len(bwInfo.BandwidthResults) == 0 {
+		return Bandwidth{}, fmt.Errorf("helper has no bw to %s, iperf kept failing", host)
+	}
+	bw := bwInfo.BandwidthResults[0]
+	bw.Estimator = "probe"
+	return bw, nil
+}
+
+func (m *HelperMeasurer) Latency(ctx context.Context, host string) (Latency, error) {
+	latencyResponse, err := m.query(ctx, "/latency", url.Values{"host": {host}})
+	if err != nil {
+		return Latency{}, err
+	}
+	latencyInfo, err := GetLatencyResults(latencyResponse)
+	if err != nil {
+		return Latency{}, err
+	}
+	if len(latencyInfo.LatencyResults) == 0 {
+		return Latency{}, fmt.Errorf("helper has no latency to %s", host)
+	}
+	return latencyInfo.LatencyResults[0], nil
+}
+
+// the helper traces the hosts of its own config, hosts is ignored
+func (m *HelperMeasurer) Traceroute(ctx context.Context, hosts []string) (TracerouteResults, error) {
+	trResponse, err := m.query(ctx, "/traceroute", nil)
+	if err != nil {
+		return TracerouteResults{}, err
+	}
+	return GetTrResults(trResponse)
+}
+
+func GetBwResults(bwResponse []byte) (BandwidthResults, error) {
+	var bwInfo BandwidthResults
+	if err := json.Unmarshal(bwResponse, &bwInfo); err != nil {
+		return bwInfo, fmt.Errorf("bad bw results %s: %v", bwResponse, err)
+	}
+	return bwInfo, nil
+}
+
+func GetTrResults(trResponse []byte) (TracerouteResults, error) {
+	var trInfo TracerouteResults
+	if err := json.Unmarshal(trResponse, &trInfo); err != nil {
+		return trInfo, fmt.Errorf("bad traceroute results %s: %v", trResponse, err)
+	}
+	return trInfo, nil
+}
+
+func GetLatencyResults(latencyResponse []byte) (LatencyResults, error) {
+	var latencyInfo LatencyResults
+	if err := json.Unmarshal(latencyResponse, &latencyInfo); err != nil {
+		return latencyInfo, fmt.Errorf("bad latency results %s: %v", latencyResponse, err)
+	}
+	return latencyInfo, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// weight of the newest sample in the EWMA
+const HISTORY_EWMA_ALPHA = 0.3
+
+// byte counters kept per peer for the traffic rate of GetStats
+const TRAFFIC_HISTORY_SIZE = 10
+
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// History is a ring of the last size samples of one value
+type History struct {
+	samples []Sample
+	next    int // where the next sample goes once the ring is full
+	size    int
+}
+
+func NewHistory(size int) *History {
+	return &History{samples: make([]Sample, 0, size), size: size}
+}
+
+// replaces the oldest sample once there are size of them
+func (h *History) Add(now time.Time, value float64) {
+	if len(h.samples) < h.size {
+		h.samples = append(h.samples, Sample{Time: now, Value: value})
+		return
+	}
+	h.samples[h.next] = Sample{Time: now, Value: value}
+	h.next = (h.next + 1) % h.size
+}
+
+// samples taken at or after since, oldest first. The zero time gives all of them
+func (h *History) Samples(since time.Time) []Sample {
+	samples := make([]Sample, 0)
+	for i := 0; i < len(h.samples); i++ {
+		sample := h.samples[(h.next+i)%len(h.samples)]
+		if !sample.Time.Before(since) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// per second change from the oldest to the newest sample, for counters. 0 with fewer than 2 samples
+func (h *History) Rate() float64 {
+	samples := h.Samples(time.Time{})
+	if len(samples) < 2 {
+		return 0
+	}
+	oldest, newest := samples[0], samples[len(samples)-1]
+	elapsed := newest.Time.Sub(oldest.Time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return (newest.Value - oldest.Value) / elapsed
+}
+
+// aggregate of the samples, oldest first. 0 if there are none
+func aggregate(samples []Sample, agg pb.Aggregate) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]float64, 0)
+	for _, sample := range samples {
+		values = append(values, sample.Value)
+	}
+	switch agg {
+	case pb.Aggregate_EWMA:
+		ewma := values[0]
+		for _, value := range values[1:] {
+			ewma = HISTORY_EWMA_ALPHA*value + (1-HISTORY_EWMA_ALPHA)*ewma
+		}
+		return ewma
+	case pb.Aggregate_P5:
+		return percentile(values, 5)
+	case pb.Aggregate_P50:
+		return percentile(values, 50)
+	case pb.Aggregate_P95:
+		return percentile(values, 95)
+	case pb.Aggregate_MIN:
+		return percentile(values, 0)
+	}
+	return values[len(values)-1]
+}
+
+// nearest rank percentile, values are sorted in place
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
+
+// history of the bw measured to one peer
+type peerHistory struct {
+	sndBw     *History
+	rcvBw     *History
+	rcvBwLow  *History
+	rcvBwHigh *History
+	estimator string // of the last measurement
+}
+
+// BwHistory keeps the last measurements of the bw to every peer
+type BwHistory struct {
+	size  int
+	peers map[string]*peerHistory
+	lock  *sync.Mutex
+}
+
+func NewBwHistory(size int) *BwHistory {
+	return &BwHistory{size: size, peers: make(map[string]*peerHistory, 0), lock: &sync.Mutex{}}
+}
+
+func (bh *BwHistory) Add(now time.Time, bw Bandwidth) {
+	bh.lock.Lock()
+	defer bh.lock.Unlock()
+	peer, exists := bh.peers[bw.Host]
+	if !exists {
+		peer = &peerHistory{sndBw: NewHistory(bh.size), rcvBw: NewHistory(bh.size), rcvBwLow: NewHistory(bh.size), rcvBwHigh: NewHistory(bh.size)}
+		bh.peers[bw.Host] = peer
+	}
+	peer.sndBw.Add(now, bw.SndBw)
+	peer.rcvBw.Add(now, bw.RcvBw)
+	peer.rcvBwLow.Add(now, bw.RcvBwLow)
+	peer.rcvBwHigh.Add(now, bw.RcvBwHigh)
+	peer.estimator = bw.Estimator
+}
+
+// every bw of host aggregated over the measurements of the last window, all of them if window is 0.
+// False if there is no measurement in the window
+func (bh *BwHistory) Aggregate(host string, agg pb.Aggregate, window time.Duration) (Bandwidth, bool) {
+	bh.lock.Lock()
+	defer bh.lock.Unlock()
+	peer, exists := bh.peers[host]
+	if !exists {
+		return Bandwidth{}, false
+	}
+	since := time.Time{}
+	if window > 0 {
+		since = time.Now().Add(-window)
+	}
+	rcvBw := peer.rcvBw.Samples(since)
+	if len(rcvBw) == 0 {
+		return Bandwidth{}, false
+	}
+	return Bandwidth{Host: host, SndBw: aggregate(peer.sndBw.Samples(since), agg), RcvBw: aggregate(rcvBw, agg),
+		RcvBwLow: aggregate(peer.rcvBwLow.Samples(since), agg), RcvBwHigh: aggregate(peer.rcvBwHigh.Samples(since), agg), Estimator: peer.estimator}, true
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// echo requests per latency measurement, as ping -c 10 in the helper
+const PING_COUNT = 10
+
+const PING_INTERVAL = 200 * time.Millisecond
+
+// an echo request or traceroute probe without an answer after this long is lost
+const ICMP_TIMEOUT = time.Second
+
+const TRACEROUTE_MAX_HOPS = 30
+
+// the first destination port of the traceroute probes, one more for every hop
+const TRACEROUTE_PORT = 33434
+
+// a trace stops after this many hops in a row did not answer
+const TRACEROUTE_MAX_SILENT = 3
+
+const PROTOCOL_ICMP = 1
+const PROTOCOL_UDP = 17
+
+// every ping gets its own echo id, raw ICMP sockets see the replies to all of them
+var pingIds uint32 = uint32(os.Getpid())
+
+var errIcmpTimeout = errors.New("timed out")
+
+func resolveIPv4(host string) (net.IP, error) {
+	addr, err := net.ResolveIPAddr("ip4", host)
+	if err != nil {
+		return nil, err
+	}
+	return addr.IP, nil
+}
+
+// reads ICMP messages until match accepts one or the wait is over, the wait ends at the ctx deadline if that is sooner
+func readIcmp(ctx context.Context, conn *icmp.PacketConn, wait time.Duration, match func(msg *icmp.Message, peer net.IP) bool) error {
+	deadline := time.Now().Add(wait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return errIcmpTimeout
+			}
+			return err
+		}
+		msg, err := icmp.ParseMessage(PROTOCOL_ICMP, buf[:n])
+		if err != nil {
+			continue
+		}
+		if match(msg, peer.(*net.IPAddr).IP) {
+			return nil
+		}
+	}
+}
+
+// mean round trip time of PING_COUNT echo requests, the lost ones are left out
+func (m *GoMeasurer) Latency(ctx context.Context, host string) (Latency, error) {
+	dst, err := resolveIPv4(host)
+	if err != nil {
+		return Latency{}, err
+	}
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return Latency{}, err
+	}
+	defer conn.Close()
+	id := int(atomic.AddUint32(&pingIds, 1) & 0xffff)
+	total := time.Duration(0)
+	replies := 0
+	for seq := 0; seq < PING_COUNT; seq++ {
+		msg := icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("netmon")}}
+		b, err := msg.Marshal(nil)
+		if err != nil {
+			return Latency{}, err
+		}
+		start := time.Now()
+		if _, err := conn.WriteTo(b, &net.IPAddr{IP: dst}); err != nil {
+			return Latency{}, err
+		}
+		err = readIcmp(ctx, conn, ICMP_TIMEOUT, func(reply *icmp.Message, peer net.IP) bool {
+			echo, ok := reply.Body.(*icmp.Echo)
+			return ok && reply.Type == ipv4.ICMPTypeEchoReply && peer.Equal(dst) && echo.ID == id && echo.Seq == seq
+		})
+		if err == nil {
+			total += time.Since(start)
+			replies += 1
+		} else if err != errIcmpTimeout {
+			return Latency{}, err
+		}
+		time.Sleep(PING_INTERVAL)
+	}
+	if replies == 0 {
+		return Latency{}, fmt.Errorf("no echo replies from %s", host)
+	}
+	return Latency{Host: host, Latency: float64(total) / float64(replies) / float64(time.Millisecond)}, nil
+}
+
+func (m *GoMeasurer) Traceroute(ctx context.Context, hosts []string) (TracerouteResults, error) {
+	trInfo := TracerouteResults{TracerouteResults: make([]Traceroute, 0)}
+	for _, host := range hosts {
+		route, err := traceroute(ctx, host)
+		if err != nil {
+			if ctx.Err() != nil {
+				return trInfo, err
+			}
+			log.Printf("traceroute to %s failed: %v", host, err)
+			continue
+		}
+		trInfo.TracerouteResults = append(trInfo.TracerouteResults, Traceroute{Host: host, Route: route})
+	}
+	return trInfo, nil
+}
+
+// sends a UDP probe with ttl 1, 2, ... until host answers with port unreachable. The hops are the ips
+// that answered, * for the ones that did not, as traceroute prints them
+func traceroute(ctx context.Context, host string) ([]string, error) {
+	dst, err := resolveIPv4(host)
+	if err != nil {
+		return nil, err
+	}
+	icmpConn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, err
+	}
+	defer icmpConn.Close()
+	udpConn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer udpConn.Close()
+	probeConn := ipv4.NewPacketConn(udpConn)
+	route := make([]string, 0)
+	silent := 0
+	for ttl := 1; ttl <= TRACEROUTE_MAX_HOPS && silent < TRACEROUTE_MAX_SILENT; ttl++ {
+		if err := probeConn.SetTTL(ttl); err != nil {
+			return nil, err
+		}
+		port := TRACEROUTE_PORT + ttl
+		if _, err := udpConn.WriteTo([]byte("netmon"), &net.UDPAddr{IP: dst, Port: port}); err != nil {
+			return nil, err
+		}
+		hop := "*"
+		reached := false
+		err := readIcmp(ctx, icmpConn, ICMP_TIMEOUT, func(msg *icmp.Message, peer net.IP) bool {
+			var data []byte
+			switch body := msg.Body.(type) {
+			case *icmp.TimeExceeded:
+				data = body.Data
+			case *icmp.DstUnreach:
+				data = body.Data
+				reached = true
+			default:
+				return false
+			}
+			if !isProbe(data, dst, port) {
+				reached = false
+				return false
+			}
+			hop = peer.String()
+			return true
+		})
+		if err != nil && err != errIcmpTimeout {
+			return nil, err
+		}
+		route = append(route, hop)
+		if reached {
+			return route, nil
+		}
+		if hop == "*" {
+			silent += 1
+		} else {
+			silent = 0
+		}
+	}
+	return route, nil
+}
+
+// whether the packet quoted in an ICMP error is the probe to dst:port
+func isProbe(data []byte, dst net.IP, port int) bool {
+	header, err := ipv4.ParseHeader(data)
+	if err != nil || header.Protocol != PROTOCOL_UDP || !header.Dst.Equal(dst) || len(data) < header.Len+4 {
+		return false
+	}
+	return int(binary.BigEndian.Uint16(data[header.Len+2:header.Len+4])) == port
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
@@ -10,7 +9,6 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
-	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -31,6 +29,33 @@ var (
 var (
 	helper = flag.String("helper", "0.0.0.0:6000", "net helper ip/port")
 )
+var (
+	backend = flag.String("backend", "go", "measurements by netmon itself (go) or by the python net helper (helper)")
+)
+var (
+	estimator = flag.String("estimator", "probe", "bandwidth estimates from full throughput probes (probe) or from packet trains and adaptive headroom probes (train), train needs the go backend")
+)
+var (
+	probePort = flag.Int("probe-port", 50052, "port of the throughput probe server, the same on every node")
+)
+var (
+	egress = flag.Bool("egress", true, "also count what is sent with a tc egress hook on the device")
+)
+var (
+	historySize = flag.Int("history", 100, "bw measurements kept per peer for the aggregates")
+)
+var (
+	qos = flag.String("qos", "", "enforce the rate limits the controller sets on pod traffic with htb classes (htb), off if empty")
+)
+var (
+	qosDevice = flag.String("qos-device", "flannel.1", "device the pod traffic to other nodes leaves on, before it is encapsulated")
+)
+var (
+	qosRate = flag.String("qos-rate", "10gbit", "rate of the qos device, traffic without a limit shares it")
+)
+var (
+	windows = flag.String("windows", "10s,1m,5m", "windows the XDP traffic rates are computed over")
+)
 
 func readConfig(configfile string) []string {
 	body, err := ioutil.ReadFile(configfile)
@@ -54,130 +79,82 @@ type server struct {
 	HeadroomCacheMeasured  map[string]Bandwidth // dest node -> available bw [to check if excess capacity is available. The goal is to avoid disrupting existing flows. The headroom bw is specified by the controller]
 	HeadroomCacheRequested map[string]pb.BandwidthInfo
 	TrCache                TracerouteResults // dest node -> traceroute map
-	LatencyCache           LatencyResults    // dest node -> latency map
+	LatencyCache           map[string]Latency // dest node -> latency map
 	mu                     sync.Mutex
-	netClient              http.Client
+	measurer               Measurer
 	hosts                  []string
 	bpfRunner              *BPFRunner
 	hostIdx                int
 	pendingBwRequest       bool
 	headroomIdx            int
+	traffic                map[string]float64 // dest node -> traffic sent, from the last refresh
+	watchLock              sync.Mutex
+	watchers               map[int]chan bool // WatchNetInfo streams, woken up after every refresh
+	nextWatcher            int
+	trafficWindows         *TrafficWindows // received, by source ip
+	sentWindows            *TrafficWindows // sent, by destination ip
+	flowWindows            *TrafficWindows // by FlowKey.String()
+	flowKeys               map[string]FlowKey
+	flowLock               sync.Mutex
+	bwHistory              *BwHistory // of BwCache
+	headroomHistory        *BwHistory // of HeadroomCacheMeasured
+	routes                 *RouteTable // versions of the routes in TrCache
+	qos                    *QosEnforcer // nil if netmon runs without -qos
 }
 
-func (s *server) QueryNetStats(hostname string, qty string) ([]byte, error) {
-	reqURL := "http://" + *helper
-	fmt.Printf("host = %s\n", hostname)
-	res, err := s.netClient.Get(reqURL + "/" + qty + "?host=" + hostname)
-	if err != nil {
-		fmt.Printf("client: could not create request: %s\n", err)
-		return []byte(""), err
-	}
-
-	fmt.Printf("client: status code: %d\n", res.StatusCode)
-
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		fmt.Printf("client: could not read response body: %s\n", err)
-		return []byte(""), err
-	}
-	fmt.Printf(string(resBody))
-	return resBody, nil
-
-}
-
-func (s *server) QueryHeadroom(hostname string, bw float32) ([]byte, error) {
-	reqURL := "http://" + *helper
-	fmt.Printf("host = %s bw = %f\n", hostname, bw)
-	res, err := s.netClient.Get(reqURL + "/bw" + "?host=" + hostname + "&bwmax=" + fmt.Sprintf("%f", bw))
-	if err != nil {
-		fmt.Printf("client: could not create request: %s\n", err)
-		return []byte(""), err
-	}
-
-	fmt.Printf("client: status code: %d\n", res.StatusCode)
-
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		fmt.Printf("client: could not read response body: %s\n", err)
-		return []byte(""), err
-	}
-	fmt.Printf(string(resBody))
-	return resBody, nil
-
-}
-func (s *server) QueryTrStats() ([]byte, error) {
-	reqURL := "http://" + *helper
-	res, err := s.netClient.Get(reqURL + "/traceroute")
-	if err != nil {
-		fmt.Printf("client: could not create request: %s\n", err)
-		return []byte(""), err
-	}
-
-	fmt.Printf("client: status code: %d\n", res.StatusCode)
-
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		fmt.Printf("client: could not read response body: %s\n", err)
-		return []byte(""), err
+// the last bw measured to dst from cache, or the aggregate of the history of the last window seconds
+func bandwidthFor(cache map[string]Bandwidth, history *BwHistory, dst string, agg pb.Aggregate, window int32) (Bandwidth, bool) {
+	if agg == pb.Aggregate_LAST {
+		bw, exists := cache[dst]
+		return bw, exists
 	}
-	fmt.Printf(string(resBody))
-	return resBody, nil
-}
-
-func GetBwResults(bwResponse []byte) BandwidthResults {
-	var bwInfo BandwidthResults
-	json.Unmarshal(bwResponse, &bwInfo)
-	fmt.Printf("\noriginal %s unmarshal %v\n", string(bwResponse), bwInfo)
-	return bwInfo
-}
-
-func GetTrResults(trResponse []byte) TracerouteResults {
-	var trInfo TracerouteResults
-	json.Unmarshal(trResponse, &trInfo)
-	return trInfo
-}
-
-func GetLatencyResults(latencyResponse []byte) LatencyResults {
-	var latencyInfo LatencyResults
-	json.Unmarshal(latencyResponse, &latencyInfo)
-	return latencyInfo
+	return history.Aggregate(dst, agg, time.Duration(window)*time.Second)
 }
 
 func (s *server) GetNetInfo(ctx context.Context, in *pb.NetInfoRequest) (*pb.NetInfoReply, error) {
 	log.Printf("Received: req")
-	//s.mu.Lock()
 	bwUsed := s.bpfRunner.GetStats()
+	// UpdateCache writes the caches in place, read them under the lock like watchSnapshot
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	bwInfos := make([]*pb.BandwidthInfo, 0)
 	bws := s.BwCache
 	trs := s.TrCache
-	//s.mu.Unlock()
 	if !s.pendingBwRequest {
 		s.pendingBwRequest = in.ShouldUpdate
 	}
 	for dst, trafficSent := range bwUsed {
-		bw, exists := bws[dst]
+		bw, exists := bandwidthFor(bws, s.bwHistory, dst, in.Aggregate, in.Window)
 		log.Printf("BWInfoFull: Host = %s bw = %f", bw.Host, bw.RcvBw)
 		bwInfo := pb.BandwidthInfo{Host: dst, RecvBwUsed: float32(trafficSent)}
 		if exists {
-			bwInfo.SendBw = float32(bw.SndBw)
-			bwInfo.ReceiveBw = float32(bw.RcvBw)
+			setBandwidth(&bwInfo, bw)
 		}
 		bwInfos = append(bwInfos, &bwInfo)
 	}
 	log.Printf("Got %d bws", len(bwInfos))
 	trInfos := make([]*pb.TracerouteInfo, 0)
 	for _, tr := range trs.TracerouteResults {
-		trInfo := pb.TracerouteInfo{Host: tr.Host, Hops: tr.Route}
-		trInfos = append(trInfos, &trInfo)
+		trInfos = append(trInfos, s.traceInfo(tr))
 	}
-	reply := &pb.NetInfoReply{BwInfo: bwInfos, TrInfo: trInfos}
+	reply := &pb.NetInfoReply{BwInfo: bwInfos, TrInfo: trInfos, LatInfo: s.GetLatencyInfos(), BpfInfo: s.trafficWindows.Infos(), BpfSentInfo: s.sentWindows.Infos()}
 	return reply, nil
 }
 
+// s.mu is held
+func (s *server) GetLatencyInfos() []*pb.LatencyInfo {
+	latInfos := make([]*pb.LatencyInfo, 0)
+	latencyCache := s.LatencyCache
+	for dst, latency := range latencyCache {
+		latInfos = append(latInfos, &pb.LatencyInfo{Host: dst, Latency: float32(latency.Latency)})
+	}
+	return latInfos
+}
+
 func (s *server) GetHeadroomInfo(ctx context.Context, in *pb.HeadroomInfoRequest) (*pb.NetInfoReply, error) {
-	//s.mu.Lock()
-	//defer s.mu.Unlock()
 	bwUsed := s.bpfRunner.GetStats()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	hrInfos := make([]*pb.BandwidthInfo, 0)
 	log.Printf("cahce has %d measure and  %d reqs , req has %d bws", len(s.HeadroomCacheMeasured), len(s.HeadroomCacheRequested), len(in.BwInfo))
 	for dst, trafficSent := range bwUsed {
@@ -195,10 +172,9 @@ func (s *server) GetHeadroomInfo(ctx context.Context, in *pb.HeadroomInfoRequest
 			log.Printf("host = %s headroom req = %f %v", bwInfo.Host, hostInfo.SendBw, hostInfo)
 			s.HeadroomCacheRequested[hostInfo.Host] = *hostInfo
 
-			measuredHeadroom, exists := s.HeadroomCacheMeasured[hostInfo.Host]
+			measuredHeadroom, exists := bandwidthFor(s.HeadroomCacheMeasured, s.headroomHistory, hostInfo.Host, in.Aggregate, in.Window)
 			if exists {
-				bwInfo.ReceiveBw = float32(measuredHeadroom.RcvBw)
-				bwInfo.SendBw = float32(measuredHeadroom.SndBw)
+				setBandwidth(&bwInfo, measuredHeadroom)
 			}
 		}
 		hrInfos = append(hrInfos, &bwInfo)
@@ -214,11 +190,10 @@ func (s *server) GetHeadroomInfo(ctx context.Context, in *pb.HeadroomInfoRequest
 	}
 	trInfos := make([]*pb.TracerouteInfo, 0)
 	for _, tr := range s.TrCache.TracerouteResults {
-		trInfo := pb.TracerouteInfo{Host: tr.Host, Hops: tr.Route}
-		trInfos = append(trInfos, &trInfo)
+		trInfos = append(trInfos, s.traceInfo(tr))
 	}
 
-	reply := &pb.NetInfoReply{BwInfo: hrInfos, TrInfo: trInfos}
+	reply := &pb.NetInfoReply{BwInfo: hrInfos, TrInfo: trInfos, LatInfo: s.GetLatencyInfos(), BpfInfo: s.trafficWindows.Infos(), BpfSentInfo: s.sentWindows.Infos()}
 	return reply, nil
 }
 
@@ -230,22 +205,26 @@ func (s *server) GetUpdatedNetStats() (BandwidthResults, TracerouteResults, Late
 		s.pendingBwRequest = true
 		host := s.hosts[s.hostIdx]
 		fmt.Printf("host = %s idx = %d\n", host, s.hostIdx)
-		bwResponse, err := s.QueryNetStats(host, "bw")
+		ctx, cancel := context.WithTimeout(context.Background(), MEASURE_TIMEOUT)
+		bw, err := s.measurer.Bandwidth(ctx, host, 0)
+		cancel()
 		if err == nil {
-			bwInfo := GetBwResults(bwResponse)
-			if len(bwInfo.BandwidthResults) > 0 {
-
-				log.Printf("Update stat for %s rcv bw = %f snd bw = %f %v", host, bwInfo.BandwidthResults[0].RcvBw, bwInfo.BandwidthResults[0].SndBw, bwInfo)
-				allBwInfo.BandwidthResults = append(allBwInfo.BandwidthResults, bwInfo.BandwidthResults[0])
-				s.hostIdx = (s.hostIdx + 1)
-
-			}
+			log.Printf("Update stat for %s rcv bw = %f snd bw = %f %v", host, bw.RcvBw, bw.SndBw, bw)
+			allBwInfo.BandwidthResults = append(allBwInfo.BandwidthResults, bw)
+			s.hostIdx = (s.hostIdx + 1)
+		} else {
+			log.Printf("bw to %s failed: %v", host, err)
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), MEASURE_TIMEOUT)
+		latency, err := s.measurer.Latency(ctx, host)
+		cancel()
+		if err == nil {
+			allLatencyInfo.LatencyResults = append(allLatencyInfo.LatencyResults, latency)
+		} else {
+			log.Printf("latency to %s failed: %v", host, err)
 		}
 	//}
-	trResponse, err := s.QueryTrStats()
-	if err == nil {
-		trInfo = GetTrResults(trResponse)
-	}
+	trInfo = s.traceroute()
 	fmt.Printf("tr: Got %d hosts\n", len(trInfo.TracerouteResults))
 
 	fmt.Printf("bw: Got %d hosts\n", len(allBwInfo.BandwidthResults))
@@ -260,26 +239,32 @@ func (s *server) GetUpdatedHeadroomStats() (BandwidthResults, TracerouteResults)
 	fmt.Printf("headroom host = %s headroom idx = %d\n", host, s.headroomIdx)
 	headroomReq, exists := s.HeadroomCacheRequested[host]
 	if exists {
-		fmt.Sprintf("Query host %s with bw %f", host, headroomReq)
-		bwResponse, err := s.QueryHeadroom(host, float32(headroomReq.SendBw))
+		ctx, cancel := context.WithTimeout(context.Background(), MEASURE_TIMEOUT)
+		bw, err := s.measurer.Bandwidth(ctx, host, float64(headroomReq.SendBw))
+		cancel()
 		if err == nil {
-
-			bwInfo := GetBwResults(bwResponse)
-			if len(bwInfo.BandwidthResults) > 0 {
-				log.Printf("Headroom host %s requested = %f actual = %f\n", host, headroomReq.SendBw, bwInfo.BandwidthResults[0].RcvBw)
-				headroomInfo.BandwidthResults = append(headroomInfo.BandwidthResults, bwInfo.BandwidthResults[0])
-				s.headroomIdx = (s.headroomIdx + 1) % len(s.hosts)
-			}
+			log.Printf("Headroom host %s requested = %f actual = %f\n", host, headroomReq.SendBw, bw.RcvBw)
+			headroomInfo.BandwidthResults = append(headroomInfo.BandwidthResults, bw)
+			s.headroomIdx = (s.headroomIdx + 1) % len(s.hosts)
+		} else {
+			log.Printf("headroom to %s failed: %v", host, err)
 		}
 	}
 	//}
-	trResponse, err := s.QueryTrStats()
-	if err == nil {
-		trInfo = GetTrResults(trResponse)
-	}
+	trInfo = s.traceroute()
 	return headroomInfo, trInfo
 }
 
+func (s *server) traceroute() TracerouteResults {
+	ctx, cancel := context.WithTimeout(context.Background(), MEASURE_TIMEOUT)
+	defer cancel()
+	trInfo, err := s.measurer.Traceroute(ctx, s.hosts)
+	if err != nil {
+		log.Printf("traceroute failed: %v", err)
+	}
+	return trInfo
+}
+
 func (s *server) UpdateCache() {
 	s.mu.Lock()
 	if s.pendingBwRequest {
@@ -288,20 +273,28 @@ func (s *server) UpdateCache() {
 		for _, bwResult := range bwInfo.BandwidthResults {
 			log.Printf("Updated %s", bwResult.Host)
 			s.BwCache[bwResult.Host] = bwResult
+			s.bwHistory.Add(time.Now(), bwResult)
 		}
 		if s.hostIdx == len(s.hosts) {
 			s.pendingBwRequest = false
 			s.hostIdx = 0
 		}
-		s.LatencyCache = latencyInfo
+		for _, latencyResult := range latencyInfo.LatencyResults {
+			log.Printf("Updated %s latency = %f", latencyResult.Host, latencyResult.Latency)
+			s.LatencyCache[latencyResult.Host] = latencyResult
+		}
 	}
 	bwInfo, trInfo := s.GetUpdatedHeadroomStats()
 	for _, bwResult := range bwInfo.BandwidthResults {
 		fmt.Printf("Updated %s headroom", bwResult.Host)
 		s.HeadroomCacheMeasured[bwResult.Host] = bwResult
+		s.headroomHistory.Add(time.Now(), bwResult)
 	}
 	if len(trInfo.TracerouteResults) > 0 {
 		s.TrCache = trInfo
+		if changes := s.routes.Update(time.Now(), trInfo); len(changes) > 0 {
+			log.Printf("%d routes changed", len(changes))
+		}
 	}
 
 	s.mu.Unlock()
@@ -311,7 +304,11 @@ func (s *server) DoInBackground() {
 	//go func() {
 	for {
 		s.UpdateCache()
-		s.bpfRunner.GetStats()
+		traffic := s.bpfRunner.GetStats()
+		s.mu.Lock()
+		s.traffic = traffic
+		s.mu.Unlock()
+		s.notifyWatchers()
 		time.Sleep(15 * time.Second)
 	}
 	//}()
@@ -324,16 +321,37 @@ func StartServer() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	bpfRunner := NewBPFRunner(*device)
+	trafficWindows, err := parseWindows(*windows)
+	if err != nil {
+		log.Fatalf("bad traffic windows %s: %v", *windows, err)
+	}
+	measurer, err := NewMeasurer(*backend, *helper, *probePort, *estimator)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *backend == "go" {
+		go func() {
+			log.Fatalf("probe server failed: %v", ServeProbes(*probePort))
+		}()
+	}
+	bpfRunner := NewBPFRunner(*device, *egress)
 	s := grpc.NewServer()
-	client := http.Client{Timeout: 60 * time.Second}
-	monserver := &server{netClient: client, hosts: hosts, bpfRunner: bpfRunner, hostIdx: 0, BwCache: make(map[string]Bandwidth, 0), HeadroomCacheRequested: make(map[string]pb.BandwidthInfo, 0), HeadroomCacheMeasured: make(map[string]Bandwidth, 0), pendingBwRequest: true, headroomIdx: 0}
-
+	monserver := &server{measurer: measurer, hosts: hosts, bpfRunner: bpfRunner, hostIdx: 0, BwCache: make(map[string]Bandwidth, 0), HeadroomCacheRequested: make(map[string]pb.BandwidthInfo, 0), HeadroomCacheMeasured: make(map[string]Bandwidth, 0), LatencyCache: make(map[string]Latency, 0), pendingBwRequest: true, headroomIdx: 0, traffic: make(map[string]float64, 0), watchers: make(map[int]chan bool, 0), trafficWindows: NewTrafficWindows(trafficWindows), sentWindows: NewTrafficWindows(trafficWindows), flowWindows: NewTrafficWindows(trafficWindows), flowKeys: make(map[string]FlowKey, 0),
+		bwHistory: NewBwHistory(*historySize), headroomHistory: NewBwHistory(*historySize), routes: NewRouteTable()}
+
+	switch *qos {
+	case "":
+	case "htb":
+		monserver.qos = NewQosEnforcer(NewHtbShaper(*qosDevice, *qosRate))
+	default:
+		log.Fatalf("unknown qos backend %s", *qos)
+	}
 	pb.RegisterNetMonitorServer(s, monserver)
 	log.Printf("server listening at %v", lis.Addr())
 	go func() {
 		monserver.DoInBackground()
 	}()
+	go monserver.SampleTraffic()
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// longest a single measurement may take, the helper retries iperf for up to 60s
+const MEASURE_TIMEOUT = 90 * time.Second
+
+// Measurer runs the active measurements from this node to other nodes
+type Measurer interface {
+	// throughput to host, capped at limit bits/s if limit > 0
+	Bandwidth(ctx context.Context, host string, limit float64) (Bandwidth, error)
+	// average round trip time to host
+	Latency(ctx context.Context, host string) (Latency, error)
+	// hops to every host, a host that could not be traced is left out
+	Traceroute(ctx context.Context, hosts []string) (TracerouteResults, error)
+}
+
+// backend is "go" for the measurements built into netmon or "helper" for the python net_helper at
+// helperAddress. estimator is "probe" for full throughput probes or "train" for packet trains and
+// adaptive headroom probes, which only the go backend has
+func NewMeasurer(backend string, helperAddress string, probePort int, estimator string) (Measurer, error) {
+	if estimator != "probe" && estimator != "train" {
+		return nil, fmt.Errorf("unknown bandwidth estimator %s, want probe or train", estimator)
+	}
+	switch backend {
+	case "go":
+		if estimator == "train" {
+			return NewTrainMeasurer(probePort), nil
+		}
+		return NewGoMeasurer(probePort), nil
+	case "helper":
+		if estimator == "train" {
+			return nil, fmt.Errorf("the train estimator needs the go backend")
+		}
+		return NewHelperMeasurer(helperAddress), nil
+	}
+	return nil, fmt.Errorf("unknown measurement backend %s, want go or helper", backend)
+}
+
+// the measured bandwidth of bw in bwInfo, recvBwUsed is left alone
+func setBandwidth(bwInfo *pb.BandwidthInfo, bw Bandwidth) {
+	bwInfo.SendBw = float32(bw.SndBw)
+	bwInfo.ReceiveBw = float32(bw.RcvBw)
+	bwInfo.ReceiveBwLow = float32(bw.RcvBwLow)
+	bwInfo.ReceiveBwHigh = float32(bw.RcvBwHigh)
+	bwInfo.Estimator = bw.Estimator
+}
@@ -1,4 +1,25 @@
 #include <linux/ip.h>
+#include <linux/tcp.h>
+#include <linux/udp.h>
+
+// flannel's vxlan port, the flows inside are counted by the inner pod ips
+#define VXLAN_PORT 8472
+#define VXLAN_HLEN 8
+
+// headers up to the inner udp header of a vxlan packet
+#define FLOW_HEADER_LEN (2 * (sizeof(struct ethhdr) + sizeof(struct iphdr) + sizeof(struct udphdr)) + VXLAN_HLEN)
+
+struct flow_key {
+    u32 saddr;
+    u32 daddr;
+    u16 dport;  // 0 if not tcp or udp
+    u16 protocol;
+};
+
+struct flow_counters {
+    u64 packets;
+    u64 bytes;
+};
 
 // Returns the protocol byte for an IP packet, 0 for anything else
 static __always_inline u64 lookup_protocol(struct xdp_md *ctx)
@@ -58,3 +79,46 @@ static inline int get_pkt_size( struct xdp_md *ctx) {
 	}
 	return size;
 }
+
+// Fills key from the ipv4 packet in eth, the port only if the ip header has no options.
+// Returns 0 for anything else
+static __always_inline int parse_ip(struct ethhdr *eth, void *data_end, struct flow_key *key)
+{
+    if ((void *)(eth + 1) > data_end || bpf_ntohs(eth->h_proto) != ETH_P_IP)
+        return 0;
+    struct iphdr *iph = (void *)(eth + 1);
+    if ((void *)(iph + 1) > data_end)
+        return 0;
+    key->saddr = iph->saddr;
+    key->daddr = iph->daddr;
+    key->protocol = iph->protocol;
+    key->dport = 0;
+    if (iph->ihl != 5)
+        return 1;
+    if (iph->protocol == IPPROTO_TCP) {
+        struct tcphdr *tcp = (void *)(iph + 1);
+        if ((void *)(tcp + 1) <= data_end)
+            key->dport = bpf_ntohs(tcp->dest);
+    } else if (iph->protocol == IPPROTO_UDP) {
+        struct udphdr *udp = (void *)(iph + 1);
+        if ((void *)(udp + 1) <= data_end)
+            key->dport = bpf_ntohs(udp->dest);
+    }
+    return 1;
+}
+
+// outer is the packet between the nodes, flow the same packet or the one inside if it is vxlan.
+// Returns 0 if the packet is not ipv4
+static __always_inline int parse_flow(void *data, void *data_end, struct flow_key *outer, struct flow_key *flow)
+{
+    if (!parse_ip(data, data_end, outer))
+        return 0;
+    *flow = *outer;
+    if (outer->protocol == IPPROTO_UDP && outer->dport == VXLAN_PORT) {
+        struct ethhdr *inner = data + sizeof(struct ethhdr) + sizeof(struct iphdr) + sizeof(struct udphdr) + VXLAN_HLEN;
+        struct flow_key inner_key = {};
+        if (parse_ip(inner, data_end, &inner_key))
+            *flow = inner_key;
+    }
+    return 1;
+}
@@ -22,9 +22,14 @@ import (
 )
 
 const source string = `
+#include <uapi/linux/pkt_cls.h>
 #include "packet.h"
 BPF_HASH(packets);
 BPF_HASH(packetsize);
+BPF_HASH(sentpackets);
+BPF_HASH(sentsize);
+BPF_HASH(ingress_flows, struct flow_key, struct flow_counters, 10240);
+BPF_HASH(egress_flows, struct flow_key, struct flow_counters, 10240);
 
 int hello_packet(struct xdp_md *ctx) {
     u64 counter = 0;
@@ -52,8 +57,61 @@ int hello_packet(struct xdp_md *ctx) {
 		packetsize.update(&key, &size);
     }
 
+    struct flow_key outer = {};
+    struct flow_key flow = {};
+    if (parse_flow((void *)(long)ctx->data, (void *)(long)ctx->data_end, &outer, &flow)) {
+        struct flow_counters *fc = ingress_flows.lookup(&flow);
+        if (fc != 0) {
+            fc->packets++;
+            fc->bytes += cur_pkt_size;
+        } else {
+            struct flow_counters init = {1, cur_pkt_size};
+            ingress_flows.update(&flow, &init);
+        }
+    }
+
     return XDP_PASS;
 }
+
+// tc egress hook, counts what the node sends by destination ip and by flow
+int egress_packet(struct __sk_buff *skb) {
+    u64 counter = 0;
+    u64 size = 0;
+    u64 key = 0;
+    u64 *p;
+    struct flow_key outer = {};
+    struct flow_key flow = {};
+
+    // the headers may not be in the linear part of the skb yet
+    if ((void *)(long)skb->data + FLOW_HEADER_LEN > (void *)(long)skb->data_end)
+        bpf_skb_pull_data(skb, FLOW_HEADER_LEN);
+    if (!parse_flow((void *)(long)skb->data, (void *)(long)skb->data_end, &outer, &flow))
+        return TC_ACT_OK;
+
+    key = outer.daddr;
+    p = sentpackets.lookup(&key);
+    if (p != 0) {
+        counter = *p;
+    }
+    counter++;
+    sentpackets.update(&key, &counter);
+    p = sentsize.lookup(&key);
+    if (p != 0) {
+        size = *p;
+    }
+    size += skb->len;
+    sentsize.update(&key, &size);
+
+    struct flow_counters *fc = egress_flows.lookup(&flow);
+    if (fc != 0) {
+        fc->packets++;
+        fc->bytes += skb->len;
+    } else {
+        struct flow_counters init = {1, skb->len};
+        egress_flows.update(&flow, &init);
+    }
+    return TC_ACT_OK;
+}
 `
 
 func usage() {
@@ -68,17 +126,25 @@ func int2ip(nn uint32) net.IP {
 }
 
 type BPFRunner struct {
-	PktStats            *bpf.Table
-	PktSize             *bpf.Table
-	device              string
-	module              *bpf.Module
-	lastObservedTraffic map[string][]float64
-	lastTs              []int64
-	lock                *sync.Mutex
+	PktStats       *bpf.Table
+	PktSize        *bpf.Table
+	SentPkts       *bpf.Table // by destination ip, empty if the egress hook is not attached
+	SentSize       *bpf.Table
+	IngressFlows   *bpf.Table
+	EgressFlows    *bpf.Table
+	device         string
+	egress         bool // tc egress hook attached to device
+	flowsSeen      map[string]flowSeen
+	module         *bpf.Module
+	trafficHistory map[string]*History // source ip -> bytes received, the last TRAFFIC_HISTORY_SIZE reads
+	lock           *sync.Mutex
 }
 
 func (runner *BPFRunner) Close() {
 	defer runner.module.Close()
+	if runner.egress {
+		detachEgress(runner.device)
+	}
 	defer func() {
 		if err := runner.module.RemoveXDP(runner.device); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to remove XDP from %s: %v\n", device, err)
@@ -86,7 +152,8 @@ func (runner *BPFRunner) Close() {
 	}()
 }
 
-func NewBPFRunner(device string) *BPFRunner {
+// the tc egress hook is attached too if egress is set, without it only what the node receives is counted
+func NewBPFRunner(device string, egress bool) *BPFRunner {
 	module := bpf.NewModule(source, []string{})
 	mu := &sync.Mutex{}
 	fn, err := module.Load("hello_packet", C.BPF_PROG_TYPE_XDP, 1, 65536)
@@ -101,65 +168,124 @@ func NewBPFRunner(device string) *BPFRunner {
 		os.Exit(1)
 	}
 
+	if egress {
+		egressFd, err := module.Load("egress_packet", C.BPF_PROG_TYPE_SCHED_CLS, 1, 65536)
+		if err == nil {
+			err = attachEgress(egressFd, device)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to attach tc egress prog, only counting received packets: %v\n", err)
+			egress = false
+		}
+	}
+
 	fmt.Println("Counting packets, hit CTRL+C to stop")
 
 	pktcnt := bpf.NewTable(module.TableId("packets"), module)
 	pktsize := bpf.NewTable(module.TableId("packetsize"), module)
-	bpfRunner := &BPFRunner{lock: mu, PktStats: pktcnt, device: device, module: module, PktSize: pktsize, lastObservedTraffic: make(map[string][]float64, 0)}
+	bpfRunner := &BPFRunner{lock: mu, PktStats: pktcnt, device: device, module: module, PktSize: pktsize, trafficHistory: make(map[string]*History, 0),
+		SentPkts: bpf.NewTable(module.TableId("sentpackets"), module), SentSize: bpf.NewTable(module.TableId("sentsize"), module),
+		IngressFlows: bpf.NewTable(module.TableId("ingress_flows"), module), EgressFlows: bpf.NewTable(module.TableId("egress_flows"), module),
+		egress: egress, flowsSeen: make(map[string]flowSeen, 0)}
 
 	_ = bpfRunner.GetStats()
 	_ = bpfRunner.GetStats()
 	return bpfRunner
 }
 
-func getMean(values []float64, times []int64) float64 {
-	sum := 0.0
-	if len(values) == 1 {
-		return values[0]
-	}
-	for i := 1; i < len(values); i++ {
-		bw := values[i] - values[i-1]
-		tdiff := times[i] - times[i-1]
-		if tdiff == 0 {
-			continue
-		}
-		sum += (bw / 1000.0) / float64(tdiff)
-	}
-
-	return (sum / float64(len(values))) * 1000.0
-}
+// bits/s received from every source ip over the last TRAFFIC_HISTORY_SIZE reads of the counters
 func (runner *BPFRunner) GetStats() map[string]float64 {
 	runner.lock.Lock()
 	defer runner.lock.Unlock()
-	trafficMap := make(map[string][]float64, 0)
+	now := time.Now()
 	for it := runner.PktSize.Iter(); it.Next(); {
 		key := bpf.GetHostByteOrder().Uint32(it.Key())
 		value := bpf.GetHostByteOrder().Uint64(it.Leaf())
-		trafficMap[fmt.Sprintf("%s", int2ip(key))] = []float64{float64(value)}
-		if value > 0 {
-			fmt.Printf("%s: %v bytes\n", int2ip(key), value)
-		}
-	}
-	if len(runner.lastObservedTraffic) == 0 {
-		runner.lastObservedTraffic = trafficMap
-		runner.lastTs = []int64{time.Now().Unix()}
-	} else {
-		for k, v := range trafficMap {
-			runner.lastObservedTraffic[k] = append(runner.lastObservedTraffic[k], v...)
+		host := fmt.Sprintf("%s", int2ip(key))
+		history, exists := runner.trafficHistory[host]
+		if !exists {
+			history = NewHistory(TRAFFIC_HISTORY_SIZE)
+			runner.trafficHistory[host] = history
 		}
-		runner.lastTs = append(runner.lastTs, time.Now().Unix())
+		history.Add(now, float64(value))
 	}
 	bws := make(map[string]float64, 0)
-	for host, vals := range runner.lastObservedTraffic {
+	for host, history := range runner.trafficHistory {
+		bws[host] = 8 * history.Rate()
+	}
+	return bws
+}
 
-		//prevBytes, val := runner.lastObservedTraffic[host]
-		meanTraffic := getMean(vals, runner.lastTs)
-		bws[host] = 8 * meanTraffic
+// packets and bytes received from every source ip since the program was attached
+func (runner *BPFRunner) GetCounters() map[string]PeerCounters {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	return readCounters(runner.PktStats, runner.PktSize)
+}
+
+// packets and bytes sent to every destination ip since the egress hook was attached
+func (runner *BPFRunner) GetSentCounters() map[string]PeerCounters {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	return readCounters(runner.SentPkts, runner.SentSize)
+}
 
+func readCounters(pktTable *bpf.Table, sizeTable *bpf.Table) map[string]PeerCounters {
+	counters := make(map[string]PeerCounters, 0)
+	for it := pktTable.Iter(); it.Next(); {
+		key := bpf.GetHostByteOrder().Uint32(it.Key())
+		host := fmt.Sprintf("%s", int2ip(key))
+		peer := counters[host]
+		peer.Packets = bpf.GetHostByteOrder().Uint64(it.Leaf())
+		counters[host] = peer
 	}
-	//runner.lastObservedTraffic = trafficMap
-	return bws
+	for it := sizeTable.Iter(); it.Next(); {
+		key := bpf.GetHostByteOrder().Uint32(it.Key())
+		host := fmt.Sprintf("%s", int2ip(key))
+		peer := counters[host]
+		peer.Bytes = bpf.GetHostByteOrder().Uint64(it.Leaf())
+		counters[host] = peer
+	}
+	return counters
+}
+
+// counters of every flow received and sent, by FlowKey.String(). Flows idle for FLOW_IDLE_TIMEOUT
+// are removed from the tables so new flows find room
+func (runner *BPFRunner) GetFlows() map[string]FlowCount {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	now := time.Now()
+	flows := make(map[string]FlowCount, 0)
+	for _, table := range []*bpf.Table{runner.IngressFlows, runner.EgressFlows} {
+		egress := table == runner.EgressFlows
+		idle := make([][]byte, 0)
+		for it := table.Iter(); it.Next(); {
+			k, v := it.Key(), it.Leaf()
+			order := bpf.GetHostByteOrder()
+			key := FlowKey{Src: net.IPv4(k[0], k[1], k[2], k[3]).String(), Dst: net.IPv4(k[4], k[5], k[6], k[7]).String(),
+				DstPort: order.Uint16(k[8:10]), Protocol: order.Uint16(k[10:12]), Egress: egress}
+			counters := PeerCounters{Packets: order.Uint64(v[0:8]), Bytes: order.Uint64(v[8:16])}
+			seen, exists := runner.flowsSeen[key.String()]
+			if !exists || seen.bytes != counters.Bytes {
+				seen = flowSeen{bytes: counters.Bytes, time: now}
+				runner.flowsSeen[key.String()] = seen
+			}
+			if now.Sub(seen.time) > FLOW_IDLE_TIMEOUT {
+				idle = append(idle, append([]byte{}, k...))
+				delete(runner.flowsSeen, key.String())
+				continue
+			}
+			flows[key.String()] = FlowCount{Key: key, Counters: counters}
+		}
+		for _, k := range idle {
+			if err := table.Delete(k); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove idle flow: %v\n", err)
+			}
+		}
+	}
+	return flows
 }
+
 func (runner *BPFRunner) PrintStats() {
 	runner.lock.Lock()
 	defer runner.lock.Unlock()
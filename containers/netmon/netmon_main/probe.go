@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+)
+
+// how long a throughput probe sends, as the 2s iperf runs of the helper
+const PROBE_DURATION = 2 * time.Second
+
+// probes start after a random wait of up to this long so nodes do not all probe at once
+const PROBE_JITTER = 5 * time.Second
+
+// a probe server gives up on a connection after this long
+const PROBE_TIMEOUT = 30 * time.Second
+
+const PROBE_BUFFER_SIZE = 128 * 1024
+
+// a rate limited probe sends this often
+const PROBE_PACING_INTERVAL = 10 * time.Millisecond
+
+// GoMeasurer measures without any helper: a tcp throughput probe against the probe server of the
+// other node's netmon, ICMP echo for latency and a UDP traceroute
+type GoMeasurer struct {
+	probePort int
+}
+
+func NewGoMeasurer(probePort int) *GoMeasurer {
+	return &GoMeasurer{probePort: probePort}
+}
+
+// sends to the probe server on host for PROBE_DURATION, paced to limit bits/s if limit > 0
+func (m *GoMeasurer) Bandwidth(ctx context.Context, host string, limit float64) (Bandwidth, error) {
+	if err := probeJitter(ctx); err != nil {
+		return Bandwidth{}, err
+	}
+	bw, err := m.probe(ctx, host, limit, PROBE_DURATION)
+	bw.Estimator = "probe"
+	return bw, err
+}
+
+// waits a random time up to PROBE_JITTER
+func probeJitter(ctx context.Context) error {
+	select {
+	case <-time.After(time.Duration(rand.Int63n(int64(PROBE_JITTER)))):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sends to the probe server on host for duration, paced to limit bits/s if limit > 0. The server
+// answers with the bytes it got and how long that took once the probe closes its side
+func (m *GoMeasurer) probe(ctx context.Context, host string, limit float64, duration time.Duration) (Bandwidth, error) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(m.probePort)))
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	buf := make([]byte, PROBE_BUFFER_SIZE)
+	if limit > 0 {
+		chunk := int(limit / 8 * PROBE_PACING_INTERVAL.Seconds())
+		if chunk < 1 {
+			chunk = 1
+		}
+		if chunk < len(buf) {
+			buf = buf[:chunk]
+		}
+	}
+	sent := uint64(0)
+	start := time.Now()
+	for elapsed := time.Duration(0); elapsed < duration; elapsed = time.Since(start) {
+		if err := ctx.Err(); err != nil {
+			return Bandwidth{}, err
+		}
+		if limit > 0 {
+			// wait until what was sent so far is within the limit
+			due := time.Duration(float64(8*sent) / limit * float64(time.Second))
+			if due > elapsed {
+				time.Sleep(due - elapsed)
+				continue
+			}
+		}
+		n, err := conn.Write(buf)
+		sent += uint64(n)
+		if err != nil {
+			return Bandwidth{}, fmt.Errorf("probe to %s: %v", host, err)
+		}
+	}
+	sendTime := time.Since(start)
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		return Bandwidth{}, err
+	}
+	reply := make([]byte, 16)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return Bandwidth{}, fmt.Errorf("probe to %s: no reply: %v", host, err)
+	}
+	received := binary.BigEndian.Uint64(reply[0:8])
+	receiveTime := time.Duration(binary.BigEndian.Uint64(reply[8:16]))
+	bw := Bandwidth{Host: host, Snd: float64(sent), Rcv: float64(received), SndBw: float64(8*sent) / sendTime.Seconds()}
+	if receiveTime > 0 {
+		bw.RcvBw = float64(8*received) / receiveTime.Seconds()
+	}
+	log.Printf("probe to %s: sent %d bytes in %v, received %d in %v", host, sent, sendTime, received, receiveTime)
+	return bw, nil
+}
+
+// answers the throughput probes of the other nodes
+func ServeProbes(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	log.Printf("probe server listening at %v", lis.Addr())
+	trainConn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	go serveTrains(trainConn)
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			log.Printf("probe server: %v", err)
+			continue
+		}
+		go handleProbe(conn)
+	}
+}
+
+// reads until the probe closes its side, then sends the bytes read and the time from the first to the last
+func handleProbe(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(PROBE_TIMEOUT))
+	buf := make([]byte, PROBE_BUFFER_SIZE)
+	received := uint64(0)
+	var start, last time.Time
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if received == 0 {
+				start = time.Now()
+			}
+			received += uint64(n)
+			last = time.Now()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("probe from %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+	reply := make([]byte, 16)
+	binary.BigEndian.PutUint64(reply[0:8], received)
+	binary.BigEndian.PutUint64(reply[8:16], uint64(last.Sub(start)))
+	if _, err := conn.Write(reply); err != nil {
+		log.Printf("probe from %v: %v", conn.RemoteAddr(), err)
+	}
+}
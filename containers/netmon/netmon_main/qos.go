@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// htb class minor ids, the limits get the ones from QOS_FIRST_CLASS on, traffic without a limit goes to QOS_DEFAULT_CLASS
+const QOS_FIRST_CLASS = 0x10
+const QOS_DEFAULT_CLASS = 0xffff
+
+// prio of the filters that put the traffic of a pod pair into its class
+const QOS_FILTER_PRIO = "10"
+
+// tc does not take rates below this, in bits per second
+const QOS_MIN_RATE = 8000
+
+// ClassStats is what tc counted for the class of a limit
+type ClassStats struct {
+	Bytes uint64
+	Drops uint64
+}
+
+// Shaper sets up the rate limits of the pod pairs on the device, each limit in a class of its own
+type Shaper interface {
+	Name() string
+	Setup() error
+	Apply(class uint16, limit *pb.QosLimit) error // adds the class or changes its rate
+	Remove(class uint16) error
+	Stats() (map[uint16]ClassStats, error)
+}
+
+// HtbShaper puts the traffic of every limited pod pair into an htb class with that rate as rate and
+// ceil. A flower filter on the pod ips picks the class, the rest of the traffic is not limited
+type HtbShaper struct {
+	device   string
+	linkRate string // rate of the root and default class, e.g. 10gbit
+}
+
+func NewHtbShaper(device string, linkRate string) *HtbShaper {
+	return &HtbShaper{device: device, linkRate: linkRate}
+}
+
+func (shaper *HtbShaper) Name() string {
+	return "htb"
+}
+
+func classId(class uint16) string {
+	return fmt.Sprintf("1:%x", class)
+}
+
+// replaces the root qdisc of the device, limits set by an earlier netmon are gone after it
+func (shaper *HtbShaper) Setup() error {
+	if err := runTc("qdisc", "replace", "dev", shaper.device, "root", "handle", "1:", "htb", "default", fmt.Sprintf("%x", QOS_DEFAULT_CLASS)); err != nil {
+		return err
+	}
+	if err := runTc("class", "replace", "dev", shaper.device, "parent", "1:", "classid", "1:1", "htb", "rate", shaper.linkRate); err != nil {
+		return err
+	}
+	return runTc("class", "replace", "dev", shaper.device, "parent", "1:1", "classid", classId(QOS_DEFAULT_CLASS), "htb", "rate", shaper.linkRate)
+}
+
+func (shaper *HtbShaper) Apply(class uint16, limit *pb.QosLimit) error {
+	rate := fmt.Sprintf("%dbit", int64(limit.Rate))
+	if err := runTc("class", "replace", "dev", shaper.device, "parent", "1:1", "classid", classId(class), "htb", "rate", rate, "ceil", rate); err != nil {
+		return err
+	}
+	return runTc("filter", "replace", "dev", shaper.device, "parent", "1:", "protocol", "ip", "prio", QOS_FILTER_PRIO, "handle", strconv.Itoa(int(class)),
+		"flower", "src_ip", limit.Src, "dst_ip", limit.Dst, "classid", classId(class))
+}
+
+func (shaper *HtbShaper) Remove(class uint16) error {
+	if err := runTc("filter", "del", "dev", shaper.device, "parent", "1:", "protocol", "ip", "prio", QOS_FILTER_PRIO, "handle", strconv.Itoa(int(class)), "flower"); err != nil {
+		return err
+	}
+	return runTc("class", "del", "dev", shaper.device, "classid", classId(class))
+}
+
+func (shaper *HtbShaper) Stats() (map[uint16]ClassStats, error) {
+	out, err := exec.Command("tc", "-s", "-j", "class", "show", "dev", shaper.device).Output()
+	if err != nil {
+		return nil, fmt.Errorf("tc class show: %v", err)
+	}
+	classes := make([]struct {
+		Handle string `json:"handle"`
+		Stats  struct {
+			Bytes uint64 `json:"bytes"`
+			Drops uint64 `json:"drops"`
+		} `json:"stats"`
+	}, 0)
+	if err := json.Unmarshal(out, &classes); err != nil {
+		return nil, fmt.Errorf("tc class show: %v", err)
+	}
+	stats := make(map[uint16]ClassStats, 0)
+	for _, c := range classes {
+		parts := strings.Split(c.Handle, ":")
+		if len(parts) != 2 || parts[0] != "1" {
+			continue
+		}
+		minor, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			continue
+		}
+		stats[uint16(minor)] = ClassStats{Bytes: c.Stats.Bytes, Drops: c.Stats.Drops}
+	}
+	return stats, nil
+}
+
+type qosClass struct {
+	limit *pb.QosLimit
+	class uint16
+	err   string // why the limit is not enforced
+}
+
+// QosEnforcer keeps the limits of the node in line with the last policy it got. Only what changed
+// since the policy before is sent to the shaper
+type QosEnforcer struct {
+	lock     *sync.Mutex
+	shaper   Shaper
+	setupErr error
+	version  int64
+	classes  map[string]*qosClass // src>dst -> its class
+	free     []uint16
+	next     uint16
+}
+
+func NewQosEnforcer(shaper Shaper) *QosEnforcer {
+	enforcer := &QosEnforcer{lock: &sync.Mutex{}, shaper: shaper, classes: make(map[string]*qosClass, 0), free: make([]uint16, 0), next: QOS_FIRST_CLASS}
+	enforcer.setupErr = shaper.Setup()
+	if enforcer.setupErr != nil {
+		log.Printf("Could not set up %s qos: %v", shaper.Name(), enforcer.setupErr)
+	}
+	return enforcer
+}
+
+func qosKey(limit *pb.QosLimit) string {
+	return limit.Src + ">" + limit.Dst
+}
+
+func (enforcer *QosEnforcer) newClass() (uint16, bool) {
+	if len(enforcer.free) > 0 {
+		class := enforcer.free[len(enforcer.free)-1]
+		enforcer.free = enforcer.free[:len(enforcer.free)-1]
+		return class, true
+	}
+	if enforcer.next >= QOS_DEFAULT_CLASS {
+		return 0, false
+	}
+	enforcer.next += 1
+	return enforcer.next - 1, true
+}
+
+func validLimit(limit *pb.QosLimit) string {
+	if net.ParseIP(limit.Src).To4() == nil || net.ParseIP(limit.Dst).To4() == nil {
+		return fmt.Sprintf("not an ipv4 pair: %s -> %s", limit.Src, limit.Dst)
+	}
+	if limit.Rate < QOS_MIN_RATE {
+		return fmt.Sprintf("rate %f below %d", limit.Rate, QOS_MIN_RATE)
+	}
+	return ""
+}
+
+// makes the limits of policy the ones enforced. Limits of the same pod pair are added up
+func (enforcer *QosEnforcer) SetPolicy(policy *pb.QosPolicy) {
+	enforcer.lock.Lock()
+	defer enforcer.lock.Unlock()
+	enforcer.version = policy.Version
+	if enforcer.setupErr != nil {
+		return
+	}
+	wanted := make(map[string]*pb.QosLimit, 0)
+	for _, limit := range policy.Limits {
+		key := qosKey(limit)
+		if other, exists := wanted[key]; exists {
+			wanted[key] = &pb.QosLimit{Id: other.Id + "," + limit.Id, Src: limit.Src, Dst: limit.Dst, Rate: other.Rate + limit.Rate}
+			continue
+		}
+		wanted[key] = limit
+	}
+	for key, c := range enforcer.classes {
+		if _, exists := wanted[key]; exists {
+			continue
+		}
+		enforcer.removeClass(key, c)
+		delete(enforcer.classes, key)
+		log.Printf("Removed the limit %s", key)
+	}
+	for key, limit := range wanted {
+		c, exists := enforcer.classes[key]
+		if exists && c.err == "" && c.limit.Rate == limit.Rate {
+			c.limit = limit
+			continue
+		}
+		if invalid := validLimit(limit); invalid != "" {
+			if exists {
+				enforcer.removeClass(key, c)
+			}
+			enforcer.classes[key] = &qosClass{limit: limit, err: invalid}
+			continue
+		}
+		if !exists || c.class == 0 {
+			class, ok := enforcer.newClass()
+			if !ok {
+				enforcer.classes[key] = &qosClass{limit: limit, err: "out of htb classes"}
+				continue
+			}
+			c = &qosClass{class: class}
+			enforcer.classes[key] = c
+		}
+		c.limit, c.err = limit, ""
+		if err := enforcer.shaper.Apply(c.class, limit); err != nil {
+			c.err = err.Error()
+			log.Printf("Could not limit %s to %f: %v", key, limit.Rate, err)
+			continue
+		}
+		log.Printf("Limited %s (%s) to %f", key, limit.Id, limit.Rate)
+	}
+}
+
+// takes the class of a limit off the device and frees it, a limit that was never applied has none
+func (enforcer *QosEnforcer) removeClass(key string, c *qosClass) {
+	if c.class == 0 {
+		return
+	}
+	if err := enforcer.shaper.Remove(c.class); err != nil {
+		log.Printf("Could not remove the limit %s: %v", key, err)
+	}
+	enforcer.free = append(enforcer.free, c.class)
+	c.class = 0
+}
+
+func (enforcer *QosEnforcer) Status() *pb.QosStatus {
+	enforcer.lock.Lock()
+	defer enforcer.lock.Unlock()
+	status := &pb.QosStatus{Version: enforcer.version, Limits: make([]*pb.QosLimitStatus, 0)}
+	if enforcer.setupErr != nil {
+		status.Error = enforcer.setupErr.Error()
+		return status
+	}
+	status.Backend = enforcer.shaper.Name()
+	stats, err := enforcer.shaper.Stats()
+	if err != nil {
+		log.Printf("Could not get the qos stats: %v", err)
+	}
+	keys := make([]string, 0)
+	for key := range enforcer.classes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := enforcer.classes[key]
+		limitStatus := &pb.QosLimitStatus{Limit: c.limit, Enforced: c.err == "", Error: c.err}
+		if c.err == "" {
+			limitStatus.Bytes, limitStatus.Drops = stats[c.class].Bytes, stats[c.class].Drops
+		}
+		status.Limits = append(status.Limits, limitStatus)
+	}
+	return status
+}
+
+func qosOff() *pb.QosStatus {
+	return &pb.QosStatus{Error: "qos enforcement is off, netmon runs without -qos", Limits: make([]*pb.QosLimitStatus, 0)}
+}
+
+func (s *server) SetQosPolicy(ctx context.Context, in *pb.QosPolicy) (*pb.QosStatus, error) {
+	if s.qos == nil {
+		return qosOff(), nil
+	}
+	log.Printf("Got qos policy %d with %d limits", in.Version, len(in.Limits))
+	s.qos.SetPolicy(in)
+	return s.qos.Status(), nil
+}
+
+func (s *server) GetQosStatus(ctx context.Context, in *pb.QosStatusRequest) (*pb.QosStatus, error) {
+	if s.qos == nil {
+		return qosOff(), nil
+	}
+	return s.qos.Status(), nil
+}
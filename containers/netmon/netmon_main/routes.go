@@ -0,0 +1,190 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// route changes kept for WatchRouteChanges streams that reconnect
+const ROUTE_CHANGES_KEPT = 100
+
+// traceroutes kept per host to find the routes traffic to it is spread over
+const ROUTE_SAMPLES = 10
+
+type routeVersion struct {
+	hops    []string
+	version uint64
+	recent  [][]string // last ROUTE_SAMPLES traceroutes, oldest first
+}
+
+// RouteTable keeps the current route to every host with its version, and the last changes
+type RouteTable struct {
+	lock    *sync.Mutex
+	routes  map[string]routeVersion // dest node -> route
+	changes []*pb.RouteChange       // oldest first
+	seq     uint64                  // of the last change
+}
+
+func NewRouteTable() *RouteTable {
+	return &RouteTable{lock: &sync.Mutex{}, routes: make(map[string]routeVersion, 0), changes: make([]*pb.RouteChange, 0)}
+}
+
+// whether two traceroutes went over the same hops. A hop that did not answer in either of them
+// matches any hop, so a lost probe is not taken for a route change
+func sameRoute(hops []string, other []string) bool {
+	if len(hops) != len(other) {
+		return false
+	}
+	for i, hop := range hops {
+		if hop != other[i] && hop != "*" && other[i] != "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// compares the traceroutes with the current routes and returns the changes. An empty route means
+// the traceroute failed and keeps the current one. Going back to a route one of the last traceroutes
+// took is no change, with ECMP the traceroutes alternate between the routes
+func (table *RouteTable) Update(now time.Time, trInfo TracerouteResults) []*pb.RouteChange {
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	changes := make([]*pb.RouteChange, 0)
+	for _, tr := range trInfo.TracerouteResults {
+		if len(tr.Route) == 0 {
+			continue
+		}
+		cur, exists := table.routes[tr.Host]
+		if !exists {
+			table.routes[tr.Host] = routeVersion{hops: tr.Route, version: 1, recent: [][]string{tr.Route}}
+			continue
+		}
+		recent := append(cur.recent, tr.Route)
+		if len(recent) > ROUTE_SAMPLES {
+			recent = recent[len(recent)-ROUTE_SAMPLES:]
+		}
+		if sameRoute(cur.hops, tr.Route) {
+			table.routes[tr.Host] = routeVersion{hops: fillHops(tr.Route, cur.hops), version: cur.version, recent: recent}
+			continue
+		}
+		if seenRecently(cur.recent, tr.Route) {
+			table.routes[tr.Host] = routeVersion{hops: tr.Route, version: cur.version, recent: recent}
+			continue
+		}
+		table.seq += 1
+		change := &pb.RouteChange{Seq: table.seq, Host: tr.Host, Version: cur.version + 1, OldHops: cur.hops, NewHops: tr.Route, Time: now.Unix()}
+		log.Printf("route to %s changed from %v to %v, version %d", tr.Host, cur.hops, tr.Route, change.Version)
+		table.routes[tr.Host] = routeVersion{hops: tr.Route, version: change.Version, recent: recent}
+		changes = append(changes, change)
+	}
+	table.changes = append(table.changes, changes...)
+	if len(table.changes) > ROUTE_CHANGES_KEPT {
+		table.changes = table.changes[len(table.changes)-ROUTE_CHANGES_KEPT:]
+	}
+	return changes
+}
+
+// hops with the hops that did not answer taken from the same route traced before
+func fillHops(hops []string, before []string) []string {
+	filled := make([]string, len(hops))
+	for i, hop := range hops {
+		filled[i] = hop
+		if hop == "*" {
+			filled[i] = before[i]
+		}
+	}
+	return filled
+}
+
+func seenRecently(recent [][]string, hops []string) bool {
+	for _, other := range recent {
+		if sameRoute(other, hops) {
+			return true
+		}
+	}
+	return false
+}
+
+// every route the last traceroutes to host took with the share of them that took it, most taken first
+func (table *RouteTable) Routes(host string) []*pb.RouteInfo {
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	recent := table.routes[host].recent
+	routes := make([]*pb.RouteInfo, 0)
+	counts := make([]int, 0)
+	for _, hops := range recent {
+		found := false
+		for i, route := range routes {
+			if sameRoute(route.Hops, hops) {
+				route.Hops = fillHops(route.Hops, hops)
+				counts[i] += 1
+				found = true
+				break
+			}
+		}
+		if !found {
+			routes = append(routes, &pb.RouteInfo{Hops: hops})
+			counts = append(counts, 1)
+		}
+	}
+	for i, route := range routes {
+		route.Weight = float32(counts[i]) / float32(len(recent))
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Weight > routes[j].Weight })
+	return routes
+}
+
+// version of the route to host, 0 if there is none yet
+func (table *RouteTable) Version(host string) uint64 {
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	return table.routes[host].version
+}
+
+// changes kept with a seq above after, all of them if after is from before netmon restarted
+func (table *RouteTable) Since(after uint64) []*pb.RouteChange {
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	if after > table.seq {
+		after = 0
+	}
+	changes := make([]*pb.RouteChange, 0)
+	for _, change := range table.changes {
+		if change.Seq > after {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// traceroute info of tr with the version of its route and the routes the last traceroutes took
+func (s *server) traceInfo(tr Traceroute) *pb.TracerouteInfo {
+	return &pb.TracerouteInfo{Host: tr.Host, Hops: tr.Route, Version: s.routes.Version(tr.Host), Routes: s.routes.Routes(tr.Host)}
+}
+
+// sends the changes after the one in asks for, and every change found after every refresh, until the client goes away
+func (s *server) WatchRouteChanges(in *pb.RouteChangeRequest, stream pb.NetMonitor_WatchRouteChangesServer) error {
+	id, changed := s.addWatcher()
+	defer s.removeWatcher(id)
+	log.Printf("route watcher %d started after %d", id, in.After)
+	after := in.After
+	for {
+		for _, change := range s.routes.Since(after) {
+			if err := stream.Send(change); err != nil {
+				log.Printf("route watcher %d failed: %v", id, err)
+				return err
+			}
+			after = change.Seq
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			log.Printf("route watcher %d done", id)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+)
+
+// how often the XDP and tc counters are read
+const TRAFFIC_SAMPLE_INTERVAL = time.Second
+
+// flows without new packets for this long are removed from the flow tables
+const FLOW_IDLE_TIMEOUT = 10 * time.Minute
+
+// counters of the bpf programs for one peer ip or flow
+type PeerCounters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// flow as the bpf programs count it, the inner pod ips for flannel's vxlan
+type FlowKey struct {
+	Src      string
+	Dst      string
+	DstPort  uint16 // 0 if not tcp or udp
+	Protocol uint16
+	Egress   bool // counted by the tc egress hook, by XDP if not set
+}
+
+func (key FlowKey) String() string {
+	direction := "in"
+	if key.Egress {
+		direction = "out"
+	}
+	return fmt.Sprintf("%s %s>%s:%d/%d", direction, key.Src, key.Dst, key.DstPort, key.Protocol)
+}
+
+type FlowCount struct {
+	Key      FlowKey
+	Counters PeerCounters
+}
+
+// when the bytes of a flow last changed
+type flowSeen struct {
+	bytes uint64
+	time  time.Time
+}
+
+type trafficSample struct {
+	time     time.Time
+	counters map[string]PeerCounters
+}
+
+// TrafficWindows keeps samples of the bpf counters for the longest window and computes the
+// rate of every peer or flow over each window
+type TrafficWindows struct {
+	windows []time.Duration // shortest first
+	samples []trafficSample // oldest first
+	lock    *sync.Mutex
+}
+
+func NewTrafficWindows(windows []time.Duration) *TrafficWindows {
+	sorted := append([]time.Duration{}, windows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &TrafficWindows{windows: sorted, samples: make([]trafficSample, 0), lock: &sync.Mutex{}}
+}
+
+// comma separated durations, e.g. 10s,1m,5m
+func parseWindows(windowList string) ([]time.Duration, error) {
+	windows := make([]time.Duration, 0)
+	for _, w := range strings.Split(windowList, ",") {
+		window, err := time.ParseDuration(strings.TrimSpace(w))
+		if err != nil {
+			return nil, err
+		}
+		if window < time.Second {
+			return nil, fmt.Errorf("window %v is shorter than 1s", window)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// adds a sample and drops the ones not needed for the longest window any more
+func (tw *TrafficWindows) Add(now time.Time, counters map[string]PeerCounters) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	tw.samples = append(tw.samples, trafficSample{time: now, counters: counters})
+	if len(tw.windows) == 0 {
+		tw.samples = tw.samples[len(tw.samples)-1:]
+		return
+	}
+	// keep the newest sample that is at least the longest window old
+	oldest := now.Add(-tw.windows[len(tw.windows)-1])
+	drop := 0
+	for drop+1 < len(tw.samples) && !tw.samples[drop+1].time.After(oldest) {
+		drop += 1
+	}
+	tw.samples = tw.samples[drop:]
+}
+
+// sample the rate over window is computed from, the newest one at least window old or the oldest one there is
+func (tw *TrafficWindows) startOf(window time.Duration) trafficSample {
+	last := tw.samples[len(tw.samples)-1]
+	start := tw.samples[0]
+	for _, sample := range tw.samples {
+		if last.time.Sub(sample.time) < window {
+			break
+		}
+		start = sample
+	}
+	return start
+}
+
+// counters and rates of every peer, sorted by host
+func (tw *TrafficWindows) Infos() []*pb.TrafficInfo {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	infos := make([]*pb.TrafficInfo, 0)
+	if len(tw.samples) == 0 {
+		return infos
+	}
+	last := tw.samples[len(tw.samples)-1]
+	for host, counters := range last.counters {
+		info := &pb.TrafficInfo{Host: host, Packets: counters.Packets, Bytes: counters.Bytes, Rates: make([]*pb.TrafficRate, 0)}
+		for _, window := range tw.windows {
+			start := tw.startOf(window)
+			rate := &pb.TrafficRate{Window: int32(window / time.Second)}
+			elapsed := last.time.Sub(start.time).Seconds()
+			// counters only go up, a peer seen for the first time started at 0
+			prev := start.counters[host]
+			if elapsed > 0 && counters.Bytes >= prev.Bytes && counters.Packets >= prev.Packets {
+				rate.BitRate = float32(float64(8*(counters.Bytes-prev.Bytes)) / elapsed)
+				rate.PacketRate = float32(float64(counters.Packets-prev.Packets) / elapsed)
+			}
+			info.Rates = append(info.Rates, rate)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Host < infos[j].Host })
+	return infos
+}
+
+// reads the XDP and tc counters every TRAFFIC_SAMPLE_INTERVAL
+func (s *server) SampleTraffic() {
+	for {
+		now := time.Now()
+		s.trafficWindows.Add(now, s.bpfRunner.GetCounters())
+		s.sentWindows.Add(now, s.bpfRunner.GetSentCounters())
+		flows := s.bpfRunner.GetFlows()
+		flowCounters := make(map[string]PeerCounters, 0)
+		flowKeys := make(map[string]FlowKey, 0)
+		for key, flow := range flows {
+			flowCounters[key] = flow.Counters
+			flowKeys[key] = flow.Key
+		}
+		s.flowWindows.Add(now, flowCounters)
+		s.flowLock.Lock()
+		s.flowKeys = flowKeys
+		s.flowLock.Unlock()
+		time.Sleep(TRAFFIC_SAMPLE_INTERVAL)
+	}
+}
+
+// counters and rates of the flows from or to in.Hosts, every flow if no hosts are given
+func (s *server) GetFlowInfo(ctx context.Context, in *pb.FlowInfoRequest) (*pb.NetInfoReply, error) {
+	hosts := make(map[string]bool, 0)
+	for _, host := range in.Hosts {
+		hosts[host] = true
+	}
+	s.flowLock.Lock()
+	flowKeys := s.flowKeys
+	s.flowLock.Unlock()
+	flowInfos := make([]*pb.FlowInfo, 0)
+	for _, info := range s.flowWindows.Infos() {
+		key, exists := flowKeys[info.Host]
+		if !exists || (len(hosts) > 0 && !hosts[key.Src] && !hosts[key.Dst]) {
+			continue
+		}
+		flowInfos = append(flowInfos, &pb.FlowInfo{Src: key.Src, Dst: key.Dst, DstPort: uint32(key.DstPort), Protocol: uint32(key.Protocol),
+			Egress: key.Egress, Packets: info.Packets, Bytes: info.Bytes, Rates: info.Rates})
+	}
+	log.Printf("Sending %d of %d flows", len(flowInfos), len(flowKeys))
+	return &pb.NetInfoReply{FlowInfo: flowInfos}, nil
+}
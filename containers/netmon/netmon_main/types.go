@@ -6,11 +6,15 @@ type Bandwidth struct {
 	Rcv   float64 `json:"Rcv"`
 	SndBw float64 `json:"SndBw"`
 	RcvBw float64 `json:"RcvBw"`
+	// confidence interval of RcvBw, both 0 if the estimator gives none
+	RcvBwLow  float64 `json:"RcvBwLow"`
+	RcvBwHigh float64 `json:"RcvBwHigh"`
+	Estimator string  `json:"Estimator"`
 }
 
 type Latency struct {
 	Host    string
-	Latency float64
+	Latency float64 // ms
 }
 type LatencyResults struct {
 	LatencyResults []Latency
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	pb "github.gatech.edu/cs-epl/mesh-bw-scheduler/netmon"
+	"google.golang.org/protobuf/proto"
+)
+
+// registers a watcher, the returned channel gets a value whenever the caches change
+func (s *server) addWatcher() (int, chan bool) {
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	id := s.nextWatcher
+	s.nextWatcher += 1
+	changed := make(chan bool, 1)
+	s.watchers[id] = changed
+	return id, changed
+}
+
+func (s *server) removeWatcher(id int) {
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	delete(s.watchers, id)
+}
+
+// wakes up every watcher, a watcher that has not caught up yet only sees the latest state
+func (s *server) notifyWatchers() {
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	for _, changed := range s.watchers {
+		select {
+		case changed <- true:
+		default:
+		}
+	}
+}
+
+// full update with the current caches and the bw aggregated as in asks, hosts are sorted so equal caches give equal updates
+func (s *server) watchSnapshot(in *pb.WatchRequest) *pb.NetInfoUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update := &pb.NetInfoUpdate{Full: true, BwInfo: make([]*pb.BandwidthInfo, 0), HeadroomInfo: make([]*pb.BandwidthInfo, 0),
+		TrInfo: make([]*pb.TracerouteInfo, 0), LatInfo: make([]*pb.LatencyInfo, 0), BpfInfo: s.trafficWindows.Infos(), BpfSentInfo: s.sentWindows.Infos()}
+	dsts := make([]string, 0)
+	for dst, _ := range s.traffic {
+		dsts = append(dsts, dst)
+	}
+	sort.Strings(dsts)
+	// same hosts as GetNetInfo, the ones there is traffic to
+	for _, dst := range dsts {
+		bwInfo := &pb.BandwidthInfo{Host: dst, RecvBwUsed: float32(s.traffic[dst])}
+		if bw, exists := bandwidthFor(s.BwCache, s.bwHistory, dst, in.Aggregate, in.Window); exists {
+			setBandwidth(bwInfo, bw)
+		}
+		update.BwInfo = append(update.BwInfo, bwInfo)
+		hrInfo := &pb.BandwidthInfo{Host: dst, RecvBwUsed: float32(s.traffic[dst])}
+		if headroom, exists := bandwidthFor(s.HeadroomCacheMeasured, s.headroomHistory, dst, in.Aggregate, in.Window); exists {
+			setBandwidth(hrInfo, headroom)
+		}
+		update.HeadroomInfo = append(update.HeadroomInfo, hrInfo)
+	}
+	for _, tr := range s.TrCache.TracerouteResults {
+		update.TrInfo = append(update.TrInfo, s.traceInfo(tr))
+	}
+	sort.Slice(update.TrInfo, func(i, j int) bool { return update.TrInfo[i].Host < update.TrInfo[j].Host })
+	for dst, latency := range s.LatencyCache {
+		update.LatInfo = append(update.LatInfo, &pb.LatencyInfo{Host: dst, Latency: float32(latency.Latency)})
+	}
+	sort.Slice(update.LatInfo, func(i, j int) bool { return update.LatInfo[i].Host < update.LatInfo[j].Host })
+	return update
+}
+
+// what changed from last to cur, both full updates. nil if nothing changed, cur itself if there
+// was no last update or a host went away
+func diffUpdates(last *pb.NetInfoUpdate, cur *pb.NetInfoUpdate) *pb.NetInfoUpdate {
+	if last == nil {
+		return cur
+	}
+	delta := &pb.NetInfoUpdate{}
+	removed := false
+	delta.BwInfo, removed = diffBandwidth(last.BwInfo, cur.BwInfo)
+	if removed {
+		return cur
+	}
+	delta.HeadroomInfo, removed = diffBandwidth(last.HeadroomInfo, cur.HeadroomInfo)
+	if removed {
+		return cur
+	}
+	lastTr := make(map[string]*pb.TracerouteInfo, 0)
+	for _, tr := range last.TrInfo {
+		lastTr[tr.Host] = tr
+	}
+	for _, tr := range cur.TrInfo {
+		if prev, exists := lastTr[tr.Host]; !exists || !proto.Equal(prev, tr) {
+			delta.TrInfo = append(delta.TrInfo, tr)
+		}
+		delete(lastTr, tr.Host)
+	}
+	lastLat := make(map[string]*pb.LatencyInfo, 0)
+	for _, lat := range last.LatInfo {
+		lastLat[lat.Host] = lat
+	}
+	for _, lat := range cur.LatInfo {
+		if prev, exists := lastLat[lat.Host]; !exists || !proto.Equal(prev, lat) {
+			delta.LatInfo = append(delta.LatInfo, lat)
+		}
+		delete(lastLat, lat.Host)
+	}
+	if len(lastTr) > 0 || len(lastLat) > 0 {
+		return cur
+	}
+	delta.BpfInfo, removed = diffTraffic(last.BpfInfo, cur.BpfInfo)
+	if removed {
+		return cur
+	}
+	delta.BpfSentInfo, removed = diffTraffic(last.BpfSentInfo, cur.BpfSentInfo)
+	if removed {
+		return cur
+	}
+	if len(delta.BwInfo)+len(delta.HeadroomInfo)+len(delta.TrInfo)+len(delta.LatInfo)+len(delta.BpfInfo)+len(delta.BpfSentInfo) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// hosts of cur that are new or changed, and whether a host of last is gone
+func diffBandwidth(last []*pb.BandwidthInfo, cur []*pb.BandwidthInfo) ([]*pb.BandwidthInfo, bool) {
+	lastBw := make(map[string]*pb.BandwidthInfo, 0)
+	for _, bw := range last {
+		lastBw[bw.Host] = bw
+	}
+	changed := make([]*pb.BandwidthInfo, 0)
+	for _, bw := range cur {
+		if prev, exists := lastBw[bw.Host]; !exists || !proto.Equal(prev, bw) {
+			changed = append(changed, bw)
+		}
+		delete(lastBw, bw.Host)
+	}
+	return changed, len(lastBw) > 0
+}
+
+// hosts of cur whose counts are new or changed, and whether a host of last is gone
+func diffTraffic(last []*pb.TrafficInfo, cur []*pb.TrafficInfo) ([]*pb.TrafficInfo, bool) {
+	lastTraffic := make(map[string]*pb.TrafficInfo, 0)
+	for _, traffic := range last {
+		lastTraffic[traffic.Host] = traffic
+	}
+	changed := make([]*pb.TrafficInfo, 0)
+	for _, traffic := range cur {
+		if prev, exists := lastTraffic[traffic.Host]; !exists || !proto.Equal(prev, traffic) {
+			changed = append(changed, traffic)
+		}
+		delete(lastTraffic, traffic.Host)
+	}
+	return changed, len(lastTraffic) > 0
+}
+
+// sends the caches when the stream starts and what changed after every refresh, until the client goes away
+func (s *server) WatchNetInfo(in *pb.WatchRequest, stream pb.NetMonitor_WatchNetInfoServer) error {
+	id, changed := s.addWatcher()
+	defer s.removeWatcher(id)
+	log.Printf("watcher %d started", id)
+	var last *pb.NetInfoUpdate
+	for {
+		cur := s.watchSnapshot(in)
+		if update := diffUpdates(last, cur); update != nil {
+			if err := stream.Send(update); err != nil {
+				log.Printf("watcher %d failed: %v", id, err)
+				return err
+			}
+			log.Printf("watcher %d: sent %d bws %d headrooms %d traceroutes %d bpf %d bpf sent full = %v", id, len(update.BwInfo), len(update.HeadroomInfo), len(update.TrInfo), len(update.BpfInfo), len(update.BpfSentInfo), update.Full)
+		}
+		last = cur
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			log.Printf("watcher %d done", id)
+			return nil
+		}
+	}
+}
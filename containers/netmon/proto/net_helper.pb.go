@@ -20,12 +20,73 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// what is sent for the bw measured to a host, from the history netmon keeps of its measurements
+type Aggregate int32
+
+const (
+	Aggregate_LAST Aggregate = 0 // the last measurement
+	Aggregate_EWMA Aggregate = 1
+	Aggregate_P5   Aggregate = 2
+	Aggregate_P50  Aggregate = 3
+	Aggregate_P95  Aggregate = 4
+	Aggregate_MIN  Aggregate = 5
+)
+
+// Enum value maps for Aggregate.
+var (
+	Aggregate_name = map[int32]string{
+		0: "LAST",
+		1: "EWMA",
+		2: "P5",
+		3: "P50",
+		4: "P95",
+		5: "MIN",
+	}
+	Aggregate_value = map[string]int32{
+		"LAST": 0,
+		"EWMA": 1,
+		"P5":   2,
+		"P50":  3,
+		"P95":  4,
+		"MIN":  5,
+	}
+)
+
+func (x Aggregate) Enum() *Aggregate {
+	p := new(Aggregate)
+	*p = x
+	return p
+}
+
+func (x Aggregate) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Aggregate) Descriptor() protoreflect.EnumDescriptor {
+	return file_net_helper_proto_enumTypes[0].Descriptor()
+}
+
+func (Aggregate) Type() protoreflect.EnumType {
+	return &file_net_helper_proto_enumTypes[0]
+}
+
+func (x Aggregate) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Aggregate.Descriptor instead.
+func (Aggregate) EnumDescriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{0}
+}
+
 type NetInfoRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ShouldUpdate bool `protobuf:"varint,1,opt,name=ShouldUpdate,proto3" json:"ShouldUpdate,omitempty"`
+	ShouldUpdate bool      `protobuf:"varint,1,opt,name=ShouldUpdate,proto3" json:"ShouldUpdate,omitempty"`
+	Aggregate    Aggregate `protobuf:"varint,2,opt,name=aggregate,proto3,enum=netmon.Aggregate" json:"aggregate,omitempty"`
+	Window       int32     `protobuf:"varint,3,opt,name=window,proto3" json:"window,omitempty"` // seconds of history to aggregate, 0 for all of it
 }
 
 func (x *NetInfoRequest) Reset() {
@@ -67,12 +128,28 @@ func (x *NetInfoRequest) GetShouldUpdate() bool {
 	return false
 }
 
+func (x *NetInfoRequest) GetAggregate() Aggregate {
+	if x != nil {
+		return x.Aggregate
+	}
+	return Aggregate_LAST
+}
+
+func (x *NetInfoRequest) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
 type HeadroomInfoRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	BwInfo []*BandwidthInfo `protobuf:"bytes,1,rep,name=bwInfo,proto3" json:"bwInfo,omitempty"`
+	BwInfo    []*BandwidthInfo `protobuf:"bytes,1,rep,name=bwInfo,proto3" json:"bwInfo,omitempty"`
+	Aggregate Aggregate        `protobuf:"varint,2,opt,name=aggregate,proto3,enum=netmon.Aggregate" json:"aggregate,omitempty"`
+	Window    int32            `protobuf:"varint,3,opt,name=window,proto3" json:"window,omitempty"`
 }
 
 func (x *HeadroomInfoRequest) Reset() {
@@ -114,13 +191,31 @@ func (x *HeadroomInfoRequest) GetBwInfo() []*BandwidthInfo {
 	return nil
 }
 
+func (x *HeadroomInfoRequest) GetAggregate() Aggregate {
+	if x != nil {
+		return x.Aggregate
+	}
+	return Aggregate_LAST
+}
+
+func (x *HeadroomInfoRequest) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
 type NetInfoReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	BwInfo []*BandwidthInfo  `protobuf:"bytes,1,rep,name=bwInfo,proto3" json:"bwInfo,omitempty"`
-	TrInfo []*TracerouteInfo `protobuf:"bytes,2,rep,name=trInfo,proto3" json:"trInfo,omitempty"`
+	BwInfo      []*BandwidthInfo  `protobuf:"bytes,1,rep,name=bwInfo,proto3" json:"bwInfo,omitempty"`
+	TrInfo      []*TracerouteInfo `protobuf:"bytes,2,rep,name=trInfo,proto3" json:"trInfo,omitempty"`
+	LatInfo     []*LatencyInfo    `protobuf:"bytes,3,rep,name=latInfo,proto3" json:"latInfo,omitempty"`
+	BpfInfo     []*TrafficInfo    `protobuf:"bytes,4,rep,name=bpfInfo,proto3" json:"bpfInfo,omitempty"`
+	BpfSentInfo []*TrafficInfo    `protobuf:"bytes,5,rep,name=bpfSentInfo,proto3" json:"bpfSentInfo,omitempty"`
+	FlowInfo    []*FlowInfo       `protobuf:"bytes,6,rep,name=flowInfo,proto3" json:"flowInfo,omitempty"`
 }
 
 func (x *NetInfoReply) Reset() {
@@ -169,6 +264,233 @@ func (x *NetInfoReply) GetTrInfo() []*TracerouteInfo {
 	return nil
 }
 
+func (x *NetInfoReply) GetLatInfo() []*LatencyInfo {
+	if x != nil {
+		return x.LatInfo
+	}
+	return nil
+}
+
+func (x *NetInfoReply) GetBpfInfo() []*TrafficInfo {
+	if x != nil {
+		return x.BpfInfo
+	}
+	return nil
+}
+
+func (x *NetInfoReply) GetBpfSentInfo() []*TrafficInfo {
+	if x != nil {
+		return x.BpfSentInfo
+	}
+	return nil
+}
+
+func (x *NetInfoReply) GetFlowInfo() []*FlowInfo {
+	if x != nil {
+		return x.FlowInfo
+	}
+	return nil
+}
+
+type FlowInfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hosts []string `protobuf:"bytes,1,rep,name=hosts,proto3" json:"hosts,omitempty"` // only flows from or to these ips, every flow if empty
+}
+
+func (x *FlowInfoRequest) Reset() {
+	*x = FlowInfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowInfoRequest) ProtoMessage() {}
+
+func (x *FlowInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowInfoRequest.ProtoReflect.Descriptor instead.
+func (*FlowInfoRequest) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FlowInfoRequest) GetHosts() []string {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Aggregate Aggregate `protobuf:"varint,1,opt,name=aggregate,proto3,enum=netmon.Aggregate" json:"aggregate,omitempty"`
+	Window    int32     `protobuf:"varint,2,opt,name=window,proto3" json:"window,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchRequest) GetAggregate() Aggregate {
+	if x != nil {
+		return x.Aggregate
+	}
+	return Aggregate_LAST
+}
+
+func (x *WatchRequest) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+// Hosts that changed since the previous update of the stream. A full update replaces everything
+// sent before, the first update of a stream is always full
+type NetInfoUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Full         bool              `protobuf:"varint,1,opt,name=full,proto3" json:"full,omitempty"`
+	BwInfo       []*BandwidthInfo  `protobuf:"bytes,2,rep,name=bwInfo,proto3" json:"bwInfo,omitempty"`             // link bw and traffic like GetNetInfo
+	HeadroomInfo []*BandwidthInfo  `protobuf:"bytes,3,rep,name=headroomInfo,proto3" json:"headroomInfo,omitempty"` // last measured headroom and traffic
+	TrInfo       []*TracerouteInfo `protobuf:"bytes,4,rep,name=trInfo,proto3" json:"trInfo,omitempty"`
+	LatInfo      []*LatencyInfo    `protobuf:"bytes,5,rep,name=latInfo,proto3" json:"latInfo,omitempty"`
+	BpfInfo      []*TrafficInfo    `protobuf:"bytes,6,rep,name=bpfInfo,proto3" json:"bpfInfo,omitempty"`
+	BpfSentInfo  []*TrafficInfo    `protobuf:"bytes,7,rep,name=bpfSentInfo,proto3" json:"bpfSentInfo,omitempty"`
+}
+
+func (x *NetInfoUpdate) Reset() {
+	*x = NetInfoUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NetInfoUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetInfoUpdate) ProtoMessage() {}
+
+func (x *NetInfoUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetInfoUpdate.ProtoReflect.Descriptor instead.
+func (*NetInfoUpdate) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NetInfoUpdate) GetFull() bool {
+	if x != nil {
+		return x.Full
+	}
+	return false
+}
+
+func (x *NetInfoUpdate) GetBwInfo() []*BandwidthInfo {
+	if x != nil {
+		return x.BwInfo
+	}
+	return nil
+}
+
+func (x *NetInfoUpdate) GetHeadroomInfo() []*BandwidthInfo {
+	if x != nil {
+		return x.HeadroomInfo
+	}
+	return nil
+}
+
+func (x *NetInfoUpdate) GetTrInfo() []*TracerouteInfo {
+	if x != nil {
+		return x.TrInfo
+	}
+	return nil
+}
+
+func (x *NetInfoUpdate) GetLatInfo() []*LatencyInfo {
+	if x != nil {
+		return x.LatInfo
+	}
+	return nil
+}
+
+func (x *NetInfoUpdate) GetBpfInfo() []*TrafficInfo {
+	if x != nil {
+		return x.BpfInfo
+	}
+	return nil
+}
+
+func (x *NetInfoUpdate) GetBpfSentInfo() []*TrafficInfo {
+	if x != nil {
+		return x.BpfSentInfo
+	}
+	return nil
+}
+
 type BandwidthInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -178,12 +500,16 @@ type BandwidthInfo struct {
 	SendBw     float32 `protobuf:"fixed32,2,opt,name=sendBw,proto3" json:"sendBw,omitempty"`
 	ReceiveBw  float32 `protobuf:"fixed32,3,opt,name=receiveBw,proto3" json:"receiveBw,omitempty"`
 	RecvBwUsed float32 `protobuf:"fixed32,4,opt,name=recvBwUsed,proto3" json:"recvBwUsed,omitempty"`
+	// confidence interval of receiveBw, both 0 if the estimator gives none
+	ReceiveBwLow  float32 `protobuf:"fixed32,5,opt,name=receiveBwLow,proto3" json:"receiveBwLow,omitempty"`
+	ReceiveBwHigh float32 `protobuf:"fixed32,6,opt,name=receiveBwHigh,proto3" json:"receiveBwHigh,omitempty"`
+	Estimator     string  `protobuf:"bytes,7,opt,name=estimator,proto3" json:"estimator,omitempty"` // probe, train or adaptive, empty if not measured yet
 }
 
 func (x *BandwidthInfo) Reset() {
 	*x = BandwidthInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_net_helper_proto_msgTypes[3]
+		mi := &file_net_helper_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -196,7 +522,7 @@ func (x *BandwidthInfo) String() string {
 func (*BandwidthInfo) ProtoMessage() {}
 
 func (x *BandwidthInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_net_helper_proto_msgTypes[3]
+	mi := &file_net_helper_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -209,7 +535,7 @@ func (x *BandwidthInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BandwidthInfo.ProtoReflect.Descriptor instead.
 func (*BandwidthInfo) Descriptor() ([]byte, []int) {
-	return file_net_helper_proto_rawDescGZIP(), []int{3}
+	return file_net_helper_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *BandwidthInfo) GetHost() string {
@@ -240,19 +566,40 @@ func (x *BandwidthInfo) GetRecvBwUsed() float32 {
 	return 0
 }
 
+func (x *BandwidthInfo) GetReceiveBwLow() float32 {
+	if x != nil {
+		return x.ReceiveBwLow
+	}
+	return 0
+}
+
+func (x *BandwidthInfo) GetReceiveBwHigh() float32 {
+	if x != nil {
+		return x.ReceiveBwHigh
+	}
+	return 0
+}
+
+func (x *BandwidthInfo) GetEstimator() string {
+	if x != nil {
+		return x.Estimator
+	}
+	return ""
+}
+
 type LatencyInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Host    string  `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
-	Latency float32 `protobuf:"fixed32,2,opt,name=latency,proto3" json:"latency,omitempty"`
+	Latency float32 `protobuf:"fixed32,2,opt,name=latency,proto3" json:"latency,omitempty"` // average round trip time in ms
 }
 
 func (x *LatencyInfo) Reset() {
 	*x = LatencyInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_net_helper_proto_msgTypes[4]
+		mi := &file_net_helper_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -265,7 +612,7 @@ func (x *LatencyInfo) String() string {
 func (*LatencyInfo) ProtoMessage() {}
 
 func (x *LatencyInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_net_helper_proto_msgTypes[4]
+	mi := &file_net_helper_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +625,7 @@ func (x *LatencyInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LatencyInfo.ProtoReflect.Descriptor instead.
 func (*LatencyInfo) Descriptor() ([]byte, []int) {
-	return file_net_helper_proto_rawDescGZIP(), []int{4}
+	return file_net_helper_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *LatencyInfo) GetHost() string {
@@ -300,14 +647,16 @@ type TracerouteInfo struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Host string   `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
-	Hops []string `protobuf:"bytes,2,rep,name=hops,proto3" json:"hops,omitempty"`
+	Host    string       `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Hops    []string     `protobuf:"bytes,2,rep,name=hops,proto3" json:"hops,omitempty"`
+	Version uint64       `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // 1 for the first route netmon found to host, increased every time the hops change
+	Routes  []*RouteInfo `protobuf:"bytes,4,rep,name=routes,proto3" json:"routes,omitempty"`    // every route the last traceroutes took, e.g. with ECMP, most taken first
 }
 
 func (x *TracerouteInfo) Reset() {
 	*x = TracerouteInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_net_helper_proto_msgTypes[5]
+		mi := &file_net_helper_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -320,7 +669,7 @@ func (x *TracerouteInfo) String() string {
 func (*TracerouteInfo) ProtoMessage() {}
 
 func (x *TracerouteInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_net_helper_proto_msgTypes[5]
+	mi := &file_net_helper_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -333,7 +682,7 @@ func (x *TracerouteInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TracerouteInfo.ProtoReflect.Descriptor instead.
 func (*TracerouteInfo) Descriptor() ([]byte, []int) {
-	return file_net_helper_proto_rawDescGZIP(), []int{5}
+	return file_net_helper_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *TracerouteInfo) GetHost() string {
@@ -350,60 +699,983 @@ func (x *TracerouteInfo) GetHops() []string {
 	return nil
 }
 
-var File_net_helper_proto protoreflect.FileDescriptor
+func (x *TracerouteInfo) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
 
-var file_net_helper_proto_rawDesc = []byte{
-	0x0a, 0x10, 0x6e, 0x65, 0x74, 0x5f, 0x68, 0x65, 0x6c, 0x70, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x06, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x22, 0x34, 0x0a, 0x0e, 0x4e, 0x65,
-	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c,
-	0x53, 0x68, 0x6f, 0x75, 0x6c, 0x64, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x0c, 0x53, 0x68, 0x6f, 0x75, 0x6c, 0x64, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
-	0x22, 0x44, 0x0a, 0x13, 0x48, 0x65, 0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66,
-	0x6f, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e,
-	0x2e, 0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06,
-	0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x6d, 0x0a, 0x0c, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66,
-	0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e,
-	0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x62,
-	0x77, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2e, 0x0a, 0x06, 0x74, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x18,
-	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54,
-	0x72, 0x61, 0x63, 0x65, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x74,
-	0x72, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x79, 0x0a, 0x0d, 0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64,
-	0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65,
-	0x6e, 0x64, 0x42, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64,
-	0x42, 0x77, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77,
-	0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x76, 0x42, 0x77, 0x55, 0x73, 0x65, 0x64, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x76, 0x42, 0x77, 0x55, 0x73, 0x65, 0x64,
-	0x22, 0x3b, 0x0a, 0x0b, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12,
-	0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68,
-	0x6f, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x22, 0x38, 0x0a,
-	0x0e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12,
-	0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68,
-	0x6f, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x32, 0x92, 0x01, 0x0a, 0x0a, 0x4e, 0x65, 0x74, 0x4d,
-	0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x3c, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x4e, 0x65, 0x74,
-	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x16, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65,
-	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6e,
-	0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x72,
-	0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e,
-	0x2e, 0x48, 0x65, 0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65,
-	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x33, 0x5a, 0x31,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x63, 0x68, 0x2e, 0x65, 0x64,
-	0x75, 0x2f, 0x63, 0x73, 0x2d, 0x65, 0x70, 0x6c, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x62, 0x77,
-	0x2d, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2f, 0x6e, 0x65, 0x74, 0x6d, 0x6f,
-	0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *TracerouteInfo) GetRoutes() []*RouteInfo {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
 }
 
-var (
-	file_net_helper_proto_rawDescOnce sync.Once
-	file_net_helper_proto_rawDescData = file_net_helper_proto_rawDesc
-)
+type RouteInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hops   []string `protobuf:"bytes,1,rep,name=hops,proto3" json:"hops,omitempty"`
+	Weight float32  `protobuf:"fixed32,2,opt,name=weight,proto3" json:"weight,omitempty"` // share of the last traceroutes that took these hops
+}
+
+func (x *RouteInfo) Reset() {
+	*x = RouteInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouteInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteInfo) ProtoMessage() {}
+
+func (x *RouteInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteInfo.ProtoReflect.Descriptor instead.
+func (*RouteInfo) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RouteInfo) GetHops() []string {
+	if x != nil {
+		return x.Hops
+	}
+	return nil
+}
+
+func (x *RouteInfo) GetWeight() float32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// Traffic from host counted by the XDP program of the node, or to host counted by its tc egress hook
+type TrafficInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Host    string         `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Packets uint64         `protobuf:"varint,2,opt,name=packets,proto3" json:"packets,omitempty"` // since netmon started
+	Bytes   uint64         `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Rates   []*TrafficRate `protobuf:"bytes,4,rep,name=rates,proto3" json:"rates,omitempty"` // shortest window first
+}
+
+func (x *TrafficInfo) Reset() {
+	*x = TrafficInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TrafficInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrafficInfo) ProtoMessage() {}
+
+func (x *TrafficInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrafficInfo.ProtoReflect.Descriptor instead.
+func (*TrafficInfo) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TrafficInfo) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TrafficInfo) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *TrafficInfo) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *TrafficInfo) GetRates() []*TrafficRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type TrafficRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Window     int32   `protobuf:"varint,1,opt,name=window,proto3" json:"window,omitempty"` // seconds
+	BitRate    float32 `protobuf:"fixed32,2,opt,name=bitRate,proto3" json:"bitRate,omitempty"`
+	PacketRate float32 `protobuf:"fixed32,3,opt,name=packetRate,proto3" json:"packetRate,omitempty"` // packets per second
+}
+
+func (x *TrafficRate) Reset() {
+	*x = TrafficRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TrafficRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrafficRate) ProtoMessage() {}
+
+func (x *TrafficRate) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrafficRate.ProtoReflect.Descriptor instead.
+func (*TrafficRate) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TrafficRate) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+func (x *TrafficRate) GetBitRate() float32 {
+	if x != nil {
+		return x.BitRate
+	}
+	return 0
+}
+
+func (x *TrafficRate) GetPacketRate() float32 {
+	if x != nil {
+		return x.PacketRate
+	}
+	return 0
+}
+
+// Traffic of one flow counted by the XDP program when the node received it, or by the tc egress hook
+// when it sent it. Flows in flannel's vxlan are counted by their inner pod ips
+type FlowInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Src      string         `protobuf:"bytes,1,opt,name=src,proto3" json:"src,omitempty"`
+	Dst      string         `protobuf:"bytes,2,opt,name=dst,proto3" json:"dst,omitempty"`
+	DstPort  uint32         `protobuf:"varint,3,opt,name=dstPort,proto3" json:"dstPort,omitempty"` // 0 if not tcp or udp
+	Protocol uint32         `protobuf:"varint,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Egress   bool           `protobuf:"varint,5,opt,name=egress,proto3" json:"egress,omitempty"`
+	Packets  uint64         `protobuf:"varint,6,opt,name=packets,proto3" json:"packets,omitempty"`
+	Bytes    uint64         `protobuf:"varint,7,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Rates    []*TrafficRate `protobuf:"bytes,8,rep,name=rates,proto3" json:"rates,omitempty"`
+}
+
+func (x *FlowInfo) Reset() {
+	*x = FlowInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowInfo) ProtoMessage() {}
+
+func (x *FlowInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowInfo.ProtoReflect.Descriptor instead.
+func (*FlowInfo) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FlowInfo) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *FlowInfo) GetDst() string {
+	if x != nil {
+		return x.Dst
+	}
+	return ""
+}
+
+func (x *FlowInfo) GetDstPort() uint32 {
+	if x != nil {
+		return x.DstPort
+	}
+	return 0
+}
+
+func (x *FlowInfo) GetProtocol() uint32 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *FlowInfo) GetEgress() bool {
+	if x != nil {
+		return x.Egress
+	}
+	return false
+}
+
+func (x *FlowInfo) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *FlowInfo) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *FlowInfo) GetRates() []*TrafficRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type RouteChangeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// changes with a larger seq are sent first, as far as netmon still keeps them. A seq larger than any
+	// netmon has means netmon restarted since, and all it keeps are sent
+	After uint64 `protobuf:"varint,1,opt,name=after,proto3" json:"after,omitempty"`
+}
+
+func (x *RouteChangeRequest) Reset() {
+	*x = RouteChangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouteChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteChangeRequest) ProtoMessage() {}
+
+func (x *RouteChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteChangeRequest.ProtoReflect.Descriptor instead.
+func (*RouteChangeRequest) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RouteChangeRequest) GetAfter() uint64 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+// The hops towards host changed between two traceroutes
+type RouteChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seq     uint64   `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"` // 1 for the first change after netmon started, increased by one with every change
+	Host    string   `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Version uint64   `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // of the route to host after the change
+	OldHops []string `protobuf:"bytes,4,rep,name=oldHops,proto3" json:"oldHops,omitempty"`
+	NewHops []string `protobuf:"bytes,5,rep,name=newHops,proto3" json:"newHops,omitempty"`
+	Time    int64    `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"` // unix seconds
+}
+
+func (x *RouteChange) Reset() {
+	*x = RouteChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouteChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteChange) ProtoMessage() {}
+
+func (x *RouteChange) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteChange.ProtoReflect.Descriptor instead.
+func (*RouteChange) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RouteChange) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *RouteChange) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *RouteChange) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RouteChange) GetOldHops() []string {
+	if x != nil {
+		return x.OldHops
+	}
+	return nil
+}
+
+func (x *RouteChange) GetNewHops() []string {
+	if x != nil {
+		return x.NewHops
+	}
+	return nil
+}
+
+func (x *RouteChange) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+// Limit on the traffic a pod on the node sends to another pod, by their ips
+type QosLimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`   // what the limit is for, e.g. the reservation pod/dep, only reported back
+	Src  string  `protobuf:"bytes,2,opt,name=src,proto3" json:"src,omitempty"` // pod ip on the node
+	Dst  string  `protobuf:"bytes,3,opt,name=dst,proto3" json:"dst,omitempty"`
+	Rate float32 `protobuf:"fixed32,4,opt,name=rate,proto3" json:"rate,omitempty"` // bits per second
+}
+
+func (x *QosLimit) Reset() {
+	*x = QosLimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QosLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QosLimit) ProtoMessage() {}
+
+func (x *QosLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QosLimit.ProtoReflect.Descriptor instead.
+func (*QosLimit) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *QosLimit) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *QosLimit) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *QosLimit) GetDst() string {
+	if x != nil {
+		return x.Dst
+	}
+	return ""
+}
+
+func (x *QosLimit) GetRate() float32 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+// Every limit the node should enforce
+type QosPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version int64       `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // reported back in the status
+	Limits  []*QosLimit `protobuf:"bytes,2,rep,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *QosPolicy) Reset() {
+	*x = QosPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QosPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QosPolicy) ProtoMessage() {}
+
+func (x *QosPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QosPolicy.ProtoReflect.Descriptor instead.
+func (*QosPolicy) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *QosPolicy) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *QosPolicy) GetLimits() []*QosLimit {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type QosStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *QosStatusRequest) Reset() {
+	*x = QosStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QosStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QosStatusRequest) ProtoMessage() {}
+
+func (x *QosStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QosStatusRequest.ProtoReflect.Descriptor instead.
+func (*QosStatusRequest) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{17}
+}
+
+type QosLimitStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Limit    *QosLimit `protobuf:"bytes,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Enforced bool      `protobuf:"varint,2,opt,name=enforced,proto3" json:"enforced,omitempty"`
+	Error    string    `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`  // why the limit is not enforced
+	Bytes    uint64    `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"` // sent under the limit since it was set
+	Drops    uint64    `protobuf:"varint,5,opt,name=drops,proto3" json:"drops,omitempty"` // packets dropped by the limit since it was set
+}
+
+func (x *QosLimitStatus) Reset() {
+	*x = QosLimitStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QosLimitStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QosLimitStatus) ProtoMessage() {}
+
+func (x *QosLimitStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QosLimitStatus.ProtoReflect.Descriptor instead.
+func (*QosLimitStatus) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *QosLimitStatus) GetLimit() *QosLimit {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+func (x *QosLimitStatus) GetEnforced() bool {
+	if x != nil {
+		return x.Enforced
+	}
+	return false
+}
+
+func (x *QosLimitStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *QosLimitStatus) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *QosLimitStatus) GetDrops() uint64 {
+	if x != nil {
+		return x.Drops
+	}
+	return 0
+}
+
+// What the node enforces. error is set when the node does not enforce limits at all, e.g. when netmon
+// runs without -qos or the qdisc could not be set up
+type QosStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version int64             `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // of the last policy set, 0 if there was none
+	Backend string            `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"`  // htb, empty if limits are not enforced
+	Error   string            `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Limits  []*QosLimitStatus `protobuf:"bytes,4,rep,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *QosStatus) Reset() {
+	*x = QosStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_net_helper_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QosStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QosStatus) ProtoMessage() {}
+
+func (x *QosStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_net_helper_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QosStatus.ProtoReflect.Descriptor instead.
+func (*QosStatus) Descriptor() ([]byte, []int) {
+	return file_net_helper_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QosStatus) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *QosStatus) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *QosStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *QosStatus) GetLimits() []*QosLimitStatus {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+var File_net_helper_proto protoreflect.FileDescriptor
+
+var file_net_helper_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x6e, 0x65, 0x74, 0x5f, 0x68, 0x65, 0x6c, 0x70, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x06, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x22, 0x7d, 0x0a, 0x0e, 0x4e, 0x65,
+	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c,
+	0x53, 0x68, 0x6f, 0x75, 0x6c, 0x64, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0c, 0x53, 0x68, 0x6f, 0x75, 0x6c, 0x64, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x2f, 0x0a, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x41, 0x67, 0x67,
+	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x22, 0x8d, 0x01, 0x0a, 0x13, 0x48, 0x65,
+	0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x2d, 0x0a, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x42, 0x61, 0x6e, 0x64, 0x77,
+	0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x2f, 0x0a, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x41, 0x67, 0x67,
+	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x22, 0xb0, 0x02, 0x0a, 0x0c, 0x4e, 0x65,
+	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x06, 0x62, 0x77,
+	0x49, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74,
+	0x6d, 0x6f, 0x6e, 0x2e, 0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2e, 0x0a, 0x06, 0x74, 0x72, 0x49,
+	0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x65, 0x74, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x06, 0x74, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2d, 0x0a, 0x07, 0x6c, 0x61, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6e, 0x65, 0x74,
+	0x6d, 0x6f, 0x6e, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x07, 0x6c, 0x61, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2d, 0x0a, 0x07, 0x62, 0x70, 0x66, 0x49,
+	0x6e, 0x66, 0x6f, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6e, 0x65, 0x74, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07,
+	0x62, 0x70, 0x66, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x35, 0x0a, 0x0b, 0x62, 0x70, 0x66, 0x53, 0x65,
+	0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6e,
+	0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x0b, 0x62, 0x70, 0x66, 0x53, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2c,
+	0x0a, 0x08, 0x66, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x10, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x08, 0x66, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x27, 0x0a, 0x0f,
+	0x46, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x68, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
+	0x68, 0x6f, 0x73, 0x74, 0x73, 0x22, 0x57, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
+	0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f,
+	0x6e, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52, 0x09, 0x61, 0x67, 0x67,
+	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x22, 0xd2,
+	0x02, 0x0a, 0x0d, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x75, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x66, 0x75, 0x6c, 0x6c, 0x12, 0x2d, 0x0a, 0x06, 0x62, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x42, 0x61,
+	0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x62, 0x77, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x39, 0x0a, 0x0c, 0x68, 0x65, 0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49,
+	0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x0c, 0x68, 0x65, 0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2e,
+	0x0a, 0x06, 0x74, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x72, 0x6f, 0x75,
+	0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x74, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2d,
+	0x0a, 0x07, 0x6c, 0x61, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x6c, 0x61, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2d, 0x0a,
+	0x07, 0x62, 0x70, 0x66, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x07, 0x62, 0x70, 0x66, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x35, 0x0a, 0x0b,
+	0x62, 0x70, 0x66, 0x53, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x13, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66,
+	0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0b, 0x62, 0x70, 0x66, 0x53, 0x65, 0x6e, 0x74, 0x49,
+	0x6e, 0x66, 0x6f, 0x22, 0xe1, 0x01, 0x0a, 0x0d, 0x42, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74,
+	0x68, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e,
+	0x64, 0x42, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x42,
+	0x77, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77, 0x12,
+	0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x76, 0x42, 0x77, 0x55, 0x73, 0x65, 0x64, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x76, 0x42, 0x77, 0x55, 0x73, 0x65, 0x64, 0x12,
+	0x22, 0x0a, 0x0c, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77, 0x4c, 0x6f, 0x77, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0c, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77,
+	0x4c, 0x6f, 0x77, 0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x42, 0x77,
+	0x48, 0x69, 0x67, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0d, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x42, 0x77, 0x48, 0x69, 0x67, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x73, 0x74,
+	0x69, 0x6d, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x73,
+	0x74, 0x69, 0x6d, 0x61, 0x74, 0x6f, 0x72, 0x22, 0x3b, 0x0a, 0x0b, 0x4c, 0x61, 0x74, 0x65, 0x6e,
+	0x63, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x61,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x6c, 0x61, 0x74,
+	0x65, 0x6e, 0x63, 0x79, 0x22, 0x7d, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x72, 0x6f, 0x75,
+	0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f,
+	0x70, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x06, 0x72, 0x6f, 0x75, 0x74,
+	0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f,
+	0x6e, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x72, 0x6f, 0x75,
+	0x74, 0x65, 0x73, 0x22, 0x37, 0x0a, 0x09, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04,
+	0x68, 0x6f, 0x70, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x7c, 0x0a, 0x0b,
+	0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x68,
+	0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12,
+	0x18, 0x0a, 0x07, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x12,
+	0x29, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x52,
+	0x61, 0x74, 0x65, 0x52, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x22, 0x5f, 0x0a, 0x0b, 0x54, 0x72,
+	0x61, 0x66, 0x66, 0x69, 0x63, 0x52, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f,
+	0x77, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x69, 0x74, 0x52, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x07, 0x62, 0x69, 0x74, 0x52, 0x61, 0x74, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x0a, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x22, 0xd7, 0x01, 0x0a, 0x08,
+	0x46, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x73,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x64, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x64,
+	0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61,
+	0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x70, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x29, 0x0a, 0x05, 0x72, 0x61,
+	0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6e, 0x65, 0x74, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x52, 0x61, 0x74, 0x65, 0x52, 0x05,
+	0x72, 0x61, 0x74, 0x65, 0x73, 0x22, 0x2a, 0x0a, 0x12, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61,
+	0x66, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x61, 0x66, 0x74, 0x65,
+	0x72, 0x22, 0x95, 0x01, 0x0a, 0x0b, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03,
+	0x73, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x6c, 0x64, 0x48, 0x6f, 0x70, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x6f, 0x6c, 0x64, 0x48, 0x6f, 0x70, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6e,
+	0x65, 0x77, 0x48, 0x6f, 0x70, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65,
+	0x77, 0x48, 0x6f, 0x70, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x52, 0x0a, 0x08, 0x51, 0x6f, 0x73,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x73, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x61, 0x74,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x72, 0x61, 0x74, 0x65, 0x22, 0x4f, 0x0a,
+	0x09, 0x51, 0x6f, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x51, 0x6f,
+	0x73, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x22, 0x12,
+	0x0a, 0x10, 0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x96, 0x01, 0x0a, 0x0e, 0x51, 0x6f, 0x73, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x51, 0x6f,
+	0x73, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x65, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x65, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x72, 0x6f, 0x70, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x64, 0x72, 0x6f, 0x70, 0x73, 0x22, 0x85, 0x01, 0x0a, 0x09,
+	0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x2e, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x51, 0x6f, 0x73,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x73, 0x2a, 0x42, 0x0a, 0x09, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x12, 0x08, 0x0a, 0x04, 0x4c, 0x41, 0x53, 0x54, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x45, 0x57,
+	0x4d, 0x41, 0x10, 0x01, 0x12, 0x06, 0x0a, 0x02, 0x50, 0x35, 0x10, 0x02, 0x12, 0x07, 0x0a, 0x03,
+	0x50, 0x35, 0x30, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x50, 0x39, 0x35, 0x10, 0x04, 0x12, 0x07,
+	0x0a, 0x03, 0x4d, 0x49, 0x4e, 0x10, 0x05, 0x32, 0xd4, 0x03, 0x0a, 0x0a, 0x4e, 0x65, 0x74, 0x4d,
+	0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x3c, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x4e, 0x65, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x16, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65,
+	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6e,
+	0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x72,
+	0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e,
+	0x2e, 0x48, 0x65, 0x61, 0x64, 0x72, 0x6f, 0x6f, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65,
+	0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x0c,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x2e, 0x6e,
+	0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x15, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e, 0x65, 0x74, 0x49,
+	0x6e, 0x66, 0x6f, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x3e, 0x0a,
+	0x0b, 0x47, 0x65, 0x74, 0x46, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x17, 0x2e, 0x6e,
+	0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x4e,
+	0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x48, 0x0a,
+	0x11, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x73, 0x12, 0x1a, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x52, 0x6f, 0x75, 0x74,
+	0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13,
+	0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x36, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x51, 0x6f,
+	0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e,
+	0x2e, 0x51, 0x6f, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x1a, 0x11, 0x2e, 0x6e, 0x65, 0x74,
+	0x6d, 0x6f, 0x6e, 0x2e, 0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12,
+	0x3d, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x18, 0x2e, 0x6e, 0x65, 0x74, 0x6d, 0x6f, 0x6e, 0x2e, 0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x6e, 0x65, 0x74, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x51, 0x6f, 0x73, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x42, 0x33,
+	0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x63, 0x68, 0x2e,
+	0x65, 0x64, 0x75, 0x2f, 0x63, 0x73, 0x2d, 0x65, 0x70, 0x6c, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x2d,
+	0x62, 0x77, 0x2d, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2f, 0x6e, 0x65, 0x74,
+	0x6d, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_net_helper_proto_rawDescOnce sync.Once
+	file_net_helper_proto_rawDescData = file_net_helper_proto_rawDesc
+)
 
 func file_net_helper_proto_rawDescGZIP() []byte {
 	file_net_helper_proto_rawDescOnce.Do(func() {
@@ -412,28 +1684,73 @@ func file_net_helper_proto_rawDescGZIP() []byte {
 	return file_net_helper_proto_rawDescData
 }
 
-var file_net_helper_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_net_helper_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_net_helper_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_net_helper_proto_goTypes = []interface{}{
-	(*NetInfoRequest)(nil),      // 0: netmon.NetInfoRequest
-	(*HeadroomInfoRequest)(nil), // 1: netmon.HeadroomInfoRequest
-	(*NetInfoReply)(nil),        // 2: netmon.NetInfoReply
-	(*BandwidthInfo)(nil),       // 3: netmon.BandwidthInfo
-	(*LatencyInfo)(nil),         // 4: netmon.LatencyInfo
-	(*TracerouteInfo)(nil),      // 5: netmon.TracerouteInfo
+	(Aggregate)(0),              // 0: netmon.Aggregate
+	(*NetInfoRequest)(nil),      // 1: netmon.NetInfoRequest
+	(*HeadroomInfoRequest)(nil), // 2: netmon.HeadroomInfoRequest
+	(*NetInfoReply)(nil),        // 3: netmon.NetInfoReply
+	(*FlowInfoRequest)(nil),     // 4: netmon.FlowInfoRequest
+	(*WatchRequest)(nil),        // 5: netmon.WatchRequest
+	(*NetInfoUpdate)(nil),       // 6: netmon.NetInfoUpdate
+	(*BandwidthInfo)(nil),       // 7: netmon.BandwidthInfo
+	(*LatencyInfo)(nil),         // 8: netmon.LatencyInfo
+	(*TracerouteInfo)(nil),      // 9: netmon.TracerouteInfo
+	(*RouteInfo)(nil),           // 10: netmon.RouteInfo
+	(*TrafficInfo)(nil),         // 11: netmon.TrafficInfo
+	(*TrafficRate)(nil),         // 12: netmon.TrafficRate
+	(*FlowInfo)(nil),            // 13: netmon.FlowInfo
+	(*RouteChangeRequest)(nil),  // 14: netmon.RouteChangeRequest
+	(*RouteChange)(nil),         // 15: netmon.RouteChange
+	(*QosLimit)(nil),            // 16: netmon.QosLimit
+	(*QosPolicy)(nil),           // 17: netmon.QosPolicy
+	(*QosStatusRequest)(nil),    // 18: netmon.QosStatusRequest
+	(*QosLimitStatus)(nil),      // 19: netmon.QosLimitStatus
+	(*QosStatus)(nil),           // 20: netmon.QosStatus
 }
 var file_net_helper_proto_depIdxs = []int32{
-	3, // 0: netmon.HeadroomInfoRequest.bwInfo:type_name -> netmon.BandwidthInfo
-	3, // 1: netmon.NetInfoReply.bwInfo:type_name -> netmon.BandwidthInfo
-	5, // 2: netmon.NetInfoReply.trInfo:type_name -> netmon.TracerouteInfo
-	0, // 3: netmon.NetMonitor.GetNetInfo:input_type -> netmon.NetInfoRequest
-	1, // 4: netmon.NetMonitor.GetHeadroomInfo:input_type -> netmon.HeadroomInfoRequest
-	2, // 5: netmon.NetMonitor.GetNetInfo:output_type -> netmon.NetInfoReply
-	2, // 6: netmon.NetMonitor.GetHeadroomInfo:output_type -> netmon.NetInfoReply
-	5, // [5:7] is the sub-list for method output_type
-	3, // [3:5] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	0,  // 0: netmon.NetInfoRequest.aggregate:type_name -> netmon.Aggregate
+	7,  // 1: netmon.HeadroomInfoRequest.bwInfo:type_name -> netmon.BandwidthInfo
+	0,  // 2: netmon.HeadroomInfoRequest.aggregate:type_name -> netmon.Aggregate
+	7,  // 3: netmon.NetInfoReply.bwInfo:type_name -> netmon.BandwidthInfo
+	9,  // 4: netmon.NetInfoReply.trInfo:type_name -> netmon.TracerouteInfo
+	8,  // 5: netmon.NetInfoReply.latInfo:type_name -> netmon.LatencyInfo
+	11, // 6: netmon.NetInfoReply.bpfInfo:type_name -> netmon.TrafficInfo
+	11, // 7: netmon.NetInfoReply.bpfSentInfo:type_name -> netmon.TrafficInfo
+	13, // 8: netmon.NetInfoReply.flowInfo:type_name -> netmon.FlowInfo
+	0,  // 9: netmon.WatchRequest.aggregate:type_name -> netmon.Aggregate
+	7,  // 10: netmon.NetInfoUpdate.bwInfo:type_name -> netmon.BandwidthInfo
+	7,  // 11: netmon.NetInfoUpdate.headroomInfo:type_name -> netmon.BandwidthInfo
+	9,  // 12: netmon.NetInfoUpdate.trInfo:type_name -> netmon.TracerouteInfo
+	8,  // 13: netmon.NetInfoUpdate.latInfo:type_name -> netmon.LatencyInfo
+	11, // 14: netmon.NetInfoUpdate.bpfInfo:type_name -> netmon.TrafficInfo
+	11, // 15: netmon.NetInfoUpdate.bpfSentInfo:type_name -> netmon.TrafficInfo
+	10, // 16: netmon.TracerouteInfo.routes:type_name -> netmon.RouteInfo
+	12, // 17: netmon.TrafficInfo.rates:type_name -> netmon.TrafficRate
+	12, // 18: netmon.FlowInfo.rates:type_name -> netmon.TrafficRate
+	16, // 19: netmon.QosPolicy.limits:type_name -> netmon.QosLimit
+	16, // 20: netmon.QosLimitStatus.limit:type_name -> netmon.QosLimit
+	19, // 21: netmon.QosStatus.limits:type_name -> netmon.QosLimitStatus
+	1,  // 22: netmon.NetMonitor.GetNetInfo:input_type -> netmon.NetInfoRequest
+	2,  // 23: netmon.NetMonitor.GetHeadroomInfo:input_type -> netmon.HeadroomInfoRequest
+	5,  // 24: netmon.NetMonitor.WatchNetInfo:input_type -> netmon.WatchRequest
+	4,  // 25: netmon.NetMonitor.GetFlowInfo:input_type -> netmon.FlowInfoRequest
+	14, // 26: netmon.NetMonitor.WatchRouteChanges:input_type -> netmon.RouteChangeRequest
+	17, // 27: netmon.NetMonitor.SetQosPolicy:input_type -> netmon.QosPolicy
+	18, // 28: netmon.NetMonitor.GetQosStatus:input_type -> netmon.QosStatusRequest
+	3,  // 29: netmon.NetMonitor.GetNetInfo:output_type -> netmon.NetInfoReply
+	3,  // 30: netmon.NetMonitor.GetHeadroomInfo:output_type -> netmon.NetInfoReply
+	6,  // 31: netmon.NetMonitor.WatchNetInfo:output_type -> netmon.NetInfoUpdate
+	3,  // 32: netmon.NetMonitor.GetFlowInfo:output_type -> netmon.NetInfoReply
+	15, // 33: netmon.NetMonitor.WatchRouteChanges:output_type -> netmon.RouteChange
+	20, // 34: netmon.NetMonitor.SetQosPolicy:output_type -> netmon.QosStatus
+	20, // 35: netmon.NetMonitor.GetQosStatus:output_type -> netmon.QosStatus
+	29, // [29:36] is the sub-list for method output_type
+	22, // [22:29] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_net_helper_proto_init() }
@@ -479,7 +1796,7 @@ func file_net_helper_proto_init() {
 			}
 		}
 		file_net_helper_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BandwidthInfo); i {
+			switch v := v.(*FlowInfoRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -491,7 +1808,7 @@ func file_net_helper_proto_init() {
 			}
 		}
 		file_net_helper_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LatencyInfo); i {
+			switch v := v.(*WatchRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -503,6 +1820,42 @@ func file_net_helper_proto_init() {
 			}
 		}
 		file_net_helper_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NetInfoUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BandwidthInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatencyInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TracerouteInfo); i {
 			case 0:
 				return &v.state
@@ -514,19 +1867,152 @@ func file_net_helper_proto_init() {
 				return nil
 			}
 		}
+		file_net_helper_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouteInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TrafficInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TrafficRate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouteChangeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouteChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QosLimit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QosPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QosStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QosLimitStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_net_helper_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QosStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_net_helper_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_net_helper_proto_goTypes,
 		DependencyIndexes: file_net_helper_proto_depIdxs,
+		EnumInfos:         file_net_helper_proto_enumTypes,
 		MessageInfos:      file_net_helper_proto_msgTypes,
 	}.Build()
 	File_net_helper_proto = out.File
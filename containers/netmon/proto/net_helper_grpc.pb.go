@@ -25,6 +25,14 @@ type NetMonitorClient interface {
 	// Sends a greeting
 	GetNetInfo(ctx context.Context, in *NetInfoRequest, opts ...grpc.CallOption) (*NetInfoReply, error)
 	GetHeadroomInfo(ctx context.Context, in *HeadroomInfoRequest, opts ...grpc.CallOption) (*NetInfoReply, error)
+	// Pushes the caches of the node whenever they change, the first update has everything
+	WatchNetInfo(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (NetMonitor_WatchNetInfoClient, error)
+	GetFlowInfo(ctx context.Context, in *FlowInfoRequest, opts ...grpc.CallOption) (*NetInfoReply, error)
+	// Sends the route changes netmon saw after the one asked for, then every new one as traceroutes find it
+	WatchRouteChanges(ctx context.Context, in *RouteChangeRequest, opts ...grpc.CallOption) (NetMonitor_WatchRouteChangesClient, error)
+	// Replaces the rate limits the node enforces on what its pods send, limits not in the policy are removed
+	SetQosPolicy(ctx context.Context, in *QosPolicy, opts ...grpc.CallOption) (*QosStatus, error)
+	GetQosStatus(ctx context.Context, in *QosStatusRequest, opts ...grpc.CallOption) (*QosStatus, error)
 }
 
 type netMonitorClient struct {
@@ -53,6 +61,97 @@ func (c *netMonitorClient) GetHeadroomInfo(ctx context.Context, in *HeadroomInfo
 	return out, nil
 }
 
+func (c *netMonitorClient) WatchNetInfo(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (NetMonitor_WatchNetInfoClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NetMonitor_ServiceDesc.Streams[0], "/netmon.NetMonitor/WatchNetInfo", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &netMonitorWatchNetInfoClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NetMonitor_WatchNetInfoClient interface {
+	Recv() (*NetInfoUpdate, error)
+	grpc.ClientStream
+}
+
+type netMonitorWatchNetInfoClient struct {
+	grpc.ClientStream
+}
+
+func (x *netMonitorWatchNetInfoClient) Recv() (*NetInfoUpdate, error) {
+	m := new(NetInfoUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *netMonitorClient) GetFlowInfo(ctx context.Context, in *FlowInfoRequest, opts ...grpc.CallOption) (*NetInfoReply, error) {
+	out := new(NetInfoReply)
+	err := c.cc.Invoke(ctx, "/netmon.NetMonitor/GetFlowInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netMonitorClient) WatchRouteChanges(ctx context.Context, in *RouteChangeRequest, opts ...grpc.CallOption) (NetMonitor_WatchRouteChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NetMonitor_ServiceDesc.Streams[1], "/netmon.NetMonitor/WatchRouteChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &netMonitorWatchRouteChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NetMonitor_WatchRouteChangesClient interface {
+	Recv() (*RouteChange, error)
+	grpc.ClientStream
+}
+
+type netMonitorWatchRouteChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *netMonitorWatchRouteChangesClient) Recv() (*RouteChange, error) {
+	m := new(RouteChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *netMonitorClient) SetQosPolicy(ctx context.Context, in *QosPolicy, opts ...grpc.CallOption) (*QosStatus, error) {
+	out := new(QosStatus)
+	err := c.cc.Invoke(ctx, "/netmon.NetMonitor/SetQosPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netMonitorClient) GetQosStatus(ctx context.Context, in *QosStatusRequest, opts ...grpc.CallOption) (*QosStatus, error) {
+	out := new(QosStatus)
+	err := c.cc.Invoke(ctx, "/netmon.NetMonitor/GetQosStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NetMonitorServer is the server API for NetMonitor service.
 // All implementations must embed UnimplementedNetMonitorServer
 // for forward compatibility
@@ -60,6 +159,14 @@ type NetMonitorServer interface {
 	// Sends a greeting
 	GetNetInfo(context.Context, *NetInfoRequest) (*NetInfoReply, error)
 	GetHeadroomInfo(context.Context, *HeadroomInfoRequest) (*NetInfoReply, error)
+	// Pushes the caches of the node whenever they change, the first update has everything
+	WatchNetInfo(*WatchRequest, NetMonitor_WatchNetInfoServer) error
+	GetFlowInfo(context.Context, *FlowInfoRequest) (*NetInfoReply, error)
+	// Sends the route changes netmon saw after the one asked for, then every new one as traceroutes find it
+	WatchRouteChanges(*RouteChangeRequest, NetMonitor_WatchRouteChangesServer) error
+	// Replaces the rate limits the node enforces on what its pods send, limits not in the policy are removed
+	SetQosPolicy(context.Context, *QosPolicy) (*QosStatus, error)
+	GetQosStatus(context.Context, *QosStatusRequest) (*QosStatus, error)
 	mustEmbedUnimplementedNetMonitorServer()
 }
 
@@ -73,6 +180,21 @@ func (UnimplementedNetMonitorServer) GetNetInfo(context.Context, *NetInfoRequest
 func (UnimplementedNetMonitorServer) GetHeadroomInfo(context.Context, *HeadroomInfoRequest) (*NetInfoReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHeadroomInfo not implemented")
 }
+func (UnimplementedNetMonitorServer) WatchNetInfo(*WatchRequest, NetMonitor_WatchNetInfoServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchNetInfo not implemented")
+}
+func (UnimplementedNetMonitorServer) GetFlowInfo(context.Context, *FlowInfoRequest) (*NetInfoReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlowInfo not implemented")
+}
+func (UnimplementedNetMonitorServer) WatchRouteChanges(*RouteChangeRequest, NetMonitor_WatchRouteChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRouteChanges not implemented")
+}
+func (UnimplementedNetMonitorServer) SetQosPolicy(context.Context, *QosPolicy) (*QosStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQosPolicy not implemented")
+}
+func (UnimplementedNetMonitorServer) GetQosStatus(context.Context, *QosStatusRequest) (*QosStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQosStatus not implemented")
+}
 func (UnimplementedNetMonitorServer) mustEmbedUnimplementedNetMonitorServer() {}
 
 // UnsafeNetMonitorServer may be embedded to opt out of forward compatibility for this service.
@@ -122,6 +244,102 @@ func _NetMonitor_GetHeadroomInfo_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NetMonitor_WatchNetInfo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetMonitorServer).WatchNetInfo(m, &netMonitorWatchNetInfoServer{stream})
+}
+
+type NetMonitor_WatchNetInfoServer interface {
+	Send(*NetInfoUpdate) error
+	grpc.ServerStream
+}
+
+type netMonitorWatchNetInfoServer struct {
+	grpc.ServerStream
+}
+
+func (x *netMonitorWatchNetInfoServer) Send(m *NetInfoUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NetMonitor_GetFlowInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlowInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetMonitorServer).GetFlowInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netmon.NetMonitor/GetFlowInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetMonitorServer).GetFlowInfo(ctx, req.(*FlowInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetMonitor_WatchRouteChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RouteChangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetMonitorServer).WatchRouteChanges(m, &netMonitorWatchRouteChangesServer{stream})
+}
+
+type NetMonitor_WatchRouteChangesServer interface {
+	Send(*RouteChange) error
+	grpc.ServerStream
+}
+
+type netMonitorWatchRouteChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *netMonitorWatchRouteChangesServer) Send(m *RouteChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NetMonitor_SetQosPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QosPolicy)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetMonitorServer).SetQosPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netmon.NetMonitor/SetQosPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetMonitorServer).SetQosPolicy(ctx, req.(*QosPolicy))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetMonitor_GetQosStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QosStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetMonitorServer).GetQosStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netmon.NetMonitor/GetQosStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetMonitorServer).GetQosStatus(ctx, req.(*QosStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NetMonitor_ServiceDesc is the grpc.ServiceDesc for NetMonitor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -137,7 +355,30 @@ var NetMonitor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHeadroomInfo",
 			Handler:    _NetMonitor_GetHeadroomInfo_Handler,
 		},
+		{
+			MethodName: "GetFlowInfo",
+			Handler:    _NetMonitor_GetFlowInfo_Handler,
+		},
+		{
+			MethodName: "SetQosPolicy",
+			Handler:    _NetMonitor_SetQosPolicy_Handler,
+		},
+		{
+			MethodName: "GetQosStatus",
+			Handler:    _NetMonitor_GetQosStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchNetInfo",
+			Handler:       _NetMonitor_WatchNetInfo_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchRouteChanges",
+			Handler:       _NetMonitor_WatchRouteChanges_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "net_helper.proto",
 }
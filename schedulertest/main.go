@@ -56,6 +56,12 @@ func readApp(appFilename string, depsFilename string) meshscheduler.Application
 			srcComp.Bandwidth = make(map[string]float64, 0)
 		}
 		srcComp.Bandwidth[d.Dst] = d.Bandwidth
+		if d.MaxLatency > 0 {
+			if len(srcComp.MaxLatency) == 0 {
+				srcComp.MaxLatency = make(map[string]float64, 0)
+			}
+			srcComp.MaxLatency[d.Dst] = d.MaxLatency
+		}
 		componentsMap[d.Src] = srcComp
 	}
 	app.Components = componentsMap
@@ -114,8 +120,21 @@ func readPaths(filename string, linksMap meshscheduler.LinkMap) meshscheduler.Ro
 			pathsMap[src][dst] = r
 		}
 	}
+	// rows after the first for the same src,dst are other next hops the traffic is spread over
+	alternates := make(map[string]map[string][]*meshscheduler.InputPath, 0)
+	pairCount := 0
 	for _, p := range paths {
-		_, exists := pathsMap[p.Src]
+		_, exists := alternates[p.Src]
+		if !exists {
+			alternates[p.Src] = make(map[string][]*meshscheduler.InputPath, 0)
+		}
+		_, exists = alternates[p.Src][p.Dst]
+		alternates[p.Src][p.Dst] = append(alternates[p.Src][p.Dst], p)
+		if exists {
+			continue
+		}
+		pairCount += 1
+		_, exists = pathsMap[p.Src]
 		if !exists {
 			pathsMap[p.Src] = make(map[string]meshscheduler.Route, 0)
 		}
@@ -141,7 +160,7 @@ func readPaths(filename string, linksMap meshscheduler.LinkMap) meshscheduler.Ro
 	completedCount := 0
 	completedPaths := make(map[string]map[string]bool, 0)
 	for {
-		if completedCount == len(paths) {
+		if completedCount == pairCount {
 			break
 		}
 		//fmt.Printf("Completed = %d\n", completedCount)
@@ -184,6 +203,36 @@ func readPaths(filename string, linksMap meshscheduler.LinkMap) meshscheduler.Ro
 
 		}
 	}
+	for src, dstRows := range alternates {
+		for dst, rows := range dstRows {
+			if len(rows) < 2 {
+				continue
+			}
+			path := pathsMap[src][dst]
+			for i, row := range rows {
+				pathBw := path.PathBw
+				if i > 0 {
+					link, linkExists := linksMap[src][row.NextHop]
+					if !linkExists {
+						continue
+					}
+					pathBw = []*meshscheduler.LinkBandwidth{link}
+					if row.NextHop != dst {
+						pathBw = append(pathBw, pathsMap[row.NextHop][dst].PathBw...)
+					}
+				}
+				// without weights the traffic is split equally
+				path.Paths = append(path.Paths, meshscheduler.WeightedPath{PathBw: pathBw, Weight: row.Weight})
+			}
+			pathsMap[src][dst] = path
+		}
+	}
+	for src, dstPath := range pathsMap {
+		for dst, path := range dstPath {
+			path.Latency = path.ComputeLatency()
+			pathsMap[src][dst] = path
+		}
+	}
 	fmt.Printf("Finished processing paths\n")
 	//for src, pathDist := range pathsMap {
 	//	for dst, path := range pathDist {
@@ -209,7 +258,7 @@ func readLinks(filename string) meshscheduler.LinkMap {
 
 	linksMap := make(meshscheduler.LinkMap, 0)
 	for _, l := range links {
-		link := &meshscheduler.LinkBandwidth{Src: l.Src, Dst: l.Dst, BwCapacity: l.Bw, BwInUse: 0}
+		link := &meshscheduler.LinkBandwidth{Src: l.Src, Dst: l.Dst, BwCapacity: l.Bw, BwInUse: 0, Latency: l.Latency}
 		_, exists := linksMap[l.Src]
 		if !exists {
 			linksMap[l.Src] = make(map[string]*meshscheduler.LinkBandwidth, 0)
@@ -19,7 +19,7 @@ func (opt *BaseScheduler) InitScheduler(nodes NodeMap, routes RouteMap, links Li
 	opt.ResetState(nodes, routes, links)
 	for src, dstPath := range opt.Routes {
 		for dst, path := range dstPath {
-			bbw, _ := path.FindBottleneckBw()
+			bbw := path.AvailableBw()
 			path.BwInUse = 0
 			path.BwCapacity = bbw
 			opt.Routes[src][dst] = path
@@ -40,6 +40,10 @@ func (opt *BaseScheduler) LogAssignments() {
     opt.LogAssignmentsHelper(opt.Assignments)
 }
 
+func (opt *BaseScheduler) GetAssignments() AppCompAssignment {
+	return opt.Assignments
+}
+
 func (opt *BaseScheduler) PrintAssignments() {
 	fmt.Println("\nAppId,ComponentId,NodeId")
 	for app, comps := range opt.Assignments {
@@ -106,7 +110,7 @@ func (opt *BaseScheduler) VerifyFit(assignment AppCompAssignment, app Applicatio
 		return false, &InsufficientResourceError{ResourceType: "Memory", NodeId: nodeId}
 	}
 	for dependency, bw := range comp.Bandwidth {
-		depNode, exists := appDeployment[dependency]
+		depNode, exists := app.NodeOf(assignment, dependency)
 		if exists {
 			path, exists := opt.Routes[nodeId][depNode]
 			if !exists {
@@ -118,9 +122,69 @@ func (opt *BaseScheduler) VerifyFit(assignment AppCompAssignment, app Applicatio
 			}
 		}
 	}
+	if err := opt.CheckLatency(app, comp.ComponentId, nodeId, assignment, opt.Routes); err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
+
+// yields the latency constraints between compId and the already assigned components, in both directions
+func (opt *BaseScheduler) latencyConstraints(app Application, compId string, assignment AppCompAssignment) map[string]float64 {
+	constraints := make(map[string]float64, 0)
+	for dependency, maxLatency := range app.Components[compId].MaxLatency {
+		if _, exists := app.NodeOf(assignment, dependency); exists && maxLatency > 0 {
+			constraints[dependency] = maxLatency
+		}
+	}
+	for otherId, other := range app.Components {
+		maxLatency, exists := other.MaxLatency[compId]
+		if _, assigned := assignment[app.AppId][otherId]; !exists || !assigned || maxLatency <= 0 {
+			continue
+		}
+		if current, exists := constraints[otherId]; !exists || maxLatency < current {
+			constraints[otherId] = maxLatency
+		}
+	}
+	return constraints
+}
+
+// checks that placing compId on nodeId keeps every latency constraint to the already assigned components
+func (opt *BaseScheduler) CheckLatency(app Application, compId string, nodeId string, assignment AppCompAssignment, routes RouteMap) error {
+	for dependency, maxLatency := range opt.latencyConstraints(app, compId, assignment) {
+		depNode, _ := app.NodeOf(assignment, dependency)
+		if depNode == nodeId {
+			continue
+		}
+		route, exists := routes[nodeId][depNode]
+		if !exists || route.Latency > maxLatency {
+			return &InsufficientResourceError{ResourceType: "Latency", NodeId: nodeId + ":" + depNode}
+		}
+	}
+	return nil
+}
+
+// percentage by which placing compId on nodeId exceeds its latency constraints, averaged over the constraints
+func (opt *BaseScheduler) LatencyOverconsumption(app Application, compId string, nodeId string, assignment AppCompAssignment, routes RouteMap) float64 {
+	constraints := opt.latencyConstraints(app, compId, assignment)
+	latOversum := 0.0
+	for dependency, maxLatency := range constraints {
+		depNode, _ := app.NodeOf(assignment, dependency)
+		if depNode == nodeId {
+			continue
+		}
+		route, exists := routes[nodeId][depNode]
+		if !exists {
+			latOversum += 100.0
+		} else if route.Latency > maxLatency {
+			latOversum += 100.0 * (route.Latency - maxLatency) / maxLatency
+		}
+	}
+	if len(constraints) > 0 {
+		latOversum /= float64(len(constraints))
+	}
+	return latOversum
+}
 func (opt *BaseScheduler) CopyNodes(nmap NodeMap) NodeMap {
     oldState := make(NodeMap, 0)
 	for nodeId, state := range nmap {
@@ -138,7 +202,7 @@ func (opt *BaseScheduler) CopyRoutes(rmap RouteMap, lmap LinkMap) (RouteMap, Lin
 		}
 		for dst, link := range dstLink {
 
-			oldLinks[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse}
+			oldLinks[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse, Latency: (*link).Latency}
 
 		}
 	}
@@ -155,7 +219,7 @@ func (opt *BaseScheduler) CopyRoutes(rmap RouteMap, lmap LinkMap) (RouteMap, Lin
 				oldLink, _ := oldLinks[pbw.Src][pbw.Dst]
 				pathBw = append(pathBw, oldLink)
 			}
-			oldRoutes[src][dst] = Route{Src: route.Src, Dst: route.Dst, BwCapacity: route.BwCapacity, BwInUse: route.BwInUse, PathBw: pathBw}
+			oldRoutes[src][dst] = Route{Src: route.Src, Dst: route.Dst, BwCapacity: route.BwCapacity, BwInUse: route.BwInUse, Latency: route.Latency, PathBw: pathBw, Paths: copyPaths(route.Paths, oldLinks)}
 
 		}
 		//oldRoutes[src] = curDstRoute
@@ -188,7 +252,7 @@ func (opt *BaseScheduler) CopyState() (NodeMap, RouteMap, LinkMap) {
 		}
 		for dst, link := range dstLink {
 
-			oldLinks[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse}
+			oldLinks[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse, Latency: (*link).Latency}
 
 		}
 	}
@@ -205,7 +269,7 @@ func (opt *BaseScheduler) CopyState() (NodeMap, RouteMap, LinkMap) {
 				oldLink, _ := oldLinks[pbw.Src][pbw.Dst]
 				pathBw = append(pathBw, oldLink)
 			}
-			oldRoutes[src][dst] = Route{Src: route.Src, Dst: route.Dst, BwCapacity: route.BwCapacity, BwInUse: route.BwInUse, PathBw: pathBw}
+			oldRoutes[src][dst] = Route{Src: route.Src, Dst: route.Dst, BwCapacity: route.BwCapacity, BwInUse: route.BwInUse, Latency: route.Latency, PathBw: pathBw, Paths: copyPaths(route.Paths, oldLinks)}
 
 		}
 		//oldRoutes[src] = curDstRoute
@@ -223,7 +287,7 @@ func (opt *BaseScheduler) ResetState(nodes NodeMap, routes RouteMap, links LinkM
 			opt.Links[src] = make(map[string]*LinkBandwidth, 0)
 		}
 		for dst, link := range dstLink {
-			opt.Links[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse}
+			opt.Links[src][dst] = &LinkBandwidth{Src: (*link).Src, Dst: (*link).Dst, BwCapacity: (*link).BwCapacity, BwInUse: (*link).BwInUse, Latency: (*link).Latency}
 		}
 	}
 	for src, dstRoute := range routes {
@@ -238,6 +302,7 @@ func (opt *BaseScheduler) ResetState(nodes NodeMap, routes RouteMap, links LinkM
 				route.PathBw[idx] = oldLink
 
 			}
+			route.Paths = copyPaths(route.Paths, opt.Links)
 			_, link := route.FindBottleneckBw()
 			route.RecomputeBw(link)
 			opt.Routes[src][dst] = route
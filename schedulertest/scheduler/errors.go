@@ -20,3 +20,8 @@ type InsufficientResourceError struct {
 func (e *InsufficientResourceError) Error() string {
 	return fmt.Sprintf("Insufficient resource %s on node %s\n", e.ResourceType, e.NodeId)
 }
+
+func IsLatencyError(err error) bool {
+	resErr, ok := err.(*InsufficientResourceError)
+	return ok && resErr.ResourceType == "Latency"
+}
@@ -10,12 +10,14 @@ type InputLink struct {
 	Src string `csv:"src"`
 	Dst string `csv:"dst"`
 	Bw  float64    `csv:"bw_mbps"`
+	Latency float64 `csv:"latency_ms"` // optional
 }
 
 type InputPath struct {
-	Src     string `csv:"src"`
-	Dst     string `csv:"dst"`
-	NextHop string `csv:"next_hop"`
+	Src     string  `csv:"src"`
+	Dst     string  `csv:"dst"`
+	NextHop string  `csv:"next_hop"`
+	Weight  float64 `csv:"weight"` // share of the traffic over next_hop when src,dst has several rows (ECMP)
 }
 
 type InputComponent struct {
@@ -28,4 +30,5 @@ type InputComponentDependency struct {
 	Src       string `csv:"src"`
 	Dst       string `csv:"dst"`
 	Bandwidth float64    `csv:"bw_mbps"`
+	MaxLatency float64 `csv:"max_latency_ms"` // optional, 0 means no constraint
 }
@@ -0,0 +1,192 @@
+package meshscheduler
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/glog"
+)
+
+func (r *Route) IsMultipath() bool {
+	return len(r.Paths) > 1
+}
+
+// bw left on the route. For a multipath route the max flow over the bw left on the links of its paths,
+// the sum over the paths if they share no link
+func (r *Route) AvailableBw() float64 {
+	if !r.IsMultipath() {
+		bw, _ := r.FindBottleneckBw()
+		return bw
+	}
+	flow, _ := r.maxFlow(math.Inf(1))
+	return flow
+}
+
+// adds bw to the use of the route. A multipath route spreads it over its paths by their weights if they
+// have room for it, else along its max flow. What does not fit is spread by the weights
+func (r *Route) Reserve(bw float64) {
+	if !r.IsMultipath() {
+		_, bottleneckLink := r.FindBottleneckBw()
+		bottleneckLink.BwInUse += bw
+		r.SetPathBw(bottleneckLink.BwInUse)
+		return
+	}
+	r.BwInUse += bw
+	if weighted := r.weightedUse(bw); fits(weighted) {
+		addUse(weighted)
+		return
+	}
+	flow, use := r.maxFlow(bw)
+	addUse(use)
+	if flow < bw {
+		addUse(r.weightedUse(bw - flow))
+	}
+}
+
+// bw to add to every link if bw is spread over the paths by their weights, equally if there are no weights
+func (r *Route) weightedUse(bw float64) map[*LinkBandwidth]float64 {
+	total := 0.0
+	for _, path := range r.Paths {
+		total += path.Weight
+	}
+	use := make(map[*LinkBandwidth]float64, 0)
+	for _, path := range r.Paths {
+		share := 1.0 / float64(len(r.Paths))
+		if total > 0 {
+			share = path.Weight / total
+		}
+		for _, link := range path.PathBw {
+			use[link] += share * bw
+		}
+	}
+	return use
+}
+
+func fits(use map[*LinkBandwidth]float64) bool {
+	for link, bw := range use {
+		if link.BwInUse+bw > link.BwCapacity {
+			return false
+		}
+	}
+	return true
+}
+
+func addUse(use map[*LinkBandwidth]float64) {
+	for link, bw := range use {
+		link.BwInUse += bw
+	}
+}
+
+// max flow of at most limit from Src to Dst over the bw left on the links of the paths, with the flow
+// it puts on every link. Shortest augmenting paths first, in the order of the node ids
+func (r *Route) maxFlow(limit float64) (float64, map[*LinkBandwidth]float64) {
+	residual := make(map[string]map[string]float64, 0)
+	edges := make(map[string]map[string]*LinkBandwidth, 0)
+	addResidual := func(src string, dst string, bw float64) {
+		if _, exists := residual[src]; !exists {
+			residual[src] = make(map[string]float64, 0)
+		}
+		residual[src][dst] += bw
+	}
+	for _, path := range r.Paths {
+		for _, link := range path.PathBw {
+			if _, exists := edges[link.Src]; !exists {
+				edges[link.Src] = make(map[string]*LinkBandwidth, 0)
+			}
+			if _, exists := edges[link.Src][link.Dst]; exists {
+				continue
+			}
+			edges[link.Src][link.Dst] = link
+			addResidual(link.Src, link.Dst, math.Max(0, link.BwCapacity-link.BwInUse))
+			addResidual(link.Dst, link.Src, 0)
+		}
+	}
+	flow := 0.0
+	for flow < limit {
+		prev := map[string]string{r.Src: r.Src}
+		queue := []string{r.Src}
+		for len(queue) > 0 {
+			if _, found := prev[r.Dst]; found {
+				break
+			}
+			node := queue[0]
+			queue = queue[1:]
+			next := make([]string, 0)
+			for dst, bw := range residual[node] {
+				if _, seen := prev[dst]; !seen && bw > 0 {
+					next = append(next, dst)
+				}
+			}
+			sort.Strings(next)
+			for _, dst := range next {
+				prev[dst] = node
+				queue = append(queue, dst)
+			}
+		}
+		if _, found := prev[r.Dst]; !found || r.Src == r.Dst {
+			break
+		}
+		bw := limit - flow
+		for node := r.Dst; node != r.Src; node = prev[node] {
+			bw = math.Min(bw, residual[prev[node]][node])
+		}
+		for node := r.Dst; node != r.Src; node = prev[node] {
+			residual[prev[node]][node] -= bw
+			residual[node][prev[node]] += bw
+		}
+		flow += bw
+	}
+	// the flow on a link is what its residual went down by. Flow the other way on a link back
+	// raises it, so only the net flow is counted and on one of the two links
+	use := make(map[*LinkBandwidth]float64, 0)
+	for src, dsts := range edges {
+		for dst, link := range dsts {
+			used := math.Max(0, link.BwCapacity-link.BwInUse) - residual[src][dst]
+			if used > 0 {
+				use[link] = used
+			}
+		}
+	}
+	return flow, use
+}
+
+// paths with their links taken from links, nil if there are no paths
+func copyPaths(paths []WeightedPath, links LinkMap) []WeightedPath {
+	if paths == nil {
+		return nil
+	}
+	copied := make([]WeightedPath, 0)
+	for _, path := range paths {
+		pathBw := make([]*LinkBandwidth, 0)
+		for _, link := range path.PathBw {
+			pathBw = append(pathBw, links[link.Src][link.Dst])
+		}
+		copied = append(copied, WeightedPath{PathBw: pathBw, Weight: path.Weight})
+	}
+	return copied
+}
+
+// whether the route from src to dst or the one back is multipath
+func (opt *BaseScheduler) HasMultipath(routes RouteMap, src string, dst string) bool {
+	route := routes[src][dst]
+	reverse := routes[dst][src]
+	return route.IsMultipath() || reverse.IsMultipath()
+}
+
+// reserves bw on the route from src to dst and charges the route back with it too, like the single
+// path reservations do. false if the route from src to dst has not got bw left
+func (opt *BaseScheduler) ReserveMultipath(routes RouteMap, src string, dst string, bw float64) bool {
+	route := routes[src][dst]
+	if route.AvailableBw() < bw {
+		return false
+	}
+	route.Reserve(bw)
+	routes[src][dst] = route
+	reverse, exists := routes[dst][src]
+	if exists && len(reverse.PathBw) > 0 {
+		reverse.Reserve(bw)
+		routes[dst][src] = reverse
+	}
+	glog.Infof("reserved %f on multipath route %s-%s", bw, src, dst)
+	return true
+}
@@ -0,0 +1,220 @@
+package meshscheduler
+
+import (
+	"math"
+	"testing"
+)
+
+// links of the test mesh by name, a reaches d over b and over c
+func getMultipathTestLinks(capacities map[string]float64) map[string]*LinkBandwidth {
+	links := make(map[string]*LinkBandwidth, 0)
+	for _, name := range []string{"ab", "bd", "ac", "cd", "bc"} {
+		links[name] = &LinkBandwidth{Src: name[:1], Dst: name[1:], BwCapacity: capacities[name]}
+	}
+	return links
+}
+
+// route from a to d over the paths, each path given by the names of its links
+func getMultipathTestRoute(links map[string]*LinkBandwidth, weights []float64, paths ...[]string) Route {
+	route := Route{Src: "a", Dst: "d"}
+	for i, names := range paths {
+		pathBw := make([]*LinkBandwidth, 0)
+		for _, name := range names {
+			pathBw = append(pathBw, links[name])
+		}
+		if i == 0 {
+			route.PathBw = pathBw
+		}
+		if len(paths) > 1 {
+			route.Paths = append(route.Paths, WeightedPath{PathBw: pathBw, Weight: weights[i]})
+		}
+	}
+	return route
+}
+
+func floatEquals(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAvailableBw(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities map[string]float64
+		inUse      map[string]float64
+		paths      [][]string
+		want       float64
+	}{
+		{"single path", map[string]float64{"ab": 100, "bd": 50}, map[string]float64{"bd": 20}, [][]string{{"ab", "bd"}}, 30},
+		{"disjoint paths add up", map[string]float64{"ab": 100, "bd": 50, "ac": 40, "cd": 80}, nil, [][]string{{"ab", "bd"}, {"ac", "cd"}}, 90},
+		{"shared first link", map[string]float64{"ab": 60, "bd": 50, "bc": 30, "cd": 30}, nil, [][]string{{"ab", "bd"}, {"ab", "bc", "cd"}}, 60},
+		{"used links", map[string]float64{"ab": 100, "bd": 50, "ac": 40, "cd": 80}, map[string]float64{"bd": 50, "ac": 10}, [][]string{{"ab", "bd"}, {"ac", "cd"}}, 30},
+		{"overused link", map[string]float64{"ab": 100, "bd": 50, "ac": 40, "cd": 80}, map[string]float64{"bd": 70}, [][]string{{"ab", "bd"}, {"ac", "cd"}}, 40},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links := getMultipathTestLinks(test.capacities)
+			for name, bw := range test.inUse {
+				links[name].BwInUse = bw
+			}
+			route := getMultipathTestRoute(links, []float64{0.5, 0.5}, test.paths...)
+			if bw := route.AvailableBw(); !floatEquals(bw, test.want) {
+				t.Fatalf("Want %f available, got %f", test.want, bw)
+			}
+		})
+	}
+}
+
+func TestMultipathReserve(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities map[string]float64
+		weights    []float64
+		bw         float64
+		want       map[string]float64 // bw in use on the links after the reservation
+	}{
+		{"spread by the weights", map[string]float64{"ab": 100, "bd": 100, "ac": 100, "cd": 100}, []float64{0.75, 0.25}, 40,
+			map[string]float64{"ab": 30, "bd": 30, "ac": 10, "cd": 10}},
+		{"equally without weights", map[string]float64{"ab": 100, "bd": 100, "ac": 100, "cd": 100}, []float64{0, 0}, 40,
+			map[string]float64{"ab": 20, "bd": 20, "ac": 20, "cd": 20}},
+		{"weights do not fit, along the max flow", map[string]float64{"ab": 100, "bd": 100, "ac": 10, "cd": 100}, []float64{0.5, 0.5}, 40,
+			map[string]float64{"ab": 40, "bd": 40, "ac": 0, "cd": 0}},
+		{"more than the max flow, the rest by the weights", map[string]float64{"ab": 20, "bd": 100, "ac": 10, "cd": 100}, []float64{0.5, 0.5}, 40,
+			map[string]float64{"ab": 25, "bd": 25, "ac": 15, "cd": 15}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links := getMultipathTestLinks(test.capacities)
+			route := getMultipathTestRoute(links, test.weights, []string{"ab", "bd"}, []string{"ac", "cd"})
+			route.Reserve(test.bw)
+			if route.BwInUse != test.bw {
+				t.Fatalf("Want %f in use on the route, got %f", test.bw, route.BwInUse)
+			}
+			for name, want := range test.want {
+				if !floatEquals(links[name].BwInUse, want) {
+					t.Fatalf("Want %f in use on %s, got %f", want, name, links[name].BwInUse)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxFlowLimit(t *testing.T) {
+	links := getMultipathTestLinks(map[string]float64{"ab": 60, "bd": 50, "bc": 30, "cd": 30})
+	route := getMultipathTestRoute(links, []float64{0.5, 0.5}, []string{"ab", "bd"}, []string{"ab", "bc", "cd"})
+	tests := []struct {
+		limit float64
+		want  float64
+	}{
+		{10, 10},
+		{55, 55},
+		{100, 60},
+	}
+	for _, test := range tests {
+		flow, use := route.maxFlow(test.limit)
+		if !floatEquals(flow, test.want) {
+			t.Fatalf("Want a flow of %f for limit %f, got %f", test.want, test.limit, flow)
+		}
+		// the flow leaves a over its only link and reaches d over bd and cd
+		if !floatEquals(use[links["ab"]], flow) || !floatEquals(use[links["bd"]]+use[links["cd"]], flow) {
+			t.Fatalf("Want the flow %f on ab and split over bd and cd, got %v", flow, use)
+		}
+	}
+}
+
+func TestCopyPaths(t *testing.T) {
+	if copyPaths(nil, LinkMap{}) != nil {
+		t.Fatalf("Want no paths for a single path route")
+	}
+	links := getMultipathTestLinks(map[string]float64{"ab": 100, "bd": 100, "ac": 100, "cd": 100})
+	route := getMultipathTestRoute(links, []float64{0.6, 0.4}, []string{"ab", "bd"}, []string{"ac", "cd"})
+	copied := LinkMap{}
+	for _, link := range links {
+		if _, exists := copied[link.Src]; !exists {
+			copied[link.Src] = make(map[string]*LinkBandwidth, 0)
+		}
+		copied[link.Src][link.Dst] = &LinkBandwidth{Src: link.Src, Dst: link.Dst, BwCapacity: link.BwCapacity}
+	}
+	paths := copyPaths(route.Paths, copied)
+	if len(paths) != 2 || paths[1].Weight != 0.4 {
+		t.Fatalf("Want both paths with their weights, got %v", paths)
+	}
+	for i, path := range paths {
+		for j, link := range path.PathBw {
+			if link != copied[link.Src][link.Dst] || link == route.Paths[i].PathBw[j] {
+				t.Fatalf("Want the links of path %d taken from the copied links", i)
+			}
+		}
+	}
+}
+
+func TestReserveMultipath(t *testing.T) {
+	links := getMultipathTestLinks(map[string]float64{"ab": 100, "bd": 100, "ac": 100, "cd": 100})
+	route := getMultipathTestRoute(links, []float64{0.5, 0.5}, []string{"ab", "bd"}, []string{"ac", "cd"})
+	back := &LinkBandwidth{Src: "d", Dst: "a", BwCapacity: 100}
+	routes := RouteMap{"a": {"d": route}, "d": {"a": Route{Src: "d", Dst: "a", PathBw: []*LinkBandwidth{back}}}}
+	opt := &BaseScheduler{}
+
+	if !opt.HasMultipath(routes, "d", "a") {
+		t.Fatalf("Want the route back from d to a seen as multipath")
+	}
+	if opt.ReserveMultipath(routes, "a", "d", 250) {
+		t.Fatalf("Want no reservation beyond the 200 available")
+	}
+	if links["ab"].BwInUse != 0 || back.BwInUse != 0 {
+		t.Fatalf("Want nothing reserved when the route has not got the bw left")
+	}
+	if !opt.ReserveMultipath(routes, "a", "d", 60) {
+		t.Fatalf("Want 60 reserved from a to d")
+	}
+	if links["ab"].BwInUse != 30 || links["cd"].BwInUse != 30 || back.BwInUse != 60 || routes["a"]["d"].BwInUse != 60 {
+		t.Fatalf("Want 60 spread from a to d and charged to the route back, got ab %f cd %f back %f",
+			links["ab"].BwInUse, links["cd"].BwInUse, back.BwInUse)
+	}
+}
+
+func TestLatencyViolationKeepsBw(t *testing.T) {
+	type assigner interface {
+		MakeAssignment(string, string, Application, NodeMap, RouteMap, LinkMap, AppCompAssignment) (error, NodeMap, LinkMap, RouteMap)
+	}
+	tests := []struct {
+		name string
+		opt  assigner
+	}{
+		{"tabu search", NewTabuSearchScheduler()},
+		{"simulated annealing", NewSimulatedAnnealingScheduler()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links := getMultipathTestLinks(map[string]float64{"ab": 100, "bd": 100, "ac": 100, "cd": 100})
+			back := &LinkBandwidth{Src: "d", Dst: "a", BwCapacity: 100}
+			linkMap := LinkMap{"d": {"a": back}}
+			for _, link := range links {
+				if _, exists := linkMap[link.Src]; !exists {
+					linkMap[link.Src] = make(map[string]*LinkBandwidth, 0)
+				}
+				linkMap[link.Src][link.Dst] = link
+			}
+			route := getMultipathTestRoute(links, []float64{0.5, 0.5}, []string{"ab", "bd"}, []string{"ac", "cd"})
+			route.Latency = 10
+			routes := RouteMap{"a": {"d": route}, "d": {"a": Route{Src: "d", Dst: "a", Latency: 10, PathBw: []*LinkBandwidth{back}}}}
+			nodes := NodeMap{"a": Node{NodeId: "a", CpuCapacity: 10, MemoryCapacity: 10}, "d": Node{NodeId: "d", CpuCapacity: 10, MemoryCapacity: 10}}
+			app := Application{AppId: "app", Components: ComponentMap{
+				"front": Component{ComponentId: "front", Cpu: 1, Memory: 1, Bandwidth: ComponentBw{"back": 60}, MaxLatency: ComponentLatency{"back": 5}},
+				"back":  Component{ComponentId: "back", Cpu: 1, Memory: 1},
+			}}
+			assignment := AppCompAssignment{"app": {"back": "d"}}
+
+			err, nodes, _, routes := test.opt.MakeAssignment("a", "front", app, nodes, routes, linkMap, assignment)
+			if !IsLatencyError(err) {
+				t.Fatalf("Want a latency error from a to d, got %v", err)
+			}
+			if nodes["a"].CpuInUse != 1 {
+				t.Fatalf("Want the cpu of front in use on a, got %d", nodes["a"].CpuInUse)
+			}
+			if routes["a"]["d"].BwInUse != 60 || routes["d"]["a"].BwInUse != 60 {
+				t.Fatalf("Want 60 kept reserved both ways despite the latency, got %f and %f",
+					routes["a"]["d"].BwInUse, routes["d"]["a"].BwInUse)
+			}
+		})
+	}
+}
@@ -20,7 +20,7 @@ func (opt *OptimalScheduler) InitScheduler(nodes NodeMap, routes RouteMap, links
     opt.ResetState( nodes, routes, links)
     for src, dstPath := range opt.Routes {
         for dst, path := range dstPath{
-            bbw, _ := path.FindBottleneckBw()
+            bbw := path.AvailableBw()
             path.BwInUse = 0
             path.BwCapacity = bbw
             opt.Routes[src][dst] = path
@@ -117,14 +117,24 @@ func (opt *OptimalScheduler) MakeAssignment(nodeId string, componentId string, a
     n.CpuInUse += component.Cpu
     n.MemoryInUse += component.Memory
     tmpnodes[nodeId] = n
+    if err := opt.CheckLatency(app, componentId, nodeId, assignment, routes); err != nil {
+        return err, nodes, links, routes
+    }
     // can this node accommodate all bw contraints of this component to the existing component
     for dependency, bw := range component.Bandwidth {
-        depNode, exists := assignment[app.AppId][dependency]
+        depNode, exists := app.NodeOf(assignment, dependency)
         if exists {
             path, exists := tmproutes[nodeId][depNode]
             if !exists {
                 return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
             }
+            if opt.HasMultipath(tmproutes, nodeId, depNode) {
+                if !opt.ReserveMultipath(tmproutes, nodeId, depNode, bw) {
+                    return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:nodeId}, nodes, links, routes
+                }
+                opt.UpdatePaths(tmplinks, tmproutes)
+                continue
+            }
             bottleneckBw, bottleneckLink := path.FindBottleneckBw()
             glog.Infof("comp %s bw %f node %s-%s available %f",  dependency, bw, nodeId, depNode, bottleneckBw)
             if bottleneckBw  >= bw {
@@ -167,6 +177,13 @@ func (opt *OptimalScheduler) MakeAssignment(nodeId string, componentId string, a
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes,  links, routes
                 }
                 depCompBw, _ := app.Components[compId].Bandwidth[dep]
+                if opt.HasMultipath(tmproutes, nId, nodeId) {
+                    if !opt.ReserveMultipath(tmproutes, nId, nodeId, depCompBw) {
+                        return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
+                    }
+                    opt.UpdatePaths(tmplinks, tmproutes)
+                    continue
+                }
                 bottleneckBw, bottleneckLink := path.FindBottleneckBw()
                 if bottleneckBw - depCompBw < 0{
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
@@ -7,4 +7,5 @@ type Scheduler interface {
 	Schedule(Application)
     PrintState()
     PrintAssignments()
+	GetAssignments() AppCompAssignment
 }
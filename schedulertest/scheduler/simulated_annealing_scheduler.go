@@ -24,7 +24,7 @@ func (opt *SimulatedAnnealingScheduler) InitScheduler(nodes NodeMap, routes Rout
     opt.ResetState( nodes, routes, links)
     for src, dstPath := range opt.Routes {
         for dst, path := range dstPath{
-            bbw, _ := path.FindBottleneckBw()
+            bbw := path.AvailableBw()
             path.BwInUse = 0
             path.BwCapacity = bbw
             opt.Routes[src][dst] = path
@@ -93,6 +93,7 @@ func (opt *SimulatedAnnealingScheduler) computeCostUtility(app Application, assi
     overconsumptionCpu := 0.0 
     overconsumptionMem := 0.0
     overconsumptionBw := 0.0
+    overconsumptionLatency := 0.0
     nodesUsed := make(map[string]bool, 0)
     for compid, nodeid := range assignment[app.AppId]{
         _, err1 := opt.CheckFit(app.Components[compid], nodeid, nodes, links)
@@ -108,12 +109,14 @@ func (opt *SimulatedAnnealingScheduler) computeCostUtility(app Application, assi
             overconsumptionMem = 0.0
         }
         nodesUsed[nodeid] = true
+        overconsumptionLatency += opt.LatencyOverconsumption(app, compid, nodeid, assignment, routes)
         err2, newnodes, newlinks, newroutes := opt.MakeAssignment(nodeid, compid, app, nodes, routes, links, assignment)
-        if err2 != nil{
+        // latency violations are already counted in overconsumptionLatency
+        if err2 != nil && !IsLatencyError(err2){
             bwOversum := 0.0
             glog.Info(err2)
             for dep, bw := range app.Components[compid].Bandwidth{
-                depNode, _ := assignment[app.AppId][dep]
+                depNode, _ := app.NodeOf(assignment, dep)
                 route, exists := newroutes[nodeid][depNode]
                 if !exists {
                     bwOversum += 100.0
@@ -130,7 +133,7 @@ func (opt *SimulatedAnnealingScheduler) computeCostUtility(app Application, assi
         if overconsumptionBw < 0{
             overconsumptionBw = 0.0
         }
-        if err1 == nil && err2 == nil{
+        if err1 == nil && (err2 == nil || IsLatencyError(err2)){
         
             nodes, links, routes = newnodes, newlinks, newroutes
             glog.Infof("assigned comp %s to node %s\n", compid, nodeid)
@@ -138,8 +141,8 @@ func (opt *SimulatedAnnealingScheduler) computeCostUtility(app Application, assi
         }
         
     }
-    glog.Infof("cpu = %f mem=%f bw=%f\n", overconsumptionCpu, overconsumptionMem, overconsumptionBw)
-    return (overconsumptionBw + overconsumptionMem + overconsumptionCpu)/(3.0 * float64(len(nodesUsed)) ), nodes, links, routes
+    glog.Infof("cpu = %f mem=%f bw=%f latency=%f\n", overconsumptionCpu, overconsumptionMem, overconsumptionBw, overconsumptionLatency)
+    return (overconsumptionBw + overconsumptionMem + overconsumptionCpu + overconsumptionLatency)/(4.0 * float64(len(nodesUsed)) ), nodes, links, routes
 }
 
 func (opt *SimulatedAnnealingScheduler) computeCost(app Application, assignment AppCompAssignment, nodes NodeMap, links LinkMap, routes RouteMap) (float64, NodeMap, LinkMap, RouteMap){
@@ -288,14 +291,23 @@ func (opt *SimulatedAnnealingScheduler) MakeAssignment(nodeId string, componentI
     n.CpuInUse += component.Cpu
     n.MemoryInUse += component.Memory
     tmpnodes[nodeId] = n
+    // a latency violation is returned with the bw reserved, so the components costed after it see that bw in use
+    latencyErr := opt.CheckLatency(app, componentId, nodeId, assignment, routes)
     // can this node accommodate all bw contraints of this component to the existing component
     for dependency, bw := range component.Bandwidth {
-        depNode, exists := assignment[app.AppId][dependency]
+        depNode, exists := app.NodeOf(assignment, dependency)
         if exists {
             path, exists := tmproutes[nodeId][depNode]
             if !exists {
                 return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
             }
+            if opt.HasMultipath(tmproutes, nodeId, depNode) {
+                if !opt.ReserveMultipath(tmproutes, nodeId, depNode, bw) {
+                    return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:nodeId}, nodes, links, routes
+                }
+                opt.UpdatePaths(tmplinks, tmproutes)
+                continue
+            }
             bottleneckBw, bottleneckLink := path.FindBottleneckBw()
             glog.Infof("comp %s bw %f node %s-%s available %f",  dependency, bw, nodeId, depNode, bottleneckBw)
             if bottleneckBw  >= bw {
@@ -338,6 +350,13 @@ func (opt *SimulatedAnnealingScheduler) MakeAssignment(nodeId string, componentI
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nId}, nodes,  links, routes
                 }
                 depCompBw, _ := app.Components[compId].Bandwidth[dep]
+                if opt.HasMultipath(tmproutes, nId, nodeId) {
+                    if !opt.ReserveMultipath(tmproutes, nId, nodeId, depCompBw) {
+                        return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
+                    }
+                    opt.UpdatePaths(tmplinks, tmproutes)
+                    continue
+                }
                 bottleneckBw, bottleneckLink := path.FindBottleneckBw()
                 if bottleneckBw - depCompBw < 0{
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
@@ -362,7 +381,7 @@ func (opt *SimulatedAnnealingScheduler) MakeAssignment(nodeId string, componentI
     }
     nodes = tmpnodes
     routes, links = tmproutes, tmplinks
-    return nil, nodes, links, routes
+    return latencyErr, nodes, links, routes
 }
 
 
@@ -22,7 +22,7 @@ func (opt *TabuSearchScheduler) InitScheduler(nodes NodeMap, routes RouteMap, li
     opt.ResetState( nodes, routes, links)
     for src, dstPath := range opt.Routes {
         for dst, path := range dstPath{
-            bbw, _ := path.FindBottleneckBw()
+            bbw := path.AvailableBw()
             path.BwInUse = 0
             path.BwCapacity = bbw
             opt.Routes[src][dst] = path
@@ -91,6 +91,7 @@ func (opt *TabuSearchScheduler) computeCostUtility(app Application, assignment A
     overconsumptionCpu := 0.0 
     overconsumptionMem := 0.0
     overconsumptionBw := 0.0
+    overconsumptionLatency := 0.0
     nodesUsed := make(map[string]bool, 0)
     for compid, nodeid := range assignment[app.AppId]{
         _, err1 := opt.CheckFit(app.Components[compid], nodeid, nodes, links)
@@ -106,12 +107,14 @@ func (opt *TabuSearchScheduler) computeCostUtility(app Application, assignment A
             overconsumptionMem = 0.0
         }
         nodesUsed[nodeid] = true
+        overconsumptionLatency += opt.LatencyOverconsumption(app, compid, nodeid, assignment, routes)
         err2, newnodes, newlinks, newroutes := opt.MakeAssignment(nodeid, compid, app, nodes, routes, links, assignment)
-        if err2 != nil{
+        // latency violations are already counted in overconsumptionLatency
+        if err2 != nil && !IsLatencyError(err2){
             bwOversum := 0.0
             glog.Info(err2)
             for dep, bw := range app.Components[compid].Bandwidth{
-                depNode, _ := assignment[app.AppId][dep]
+                depNode, _ := app.NodeOf(assignment, dep)
                 route, exists := newroutes[nodeid][depNode]
                 if !exists {
                     bwOversum += 100.0
@@ -128,7 +131,7 @@ func (opt *TabuSearchScheduler) computeCostUtility(app Application, assignment A
         if overconsumptionBw < 0{
             overconsumptionBw = 0.0
         }
-        if err1 == nil && err2 == nil{
+        if err1 == nil && (err2 == nil || IsLatencyError(err2)){
         
             nodes, links, routes = newnodes, newlinks, newroutes
             glog.Infof("assigned comp %s to node %s\n", compid, nodeid)
@@ -136,8 +139,8 @@ func (opt *TabuSearchScheduler) computeCostUtility(app Application, assignment A
         }
 
     }
-    glog.Infof("cpu = %f mem=%f bw=%f\n", overconsumptionCpu, overconsumptionMem, overconsumptionBw)
-    return (overconsumptionBw + overconsumptionMem + overconsumptionCpu)/(3.0 *float64(len(nodesUsed))), nodes, links, routes
+    glog.Infof("cpu = %f mem=%f bw=%f latency=%f\n", overconsumptionCpu, overconsumptionMem, overconsumptionBw, overconsumptionLatency)
+    return (overconsumptionBw + overconsumptionMem + overconsumptionCpu + overconsumptionLatency)/(4.0 *float64(len(nodesUsed))), nodes, links, routes
 }
 
 
@@ -265,14 +268,23 @@ func (opt *TabuSearchScheduler) MakeAssignment(nodeId string, componentId string
     n.CpuInUse += component.Cpu
     n.MemoryInUse += component.Memory
     tmpnodes[nodeId] = n
+    // a latency violation is returned with the bw reserved, so the components costed after it see that bw in use
+    latencyErr := opt.CheckLatency(app, componentId, nodeId, assignment, routes)
     // can this node accommodate all bw contraints of this component to the existing component
     for dependency, bw := range component.Bandwidth {
-        depNode, exists := assignment[app.AppId][dependency]
+        depNode, exists := app.NodeOf(assignment, dependency)
         if exists {
             path, exists := tmproutes[nodeId][depNode]
             if !exists {
                 return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
             }
+            if opt.HasMultipath(tmproutes, nodeId, depNode) {
+                if !opt.ReserveMultipath(tmproutes, nodeId, depNode, bw) {
+                    return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:nodeId}, nodes, links, routes
+                }
+                opt.UpdatePaths(tmplinks, tmproutes)
+                continue
+            }
             bottleneckBw, bottleneckLink := path.FindBottleneckBw()
             glog.Infof("comp %s bw %f node %s-%s available %f",  dependency, bw, nodeId, depNode, bottleneckBw)
             if bottleneckBw  >= bw {
@@ -315,6 +327,13 @@ func (opt *TabuSearchScheduler) MakeAssignment(nodeId string, componentId string
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nId}, nodes,  links, routes
                 }
                 depCompBw, _ := app.Components[compId].Bandwidth[dep]
+                if opt.HasMultipath(tmproutes, nId, nodeId) {
+                    if !opt.ReserveMultipath(tmproutes, nId, nodeId, depCompBw) {
+                        return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
+                    }
+                    opt.UpdatePaths(tmplinks, tmproutes)
+                    continue
+                }
                 bottleneckBw, bottleneckLink := path.FindBottleneckBw()
                 if bottleneckBw - depCompBw < 0{
                     return &InsufficientResourceError{ResourceType:"PathBandwidth", NodeId:depNode  +":" + nodeId}, nodes, links, routes
@@ -339,7 +358,7 @@ func (opt *TabuSearchScheduler) MakeAssignment(nodeId string, componentId string
     }
     nodes = tmpnodes
     routes, links = tmproutes, tmplinks
-    return nil, nodes, links, routes
+    return latencyErr, nodes, links, routes
 
 }
 
@@ -13,6 +13,7 @@ type Node struct {
 }
 
 type ComponentBw map[string]float64        // bw to other component needed
+type ComponentLatency map[string]float64   // max latency (ms) to other component
 type ComponentMap map[string]Component // component name -> component map
 
 type Component struct {
@@ -21,11 +22,22 @@ type Component struct {
 	Memory      int
 	Bandwidth   ComponentBw
 	TotalBw     float64
+	MaxLatency  ComponentLatency
 }
 
 type Application struct {
 	AppId      string
 	Components ComponentMap
+	Placed     map[string]string // components the others depend on that already run, id -> node. They are not placed again
+}
+
+// node the component is assigned to, or the node it already runs on
+func (app Application) NodeOf(assignment AppCompAssignment, compId string) (string, bool) {
+	if nodeId, exists := assignment[app.AppId][compId]; exists {
+		return nodeId, true
+	}
+	nodeId, exists := app.Placed[compId]
+	return nodeId, exists
 }
 
 type LinkBandwidth struct {
@@ -33,6 +45,7 @@ type LinkBandwidth struct {
 	Dst        string
 	BwCapacity float64
 	BwInUse    float64
+	Latency    float64 // ms
 }
 
 type Route struct {
@@ -40,7 +53,15 @@ type Route struct {
 	Dst        string
 	BwCapacity float64
 	BwInUse    float64
+	Latency    float64 // ms
 	PathBw     []*LinkBandwidth
+	Paths      []WeightedPath // every path of a multipath route (ECMP), PathBw is one of them. nil for a single path
+}
+
+// one of the paths a multipath route spreads its traffic over
+type WeightedPath struct {
+	PathBw []*LinkBandwidth
+	Weight float64 // share of the traffic of the route on this path
 }
 
 type NodeMap map[string]Node                        // node id -> node map
@@ -63,6 +84,15 @@ func (r *Route) FindBottleneckBw() (float64, *LinkBandwidth) {
 	return minBw, linkBw
 }
 
+// end to end latency of the route as the sum of its link latencies
+func (r *Route) ComputeLatency() float64 {
+	latency := 0.0
+	for _, link := range r.PathBw {
+		latency += link.Latency
+	}
+	return latency
+}
+
 func (r *Route) SetPathBw(bw float64) {
 	r.BwInUse = bw
 	for i := 0; i < len(r.PathBw); i++ {
@@ -71,6 +101,10 @@ func (r *Route) SetPathBw(bw float64) {
 }
 
 func (r *Route) RecomputeBw(bottleneckLink *LinkBandwidth) {
+	if r.IsMultipath() {
+		// the bw of a multipath route is what Reserve put on it
+		return
+	}
 	usesLink := false
 	for i := 0; i < len(r.PathBw); i++ {
 		if r.PathBw[i].Src == bottleneckLink.Src && r.PathBw[i].Dst == bottleneckLink.Dst {